@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+)
+
+// newDataCmd 创建data命令 (检查数据目录)
+func newDataCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "data",
+		Short: "检查数据目录",
+	}
+
+	var configPath, dir string
+	inspect := &cobra.Command{
+		Use:   "inspect",
+		Short: "列出数据目录中的标的、日期范围和识别出的列，检查配置中的标的是否都有数据",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			backtestConfig, err := cfg.ToBacktestConfig()
+			if err != nil {
+				return err
+			}
+			if dir == "" {
+				dir = cfg.GetDataDir()
+			}
+
+			infos, err := data.NewCSVLoader(dir).Manifest()
+			if err != nil {
+				return err
+			}
+
+			configured := make(map[string]bool)
+			candidates := append(append([]string(nil), backtestConfig.Symbols...), backtestConfig.Benchmark)
+			blend := make([]string, 0, len(backtestConfig.BenchmarkBlend))
+			for symbol := range backtestConfig.BenchmarkBlend {
+				blend = append(blend, symbol)
+			}
+			sort.Strings(blend)
+			candidates = append(candidates, blend...)
+			symbols := make([]string, 0, len(candidates))
+			for _, symbol := range candidates {
+				if symbol != "" && !configured[symbol] {
+					configured[symbol] = true
+					symbols = append(symbols, symbol)
+				}
+			}
+			printManifest(dir, infos, configured)
+
+			found := make(map[string]data.SymbolInfo, len(infos))
+			for _, info := range infos {
+				if info.Error == "" && info.Rows > 0 {
+					found[info.Symbol] = info
+				}
+			}
+			var missing []string
+			var usable []data.SymbolInfo
+			for _, symbol := range symbols {
+				if info, ok := found[symbol]; ok {
+					usable = append(usable, info)
+					continue
+				}
+				missing = append(missing, symbol)
+				fmt.Printf("Missing: %s has no usable data file in %s\n", symbol, dir)
+			}
+
+			// 与其他标的相比在回测区间内起始较晚或结束较早的标的 (回测时需要 coverage_policy)
+			first, last := backtestConfig.EndDate, backtestConfig.StartDate
+			for _, info := range usable {
+				if info.FirstDate.Before(first) {
+					first = info.FirstDate
+				}
+				if info.LastDate.After(last) {
+					last = info.LastDate
+				}
+			}
+			if first.Before(backtestConfig.StartDate) {
+				first = backtestConfig.StartDate
+			}
+			if last.After(backtestConfig.EndDate) {
+				last = backtestConfig.EndDate
+			}
+			for _, info := range usable {
+				if info.FirstDate.After(first) || info.LastDate.Before(last) {
+					fmt.Printf("Partial: %s covers %s to %s, other symbols cover %s to %s\n", info.Symbol,
+						info.FirstDate.Format("2006-01-02"), info.LastDate.Format("2006-01-02"),
+						first.Format("2006-01-02"), last.Format("2006-01-02"))
+				}
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("%d configured symbols have no usable data: %s", len(missing), strings.Join(missing, ", "))
+			}
+			fmt.Printf("All %d configured symbols have data\n", len(symbols))
+			return nil
+		},
+	}
+	inspect.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	inspect.Flags().StringVar(&dir, "dir", "", "数据目录 (默认为配置中的 backtest.data_dir)")
+	cmd.AddCommand(inspect)
+
+	return cmd
+}
+
+// printManifest 打印数据目录概况，配置中使用的标的以 * 标记
+func printManifest(dir string, infos []data.SymbolInfo, configured map[string]bool) {
+	fmt.Printf("\n========== Data: %s ==========\n", dir)
+	fmt.Printf("  %-12s %-10s %-10s %6s  %s\n", "Symbol", "First", "Last", "Rows", "Columns")
+	for _, info := range infos {
+		mark := " "
+		if configured[info.Symbol] {
+			mark = "*"
+		}
+		if info.Error != "" {
+			fmt.Printf("%s %-12s error: %s\n", mark, info.Symbol, info.Error)
+			continue
+		}
+		fmt.Printf("%s %-12s %-10s %-10s %6d  %s\n", mark, info.Symbol,
+			info.FirstDate.Format("2006-01-02"), info.LastDate.Format("2006-01-02"), info.Rows, strings.Join(info.Columns, ","))
+		if info.Skipped > 0 {
+			fmt.Printf("  %-12s %d rows skipped (unparseable date)\n", "", info.Skipped)
+		}
+		if len(info.Unknown) > 0 {
+			fmt.Printf("  %-12s ignored columns: %s\n", "", strings.Join(info.Unknown, ","))
+		}
+		if info.Fundamentals != "" {
+			fmt.Printf("  %-12s fundamentals: %s\n", "", info.Fundamentals)
+		}
+		for _, w := range info.Warnings {
+			fmt.Printf("  %-12s warning: %s\n", "", w)
+		}
+	}
+	fmt.Println("(* = used by the config)")
+}
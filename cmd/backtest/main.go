@@ -0,0 +1,1073 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/cache"
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/cost"
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/engine"
+	"github.com/opsxjacky/Rebalance-backtest/internal/optimize"
+	"github.com/opsxjacky/Rebalance-backtest/internal/runs"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/split"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// runOptions run命令参数
+type runOptions struct {
+	configPath string
+	output     string
+	cacheDir   string
+	force      bool
+	noCache    bool
+	checkpoint string // 回测结束后保存断点的文件
+	resume     string // 继续回测的断点文件
+	extend     string // 增量回测的状态文件
+}
+
+// configOverrides 命令行中 --<配置路径> 形式的配置覆盖项
+var configOverrides []config.Override
+
+func main() {
+	args, overrides, err := splitOverrides(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	configOverrides = overrides
+
+	rootCmd := &cobra.Command{
+		Use:   "backtest",
+		Short: "资产组合再平衡回测",
+		Long: `资产组合再平衡回测
+
+任意配置项可在命令行以 --<YAML路径> 覆盖 (如 --backtest.start_date 2021-01-01、
+--strategy.params.threshold=0.08、--strategy.params.target_weights "{SPY: 0.6, TLT: 0.4}")，
+也可用环境变量覆盖 (前缀 ` + config.EnvPrefix + `，路径段以双下划线分隔，如 ` + config.EnvPrefix + `STRATEGY__PARAMS__THRESHOLD=0.08)。
+命令行覆盖优先于环境变量。`,
+	}
+	rootCmd.SetArgs(args)
+	rootCmd.AddCommand(newRunCmd())
+	rootCmd.AddCommand(newCompareCmd())
+	rootCmd.AddCommand(newBehaviorCmd())
+	rootCmd.AddCommand(newContributionsCmd())
+	rootCmd.AddCommand(newAttributionCmd())
+	rootCmd.AddCommand(newRollingCmd())
+	rootCmd.AddCommand(newGridCmd())
+	rootCmd.AddCommand(newOptimizeCmd())
+	rootCmd.AddCommand(newStartDatesCmd())
+	rootCmd.AddCommand(newScenariosCmd())
+	rootCmd.AddCommand(newStressCmd())
+	rootCmd.AddCommand(newSignalCmd())
+	rootCmd.AddCommand(newDaemonCmd())
+	rootCmd.AddCommand(newRunsCmd())
+	rootCmd.AddCommand(newDataCmd())
+
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRunCmd 创建run命令
+func newRunCmd() *cobra.Command {
+	opts := &runOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "运行回测",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/result.json)")
+	cmd.Flags().StringVar(&opts.cacheDir, "cache-dir", ".cache/results", "结果缓存目录")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "忽略缓存，强制重新运行")
+	cmd.Flags().BoolVar(&opts.noCache, "no-cache", false, "不读写结果缓存")
+	cmd.Flags().StringVar(&opts.checkpoint, "save-checkpoint", "", "回测结束后将组合和策略状态保存为断点文件 (不使用缓存)")
+	cmd.Flags().StringVar(&opts.resume, "resume", "", "从断点文件之后的交易日继续回测 (不使用缓存)")
+	cmd.Flags().StringVar(&opts.extend, "extend", "", "增量回测状态文件：存在时只回测其后的新交易日并追加到已有结果，完成后更新该文件 (不使用缓存)")
+
+	return cmd
+}
+
+// run 加载配置并运行回测 (相同配置和数据命中缓存时直接返回已保存结果)
+func run(opts *runOptions) error {
+	cfg, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	// 选项和策略配置在创建运行目录之前校验，出错时不留下空的运行目录
+	if opts.extend != "" && opts.resume != "" {
+		return fmt.Errorf("--extend cannot be combined with --resume")
+	}
+	stateful := opts.checkpoint != "" || opts.resume != "" || opts.extend != ""
+	streaming := cfg.Output.SnapshotStream != ""
+
+	// 断点相关的运行依赖配置以外的状态，不读写缓存；流式输出快照需要实际运行
+	var resultCache *cache.Cache
+	key := ""
+	if !opts.noCache && !stateful && !streaming {
+		key, err = cache.Key(cfg.Source(), dataDirs(cfg)...)
+		if err != nil {
+			return err
+		}
+		resultCache = cache.New(opts.cacheDir)
+	}
+
+	if cfg.Backtest.Baselines && (stateful || len(cfg.Sleeves) > 0 || len(cfg.Strategies) > 0) {
+		return fmt.Errorf("baselines are only supported for single-strategy backtests without checkpoints")
+	}
+
+	if len(cfg.Sleeves) > 0 {
+		if stateful {
+			return fmt.Errorf("checkpoints are not supported for sleeve backtests")
+		}
+		if streaming {
+			return fmt.Errorf("snapshot stream is not supported for sleeve backtests")
+		}
+		sleeves, err := engine.NewSleevesFromConfig(cfg)
+		if err != nil {
+			return err
+		}
+		out, err := newRunOutput(cfg, opts)
+		if err != nil {
+			return err
+		}
+		return runSleeves(cfg, sleeves, resultCache, key, opts.force, out)
+	}
+	if len(cfg.Strategies) > 0 {
+		if stateful {
+			return fmt.Errorf("checkpoints are not supported for multi-strategy backtests")
+		}
+		if streaming {
+			return fmt.Errorf("snapshot stream is not supported for multi-strategy backtests")
+		}
+		strategyRuns, err := engine.NewStrategyRunsFromConfig(cfg)
+		if err != nil {
+			return err
+		}
+		out, err := newRunOutput(cfg, opts)
+		if err != nil {
+			return err
+		}
+		return runStrategies(cfg, strategyRuns, out)
+	}
+
+	backtestConfig, err := cfg.ToBacktestConfig()
+	if err != nil {
+		return err
+	}
+	s, err := strategy.New(cfg.ToStrategyConfig())
+	if err != nil {
+		return err
+	}
+	costModel, err := cost.New(cfg.ToCostConfig())
+	if err != nil {
+		return err
+	}
+
+	e := engine.New(backtestConfig)
+	e.SetDataLoader(data.NewCSVLoader(cfg.GetDataDir()))
+	e.SetCostModel(costModel)
+	e.SetStrategy(s)
+	e.SetConfigHash(runs.ConfigHash(cfg.Source()))
+	if len(backtestConfig.Currencies) > 0 {
+		e.SetFXLoader(data.NewFXLoader(cfg.GetFXDir()))
+	}
+	if opts.resume != "" {
+		cp, err := engine.LoadCheckpoint(opts.resume)
+		if err != nil {
+			return err
+		}
+		e.Resume(cp)
+	}
+	if opts.extend != "" {
+		if _, err := os.Stat(opts.extend); err == nil {
+			state, err := engine.LoadState(opts.extend)
+			if err != nil {
+				return err
+			}
+			if err := e.Extend(state); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read state: %w", err)
+		}
+	}
+
+	out, err := newRunOutput(cfg, opts)
+	if err != nil {
+		return err
+	}
+	outputFile := out.file
+	if streaming {
+		path := cfg.Output.SnapshotStream
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(outputFile), path)
+		}
+		e.SetSnapshotStream(path)
+	}
+
+	cached := false
+	if resultCache != nil && !opts.force {
+		result := &types.BacktestResult{}
+		hit, err := resultCache.Load(key, result)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if hit {
+			fmt.Printf("Using cached result %s (use --force to re-run)\n", key[:12])
+			e.RestoreResult(result)
+			cached = true
+		}
+	}
+
+	if !cached {
+		start := time.Now()
+		result, err := e.Run()
+		engine.NotifyCompletion(cfg.Notify, s.Name(), result, err, time.Since(start))
+		if errors.Is(err, engine.ErrRunLimitExceeded) {
+			// 中止的回测仍输出诊断结果，但不写入缓存
+			e.PrintSummary()
+			if exportErr := e.ExportResults(outputFile); exportErr != nil {
+				return exportErr
+			}
+			out.finish(cfg, e, result, err)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if cfg.Backtest.Baselines {
+			if err := engine.RunBaselines(cfg, result); err != nil {
+				return err
+			}
+		}
+		if resultCache != nil {
+			if err := resultCache.Store(key, result); err != nil {
+				fmt.Printf("Warning: failed to cache result: %v\n", err)
+			}
+		}
+		if opts.checkpoint != "" {
+			if err := e.SaveCheckpoint(opts.checkpoint); err != nil {
+				return err
+			}
+		}
+		if opts.extend != "" {
+			if err := e.SaveState(opts.extend); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.PrintSummary()
+
+	if err := e.ExportResults(outputFile); err != nil {
+		return err
+	}
+	out.finish(cfg, e, e.GetResult(), nil)
+	return nil
+}
+
+// newCompareCmd 创建compare命令 (同类标的持有成本比较)
+func newCompareCmd() *cobra.Command {
+	var configPath, output string
+
+	cmd := &cobra.Command{
+		Use:   "compare",
+		Short: "比较持有各同类标的 (配置中的 equivalents) 的回测结果",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "equivalents.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			runs, err := engine.NewEquivalentRunsFromConfig(cfg)
+			if err != nil {
+				return err
+			}
+			results, err := engine.CompareEquivalents(runs)
+			if err != nil {
+				return err
+			}
+			engine.PrintEquivalentResults(results)
+			return engine.ExportEquivalentResults(results, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/equivalents.json)")
+
+	return cmd
+}
+
+// newBehaviorCmd 创建behavior命令 (行为偏差回测与理想回测对比)
+func newBehaviorCmd() *cobra.Command {
+	var configPath, output string
+
+	cmd := &cobra.Command{
+		Use:   "behavior",
+		Short: "对比理想回测与带行为偏差 (配置中的 backtest.behavior) 的回测结果",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "behavior.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			comparison, err := engine.CompareBehavior(cfg)
+			if err != nil {
+				return err
+			}
+			engine.PrintBehaviorComparison(comparison)
+			return engine.ExportBehaviorComparison(comparison, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/behavior.json)")
+
+	return cmd
+}
+
+// newAttributionCmd 创建attribution命令 (估值信号与关闭信号的对照回测)
+func newAttributionCmd() *cobra.Command {
+	var configPath, output string
+
+	cmd := &cobra.Command{
+		Use:   "attribution",
+		Short: "对比估值策略与关闭估值信号 (按偏离阈值再平衡) 的回测结果，归因估值信号的增量收益和风险",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "attribution.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			attribution, err := engine.CompareSignalsOff(cfg)
+			if err != nil {
+				return err
+			}
+			engine.PrintSignalAttribution(attribution)
+			return engine.ExportSignalAttribution(attribution, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/attribution.json)")
+
+	return cmd
+}
+
+// newContributionsCmd 创建contributions命令 (条件规则追加投入与固定计划对比)
+func newContributionsCmd() *cobra.Command {
+	var configPath, output string
+
+	cmd := &cobra.Command{
+		Use:   "contributions",
+		Short: "对比按条件规则 (配置中的 backtest.contributions) 与按固定计划追加投入的回测结果",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "contributions.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			comparison, err := engine.CompareContributions(cfg)
+			if err != nil {
+				return err
+			}
+			engine.PrintContributionComparison(comparison)
+			return engine.ExportContributionComparison(comparison, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/contributions.json)")
+
+	return cmd
+}
+
+// newRollingCmd 创建rolling命令 (滚动窗口回测)
+func newRollingCmd() *cobra.Command {
+	var configPath, output string
+	var years []int
+	var step int
+
+	cmd := &cobra.Command{
+		Use:   "rolling",
+		Short: "在回测区间内按滚动起始日期运行多个固定长度窗口，汇总收益和回撤的分布",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "rolling.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			result, err := engine.RunRolling(cfg, years, step)
+			if err != nil {
+				return err
+			}
+			engine.PrintRollingResult(result)
+			return engine.ExportRollingResult(result, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/rolling.json)")
+	cmd.Flags().IntSliceVar(&years, "years", []int{3, 5}, "窗口长度 (年)，可指定多个")
+	cmd.Flags().IntVar(&step, "step", 3, "相邻窗口起始日期的间隔 (月)")
+
+	return cmd
+}
+
+// newGridCmd 创建grid命令 (二维参数扫描)
+func newGridCmd() *cobra.Command {
+	var configPath, output, xArg, yArg string
+
+	cmd := &cobra.Command{
+		Use:   "grid",
+		Short: "对两个配置项的每组取值运行回测，输出夏普和期末价值的CSV矩阵和HTML热力图",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			x, err := engine.ParseGridAxis(xArg)
+			if err != nil {
+				return fmt.Errorf("invalid --x: %w", err)
+			}
+			y, err := engine.ParseGridAxis(yArg)
+			if err != nil {
+				return fmt.Errorf("invalid --y: %w", err)
+			}
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "grid")
+			}
+			if err := os.MkdirAll(output, 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			result, err := engine.RunGrid(configLoader(configPath), x, y)
+			if err != nil {
+				return err
+			}
+			engine.PrintGridResult(result)
+			return engine.ExportGridResult(result, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出目录 (默认为配置中的输出目录/grid)")
+	cmd.Flags().StringVar(&xArg, "x", "", "列方向的配置项和取值，如 strategy.params.threshold=0.03,0.05,0.08")
+	cmd.Flags().StringVar(&yArg, "y", "", "行方向的配置项和取值，如 strategy.params.valuation.trim_ratio=0.1,0.2,0.3")
+	cmd.MarkFlagRequired("x")
+	cmd.MarkFlagRequired("y")
+
+	return cmd
+}
+
+// newOptimizeCmd 创建optimize命令 (遗传算法参数优化)
+func newOptimizeCmd() *cobra.Command {
+	var configPath, output, objective string
+	var paramArgs []string
+	var opts optimize.Options
+	var cv cvOptions
+
+	cmd := &cobra.Command{
+		Use:   "optimize",
+		Short: "用遗传算法在给定范围内搜索多个配置项的取值，最大化夏普、卡玛或收益/换手率",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params := make([]optimize.Param, 0, len(paramArgs))
+			for _, arg := range paramArgs {
+				p, err := optimize.ParseParam(arg)
+				if err != nil {
+					return fmt.Errorf("invalid --param: %w", err)
+				}
+				params = append(params, p)
+			}
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if objective == "" {
+				objective = string(optimize.ObjectiveSharpe)
+				if len(cfg.Optimize.Objective) > 0 {
+					objective = string(optimize.ObjectiveConfig)
+				}
+			}
+			if opts.Objective, err = optimize.ParseObjective(objective); err != nil {
+				return err
+			}
+			opts.Formula = cfg.Optimize
+			if opts.Folds, err = cv.folds(cfg); err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("seed") {
+				opts.Seed = cfg.Backtest.Seed
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "optimize.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			result, err := optimize.Run(configLoader(configPath), params, opts)
+			if err != nil {
+				return err
+			}
+			optimize.PrintResult(result)
+			return optimize.ExportResult(result, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/optimize.json)")
+	cmd.Flags().StringArrayVar(&paramArgs, "param", nil, "待优化的配置项和范围，如 strategy.params.valuation.trim_ratio=0.1:0.4，可指定多个")
+	cmd.Flags().StringVar(&objective, "objective", "", "优化目标: sharpe / calmar / return_turnover / config (配置的 optimize.objective，设置该段时为默认)")
+	cmd.Flags().IntVar(&opts.Population, "population", 20, "种群规模")
+	cmd.Flags().IntVar(&opts.Generations, "generations", 10, "代数")
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 0, "随机数种子 (默认为 backtest.seed)")
+	cmd.Flags().StringVar(&cv.method, "cv", "", "交叉验证: expanding (扩展窗口) / sliding (滑动窗口) / purged (K折)，默认不划分")
+	cmd.Flags().IntVar(&cv.trainMonths, "train-months", 36, "expanding 的首个 / sliding 的训练区间长度 (月)")
+	cmd.Flags().IntVar(&cv.testMonths, "test-months", 12, "expanding / sliding 的测试区间长度 (月)")
+	cmd.Flags().IntVar(&cv.k, "folds", 5, "purged 的折数")
+	cmd.Flags().IntVar(&cv.purgeDays, "purge-days", 0, "purged 中测试区间之前不用于训练的天数")
+	cmd.Flags().IntVar(&cv.embargoDays, "embargo-days", 0, "purged 中测试区间之后不用于训练的天数")
+	cmd.MarkFlagRequired("param")
+
+	return cmd
+}
+
+// cvOptions optimize 命令的交叉验证设置
+type cvOptions struct {
+	method                  string
+	trainMonths, testMonths int
+	k                       int
+	purgeDays, embargoDays  int
+}
+
+// folds 按配置的回测区间生成交叉验证划分 (未设置 --cv 时返回 nil)
+func (o cvOptions) folds(cfg *config.Config) ([]split.Fold, error) {
+	if o.method == "" {
+		return nil, nil
+	}
+	start, err := time.Parse("2006-01-02", cfg.Backtest.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", cfg.Backtest.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %w", err)
+	}
+	switch o.method {
+	case "expanding":
+		return split.ExpandingWindow(start, end, o.trainMonths, o.testMonths)
+	case "sliding":
+		return split.SlidingWindow(start, end, o.trainMonths, o.testMonths)
+	case "purged":
+		return split.Purged(start, end, o.k, o.purgeDays, o.embargoDays)
+	}
+	return nil, fmt.Errorf("unknown --cv %q (expected expanding, sliding or purged)", o.method)
+}
+
+// newStartDatesCmd 创建start-dates命令 (起始日期敏感性分析)
+func newStartDatesCmd() *cobra.Command {
+	var configPath, output string
+	var months int
+
+	cmd := &cobra.Command{
+		Use:   "start-dates",
+		Short: "将起始日期依次推迟 1..N 个月重新回测，分析收益和最大回撤对入场时点的敏感程度",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "start_dates.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			result, err := engine.RunStartDateSensitivity(cfg, months)
+			if err != nil {
+				return err
+			}
+			engine.PrintStartDateSensitivity(result)
+			return engine.ExportStartDateSensitivity(result, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/start_dates.json)")
+	cmd.Flags().IntVar(&months, "months", 12, "起始日期最多推迟的月数")
+
+	return cmd
+}
+
+// newScenariosCmd 创建scenarios命令 (预置危机区间压力测试)
+func newScenariosCmd() *cobra.Command {
+	var configPath, output string
+	var names []string
+
+	cmd := &cobra.Command{
+		Use:   "scenarios",
+		Short: "在预置的危机区间 (2015股灾、2018熊市、2020新冠、2022回撤) 上分别回测，报告回撤、恢复时间和交易次数",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "scenarios.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			results, err := engine.RunScenarios(cfg, names)
+			if err != nil {
+				return err
+			}
+			engine.PrintScenarioResults(results)
+			return engine.ExportScenarioResults(results, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/scenarios.json)")
+	cmd.Flags().StringSliceVar(&names, "scenarios", nil, "只运行指定的情景 (2015-crash, 2018-bear, 2020-covid, 2022-drawdown)，默认全部")
+
+	return cmd
+}
+
+// newStressCmd 创建stress命令 (合成冲击压力测试)
+func newStressCmd() *cobra.Command {
+	var configPath, output string
+
+	cmd := &cobra.Command{
+		Use:   "stress",
+		Short: "对数据施加配置的合成冲击 (backtest.shocks) 后回测，与未施加冲击的回测对比组合的响应",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "stress.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			report, err := engine.RunStress(cfg)
+			if err != nil {
+				return err
+			}
+			engine.PrintStressReport(report)
+			return engine.ExportStressReport(report, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/stress.json)")
+
+	return cmd
+}
+
+// newSignalCmd 创建signal命令 (按实盘当前持仓生成当日信号和建议订单)
+func newSignalCmd() *cobra.Command {
+	var configPath, holdingsPath, checkpointPath, output string
+	var ordersPath, orderFormat string
+	var lotSize float64
+
+	cmd := &cobra.Command{
+		Use:   "signal",
+		Short: "按当前持仓、现金和当日价格生成策略信号和建议订单 (不运行历史回测)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			if len(cfg.Sleeves) > 0 {
+				return fmt.Errorf("signal mode does not support sleeves, use one config per account")
+			}
+			holdings, err := config.LoadHoldings(holdingsPath)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				output = filepath.Join(cfg.GetOutputPath(), "signal.json")
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to create output dir: %w", err)
+			}
+
+			sleeve, err := engine.NewSleeve(cfg.Strategy.Name, cfg)
+			if err != nil {
+				return err
+			}
+			if checkpointPath != "" {
+				cp, err := engine.LoadCheckpoint(checkpointPath)
+				if err != nil {
+					return err
+				}
+				sleeve.Engine.Resume(cp)
+			}
+
+			signal, err := sleeve.Engine.LiveSignal(holdings)
+			if err != nil {
+				return err
+			}
+			engine.PrintLiveSignal(signal)
+			if ordersPath != "" {
+				if err := engine.ExportOrders(signal.Orders, orderFormat, cfg.LotSizes(), lotSize, holdings.Positions, ordersPath); err != nil {
+					return err
+				}
+			}
+			return engine.ExportLiveSignal(signal, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().StringVar(&holdingsPath, "holdings", "", "当前持仓文件 (日期、现金、持仓数量，可选当日价格和估值数据)")
+	cmd.Flags().StringVar(&checkpointPath, "checkpoint", "", "恢复策略内部状态的断点文件 (如上次再平衡日期)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "结果输出文件 (默认为配置中的输出目录/signal.json)")
+	cmd.Flags().StringVar(&ordersPath, "orders", "", "按券商导入格式导出建议订单的文件")
+	cmd.Flags().StringVar(&orderFormat, "order-format", engine.OrderFormatCSV, "订单导出格式: csv (通用) 或 cn (A股券商，按整手取整)")
+	cmd.Flags().Float64Var(&lotSize, "lot-size", engine.DefaultLotSize, "cn 格式的每手股数 (未设置 assets[].lot_size 的标的)")
+	cmd.MarkFlagRequired("holdings")
+
+	return cmd
+}
+
+// newDaemonCmd 创建daemon命令 (每个工作日定时生成信号，触发再平衡时通知)
+func newDaemonCmd() *cobra.Command {
+	var configPath string
+	var once bool
+
+	cmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "每个工作日定时刷新数据、按配置中 live 段的持仓生成信号，触发再平衡时发送通知",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				return err
+			}
+			return engine.RunDaemon(cfg, once)
+		},
+	}
+
+	cmd.Flags().StringVarP(&configPath, "config", "c", "configs/default.yaml", "配置文件路径")
+	cmd.Flags().BoolVar(&once, "once", false, "立即运行一次后退出 (可由 cron 等外部调度)")
+
+	return cmd
+}
+
+// runStrategies 依次运行 strategies 段的各策略并输出对比；
+// 使用运行目录时各策略的完整结果按配置中的顺序写入 <运行目录>/strategies/<序号>.json
+func runStrategies(cfg *config.Config, strategyRuns []*engine.StrategyRun, out *runOutput) error {
+	start := time.Now()
+	results, err := engine.CompareStrategies(strategyRuns)
+	if err != nil {
+		engine.NotifyCompletion(cfg.Notify, "strategies", nil, err, time.Since(start))
+		out.finish(cfg, nil, nil, err)
+		return err
+	}
+	for _, r := range results {
+		if r.Rank == 1 {
+			engine.NotifyCompletion(cfg.Notify, "strategies (best: "+r.Name+")", r.Result, nil, time.Since(start))
+		}
+	}
+
+	engine.PrintStrategyComparison(results)
+	if err := engine.ExportStrategyComparison(results, out.file); err != nil {
+		return err
+	}
+	if out.dir != "" {
+		dir := filepath.Join(out.dir, "strategies")
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output dir: %w", err)
+		}
+		for i, run := range strategyRuns {
+			if err := run.Sleeve.Engine.ExportResults(filepath.Join(dir, fmt.Sprintf("%d.json", i+1))); err != nil {
+				return err
+			}
+		}
+	}
+	out.finish(cfg, nil, nil, nil)
+	return nil
+}
+
+// splitOverrides 从命令行参数中分离 --a.b=value 或 --a.b value 形式 (名称含点) 的配置覆盖项
+func splitOverrides(args []string) ([]string, []config.Override, error) {
+	var rest []string
+	var overrides []config.Override
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name := strings.TrimPrefix(arg, "--")
+		if name == arg || !strings.Contains(strings.SplitN(name, "=", 2)[0], ".") {
+			rest = append(rest, arg)
+			continue
+		}
+		if !strings.Contains(name, "=") {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("missing value for --%s", name)
+			}
+			i++
+			name += "=" + args[i]
+		}
+		o, err := config.ParseOverride(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		overrides = append(overrides, o)
+	}
+	return rest, overrides, nil
+}
+
+// loadConfig 加载配置并应用命令行覆盖项
+func loadConfig(path string) (*config.Config, error) {
+	return config.LoadConfig(path, configOverrides...)
+}
+
+// configLoader 返回在命令行覆盖项之后追加覆盖项重新加载配置的函数 (参数扫描和优化的每次回测使用)
+func configLoader(path string) func(...config.Override) (*config.Config, error) {
+	return func(extra ...config.Override) (*config.Config, error) {
+		overrides := append(append([]config.Override(nil), configOverrides...), extra...)
+		return config.LoadConfig(path, overrides...)
+	}
+}
+
+// runSleeves 运行多子账户回测
+func runSleeves(cfg *config.Config, sleeves []*engine.Sleeve, resultCache *cache.Cache, key string, force bool, out *runOutput) error {
+	household := &engine.HouseholdResult{}
+	hit := false
+	if resultCache != nil && !force {
+		var err error
+		hit, err = resultCache.Load(key, household)
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		if hit {
+			fmt.Printf("Using cached result %s (use --force to re-run)\n", key[:12])
+		}
+	}
+
+	if !hit {
+		start := time.Now()
+		var err error
+		household, err = engine.RunSleeves(sleeves)
+		if err != nil {
+			engine.NotifyCompletion(cfg.Notify, "household", nil, err, time.Since(start))
+			return err
+		}
+		engine.NotifyCompletion(cfg.Notify, "household", household.Combined, nil, time.Since(start))
+		if resultCache != nil {
+			if err := resultCache.Store(key, household); err != nil {
+				fmt.Printf("Warning: failed to cache result: %v\n", err)
+			}
+		}
+	}
+
+	for _, sleeve := range household.Sleeves {
+		fmt.Printf("%-20s Final: %.2f  Return: %.2f%%\n", sleeve.Name, sleeve.Result.FinalValue, sleeve.Result.TotalReturn*100)
+	}
+	fmt.Printf("%-20s Final: %.2f  Return: %.2f%%\n", "Combined", household.Combined.FinalValue, household.Combined.TotalReturn*100)
+
+	if err := engine.ExportHouseholdResults(household, out.file); err != nil {
+		return err
+	}
+	out.finish(cfg, nil, household.Combined, nil)
+	return nil
+}
+
+// runOutput 一次运行的输出位置
+type runOutput struct {
+	file       string // 结果JSON
+	id         string // 运行ID
+	dir        string // 运行目录，flat 结构或指定 --output 时为空
+	configPath string
+}
+
+// newRunOutput 确定结果输出位置：指定 --output 时写入该文件；
+// 否则按 output.layout 写入 <输出目录>/<run-id>/ (默认) 或直接写入输出目录
+func newRunOutput(cfg *config.Config, opts *runOptions) (*runOutput, error) {
+	name := cfg.Strategy.Type
+	if len(cfg.Sleeves) > 0 {
+		name = "sleeves"
+	} else if len(cfg.Strategies) > 0 {
+		name = "strategies"
+	}
+	id := runs.ID(time.Now(), name, cfg.Source())
+
+	out := &runOutput{file: opts.output, id: id, configPath: opts.configPath}
+	if out.file == "" {
+		switch cfg.Output.Layout {
+		case runs.LayoutFlat:
+			out.file = filepath.Join(cfg.GetOutputPath(), "result.json")
+		case "", runs.LayoutRun:
+			var err error
+			out.id, out.dir, err = runs.Create(cfg.GetOutputPath(), id, cfg.Source())
+			if err != nil {
+				return nil, err
+			}
+			out.file = filepath.Join(out.dir, "result.json")
+		default:
+			return nil, fmt.Errorf("unknown output layout: %s", cfg.Output.Layout)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(out.file), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output dir: %w", err)
+	}
+	return out, nil
+}
+
+// finish 登记实验记录 (配置了 output.store 时)；run 目录结构下在运行目录中补充CSV和报告，
+// 并登记到输出目录的 index.json。e 为空 (多子账户) 时不导出CSV；失败只打印警告
+func (o *runOutput) finish(cfg *config.Config, e *engine.BacktestEngine, result *types.BacktestResult, runErr error) {
+	if cfg.Output.Store != "" {
+		o.record(cfg, result, runErr)
+	}
+	if o.dir == "" {
+		return
+	}
+	if e != nil {
+		if err := e.ExportCSV(o.dir); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+	if cfg.Output.GenerateReport && result != nil {
+		cmd := exec.Command("python3", "scripts/analyze.py", o.file, filepath.Join(o.dir, "report.html"))
+		if output, err := cmd.CombinedOutput(); err != nil {
+			lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+			fmt.Printf("Warning: report generation failed: %v (%s)\n", err, lines[len(lines)-1])
+		}
+	}
+
+	entry := runs.Entry{
+		ID:       o.id,
+		Time:     time.Now(),
+		Strategy: cfg.Strategy.Name,
+		Config:   o.configPath,
+		Dir:      o.dir,
+	}
+	if result != nil {
+		entry.FinalValue, entry.TotalReturn = result.FinalValue, result.TotalReturn
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	if err := runs.Record(cfg.GetOutputPath(), entry); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	fmt.Printf("Run %s saved to: %s\n", o.id, o.dir)
+}
+
+// record 将运行的配置项和指标写入实验记录
+func (o *runOutput) record(cfg *config.Config, result *types.BacktestResult, runErr error) {
+	params, err := store.FlattenConfig(cfg.Source())
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	name := cfg.Strategy.Name
+	if name == "" {
+		name = cfg.Strategy.Type
+	}
+	record := store.Record{
+		ID:         o.id,
+		Time:       time.Now(),
+		Strategy:   name,
+		Config:     o.configPath,
+		ConfigHash: runs.ConfigHash(cfg.Source()),
+		Params:     params,
+		Dir:        o.dir,
+	}
+	if result != nil {
+		record.Metrics = store.Metrics(result)
+	}
+	if runErr != nil {
+		record.Error = runErr.Error()
+	}
+	s, err := store.Open(cfg.Output.Store)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	defer s.Close()
+	if err := s.Add(record); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// dataDirs 配置引用的所有数据目录 (含各子账户)
+func dataDirs(cfg *config.Config) []string {
+	seen := make(map[string]bool)
+	dirs := make([]string, 0)
+	add := func(dir string) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			if _, err := os.Stat(dir); err == nil {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	add(cfg.GetDataDir())
+	add(cfg.GetFXDir())
+	for i := range cfg.Sleeves {
+		sleeve := cfg.SleeveConfig(i)
+		add(sleeve.GetDataDir())
+		add(sleeve.GetFXDir())
+	}
+	return dirs
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+)
+
+// summaryMetrics 列表中展示的指标
+var summaryMetrics = []string{"total_return", "annual_return", "max_drawdown", "sharpe"}
+
+// newRunsCmd 创建runs命令 (查询 output.store 中的实验记录)
+func newRunsCmd() *cobra.Command {
+	var storePath string
+
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "查看和比较历史运行的实验记录",
+	}
+	cmd.PersistentFlags().StringVar(&storePath, "store", "output/results.db", "实验记录数据库 (配置中的 output.store，.jsonl 为 JSON Lines 文件)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "列出全部运行及主要指标",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := store.Open(storePath)
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+			records, err := s.List()
+			if err != nil {
+				return err
+			}
+			printRecords(records)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <id>",
+		Short: "显示一次运行的配置项和指标",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := store.Open(storePath)
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+			record, err := s.Get(args[0])
+			if err != nil {
+				return err
+			}
+			printRecord(record)
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "diff <id1> <id2>",
+		Short: "比较两次运行的配置项和指标",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := store.Open(storePath)
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+			left, err := s.Get(args[0])
+			if err != nil {
+				return err
+			}
+			right, err := s.Get(args[1])
+			if err != nil {
+				return err
+			}
+			printRecordDiff(left, right)
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// printRecords 打印运行列表
+func printRecords(records []store.Record) {
+	if len(records) == 0 {
+		fmt.Println("No runs recorded")
+		return
+	}
+	fmt.Printf("%-40s %-17s %-20s", "ID", "Time", "Strategy")
+	for _, name := range summaryMetrics {
+		fmt.Printf(" %14s", name)
+	}
+	fmt.Println()
+	for _, record := range records {
+		fmt.Printf("%-40s %-17s %-20s", record.ID, record.Time.Format("2006-01-02 15:04"), record.Strategy)
+		if record.Error != "" {
+			fmt.Printf(" error: %s\n", record.Error)
+			continue
+		}
+		for _, name := range summaryMetrics {
+			fmt.Printf(" %14s", formatMetric(name, record.Metrics, false))
+		}
+		fmt.Println()
+	}
+}
+
+// printRecord 打印一次运行的详情
+func printRecord(record store.Record) {
+	fmt.Printf("Run:         %s\n", record.ID)
+	fmt.Printf("Time:        %s\n", record.Time.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Strategy:    %s\n", record.Strategy)
+	fmt.Printf("Config:      %s (%s)\n", record.Config, shortHash(record.ConfigHash))
+	if record.Dir != "" {
+		fmt.Printf("Directory:   %s\n", record.Dir)
+	}
+	if record.Error != "" {
+		fmt.Printf("Error:       %s\n", record.Error)
+	}
+
+	fmt.Println("\nMetrics:")
+	for _, name := range sortedKeys(record.Metrics) {
+		fmt.Printf("  %-20s %s\n", name, formatMetric(name, record.Metrics, false))
+	}
+	fmt.Println("\nParameters:")
+	keys := make([]string, 0, len(record.Params))
+	for key := range record.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  %s = %s\n", key, record.Params[key])
+	}
+}
+
+// printRecordDiff 打印两次运行不同的配置项和指标差异
+func printRecordDiff(left, right store.Record) {
+	fmt.Printf("Comparing %s -> %s\n", left.ID, right.ID)
+	if left.ConfigHash == right.ConfigHash {
+		fmt.Println("\nConfig: identical")
+	} else {
+		fmt.Println("\nParameters:")
+		for _, diff := range store.DiffParams(left, right) {
+			fmt.Printf("  %-40s %s -> %s\n", diff.Key, orNone(diff.Left), orNone(diff.Right))
+		}
+	}
+
+	names := sortedKeys(left.Metrics)
+	for _, name := range sortedKeys(right.Metrics) {
+		if _, ok := left.Metrics[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	fmt.Println("\nMetrics:")
+	for _, name := range names {
+		delta := "-"
+		l, lok := left.Metrics[name]
+		r, rok := right.Metrics[name]
+		if lok && rok {
+			delta = formatMetric(name, map[string]float64{name: r - l}, true)
+		}
+		fmt.Printf("  %-20s %14s %14s %14s\n", name,
+			formatMetric(name, left.Metrics, false), formatMetric(name, right.Metrics, false), delta)
+	}
+}
+
+// formatMetric 格式化指标值，比例类指标以百分比显示
+func formatMetric(name string, metrics map[string]float64, signed bool) string {
+	value, ok := metrics[name]
+	if !ok || math.IsNaN(value) {
+		return "-"
+	}
+	sign := ""
+	if signed {
+		sign = "+"
+	}
+	switch name {
+	case "total_return", "annual_return", "max_drawdown", "volatility":
+		return fmt.Sprintf("%"+sign+".2f%%", value*100)
+	case "total_trades":
+		return fmt.Sprintf("%"+sign+".0f", value)
+	}
+	return fmt.Sprintf("%"+sign+".2f", value)
+}
+
+// sortedKeys 按名称排序的指标名
+func sortedKeys(metrics map[string]float64) []string {
+	keys := make([]string, 0, len(metrics))
+	for key := range metrics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// shortHash 哈希的前12位
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// orNone 空值显示为 (none)
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
@@ -0,0 +1,121 @@
+//go:build ignore
+// +build ignore
+
+// generate 生成示例数据：按固定随机种子模拟的日频行情和估值 (合成数据，并非真实市场数据)
+// 用法: go run examples/data/generate.go -o examples/data
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// asset 模拟标的参数
+type asset struct {
+	symbol    string
+	name      string
+	assetType string
+	price     float64 // 初始价格
+	drift     float64 // 年化漂移
+	vol       float64 // 年化波动率
+	beta      float64 // 对共同股票因子的暴露
+	pe        float64 // 初始PE，0表示无估值数据
+	growth    float64 // 盈利年增长率
+	isCore    bool
+	isTech    bool
+}
+
+var assets = []asset{
+	{symbol: "SPY", name: "S&P 500 ETF", assetType: "ETF", price: 270, drift: 0.09, vol: 0.18, beta: 1, pe: 21, growth: 0.07, isCore: true},
+	{symbol: "QQQ", name: "Nasdaq 100 ETF", assetType: "ETF", price: 165, drift: 0.13, vol: 0.24, beta: 1.2, pe: 24, growth: 0.11, isTech: true},
+	{symbol: "TLT", name: "20+ Year Treasury Bond ETF", assetType: "债券", price: 120, drift: 0.01, vol: 0.13, beta: -0.2},
+	{symbol: "GLD", name: "SPDR Gold Shares", assetType: "黄金", price: 125, drift: 0.06, vol: 0.14, beta: 0.05},
+}
+
+func main() {
+	out := flag.String("o", "examples/data", "输出目录")
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(20200101))
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	var dates []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			dates = append(dates, d)
+		}
+	}
+
+	// 共同股票因子和各标的的特异冲击
+	dt := 1.0 / 252
+	market := make([]float64, len(dates))
+	for i := range market {
+		market[i] = rng.NormFloat64()
+	}
+
+	for _, a := range assets {
+		if err := write(filepath.Join(*out, a.symbol+".csv"), a, dates, market, dt, rng); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// write 模拟单个标的并写出CSV
+func write(path string, a asset, dates []time.Time, market []float64, dt float64, rng *rand.Rand) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"Date", "Open", "High", "Low", "Close", "Volume", "Adj Close", "PE", "PEG", "ROE", "Asset_Type", "Name", "Is_Core", "Is_Tech"})
+
+	price := a.price
+	earnings := 0.0
+	if a.pe > 0 {
+		earnings = price / a.pe
+	}
+	// 特异冲击按 beta 调整方差，使总波动率约等于 vol
+	idio := math.Sqrt(math.Max(1-a.beta*a.beta*0.5, 0.1))
+	for i, d := range dates {
+		shock := a.beta*math.Sqrt(0.5)*market[i] + idio*rng.NormFloat64()
+		open := price
+		price *= math.Exp((a.drift-a.vol*a.vol/2)*dt + a.vol*math.Sqrt(dt)*shock)
+		rangeWidth := price * a.vol * math.Sqrt(dt) * (0.5 + rng.Float64())
+		high := math.Max(open, price) + rangeWidth/2
+		low := math.Min(open, price) - rangeWidth/2
+		volume := 5e6 * (1 + rng.Float64())
+
+		pe, peg, roe := "0", "0", "0"
+		if earnings > 0 {
+			earnings *= math.Exp(a.growth * dt)
+			pe = fmt.Sprintf("%.2f", price/earnings)
+			peg = fmt.Sprintf("%.2f", price/earnings/(a.growth*100))
+			roe = "18"
+		}
+
+		w.Write([]string{
+			d.Format("2006-01-02"),
+			fmt.Sprintf("%.2f", open),
+			fmt.Sprintf("%.2f", high),
+			fmt.Sprintf("%.2f", low),
+			fmt.Sprintf("%.2f", price),
+			fmt.Sprintf("%.0f", volume),
+			fmt.Sprintf("%.2f", price),
+			pe, peg, roe,
+			a.assetType, a.name,
+			fmt.Sprint(a.isCore), fmt.Sprint(a.isTech),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,12 @@
+// Package examples 端到端示例：内置样例数据 + 每种策略类型的配置，
+// example_test.go 通过公开入口 pkg/backtest 运行这些配置。
+//
+// data/ 下的 SPY、QQQ、TLT、GLD 行情由 data/generate.go 按固定随机种子模拟生成
+// (几何布朗运动)，仅用于演示和回归测试，不是真实市场数据。重新生成:
+//
+//	go run examples/data/generate.go
+//
+// 在仓库根目录用命令行运行任一示例配置:
+//
+//	go run ./cmd/backtest run -c examples/configs/fixed_weight.yaml --no-cache
+package examples
@@ -0,0 +1,52 @@
+package examples_test
+
+import (
+	"fmt"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/backtest"
+)
+
+// run 运行示例配置并打印期末价值和交易笔数
+func run(name string) {
+	result, err := backtest.RunFile("configs/"+name+".yaml", backtest.WithDataDir("data"))
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Printf("final value: %.2f, trades: %d\n", result.FinalValue, result.TotalTrades)
+}
+
+func Example_fixedWeight() {
+	run("fixed_weight")
+	// Output: final value: 118988.70, trades: 20
+}
+
+func Example_timeBased() {
+	run("time_based")
+	// Output: final value: 117281.95, trades: 49
+}
+
+func Example_valuation() {
+	run("valuation")
+	// Output: final value: 116928.61, trades: 148
+}
+
+func Example_weightedValuation() {
+	run("weighted_valuation")
+	// Output: final value: 113877.92, trades: 160
+}
+
+func Example_multiLevel() {
+	run("multi_level")
+	// Output: final value: 118981.87, trades: 144
+}
+
+func Example_cppi() {
+	run("cppi")
+	// Output: final value: 113346.05, trades: 280
+}
+
+func Example_blackLitterman() {
+	run("black_litterman")
+	// Output: final value: 118423.16, trades: 103
+}
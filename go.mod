@@ -3,6 +3,10 @@ module github.com/opsxjacky/Rebalance-backtest
 go 1.14
 
 require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/klauspost/compress v1.15.15
 	github.com/spf13/cobra v1.10.2
+	golang.org/x/text v0.13.0
 	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/sqlite v1.21.2
 )
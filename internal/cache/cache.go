@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Version 缓存格式版本，结果结构或引擎逻辑变更时递增，使旧缓存失效
+const Version = "3"
+
+// Cache 回测结果缓存，以 配置+数据 的哈希为键
+type Cache struct {
+	dir string
+}
+
+// New 创建结果缓存
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// Key 根据配置内容和数据目录内容计算缓存键
+// 数据目录下所有文件 (含子目录，如fx) 的路径与内容都参与哈希
+func Key(config []byte, dataDirs ...string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "version:%s\n", Version)
+	h.Write(config)
+
+	dirs := append([]string(nil), dataDirs...)
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		files := make([]string, 0)
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to scan data dir %s: %w", dir, err)
+		}
+
+		sort.Strings(files)
+		for _, file := range files {
+			rel, _ := filepath.Rel(dir, file)
+			fmt.Fprintf(h, "file:%s\n", filepath.ToSlash(rel))
+			if err := hashFile(h, file); err != nil {
+				return "", fmt.Errorf("failed to hash %s: %w", file, err)
+			}
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile 将文件内容写入哈希
+func hashFile(w io.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// path 缓存文件路径
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Load 读取缓存结果到v，未命中时返回false
+func (c *Cache) Load(key string, v interface{}) (bool, error) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to parse cache %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Store 保存结果到缓存
+func (c *Cache) Store(key string, v interface{}) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	// 先写临时文件再重命名，避免中断时留下不完整的缓存
+	tmp := c.path(key) + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return os.Rename(tmp, c.path(key))
+}
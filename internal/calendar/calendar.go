@@ -0,0 +1,145 @@
+// Package calendar 交易所交易日历：NYSE 按规则计算假日，SSE/SZSE 使用内置的休市日期表
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Calendar 交易日历：周末和假日休市
+type Calendar struct {
+	Name     string
+	holidays map[string]bool // 工作日休市日期 (2006-01-02)
+	years    map[int]bool    // 有假日数据的年份 (规则计算的日历不限年份)
+	rules    func(year int) []time.Time
+}
+
+// Names 支持的日历名称
+var Names = []string{"NYSE", "SSE", "SZSE"}
+
+// New 按名称创建交易日历 (不区分大小写)，holidayFile 非空时从文件追加休市日期
+func New(name string, holidayFile string) (*Calendar, error) {
+	c := &Calendar{Name: strings.ToUpper(name), holidays: make(map[string]bool), years: make(map[int]bool)}
+	switch c.Name {
+	case "NYSE":
+		c.rules = nyseHolidays
+		c.add(nyseClosures...)
+	case "SSE", "SZSE":
+		// 沪深交易所休市安排相同
+		c.add(cnHolidays...)
+	default:
+		return nil, fmt.Errorf("unknown calendar: %s (available: %s)", name, strings.Join(Names, ", "))
+	}
+	if holidayFile != "" {
+		if err := c.load(holidayFile); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// add 追加休市日期 (2006-01-02)
+func (c *Calendar) add(dates ...string) {
+	for _, d := range dates {
+		c.holidays[d] = true
+		if t, err := time.Parse("2006-01-02", d); err == nil {
+			c.years[t.Year()] = true
+		}
+	}
+}
+
+// load 从文件读取休市日期：每行第一列为日期 (2006-01-02)，忽略空行、# 开头的注释和无法解析的行 (如表头)
+func (c *Calendar) load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open holiday file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		field := strings.TrimSpace(strings.Split(line, ",")[0])
+		if t, err := time.Parse("2006-01-02", field); err == nil {
+			c.add(t.Format("2006-01-02"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read holiday file: %w", err)
+	}
+	return nil
+}
+
+// Covers 日历是否有该年的假日数据 (没有时只排除周末)
+func (c *Calendar) Covers(year int) bool {
+	return c.rules != nil || c.years[year]
+}
+
+// IsTradingDay 是否为交易日
+func (c *Calendar) IsTradingDay(date time.Time) bool {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false
+	}
+	key := date.Format("2006-01-02")
+	if c.holidays[key] {
+		return false
+	}
+	if c.rules != nil {
+		for _, h := range c.rules(date.Year()) {
+			if h.Format("2006-01-02") == key {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Days 返回 [start, end] 内的全部交易日 (UTC 零点)
+func (c *Calendar) Days(start, end time.Time) []time.Time {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+
+	rules := make(map[int]map[string]bool)
+	days := make([]time.Time, 0)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			continue
+		}
+		key := d.Format("2006-01-02")
+		if c.holidays[key] {
+			continue
+		}
+		if c.rules != nil {
+			holidays, ok := rules[d.Year()]
+			if !ok {
+				holidays = make(map[string]bool)
+				for _, h := range c.rules(d.Year()) {
+					holidays[h.Format("2006-01-02")] = true
+				}
+				rules[d.Year()] = holidays
+			}
+			if holidays[key] {
+				continue
+			}
+		}
+		days = append(days, d)
+	}
+	return days
+}
+
+// UncoveredYears [start, end] 内没有假日数据的年份
+func (c *Calendar) UncoveredYears(start, end time.Time) []int {
+	years := make([]int, 0)
+	for y := start.Year(); y <= end.Year(); y++ {
+		if !c.Covers(y) {
+			years = append(years, y)
+		}
+	}
+	return years
+}
@@ -0,0 +1,120 @@
+package calendar
+
+import "time"
+
+// nyseHolidays 按NYSE规则计算某年的假日 (周六的假日提前到周五、周日的顺延到周一，元旦逢周六不补休)
+func nyseHolidays(year int) []time.Time {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	observed := func(d time.Time) time.Time {
+		switch d.Weekday() {
+		case time.Saturday:
+			return d.AddDate(0, 0, -1)
+		case time.Sunday:
+			return d.AddDate(0, 0, 1)
+		}
+		return d
+	}
+
+	holidays := make([]time.Time, 0, 10)
+	if newYear := date(time.January, 1); newYear.Weekday() != time.Saturday {
+		holidays = append(holidays, observed(newYear))
+	}
+	if year >= 1998 {
+		holidays = append(holidays, nthWeekday(year, time.January, time.Monday, 3)) // 马丁·路德·金纪念日
+	}
+	holidays = append(holidays,
+		nthWeekday(year, time.February, time.Monday, 3), // 总统日
+		easter(year).AddDate(0, 0, -2),                  // 耶稣受难日
+		lastWeekday(year, time.May, time.Monday),        // 阵亡将士纪念日
+	)
+	if year >= 2022 {
+		holidays = append(holidays, observed(date(time.June, 19))) // 六月节
+	}
+	holidays = append(holidays,
+		observed(date(time.July, 4)),
+		nthWeekday(year, time.September, time.Monday, 1),  // 劳动节
+		nthWeekday(year, time.November, time.Thursday, 4), // 感恩节
+		observed(date(time.December, 25)),
+	)
+	return holidays
+}
+
+// nthWeekday 某月第 n 个星期 weekday
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	d := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(d.Weekday()) + 7) % 7
+	return d.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday 某月最后一个星期 weekday
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	d := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(d.Weekday()) - int(weekday) + 7) % 7
+	return d.AddDate(0, 0, -offset)
+}
+
+// easter 复活节日期 (公历，Anonymous Gregorian algorithm)
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// nyseClosures NYSE规则假日以外的临时休市
+var nyseClosures = []string{
+	"2001-09-11", "2001-09-12", "2001-09-13", "2001-09-14", // 9·11事件
+	"2004-06-11",               // 里根国葬
+	"2007-01-02",               // 福特国葬
+	"2012-10-29", "2012-10-30", // 飓风桑迪
+	"2018-12-05", // 老布什国葬
+	"2025-01-09", // 卡特国葬
+}
+
+// cnHolidays 沪深交易所的工作日休市日期 (按交易所公布的休市安排，2022-2025年)；
+// 其他年份可通过 backtest.calendar_holidays 文件补充
+var cnHolidays = []string{
+	// 2022
+	"2022-01-03",
+	"2022-01-31", "2022-02-01", "2022-02-02", "2022-02-03", "2022-02-04",
+	"2022-04-04", "2022-04-05",
+	"2022-05-02", "2022-05-03", "2022-05-04",
+	"2022-06-03",
+	"2022-09-12",
+	"2022-10-03", "2022-10-04", "2022-10-05", "2022-10-06", "2022-10-07",
+	// 2023
+	"2023-01-02",
+	"2023-01-23", "2023-01-24", "2023-01-25", "2023-01-26", "2023-01-27",
+	"2023-04-05",
+	"2023-05-01", "2023-05-02", "2023-05-03",
+	"2023-06-22", "2023-06-23",
+	"2023-09-29", "2023-10-02", "2023-10-03", "2023-10-04", "2023-10-05", "2023-10-06",
+	// 2024
+	"2024-01-01",
+	"2024-02-09", "2024-02-12", "2024-02-13", "2024-02-14", "2024-02-15", "2024-02-16",
+	"2024-04-04", "2024-04-05",
+	"2024-05-01", "2024-05-02", "2024-05-03",
+	"2024-06-10",
+	"2024-09-16", "2024-09-17",
+	"2024-10-01", "2024-10-02", "2024-10-03", "2024-10-04", "2024-10-07",
+	// 2025
+	"2025-01-01",
+	"2025-01-28", "2025-01-29", "2025-01-30", "2025-01-31", "2025-02-03", "2025-02-04",
+	"2025-04-04",
+	"2025-05-01", "2025-05-02", "2025-05-05",
+	"2025-06-02",
+	"2025-10-01", "2025-10-02", "2025-10-03", "2025-10-06", "2025-10-07", "2025-10-08",
+}
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
@@ -16,63 +22,470 @@ type Config struct {
 	Strategy StrategySection `yaml:"strategy"`
 	Costs    CostsSection    `yaml:"costs"`
 	Output   OutputSection   `yaml:"output"`
+	Sleeves  []SleeveSection `yaml:"sleeves"`
+
+	// Strategies 多个策略共用回测、标的和成本配置分别运行并对比，
+	// 未设置 target_weights 的策略继承 strategy 段的目标权重
+	Strategies []StrategySection `yaml:"strategies"`
+
+	Constraints ConstraintsSection `yaml:"constraints"`
+	Equivalents []EquivalentGroup  `yaml:"equivalents"`
+
+	Live   LiveSection   `yaml:"live"`
+	Notify NotifySection `yaml:"notify"`
+
+	Optimize OptimizeSection `yaml:"optimize"`
+
+	source []byte // 生效的配置内容 (应用覆盖项后)
+}
+
+// EquivalentGroup 可互相替代的同类标的 (如跟踪同一指数、费率和价差不同的ETF)
+// 组内在 assets 中的标的为当前持有的标的，比较时依次替换为组内其他标的
+type EquivalentGroup struct {
+	Name        string        `yaml:"name"`
+	Instruments []AssetConfig `yaml:"instruments"`
+}
+
+// ConstraintsSection 目标权重约束配置
+type ConstraintsSection struct {
+	MaxWeight float64                     `yaml:"max_weight"` // 任一标的权重上限
+	Symbols   map[string]WeightBoundsYAML `yaml:"symbols"`    // 单个标的上下限
+	Classes   map[string]WeightBoundsYAML `yaml:"classes"`    // 资产类别上下限 (类别见 assets.class)
+}
+
+// WeightBoundsYAML 权重上下限
+type WeightBoundsYAML struct {
+	Min float64 `yaml:"min"`
+	Max float64 `yaml:"max"`
+}
+
+// OptimizeMetrics 参数优化的目标函数和约束可以使用的指标
+var OptimizeMetrics = []string{
+	"sharpe", "calmar", "annual_return", "total_return", "volatility", "max_drawdown",
+	"turnover", "total_trades", "total_fees", "final_value",
+}
+
+// OptimizeSection 参数优化 (optimize 命令) 的目标函数：得分为各指标的加权和，
+// 违反约束时按超出量 × penalty 扣分 (约束对内置目标同样生效)
+type OptimizeSection struct {
+	Objective   map[string]float64   `yaml:"objective"`   // 指标 → 权重，如 {sharpe: 1, turnover: -0.1}
+	Constraints []OptimizeConstraint `yaml:"constraints"` // 如 {metric: max_drawdown, max: 0.2}
+	Penalty     float64              `yaml:"penalty"`     // 每单位超出量的扣分 (默认10)
+}
+
+// OptimizeConstraint 指标的上下限 (未设置的一侧不限制)
+type OptimizeConstraint struct {
+	Metric string   `yaml:"metric"`
+	Min    *float64 `yaml:"min"`
+	Max    *float64 `yaml:"max"`
+}
+
+// SleeveSection 子账户配置 (未配置的部分继承顶层配置)
+type SleeveSection struct {
+	Name           string          `yaml:"name"`
+	InitialCapital float64         `yaml:"initial_capital"`
+	DataDir        string          `yaml:"data_dir"`
+	Assets         []AssetConfig   `yaml:"assets"`
+	Strategy       StrategySection `yaml:"strategy"`
+	Costs          *CostsSection   `yaml:"costs"` // 只覆盖设置的项，其余沿用顶层 costs
 }
 
 // BacktestSection 回测配置
 type BacktestSection struct {
-	StartDate      string  `yaml:"start_date"`
-	EndDate        string  `yaml:"end_date"`
-	InitialCapital float64 `yaml:"initial_capital"`
-	Benchmark      string  `yaml:"benchmark"`
-	DataDir        string  `yaml:"data_dir"`
+	StartDate          string               `yaml:"start_date"`
+	EndDate            string               `yaml:"end_date"`
+	InitialCapital     float64              `yaml:"initial_capital"`
+	Benchmark          string               `yaml:"benchmark"`
+	BenchmarkBlend     map[string]float64   `yaml:"benchmark_blend"`     // 加权混合基准，如 {"000300": 0.6, "H11006": 0.4}
+	BenchmarkRebalance string               `yaml:"benchmark_rebalance"` // 混合基准再平衡频率 portfolio / monthly / quarterly / yearly / none
+	DataDir            string               `yaml:"data_dir"`
+	Stop               StopSection          `yaml:"stop"`
+	BaseCurrency       string               `yaml:"base_currency"`
+	FXDir              string               `yaml:"fx_dir"`
+	LiquidateAtEnd     bool                 `yaml:"liquidate_at_end"`
+	Execution          string               `yaml:"execution"`      // close / open / next_open / vwap
+	ExecutionLag       int                  `yaml:"execution_lag"`  // T日决策，T+N日成交
+	ScaleBuys          bool                 `yaml:"scale_buys"`     // 现金不足时按比例缩放所有买单
+	MaxVolumePct       float64              `yaml:"max_volume_pct"` // 单日成交不超过当日成交量的比例
+	Dust               DustSection          `yaml:"dust"`
+	InitialBuild       string               `yaml:"initial_build"` // target / strategy
+	Orders             OrderSection         `yaml:"orders"`
+	Margin             MarginSection        `yaml:"margin"`
+	CoveragePolicy     string               `yaml:"coverage_policy"` // error / shrink / stage
+	Inception          InceptionSection     `yaml:"inception"`
+	Limits             LimitsSection        `yaml:"limits"`
+	KillSwitch         KillSwitchSection    `yaml:"kill_switch"`
+	CostGate           CostGateSection      `yaml:"cost_gate"`
+	Behavior           BehaviorSection      `yaml:"behavior"`
+	Contributions      ContributionsSection `yaml:"contributions"`
+	RankWindowYears    int                  `yaml:"rank_window_years"` // 数据只有原始PE/PB时，按该窗口计算滚动百分位
+	Shocks             []ShockSection       `yaml:"shocks"`
+	Seed               int64                `yaml:"seed"`              // 随机数种子 (行为偏差模拟、合成数据等随机组件共用)
+	Baselines          bool                 `yaml:"baselines"`         // 同时运行买入持有和每年再平衡的对照回测，摘要中报告再平衡收益
+	Calendar           string               `yaml:"calendar"`          // 交易日历 NYSE/SSE/SZSE，为空时交易日为各标的日期的并集
+	CalendarHolidays   string               `yaml:"calendar_holidays"` // 追加的休市日期文件 (每行一个日期)
+}
+
+// ShockSection 合成价格冲击配置 (压力测试)，return 和 yield_change_bp 二选一
+type ShockSection struct {
+	Name          string   `yaml:"name"`
+	Start         string   `yaml:"start"`           // 冲击开始日期
+	Days          int      `yaml:"days"`            // 冲击持续的交易日数，默认1
+	Class         string   `yaml:"class"`           // 受冲击的资产类别 (见 assets.class)
+	Symbols       []string `yaml:"symbols"`         // 受冲击的标的 (与 class 合并)
+	Return        float64  `yaml:"return"`          // 冲击期内的累计价格变动，如 -0.3
+	YieldChangeBP float64  `yaml:"yield_change_bp"` // 收益率变动 (基点)，价格变动为 -久期×变动
+	Duration      float64  `yaml:"duration"`        // 修正久期 (设置 yield_change_bp 时必填)
+}
+
+// KillSwitchSection 止损开关配置
+type KillSwitchSection struct {
+	StopSection `yaml:",inline"`
+	SafeWeights map[string]float64 `yaml:"safe_weights"` // 避险配置，为空表示全部转为现金
+}
+
+// CostGateSection 再平衡成本收益门槛配置
+type CostGateSection struct {
+	MaxCostRatio float64 `yaml:"max_cost_ratio"` // 交易成本占偏离减少市值的上限，0表示不启用
+}
+
+// BehaviorSection 投资者行为偏差模拟配置
+type BehaviorSection struct {
+	SkipProbability float64 `yaml:"skip_probability"`  // 随机跳过再平衡的概率
+	DelayDays       int     `yaml:"delay_days"`        // 额外推迟成交的交易日数
+	NoBuyAfterLoss  float64 `yaml:"no_buy_after_loss"` // 上月亏损超过该比例时当月不买入
+	Seed            int64   `yaml:"seed"`
+}
+
+// InceptionSection 回测期间上市的标的的分批建仓配置 (coverage_policy: stage)
+type InceptionSection struct {
+	PhaseInSteps    int `yaml:"phase_in_steps"`    // 分批次数，默认1
+	PhaseInInterval int `yaml:"phase_in_interval"` // 相邻两批间隔的交易日数，默认5
+}
+
+// ContributionsSection 定期追加投入配置
+type ContributionsSection struct {
+	Monthly        float64 `yaml:"monthly"`          // 每月投入金额
+	BoostDrawdown  float64 `yaml:"boost_drawdown"`   // 基准回撤超过该比例时加大投入
+	BoostFactor    float64 `yaml:"boost_factor"`     // 加大投入的倍数，默认2
+	PauseCashAbove float64 `yaml:"pause_cash_above"` // 现金超过该金额时暂停投入
+}
+
+// LimitsSection 运行限制配置
+type LimitsSection struct {
+	MaxWallTime   string `yaml:"max_wall_time"` // 如 "30s"、"5m"
+	MaxTrades     int    `yaml:"max_trades"`
+	MaxRebalances int    `yaml:"max_rebalances"`
+}
+
+// MarginSection 融资融券配置
+type MarginSection struct {
+	AllowShort       bool    `yaml:"allow_short"`        // 允许负权重 (做空)
+	MaxGrossExposure float64 `yaml:"max_gross_exposure"` // 总敞口上限 (如1.5表示150%)
+}
+
+// OrderSection 下单方式配置
+type OrderSection struct {
+	Type        string  `yaml:"type"`         // market / limit
+	LimitOffset float64 `yaml:"limit_offset"` // 限价相对决策价的偏移
+	TTL         int     `yaml:"ttl"`          // 限价单有效交易日数
+	MinValue    float64 `yaml:"min_value"`    // 同一标的订单合并后的最小下单金额
+}
+
+// DustSection 碎仓清理配置
+type DustSection struct {
+	MaxWeight float64 `yaml:"max_weight"`
+	MaxValue  float64 `yaml:"max_value"`
+}
+
+// StopSection 提前终止条件配置
+type StopSection struct {
+	MaxDrawdown     float64 `yaml:"max_drawdown"`
+	ValueFloor      float64 `yaml:"value_floor"`
+	MaxLosingMonths int     `yaml:"max_losing_months"`
 }
 
 // AssetConfig 资产配置
 type AssetConfig struct {
-	Symbol string `yaml:"symbol"`
-	Name   string `yaml:"name"`
+	Symbol       string             `yaml:"symbol"`
+	Name         string             `yaml:"name"`
+	Currency     string             `yaml:"currency"`      // 计价币种，为空时视为基础币种
+	Haircut      float64            `yaml:"haircut"`       // 估值折扣，如0.005表示按收盘价的99.5%估值
+	Class        string             `yaml:"class"`         // 资产类别 (如 equity / bond)，用于类别约束
+	ExpenseRatio float64            `yaml:"expense_ratio"` // 年管理费率 (价格数据未扣除费率时设置)
+	Spread       float64            `yaml:"spread"`        // 单边买卖价差成本 (占成交额)
+	Benchmark    string             `yaml:"benchmark"`     // 资产的基准标的 (相对漂移模式)，为空时以自身为基准
+	Delisted     string             `yaml:"delisted"`      // 退市日期，当日按最后价格清仓
+	Halts        []HaltSection      `yaml:"halts"`         // 停牌区间
+	LotSize      float64            `yaml:"lot_size"`      // 交易单位 (ETF/股票整股为1，A股一手为100)，为0时允许零碎份额 (如场外基金)
+	MutualFund   *MutualFundSection `yaml:"mutual_fund"`   // 场外基金申赎规则，设置后按净值申赎
+}
+
+// MutualFundSection 场外基金申赎规则配置
+type MutualFundSection struct {
+	SettlementDays   int                      `yaml:"settlement_days"`   // 赎回款到账的交易日数 (T+N)
+	SubscriptionFees []SubscriptionFeeSection `yaml:"subscription_fees"` // 申购费率表，按金额从低到高
+	RedemptionFees   []RedemptionFeeSection   `yaml:"redemption_fees"`   // 赎回费率表，按持有天数从短到长
+}
+
+// SubscriptionFeeSection 申购费率档
+type SubscriptionFeeSection struct {
+	Below float64 `yaml:"below"` // 申购金额上限 (不含)，省略表示不设上限
+	Rate  float64 `yaml:"rate"`
+	Fixed float64 `yaml:"fixed"` // 每笔固定费用，设置后代替费率
+}
+
+// RedemptionFeeSection 赎回费率档
+type RedemptionFeeSection struct {
+	Days int     `yaml:"days"` // 持有天数上限 (不含)，省略表示不设上限
+	Rate float64 `yaml:"rate"`
+}
+
+// HaltSection 停牌区间配置
+type HaltSection struct {
+	Start string `yaml:"start"` // 停牌日
+	End   string `yaml:"end"`   // 复牌日，为空表示停牌至回测结束
 }
 
 // StrategySection 策略配置
 type StrategySection struct {
-	Type   string             `yaml:"type"`
-	Name   string             `yaml:"name"`
-	Params StrategyParams     `yaml:"params"`
+	Type   string         `yaml:"type"`
+	Name   string         `yaml:"name"`
+	Params StrategyParams `yaml:"params"`
 }
 
 // StrategyParams 策略参数
 type StrategyParams struct {
-	TargetWeights        map[string]float64  `yaml:"target_weights"`
-	Threshold            float64             `yaml:"threshold"`
-	RebalanceInterval    int                 `yaml:"rebalance_interval"`
-	MinTradeValue        float64             `yaml:"min_trade_value"`
-	MinRebalanceInterval int                 `yaml:"min_rebalance_interval"`
-	Valuation            *ValuationParamsYAML `yaml:"valuation"`
+	TargetWeights        map[string]float64        `yaml:"target_weights"`
+	Threshold            float64                   `yaml:"threshold"`
+	ThresholdMode        string                    `yaml:"threshold_mode"` // absolute / relative
+	RebalanceInterval    int                       `yaml:"rebalance_interval"`
+	MinTradeValue        float64                   `yaml:"min_trade_value"`
+	MinRebalanceInterval int                       `yaml:"min_rebalance_interval"`
+	RebalanceMode        string                    `yaml:"rebalance_mode"` // target / band / halfway / worst
+	RebalanceTopK        int                       `yaml:"rebalance_top_k"`
+	MinCashWeight        float64                   `yaml:"min_cash_weight"`
+	AssetClasses         map[string]AssetClassYAML `yaml:"asset_classes"`
+	Trend                TrendYAML                 `yaml:"trend"`
+	DriftMode            string                    `yaml:"drift_mode"` // static / relative
+	Sizing               string                    `yaml:"sizing"`     // fixed / kelly
+	Valuation            *ValuationParamsYAML      `yaml:"valuation"`
+	Kelly                *KellyYAML                `yaml:"kelly"`
+	CPPI                 *CPPIYAML                 `yaml:"cppi"`
+	BlackLitterman       *BlackLittermanYAML       `yaml:"black_litterman"`
+	Components           []ComponentYAML           `yaml:"components"` // 组合策略的子策略
+	Voting               string                    `yaml:"voting"`     // any / majority / all
+	Regime               *RegimeYAML               `yaml:"regime"`
+
+	// 按标的的偏离阈值和最小交易金额，来自 target_weights 中写成 {weight, threshold, min_trade_value} 的条目
+	SymbolThresholds     map[string]float64 `yaml:"-"`
+	SymbolMinTradeValues map[string]float64 `yaml:"-"`
+}
+
+// TargetWeightYAML target_weights 中带单独阈值的条目，如 {weight: 0.05, threshold: 0.02, min_trade_value: 200}
+type TargetWeightYAML struct {
+	Weight        float64  `yaml:"weight"`
+	Threshold     *float64 `yaml:"threshold"`
+	MinTradeValue *float64 `yaml:"min_trade_value"`
+}
+
+// UnmarshalYAML target_weights 的条目可以是权重，也可以是带单独阈值的 TargetWeightYAML
+func (p *StrategyParams) UnmarshalYAML(value *yaml.Node) error {
+	type plain StrategyParams
+	node := value
+	var entries map[string]TargetWeightYAML
+	if value.Kind == yaml.MappingNode {
+		if weights := lookup(value, "target_weights"); weights != nil && weights.Kind == yaml.MappingNode {
+			flat := *weights
+			flat.Content = make([]*yaml.Node, len(weights.Content))
+			copy(flat.Content, weights.Content)
+			for i := 0; i+1 < len(flat.Content); i += 2 {
+				if flat.Content[i+1].Kind != yaml.MappingNode {
+					continue
+				}
+				var entry TargetWeightYAML
+				if err := flat.Content[i+1].Decode(&entry); err != nil {
+					return err
+				}
+				if entries == nil {
+					entries = make(map[string]TargetWeightYAML)
+				}
+				entries[flat.Content[i].Value] = entry
+				flat.Content[i+1] = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(entry.Weight, 'g', -1, 64)}
+			}
+			if entries != nil {
+				node = &yaml.Node{Kind: yaml.MappingNode, Tag: value.Tag, Content: make([]*yaml.Node, len(value.Content))}
+				copy(node.Content, value.Content)
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					if node.Content[i+1] == weights {
+						node.Content[i+1] = &flat
+					}
+				}
+			}
+		}
+	}
+
+	if err := node.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	for symbol, entry := range entries {
+		if entry.Threshold != nil {
+			if p.SymbolThresholds == nil {
+				p.SymbolThresholds = make(map[string]float64)
+			}
+			p.SymbolThresholds[symbol] = *entry.Threshold
+		}
+		if entry.MinTradeValue != nil {
+			if p.SymbolMinTradeValues == nil {
+				p.SymbolMinTradeValues = make(map[string]float64)
+			}
+			p.SymbolMinTradeValues[symbol] = *entry.MinTradeValue
+		}
+	}
+	return nil
+}
+
+// RegimeYAML 状态切换策略配置，子策略未设置 target_weights 时继承外层的目标权重
+type RegimeYAML struct {
+	Indicator string           `yaml:"indicator"` // ma / volatility
+	Symbol    string           `yaml:"symbol"`    // 判断状态的标的，默认为回测基准
+	Window    int              `yaml:"window"`    // 均线或波动率窗口 (交易日)
+	Threshold float64          `yaml:"threshold"` // 波动率阈值 (年化)
+	Band      float64          `yaml:"band"`      // 切换缓冲 (相对切换点的比例)
+	RiskOn    *StrategySection `yaml:"risk_on"`
+	RiskOff   *StrategySection `yaml:"risk_off"`
+}
+
+// ComponentYAML 组合策略的子策略配置，未设置 target_weights 时继承组合策略的目标权重
+type ComponentYAML struct {
+	Weight          float64 `yaml:"weight"`
+	StrategySection `yaml:",inline"`
+}
+
+// KellyYAML 分数凯利仓位配置 (未设置的项使用默认值)
+type KellyYAML struct {
+	Fraction       float64 `yaml:"fraction"`        // 凯利比例
+	ExpectedReturn float64 `yaml:"expected_return"` // 最强信号对应的年化超额收益
+	VolWindow      int     `yaml:"vol_window"`      // 波动率窗口 (交易日)
+	DefaultVol     float64 `yaml:"default_vol"`     // 历史不足时的波动率
+	MaxTilt        float64 `yaml:"max_tilt"`        // 加仓倾斜上限 (相对基础权重)
+	MaxCut         float64 `yaml:"max_cut"`         // 减仓倾斜上限 (相对基础权重)
+}
+
+// BlackLittermanYAML Black-Litterman 观点融合配置 (未设置的项使用默认值)
+type BlackLittermanYAML struct {
+	RiskAversion float64 `yaml:"risk_aversion"` // 风险厌恶系数
+	ViewReturn   float64 `yaml:"view_return"`   // 百分位处于两端时的观点超额收益
+	Confidence   float64 `yaml:"confidence"`    // 观点置信度
+	VolWindow    int     `yaml:"vol_window"`    // 波动率窗口 (交易日)
+	DefaultVol   float64 `yaml:"default_vol"`   // 历史不足时的波动率
+}
+
+// CPPIYAML CPPI策略配置 (未设置的项使用默认值)
+type CPPIYAML struct {
+	Floor       float64            `yaml:"floor"`        // 保底价值占初始资金的比例
+	Multiplier  float64            `yaml:"multiplier"`   // 乘数
+	MaxExposure float64            `yaml:"max_exposure"` // 风险资产权重上限
+	FloorGrowth float64            `yaml:"floor_growth"` // 保底价值年增长率
+	Ratchet     bool               `yaml:"ratchet"`      // 保底价值随组合新高上移
+	SafeWeights map[string]float64 `yaml:"safe_weights"` // 安全资产权重，为空表示持有现金
+}
+
+// TrendYAML 均线趋势过滤配置
+type TrendYAML struct {
+	MAWindow   int     `yaml:"ma_window"`   // 均线窗口 (交易日)，0表示不启用
+	TrimFactor float64 `yaml:"trim_factor"` // 价格低于均线时的额外减仓比例
+}
+
+// AssetClassYAML 资产类别配置，symbols 为空时取 assets 中 class 为该类别的标的
+type AssetClassYAML struct {
+	Weight  float64  `yaml:"weight"`
+	Symbols []string `yaml:"symbols"`
 }
 
-// ValuationParamsYAML 估值参数YAML配置
+// ValuationParamsYAML 估值参数YAML配置，按键是否出现合并：未设置的项使用默认值，显式设置为0的项保留0 (如关闭对应档位)
 type ValuationParamsYAML struct {
-	ExtremeHighPERank float64 `yaml:"extreme_high_pe_rank"`
-	HighPERank        float64 `yaml:"high_pe_rank"`
-	LowPERank         float64 `yaml:"low_pe_rank"`
-	CoreLowPERank     float64 `yaml:"core_low_pe_rank"`
-	HighPEG           float64 `yaml:"high_peg"`
-	BubblePEG         float64 `yaml:"bubble_peg"`
-	LowPEG            float64 `yaml:"low_peg"`
-	GoodROE           float64 `yaml:"good_roe"`
-	PoorROE           float64 `yaml:"poor_roe"`
-	TrimRatio         float64 `yaml:"trim_ratio"`
-	ReduceRatio       float64 `yaml:"reduce_ratio"`
-	SellRatio         float64 `yaml:"sell_ratio"`
-	BuyRatio          float64 `yaml:"buy_ratio"`
+	ExtremeHighPERank   *float64 `yaml:"extreme_high_pe_rank"`
+	HighPERank          *float64 `yaml:"high_pe_rank"`
+	LowPERank           *float64 `yaml:"low_pe_rank"`
+	CoreLowPERank       *float64 `yaml:"core_low_pe_rank"`
+	HighPEG             *float64 `yaml:"high_peg"`
+	BubblePEG           *float64 `yaml:"bubble_peg"`
+	LowPEG              *float64 `yaml:"low_peg"`
+	GoodROE             *float64 `yaml:"good_roe"`
+	PoorROE             *float64 `yaml:"poor_roe"`
+	HighYieldRank       *float64 `yaml:"high_yield_rank"`
+	LowYieldRank        *float64 `yaml:"low_yield_rank"`
+	ExtremeLowYieldRank *float64 `yaml:"extreme_low_yield_rank"`
+	DividendSymbols     []string `yaml:"dividend_symbols"`
+	TrimRatio           *float64 `yaml:"trim_ratio"`
+	ReduceRatio         *float64 `yaml:"reduce_ratio"`
+	SellRatio           *float64 `yaml:"sell_ratio"`
+	BuyRatio            *float64 `yaml:"buy_ratio"`
+}
+
+// Params 以策略类型的默认估值参数为基础，覆盖配置中出现的项；
+// 百分位阈值统一换算为0-100刻度 (与数据一致)，配置中 (0, 1] 的值视为0-1刻度
+func (v *ValuationParamsYAML) Params(strategyType string) *types.ValuationParams {
+	params := defaultValuationParams(strategyType)
+	for _, f := range []struct {
+		dst  *float64
+		src  *float64
+		rank bool
+	}{
+		{&params.ExtremeHighPERank, v.ExtremeHighPERank, true},
+		{&params.HighPERank, v.HighPERank, true},
+		{&params.LowPERank, v.LowPERank, true},
+		{&params.CoreLowPERank, v.CoreLowPERank, true},
+		{&params.HighPEG, v.HighPEG, false},
+		{&params.BubblePEG, v.BubblePEG, false},
+		{&params.LowPEG, v.LowPEG, false},
+		{&params.GoodROE, v.GoodROE, false},
+		{&params.PoorROE, v.PoorROE, false},
+		{&params.HighYieldRank, v.HighYieldRank, true},
+		{&params.LowYieldRank, v.LowYieldRank, true},
+		{&params.ExtremeLowYieldRank, v.ExtremeLowYieldRank, true},
+		{&params.TrimRatio, v.TrimRatio, false},
+		{&params.ReduceRatio, v.ReduceRatio, false},
+		{&params.SellRatio, v.SellRatio, false},
+		{&params.BuyRatio, v.BuyRatio, false},
+	} {
+		if f.src == nil {
+			continue
+		}
+		*f.dst = *f.src
+		if f.rank && *f.dst > 0 && *f.dst <= 1 {
+			*f.dst *= 100
+		}
+	}
+	if v.DividendSymbols != nil {
+		params.DividendSymbols = v.DividendSymbols
+	}
+	return params
+}
+
+// defaultValuationParams 策略类型未设置的估值参数取值：
+// weighted_valuation 的股息率百分位阈值默认为 70/30/10，其余与 types.DefaultValuationParams 相同
+func defaultValuationParams(strategyType string) *types.ValuationParams {
+	params := types.DefaultValuationParams()
+	if strings.ToLower(strategyType) == "weighted_valuation" {
+		params.HighYieldRank = 70
+		params.LowYieldRank = 30
+		params.ExtremeLowYieldRank = 10
+	}
+	return params
 }
 
 // CostsSection 成本配置
 type CostsSection struct {
-	CommissionRate float64 `yaml:"commission_rate"`
-	MinCommission  float64 `yaml:"min_commission"`
-	SlippageRate   float64 `yaml:"slippage_rate"`
-	TaxRate        float64 `yaml:"tax_rate"`
+	CommissionRate  float64 `yaml:"commission_rate"`
+	MinCommission   float64 `yaml:"min_commission"`
+	SlippageRate    float64 `yaml:"slippage_rate"`
+	TaxRate         float64 `yaml:"tax_rate"`
+	MarginRate      float64 `yaml:"margin_rate"`       // 融资年利率
+	ShortBorrowRate float64 `yaml:"short_borrow_rate"` // 融券年费率
+	Model           string  `yaml:"model"`             // 成本模型: default (按成交额比例收取佣金)、per_share (按股数) 或 zero
+	PerShare        float64 `yaml:"per_share"`         // per_share 模型的每股佣金
 }
 
 // OutputSection 输出配置
@@ -80,24 +493,99 @@ type OutputSection struct {
 	Format         string `yaml:"format"`
 	Path           string `yaml:"path"`
 	GenerateReport bool   `yaml:"generate_report"`
+	Layout         string `yaml:"layout"`          // run (默认，每次运行写入 <path>/<run-id>/) 或 flat (直接写入 path)
+	Store          string `yaml:"store"`           // 实验记录数据库 (登记每次运行的配置项和指标，.jsonl 为 JSON Lines 文件)，为空时不记录
+	SnapshotStream string `yaml:"snapshot_stream"` // 运行中将每日完整快照写入该文件 (.jsonl 或 .csv，相对路径位于结果文件所在目录)，结果中只保留汇总序列
 }
 
-// LoadConfig 从文件加载配置
-func LoadConfig(filepath string) (*Config, error) {
-	data, err := ioutil.ReadFile(filepath)
+// readDocument 读取配置类文件并统一为YAML：按扩展名识别 .json (YAML 1.2 兼容JSON，直接按YAML解析)
+// 和 .toml (转换为YAML)，其他扩展名按YAML处理
+func readDocument(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+	case ".toml":
+		return tomlToYAML(data)
+	}
+	return data, nil
+}
 
-	var config Config
-	err = yaml.Unmarshal(data, &config)
+// LoadConfig 从文件 (YAML、JSON 或 TOML，按扩展名识别) 加载配置，
+// 依次应用环境变量 (EnvPrefix) 和 overrides 中的覆盖项
+func LoadConfig(filepath string, overrides ...Override) (*Config, error) {
+	data, err := readDocument(filepath)
 	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	overrides = append(EnvOverrides(os.Environ()), overrides...)
+	if len(overrides) > 0 {
+		if err := applyOverrides(&doc, overrides); err != nil {
+			return nil, err
+		}
+		data, err = yaml.Marshal(&doc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode config: %w", err)
+		}
+	}
+
+	var config Config
+	if len(doc.Content) > 0 {
+		if err := doc.Decode(&config); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		if err := config.mergeSleeveCosts(doc.Content[0]); err != nil {
+			return nil, err
+		}
+	}
+	config.source = data
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
 
+// mergeSleeveCosts 子账户的 costs 只覆盖其中设置的项，其余沿用顶层 costs
+func (c *Config) mergeSleeveCosts(root *yaml.Node) error {
+	sleeves := lookup(root, "sleeves")
+	if sleeves == nil || sleeves.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for i, node := range sleeves.Content {
+		if i >= len(c.Sleeves) || node.Kind != yaml.MappingNode {
+			continue
+		}
+		section := lookup(node, "costs")
+		if section == nil {
+			continue
+		}
+		costs := c.Costs
+		if err := section.Decode(&costs); err != nil {
+			return fmt.Errorf("failed to parse sleeves[%d].costs: %w", i, err)
+		}
+		c.Sleeves[i].Costs = &costs
+	}
+	return nil
+}
+
+// Source 生效的配置内容：未覆盖时为配置文件原文 (TOML为转换后的YAML)，否则为应用覆盖项后重新生成的YAML
+func (c *Config) Source() []byte {
+	return c.source
+}
+
 // ToBacktestConfig 转换为回测配置
 func (c *Config) ToBacktestConfig() (types.BacktestConfig, error) {
 	startDate, err := time.Parse("2006-01-02", c.Backtest.StartDate)
@@ -110,30 +598,324 @@ func (c *Config) ToBacktestConfig() (types.BacktestConfig, error) {
 		return types.BacktestConfig{}, fmt.Errorf("invalid end_date: %w", err)
 	}
 
+	var maxWallTime time.Duration
+	if c.Backtest.Limits.MaxWallTime != "" {
+		maxWallTime, err = time.ParseDuration(c.Backtest.Limits.MaxWallTime)
+		if err != nil {
+			return types.BacktestConfig{}, fmt.Errorf("invalid limits.max_wall_time: %w", err)
+		}
+	}
+
 	symbols := make([]string, len(c.Assets))
+	currencies := make(map[string]string)
+	haircuts := make(map[string]float64)
+	classes := make(map[string]string)
 	for i, asset := range c.Assets {
 		symbols[i] = asset.Symbol
+		if class := c.classOf(asset); class != "" {
+			classes[asset.Symbol] = class
+		}
+		if asset.Currency != "" {
+			currencies[asset.Symbol] = asset.Currency
+		}
+		if asset.Haircut != 0 {
+			if asset.Haircut < 0 || asset.Haircut >= 1 {
+				return types.BacktestConfig{}, fmt.Errorf("invalid haircut for %s: %v", asset.Symbol, asset.Haircut)
+			}
+			haircuts[asset.Symbol] = asset.Haircut
+		}
+	}
+
+	shocks, err := c.toShocks()
+	if err != nil {
+		return types.BacktestConfig{}, err
+	}
+	delistings, halts, err := c.toListingStatus()
+	if err != nil {
+		return types.BacktestConfig{}, err
 	}
 
 	return types.BacktestConfig{
-		StartDate:      startDate,
-		EndDate:        endDate,
-		InitialCapital: c.Backtest.InitialCapital,
-		Symbols:        symbols,
-		Benchmark:      c.Backtest.Benchmark,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		InitialCapital:     c.Backtest.InitialCapital,
+		Symbols:            symbols,
+		Benchmark:          c.Backtest.Benchmark,
+		BenchmarkBlend:     c.Backtest.BenchmarkBlend,
+		BenchmarkRebalance: types.BenchmarkRebalance(c.Backtest.BenchmarkRebalance),
+		StopConditions: types.StopConditions{
+			MaxDrawdown:     c.Backtest.Stop.MaxDrawdown,
+			ValueFloor:      c.Backtest.Stop.ValueFloor,
+			MaxLosingMonths: c.Backtest.Stop.MaxLosingMonths,
+		},
+		BaseCurrency:    c.Backtest.BaseCurrency,
+		Currencies:      currencies,
+		LiquidateAtEnd:  c.Backtest.LiquidateAtEnd,
+		ExecutionPolicy: types.ExecutionPolicy(c.Backtest.Execution),
+		ExecutionLag:    c.Backtest.ExecutionLag,
+		ScaleBuys:       c.Backtest.ScaleBuys,
+		MaxVolumePct:    c.Backtest.MaxVolumePct,
+		InitialBuild:    types.InitialBuildPolicy(c.Backtest.InitialBuild),
+		OrderType:       types.OrderType(c.Backtest.Orders.Type),
+		LimitOffset:     c.Backtest.Orders.LimitOffset,
+		LimitTTL:        c.Backtest.Orders.TTL,
+		MinOrderValue:   c.Backtest.Orders.MinValue,
+		AssetClasses:    classes,
+		Constraints:     c.toWeightConstraints(),
+		Margin: types.MarginConfig{
+			AllowShort:       c.Backtest.Margin.AllowShort,
+			MaxGrossExposure: c.Backtest.Margin.MaxGrossExposure,
+		},
+		Dust: types.DustPolicy{
+			MaxWeight: c.Backtest.Dust.MaxWeight,
+			MaxValue:  c.Backtest.Dust.MaxValue,
+		},
+		CoveragePolicy:  types.CoveragePolicy(c.Backtest.CoveragePolicy),
+		RankWindowYears: c.Backtest.RankWindowYears,
+		CostGate:        types.CostGate{MaxCostRatio: c.Backtest.CostGate.MaxCostRatio},
+		KillSwitch: types.KillSwitch{
+			Conditions: types.StopConditions{
+				MaxDrawdown:     c.Backtest.KillSwitch.MaxDrawdown,
+				ValueFloor:      c.Backtest.KillSwitch.ValueFloor,
+				MaxLosingMonths: c.Backtest.KillSwitch.MaxLosingMonths,
+			},
+			SafeWeights: c.Backtest.KillSwitch.SafeWeights,
+		},
+		Inception: types.InceptionPhaseIn{
+			Steps:    c.Backtest.Inception.PhaseInSteps,
+			Interval: c.Backtest.Inception.PhaseInInterval,
+		},
+		Contributions: types.ContributionSchedule{
+			Monthly:        c.Backtest.Contributions.Monthly,
+			BoostDrawdown:  c.Backtest.Contributions.BoostDrawdown,
+			BoostFactor:    c.Backtest.Contributions.BoostFactor,
+			PauseCashAbove: c.Backtest.Contributions.PauseCashAbove,
+		},
+		Behavior: types.BehaviorOverlay{
+			SkipProbability: c.Backtest.Behavior.SkipProbability,
+			DelayDays:       c.Backtest.Behavior.DelayDays,
+			NoBuyAfterLoss:  c.Backtest.Behavior.NoBuyAfterLoss,
+			Seed:            c.Backtest.Behavior.Seed,
+		},
+		Limits: types.RunLimits{
+			MaxWallTime:   maxWallTime,
+			MaxTrades:     c.Backtest.Limits.MaxTrades,
+			MaxRebalances: c.Backtest.Limits.MaxRebalances,
+		},
+		Haircuts:         haircuts,
+		LotSizes:         c.LotSizes(),
+		MutualFunds:      c.mutualFunds(),
+		Shocks:           shocks,
+		Delistings:       delistings,
+		Halts:            halts,
+		Seed:             c.Backtest.Seed,
+		Calendar:         c.Backtest.Calendar,
+		CalendarHolidays: c.Backtest.CalendarHolidays,
 	}, nil
 }
 
+// toShocks 转换冲击配置：合并 class 和 symbols 指定的标的，收益率冲击按久期换算为价格变动
+func (c *Config) toShocks() ([]types.Shock, error) {
+	shocks := make([]types.Shock, 0, len(c.Backtest.Shocks))
+	for i, s := range c.Backtest.Shocks {
+		start, err := time.Parse("2006-01-02", s.Start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shocks[%d].start: %w", i, err)
+		}
+		name := s.Name
+		if name == "" {
+			name = fmt.Sprintf("shock %d", i+1)
+		}
+
+		seen := make(map[string]bool)
+		symbols := make([]string, 0)
+		for _, symbol := range s.Symbols {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+		if s.Class != "" {
+			for _, asset := range c.Assets {
+				if c.classOf(asset) == s.Class && !seen[asset.Symbol] {
+					seen[asset.Symbol] = true
+					symbols = append(symbols, asset.Symbol)
+				}
+			}
+		}
+
+		ret := s.Return
+		if s.YieldChangeBP != 0 {
+			ret = -s.Duration * s.YieldChangeBP / 10000
+		}
+		shocks = append(shocks, types.Shock{
+			Name:    name,
+			Start:   start,
+			Days:    s.Days,
+			Symbols: symbols,
+			Return:  ret,
+		})
+	}
+	return shocks, nil
+}
+
+// toListingStatus 转换各标的的退市日期和停牌区间
+func (c *Config) toListingStatus() (map[string]time.Time, map[string][]types.Halt, error) {
+	delistings := make(map[string]time.Time)
+	halts := make(map[string][]types.Halt)
+	for _, asset := range c.Assets {
+		if asset.Delisted != "" {
+			date, err := time.Parse("2006-01-02", asset.Delisted)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid delisted date for %s: %w", asset.Symbol, err)
+			}
+			delistings[asset.Symbol] = date
+		}
+		for i, h := range asset.Halts {
+			start, err := time.Parse("2006-01-02", h.Start)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid halts[%d].start for %s: %w", i, asset.Symbol, err)
+			}
+			halt := types.Halt{Start: start}
+			if h.End != "" {
+				if halt.End, err = time.Parse("2006-01-02", h.End); err != nil {
+					return nil, nil, fmt.Errorf("invalid halts[%d].end for %s: %w", i, asset.Symbol, err)
+				}
+				if !halt.End.After(start) {
+					return nil, nil, fmt.Errorf("halts[%d] for %s ends before it starts", i, asset.Symbol)
+				}
+			}
+			halts[asset.Symbol] = append(halts[asset.Symbol], halt)
+		}
+	}
+	return delistings, halts, nil
+}
+
+// classOf 标的所属资产类别 (assets.class 优先，其次 strategy.params.asset_classes)
+func (c *Config) classOf(asset AssetConfig) string {
+	if asset.Class != "" {
+		return asset.Class
+	}
+	for name, class := range c.Strategy.Params.AssetClasses {
+		for _, symbol := range class.Symbols {
+			if symbol == asset.Symbol {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// assetClasses 汇总资产类别 (按类别名排序)
+func (c *Config) assetClasses() []types.AssetClass {
+	names := make(map[string]bool)
+	for name := range c.Strategy.Params.AssetClasses {
+		names[name] = true
+	}
+	for _, asset := range c.Assets {
+		if asset.Class != "" {
+			names[asset.Class] = true
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	classes := make([]types.AssetClass, 0, len(sorted))
+	for _, name := range sorted {
+		class := types.AssetClass{
+			Name:   name,
+			Weight: c.Strategy.Params.AssetClasses[name].Weight,
+		}
+		for _, asset := range c.Assets {
+			if c.classOf(asset) == name {
+				class.Symbols = append(class.Symbols, asset.Symbol)
+			}
+		}
+		classes = append(classes, class)
+	}
+	return classes
+}
+
+// toWeightConstraints 转换目标权重约束
+func (c *Config) toWeightConstraints() types.WeightConstraints {
+	constraints := types.WeightConstraints{
+		MaxWeight: c.Constraints.MaxWeight,
+		Symbols:   make(map[string]types.WeightBounds),
+		Classes:   make(map[string]types.WeightBounds),
+	}
+	for symbol, b := range c.Constraints.Symbols {
+		constraints.Symbols[symbol] = types.WeightBounds{Min: b.Min, Max: b.Max}
+	}
+	for class, b := range c.Constraints.Classes {
+		constraints.Classes[class] = types.WeightBounds{Min: b.Min, Max: b.Max}
+	}
+	return constraints
+}
+
 // ToCostConfig 转换为成本配置
 func (c *Config) ToCostConfig() types.CostConfig {
+	spreads := make(map[string]float64)
+	expenseRatios := make(map[string]float64)
+	for _, asset := range c.Assets {
+		if asset.Spread > 0 {
+			spreads[asset.Symbol] = asset.Spread
+		}
+		if asset.ExpenseRatio > 0 {
+			expenseRatios[asset.Symbol] = asset.ExpenseRatio
+		}
+	}
+
 	return types.CostConfig{
-		CommissionRate: c.Costs.CommissionRate,
-		MinCommission:  c.Costs.MinCommission,
-		SlippageRate:   c.Costs.SlippageRate,
-		TaxRate:        c.Costs.TaxRate,
+		CommissionRate:  c.Costs.CommissionRate,
+		MinCommission:   c.Costs.MinCommission,
+		SlippageRate:    c.Costs.SlippageRate,
+		TaxRate:         c.Costs.TaxRate,
+		MarginRate:      c.Costs.MarginRate,
+		ShortBorrowRate: c.Costs.ShortBorrowRate,
+		Model:           c.Costs.Model,
+		PerShare:        c.Costs.PerShare,
+		Spreads:         spreads,
+		ExpenseRatios:   expenseRatios,
 	}
 }
 
+// LotSizes 设置了交易单位 (assets[].lot_size) 的标的
+func (c *Config) LotSizes() map[string]float64 {
+	lots := make(map[string]float64)
+	for _, asset := range c.Assets {
+		if asset.LotSize > 0 {
+			lots[asset.Symbol] = asset.LotSize
+		}
+	}
+	return lots
+}
+
+// mutualFunds 设置了场外基金申赎规则的标的
+func (c *Config) mutualFunds() map[string]types.MutualFund {
+	funds := make(map[string]types.MutualFund)
+	for _, asset := range c.Assets {
+		if asset.MutualFund == nil {
+			continue
+		}
+		fund := types.MutualFund{SettlementDays: asset.MutualFund.SettlementDays}
+		for _, tier := range asset.MutualFund.SubscriptionFees {
+			fund.SubscriptionFees = append(fund.SubscriptionFees, types.SubscriptionFee{Below: tier.Below, Rate: tier.Rate, Fixed: tier.Fixed})
+		}
+		for _, tier := range asset.MutualFund.RedemptionFees {
+			fund.RedemptionFees = append(fund.RedemptionFees, types.RedemptionFee{Days: tier.Days, Rate: tier.Rate})
+		}
+		funds[asset.Symbol] = fund
+	}
+	return funds
+}
+
 // ToStrategyConfig 转换为策略配置
 func (c *Config) ToStrategyConfig() types.StrategyConfig {
 	config := types.StrategyConfig{
@@ -141,34 +923,294 @@ func (c *Config) ToStrategyConfig() types.StrategyConfig {
 		Type:                 c.Strategy.Type,
 		TargetWeights:        c.Strategy.Params.TargetWeights,
 		Threshold:            c.Strategy.Params.Threshold,
+		ThresholdMode:        c.Strategy.Params.ThresholdMode,
+		SymbolThresholds:     c.Strategy.Params.SymbolThresholds,
+		SymbolMinTradeValues: c.Strategy.Params.SymbolMinTradeValues,
 		RebalanceInterval:    c.Strategy.Params.RebalanceInterval,
 		MinTradeValue:        c.Strategy.Params.MinTradeValue,
 		MinRebalanceInterval: c.Strategy.Params.MinRebalanceInterval,
+		RebalanceMode:        c.Strategy.Params.RebalanceMode,
+		RebalanceTopK:        c.Strategy.Params.RebalanceTopK,
+		MinCashWeight:        c.Strategy.Params.MinCashWeight,
+		AllowShort:           c.Backtest.Margin.AllowShort,
+		MaxGrossExposure:     c.Backtest.Margin.MaxGrossExposure,
+		AssetClasses:         c.assetClasses(),
+		Trend: types.TrendFilter{
+			Window:     c.Strategy.Params.Trend.MAWindow,
+			TrimFactor: c.Strategy.Params.Trend.TrimFactor,
+		},
+		DriftMode:  c.Strategy.Params.DriftMode,
+		Benchmarks: make(map[string]string),
+		Sizing:     c.Strategy.Params.Sizing,
+		Voting:     c.Strategy.Params.Voting,
+	}
+	for _, asset := range c.Assets {
+		if asset.Benchmark != "" {
+			config.Benchmarks[asset.Symbol] = asset.Benchmark
+		}
 	}
+	config.LotSizes = c.LotSizes()
 
 	// 转换估值参数
 	if c.Strategy.Params.Valuation != nil {
-		v := c.Strategy.Params.Valuation
-		config.ValuationParams = &types.ValuationParams{
-			ExtremeHighPERank: v.ExtremeHighPERank,
-			HighPERank:        v.HighPERank,
-			LowPERank:         v.LowPERank,
-			CoreLowPERank:     v.CoreLowPERank,
-			HighPEG:           v.HighPEG,
-			BubblePEG:         v.BubblePEG,
-			LowPEG:            v.LowPEG,
-			GoodROE:           v.GoodROE,
-			PoorROE:           v.PoorROE,
-			TrimRatio:         v.TrimRatio,
-			ReduceRatio:       v.ReduceRatio,
-			SellRatio:         v.SellRatio,
-			BuyRatio:          v.BuyRatio,
+		config.ValuationParams = c.Strategy.Params.Valuation.Params(c.Strategy.Type)
+	}
+
+	for _, component := range c.Strategy.Params.Components {
+		config.Components = append(config.Components, types.StrategyComponent{
+			Weight: component.Weight,
+			Config: c.childStrategyConfig(component.StrategySection),
+		})
+	}
+
+	if r := c.Strategy.Params.Regime; r != nil {
+		config.Regime = &types.RegimeParams{
+			Indicator: r.Indicator,
+			Symbol:    r.Symbol,
+			Window:    r.Window,
+			Threshold: r.Threshold,
+			Band:      r.Band,
+		}
+		if config.Regime.Symbol == "" {
+			config.Regime.Symbol = c.Backtest.Benchmark
+		}
+		if r.RiskOn != nil {
+			child := c.childStrategyConfig(*r.RiskOn)
+			config.Regime.RiskOn = &child
+		}
+		if r.RiskOff != nil {
+			child := c.childStrategyConfig(*r.RiskOff)
+			config.Regime.RiskOff = &child
+		}
+	}
+
+	if c.Strategy.Params.Kelly != nil {
+		p := c.Strategy.Params.Kelly
+		config.KellyParams = &types.KellyParams{
+			Fraction:       p.Fraction,
+			ExpectedReturn: p.ExpectedReturn,
+			VolWindow:      p.VolWindow,
+			DefaultVol:     p.DefaultVol,
+			MaxTilt:        p.MaxTilt,
+			MaxCut:         p.MaxCut,
+		}
+	}
+
+	if c.Strategy.Params.CPPI != nil {
+		p := c.Strategy.Params.CPPI
+		config.CPPIParams = &types.CPPIParams{
+			Floor:       p.Floor,
+			Multiplier:  p.Multiplier,
+			MaxExposure: p.MaxExposure,
+			FloorGrowth: p.FloorGrowth,
+			Ratchet:     p.Ratchet,
+			SafeWeights: p.SafeWeights,
+		}
+	}
+
+	if c.Strategy.Params.BlackLitterman != nil {
+		p := c.Strategy.Params.BlackLitterman
+		config.BlackLittermanParams = &types.BlackLittermanParams{
+			RiskAversion: p.RiskAversion,
+			ViewReturn:   p.ViewReturn,
+			Confidence:   p.Confidence,
+			VolWindow:    p.VolWindow,
+			DefaultVol:   p.DefaultVol,
 		}
 	}
 
 	return config
 }
 
+// childStrategyConfig 转换组合/状态切换策略的子策略配置，未设置 target_weights 时继承外层的目标权重
+func (c *Config) childStrategyConfig(section StrategySection) types.StrategyConfig {
+	sub := *c
+	sub.Strategy = section
+	if len(sub.Strategy.Params.TargetWeights) == 0 {
+		sub.Strategy.Params.inheritTargetWeights(c.Strategy.Params)
+	}
+	return sub.ToStrategyConfig()
+}
+
+// inheritTargetWeights 继承 parent 的目标权重及按标的的阈值和最小交易金额
+func (p *StrategyParams) inheritTargetWeights(parent StrategyParams) {
+	p.TargetWeights = parent.TargetWeights
+	if len(p.SymbolThresholds) == 0 {
+		p.SymbolThresholds = parent.SymbolThresholds
+	}
+	if len(p.SymbolMinTradeValues) == 0 {
+		p.SymbolMinTradeValues = parent.SymbolMinTradeValues
+	}
+}
+
+// SleeveConfig 生成第i个子账户的完整配置
+func (c *Config) SleeveConfig(i int) *Config {
+	sleeve := c.Sleeves[i]
+	sc := *c
+	sc.Sleeves = nil
+	sc.Strategy = sleeve.Strategy
+	if sc.Strategy.Name == "" {
+		sc.Strategy.Name = sleeve.Name
+	}
+	if sleeve.InitialCapital > 0 {
+		sc.Backtest.InitialCapital = sleeve.InitialCapital
+	}
+	if sleeve.DataDir != "" {
+		sc.Backtest.DataDir = sleeve.DataDir
+	}
+	if len(sleeve.Assets) > 0 {
+		sc.Assets = sleeve.Assets
+	}
+	if sleeve.Costs != nil {
+		sc.Costs = *sleeve.Costs
+	}
+	return &sc
+}
+
+// StrategyVariant 生成 strategies 中第i个策略的完整配置
+func (c *Config) StrategyVariant(i int) *Config {
+	vc := *c
+	vc.Sleeves = nil
+	vc.Strategies = nil
+	vc.Strategy = c.Strategies[i]
+	if len(vc.Strategy.Params.TargetWeights) == 0 {
+		vc.Strategy.Params.inheritTargetWeights(c.Strategy.Params)
+	}
+	if vc.Strategy.Name == "" {
+		vc.Strategy.Name = fmt.Sprintf("%s-%d", vc.Strategy.Type, i+1)
+	}
+	return &vc
+}
+
+// BaselineVariant 生成对照回测的配置：按 weights 每隔 interval 个自然日再平衡的定期策略，
+// 关闭行为偏差、止损开关和成本门槛等作用于主策略的设置，共用回测区间、标的、成本和追加投入
+func (c *Config) BaselineVariant(name string, weights map[string]float64, interval int) *Config {
+	vc := *c
+	vc.Sleeves = nil
+	vc.Strategies = nil
+	vc.Backtest.Baselines = false
+	vc.Backtest.KillSwitch = KillSwitchSection{}
+	vc.Backtest.CostGate = CostGateSection{}
+	vc.Backtest.Behavior = BehaviorSection{}
+	vc.Output.SnapshotStream = ""
+	vc.Strategy = StrategySection{
+		Type: "time_based",
+		Name: name,
+		Params: StrategyParams{
+			TargetWeights:     weights,
+			RebalanceInterval: interval,
+			MinTradeValue:     c.Strategy.Params.MinTradeValue,
+		},
+	}
+	return &vc
+}
+
+// SignalsOffVariant 生成关闭估值信号的对照配置：valuation / weighted_valuation 改为按偏离阈值再平衡的 fixed_weight 策略，
+// 保留目标权重、资产类别、趋势过滤、调仓模式和最小交易金额等与估值无关的参数
+// 偏离阈值和口径沿用配置 (weighted_valuation 未设置时为相对偏离10%，valuation 未设置阈值时为绝对偏离5%)
+func (c *Config) SignalsOffVariant() (*Config, error) {
+	vc := *c
+	vc.Sleeves = nil
+	vc.Strategies = nil
+	params := c.Strategy.Params
+	params.Valuation = nil
+	params.Kelly = nil
+	params.Sizing = ""
+	switch strings.ToLower(c.Strategy.Type) {
+	case "valuation":
+		if params.Threshold <= 0 {
+			params.Threshold = 0.05
+		}
+	case "weighted_valuation", "weightedvaluation":
+		if params.Threshold <= 0 {
+			params.Threshold = 0.10
+		}
+		if params.ThresholdMode == "" {
+			params.ThresholdMode = "relative"
+		}
+	default:
+		return nil, fmt.Errorf("signals-off attribution requires a valuation or weighted_valuation strategy, got %s", c.Strategy.Type)
+	}
+	vc.Strategy = StrategySection{Type: "fixed_weight", Name: c.Strategy.Name + " (signals off)", Params: params}
+	return &vc, nil
+}
+
+// EquivalentConfig 将第 group 组中当前持有的标的替换为第 instrument 个标的，返回替换后的配置
+// 目标权重、资产类别和权重约束中的代码一并替换；策略参数中按代码写死的部分不做替换
+func (c *Config) EquivalentConfig(group, instrument int) (*Config, error) {
+	g := c.Equivalents[group]
+	replacement := g.Instruments[instrument]
+
+	held := ""
+	for _, inst := range g.Instruments {
+		for _, asset := range c.Assets {
+			if asset.Symbol == inst.Symbol {
+				held = inst.Symbol
+			}
+		}
+	}
+	if held == "" {
+		return nil, fmt.Errorf("equivalent group %s: no instrument found in assets", g.Name)
+	}
+
+	ec := *c
+	ec.Sleeves = nil
+	ec.Equivalents = nil
+
+	ec.Assets = make([]AssetConfig, len(c.Assets))
+	for i, asset := range c.Assets {
+		if asset.Symbol == held {
+			class := asset.Class
+			asset = replacement
+			if asset.Class == "" {
+				asset.Class = class
+			}
+		}
+		ec.Assets[i] = asset
+	}
+
+	rename := func(symbol string) string {
+		if symbol == held {
+			return replacement.Symbol
+		}
+		return symbol
+	}
+
+	ec.Strategy.Params.TargetWeights = make(map[string]float64, len(c.Strategy.Params.TargetWeights))
+	for symbol, w := range c.Strategy.Params.TargetWeights {
+		ec.Strategy.Params.TargetWeights[rename(symbol)] = w
+	}
+
+	renameKeys := func(m map[string]float64) map[string]float64 {
+		if m == nil {
+			return nil
+		}
+		renamed := make(map[string]float64, len(m))
+		for symbol, v := range m {
+			renamed[rename(symbol)] = v
+		}
+		return renamed
+	}
+	ec.Strategy.Params.SymbolThresholds = renameKeys(c.Strategy.Params.SymbolThresholds)
+	ec.Strategy.Params.SymbolMinTradeValues = renameKeys(c.Strategy.Params.SymbolMinTradeValues)
+
+	ec.Strategy.Params.AssetClasses = make(map[string]AssetClassYAML, len(c.Strategy.Params.AssetClasses))
+	for name, class := range c.Strategy.Params.AssetClasses {
+		symbols := make([]string, len(class.Symbols))
+		for i, symbol := range class.Symbols {
+			symbols[i] = rename(symbol)
+		}
+		ec.Strategy.Params.AssetClasses[name] = AssetClassYAML{Weight: class.Weight, Symbols: symbols}
+	}
+
+	ec.Constraints.Symbols = make(map[string]WeightBoundsYAML, len(c.Constraints.Symbols))
+	for symbol, b := range c.Constraints.Symbols {
+		ec.Constraints.Symbols[rename(symbol)] = b
+	}
+
+	return &ec, nil
+}
+
 // GetDataDir 获取数据目录
 func (c *Config) GetDataDir() string {
 	if c.Backtest.DataDir != "" {
@@ -177,6 +1219,14 @@ func (c *Config) GetDataDir() string {
 	return "data/sample"
 }
 
+// GetFXDir 获取汇率数据目录
+func (c *Config) GetFXDir() string {
+	if c.Backtest.FXDir != "" {
+		return c.Backtest.FXDir
+	}
+	return filepath.Join(c.GetDataDir(), "fx")
+}
+
 // GetOutputPath 获取输出路径
 func (c *Config) GetOutputPath() string {
 	if c.Output.Path != "" {
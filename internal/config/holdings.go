@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// HoldingsFile 实盘持仓文件 (信号模式输入)
+type HoldingsFile struct {
+	Date         string                         `yaml:"date"` // 为空时取数据中最后一个交易日
+	Cash         float64                        `yaml:"cash"`
+	Positions    map[string]HoldingYAML         `yaml:"positions"`
+	Prices       map[string]float64             `yaml:"prices"`
+	Fundamentals map[string]FundamentalDataYAML `yaml:"fundamentals"`
+}
+
+// HoldingYAML 单个标的的持仓
+type HoldingYAML struct {
+	Quantity float64 `yaml:"quantity"`
+	AvgCost  float64 `yaml:"avg_cost"`
+}
+
+// FundamentalDataYAML 当日估值数据，百分位需一并提供 (不会按历史重新计算)
+type FundamentalDataYAML struct {
+	PE                float64 `yaml:"pe"`
+	PERank            float64 `yaml:"pe_rank"`
+	PB                float64 `yaml:"pb"`
+	PBRank            float64 `yaml:"pb_rank"`
+	ROE               float64 `yaml:"roe"`
+	Yield             float64 `yaml:"yield"`
+	DividendYield     float64 `yaml:"dividend_yield"`
+	DividendYieldRank float64 `yaml:"dividend_yield_rank"`
+}
+
+// LoadHoldings 加载实盘持仓文件 (YAML、JSON 或 TOML，按扩展名识别)
+func LoadHoldings(filepath string) (*types.Holdings, error) {
+	data, err := readDocument(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read holdings file: %w", err)
+	}
+
+	var file HoldingsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse holdings file: %w", err)
+	}
+	return file.ToHoldings()
+}
+
+// ToHoldings 转换为持仓
+func (f *HoldingsFile) ToHoldings() (*types.Holdings, error) {
+	holdings := &types.Holdings{
+		Cash:         f.Cash,
+		Positions:    make(map[string]float64, len(f.Positions)),
+		AvgCost:      make(map[string]float64, len(f.Positions)),
+		Prices:       f.Prices,
+		Fundamentals: make(map[string]types.FundamentalData, len(f.Fundamentals)),
+	}
+	if f.Date != "" {
+		date, err := time.Parse("2006-01-02", f.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holdings date: %w", err)
+		}
+		holdings.Date = date
+	}
+	for symbol, pos := range f.Positions {
+		holdings.Positions[symbol] = pos.Quantity
+		if pos.AvgCost > 0 {
+			holdings.AvgCost[symbol] = pos.AvgCost
+		}
+	}
+	for symbol, fund := range f.Fundamentals {
+		holdings.Fundamentals[symbol] = types.FundamentalData{
+			Symbol:            symbol,
+			Timestamp:         holdings.Date,
+			PE:                fund.PE,
+			PERank:            fund.PERank,
+			PB:                fund.PB,
+			PBRank:            fund.PBRank,
+			ROE:               fund.ROE,
+			Yield:             fund.Yield,
+			DividendYield:     fund.DividendYield,
+			DividendYieldRank: fund.DividendYieldRank,
+		}
+	}
+	return holdings, nil
+}
@@ -0,0 +1,40 @@
+package config
+
+// LiveSection 每日定时运行 (daemon 命令) 设置
+type LiveSection struct {
+	Holdings       string `yaml:"holdings"`        // 持仓文件，每次运行重新读取
+	Checkpoint     string `yaml:"checkpoint"`      // 恢复策略内部状态的断点文件 (可选)
+	RunAt          string `yaml:"run_at"`          // 每个工作日的运行时间 (HH:MM)，默认 16:30
+	Timezone       string `yaml:"timezone"`        // 运行时间的时区，默认本地时区
+	RefreshCommand string `yaml:"refresh_command"` // 运行前刷新数据的命令 (可选)
+	NotifyAlways   bool   `yaml:"notify_always"`   // 未触发再平衡时也发送通知 (渠道见 notify 段)
+	MetricsAddr    string `yaml:"metrics_addr"`    // Prometheus 指标的监听地址 (如 ":9090"，路径 /metrics)，为空时不启用
+}
+
+// NotifySection 通知渠道 (可同时配置多个)，用于每日定时运行的再平衡提醒和回测完成通知
+type NotifySection struct {
+	Webhook       string           `yaml:"webhook"`        // POST JSON {"title","text"} 的地址
+	ServerChanKey string           `yaml:"serverchan_key"` // Server酱 SendKey
+	Telegram      *TelegramSection `yaml:"telegram"`
+	WeCom         string           `yaml:"wecom"` // 企业微信群机器人的 Webhook 地址
+	Email         *EmailSection    `yaml:"email"`
+
+	OnComplete    bool   `yaml:"on_complete"`    // run 命令完成 (或中止) 时发送通知
+	CompleteAfter string `yaml:"complete_after"` // 仅运行时间超过该时长时发送完成通知 (如 "10m")
+}
+
+// TelegramSection Telegram 机器人
+type TelegramSection struct {
+	Token  string `yaml:"token"`
+	ChatID string `yaml:"chat_id"`
+}
+
+// EmailSection SMTP邮件设置
+type EmailSection struct {
+	SMTPHost string   `yaml:"smtp_host"`
+	SMTPPort int      `yaml:"smtp_port"` // 默认587
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"` // 默认为用户名
+	To       []string `yaml:"to"`
+}
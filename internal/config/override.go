@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 覆盖配置项的环境变量前缀，路径段之间用双下划线分隔，
+// 如 BACKTEST_STRATEGY__PARAMS__THRESHOLD=0.08 覆盖 strategy.params.threshold
+const EnvPrefix = "BACKTEST_"
+
+// Override 覆盖一个配置项，Key 为以点分隔的YAML路径 (列表元素用下标，如 assets.0.symbol)，
+// Value 按YAML解析，可以是标量或 {SPY: 0.6, TLT: 0.4} 这样的流式写法
+type Override struct {
+	Key   string
+	Value string
+}
+
+// String 以 key=value 形式显示
+func (o Override) String() string {
+	return o.Key + "=" + o.Value
+}
+
+// ParseOverride 解析 key=value 形式的覆盖项
+func ParseOverride(arg string) (Override, error) {
+	i := strings.Index(arg, "=")
+	if i <= 0 {
+		return Override{}, fmt.Errorf("invalid override %q: expected key=value", arg)
+	}
+	return Override{Key: arg[:i], Value: arg[i+1:]}, nil
+}
+
+// EnvOverrides 从环境变量 (KEY=VALUE 列表，如 os.Environ()) 中提取以 EnvPrefix 开头的覆盖项，
+// 路径段按不区分大小写匹配配置中已有的键
+func EnvOverrides(environ []string) []Override {
+	var overrides []Override
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, EnvPrefix) {
+			continue
+		}
+		o, err := ParseOverride(strings.TrimPrefix(kv, EnvPrefix))
+		if err != nil {
+			continue
+		}
+		o.Key = strings.ToLower(strings.Replace(o.Key, "__", ".", -1))
+		overrides = append(overrides, o)
+	}
+	return overrides
+}
+
+// applyOverrides 将覆盖项写入YAML文档树，路径中不存在的映射键会被创建
+func applyOverrides(doc *yaml.Node, overrides []Override) error {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	for _, o := range overrides {
+		var value yaml.Node
+		if err := yaml.Unmarshal([]byte(o.Value), &value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", o.Key, err)
+		}
+		replacement := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: ""}
+		if len(value.Content) > 0 {
+			replacement = value.Content[0]
+		}
+		if err := setPath(doc.Content[0], strings.Split(o.Key, "."), replacement); err != nil {
+			return fmt.Errorf("invalid override %s: %w", o.Key, err)
+		}
+	}
+	return nil
+}
+
+// setPath 按路径段定位并替换节点
+func setPath(node *yaml.Node, path []string, value *yaml.Node) error {
+	key := path[0]
+	if key == "" {
+		return fmt.Errorf("empty path segment")
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if k := node.Content[i].Value; k == key || strings.EqualFold(k, key) {
+				if len(path) == 1 {
+					node.Content[i+1] = value
+					return nil
+				}
+				return setPath(node.Content[i+1], path[1:], value)
+			}
+		}
+		child := value
+		if len(path) > 1 {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if err := setPath(child, path[1:], value); err != nil {
+				return err
+			}
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		return nil
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(node.Content) {
+			return fmt.Errorf("index %s out of range (list has %d items)", key, len(node.Content))
+		}
+		if len(path) == 1 {
+			node.Content[i] = value
+			return nil
+		}
+		return setPath(node.Content[i], path[1:], value)
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			*node = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			return setPath(node, path, value)
+		}
+	}
+	return fmt.Errorf("%s is not a mapping or list", key)
+}
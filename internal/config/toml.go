@@ -0,0 +1,126 @@
+package config
+
+import (
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// tomlToYAML 将TOML文档转换为YAML文档，解析使用 BurntSushi/toml，键按在文档中出现的顺序排列，
+// 日期时间转换为字符串 (与YAML配置中的写法一致)
+func tomlToYAML(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	meta, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// 点分键 a.b.c 同时记录 a 和 a.b 首次出现的位置
+	order := make(map[string]int)
+	for i, key := range meta.Keys() {
+		for n := 1; n <= len(key); n++ {
+			if _, ok := order[key[:n].String()]; !ok {
+				order[key[:n].String()] = i
+			}
+		}
+	}
+	root, err := tomlNode(doc, "", order)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}})
+}
+
+// tomlNode 将解码后的TOML值转换为YAML节点，path 为表的点分路径 (表数组的各元素共用同一路径)
+func tomlNode(value interface{}, path string, order map[string]int) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		// 按出现顺序排列，内联表等没有记录顺序的键排在后面并按名称排序
+		rank := func(key string) int {
+			if i, ok := order[tomlKey(path, key)]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			if ri, rj := rank(keys[i]), rank(keys[j]); ri != rj {
+				return ri < rj
+			}
+			return keys[i] < keys[j]
+		})
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, key := range keys {
+			child, err := tomlNode(v[key], tomlKey(path, key), order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		return node, nil
+	case []map[string]interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := tomlNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			child, err := tomlNode(item, path, order)
+			if err != nil {
+				return nil, err
+			}
+			node.Content = append(node.Content, child)
+		}
+		return node, nil
+	case time.Time:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tomlTime(v)}, nil
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// tomlKey 拼接点分路径，含点或空格的键加引号 (与 toml.Key.String 一致)
+func tomlKey(path, key string) string {
+	k := toml.Key{key}.String()
+	if path == "" {
+		return k
+	}
+	return path + "." + k
+}
+
+// tomlTime 按TOML中的写法格式化日期时间：本地日期为 2006-01-02，本地时间和本地日期时间不带时区
+func tomlTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// lookup 查找映射中键对应的值节点
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
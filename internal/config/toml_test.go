@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTOMLToYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{
+			name: "tables keep document order",
+			toml: "[strategy]\ntype = \"valuation\"\nname = \"v\"\n[backtest]\ninitial_capital = 100000\n",
+			want: "strategy:\n    type: valuation\n    name: v\nbacktest:\n    initial_capital: 100000\n",
+		},
+		{
+			name: "array of tables",
+			toml: "[[assets]]\nsymbol = \"SPY\"\nweight = 0.6\n[[assets]]\nsymbol = \"TLT\"\nweight = 0.4\n",
+			want: "assets:\n    - symbol: SPY\n      weight: 0.6\n    - symbol: TLT\n      weight: 0.4\n",
+		},
+		{
+			name: "dotted keys",
+			toml: "backtest.limits.max_wall_time = \"1m\"\nbacktest.start_date = \"2020-01-01\"\n\"a.b\" = 1\n",
+			want: "backtest:\n    limits:\n        max_wall_time: 1m\n    start_date: \"2020-01-01\"\na.b: 1\n",
+		},
+		{
+			name: "inline tables and arrays",
+			toml: "weights = {SPY = 0.6, TLT = 0.4}\ncomponents = [{weight = 1, type = \"fixed_weight\"}]\n",
+			want: "weights:\n    SPY: 0.6\n    TLT: 0.4\ncomponents:\n    - weight: 1\n      type: fixed_weight\n",
+		},
+		{
+			name: "multiline and literal strings",
+			toml: "a = \"\"\"\nline1\nline2\"\"\"\nb = '''\nC:\\raw'''\nc = 'C:\\path'\nd = \"tab\\tend\"\n",
+			want: "a: |-\n    line1\n    line2\nb: C:\\raw\nc: C:\\path\nd: \"tab\\tend\"\n",
+		},
+		{
+			name: "dates and times as strings",
+			toml: "d = 2020-01-01\nt = 09:30:00\nldt = 2020-01-01T09:30:00\nodt = 2020-01-01T09:30:00+08:00\n",
+			want: "d: \"2020-01-01\"\nt: 09:30:00\nldt: 2020-01-01T09:30:00\nodt: \"2020-01-01T09:30:00+08:00\"\n",
+		},
+		{
+			name: "numbers and booleans",
+			toml: "i = 1_000\nf = 1.0\nx = 0x10\ninf = inf\nb = true\ns = \"123\"\n",
+			want: "i: 1000\nf: 1\nx: 16\ninf: .inf\nb: true\ns: \"123\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tomlToYAML([]byte(tt.toml))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOMLToYAMLErrors(t *testing.T) {
+	for _, src := range []string{
+		"a = ",
+		"a = 1\na = 2\n",
+		"[t]\n[t]\n",
+		"a = \"unterminated\n",
+	} {
+		if _, err := tomlToYAML([]byte(src)); err == nil {
+			t.Errorf("expected error for %q", src)
+		} else if !strings.Contains(err.Error(), "toml") {
+			t.Errorf("error for %q does not mention toml: %v", src, err)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// weightSumTolerance 目标权重合计与1的允许误差
+const weightSumTolerance = 0.01
+
+// ValidationError 一项配置错误，Path 为YAML中的位置 (如 strategy.params.target_weights.SPY)
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+// Error 实现 error 接口
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors 配置中的全部错误
+type ValidationErrors []ValidationError
+
+// Error 每行一项错误
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = "  " + err.Error()
+	}
+	return fmt.Sprintf("invalid config (%d errors):\n%s", len(e), strings.Join(lines, "\n"))
+}
+
+// validator 收集配置错误
+type validator struct {
+	errors ValidationErrors
+}
+
+// add 记录一项错误
+func (v *validator) add(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// nonNegative 检查数值不为负
+func (v *validator) nonNegative(path string, value float64) {
+	if value < 0 {
+		v.add(path, "must be non-negative, got %v", value)
+	}
+}
+
+// fraction 检查比例在 [0, 1] 之间
+func (v *validator) fraction(path string, value float64) {
+	if value < 0 || value > 1 {
+		v.add(path, "must be between 0 and 1, got %v", value)
+	}
+}
+
+// Validate 检查配置的一致性，一次返回全部错误 (ValidationErrors)：
+// 日期格式和先后、资金、标的定义、目标权重合计为1且标的均在 assets 中 (现金为 CASH)、阈值和比例范围、成本非负
+func (c *Config) Validate() error {
+	v := &validator{}
+	c.validateBacktest(v)
+	c.validateCosts(v, "costs", c.Costs)
+	c.validateConstraints(v)
+	c.validateOptimize(v)
+
+	if len(c.Sleeves) > 0 && len(c.Strategies) > 0 {
+		v.add("strategies", "cannot be combined with sleeves")
+	}
+	if len(c.Sleeves) == 0 {
+		c.validateAssets(v, "assets", c.Assets)
+	}
+	if len(c.Sleeves) == 0 && len(c.Strategies) == 0 {
+		c.validateStrategy(v, "strategy", c.Strategy, c)
+	}
+	for i := range c.Strategies {
+		vc := c.StrategyVariant(i)
+		c.validateStrategy(v, fmt.Sprintf("strategies[%d]", i), vc.Strategy, vc)
+	}
+	for i, sleeve := range c.Sleeves {
+		path := fmt.Sprintf("sleeves[%d]", i)
+		if sleeve.Name == "" {
+			v.add(path+".name", "is required")
+		}
+		v.nonNegative(path+".initial_capital", sleeve.InitialCapital)
+		sc := c.SleeveConfig(i)
+		assetsPath := "assets"
+		if len(sleeve.Assets) > 0 {
+			assetsPath = path + ".assets"
+		}
+		c.validateAssets(v, assetsPath, sc.Assets)
+		if sleeve.Costs != nil {
+			c.validateCosts(v, path+".costs", *sleeve.Costs)
+		}
+		c.validateStrategy(v, path+".strategy", sleeve.Strategy, sc)
+	}
+
+	if len(v.errors) > 0 {
+		return v.errors
+	}
+	return nil
+}
+
+// validateBacktest 检查回测区间、资金和运行参数
+func (c *Config) validateBacktest(v *validator) {
+	b := c.Backtest
+	start, startErr := time.Parse("2006-01-02", b.StartDate)
+	if startErr != nil {
+		v.add("backtest.start_date", "must be a date in YYYY-MM-DD format, got %q", b.StartDate)
+	}
+	end, endErr := time.Parse("2006-01-02", b.EndDate)
+	if endErr != nil {
+		v.add("backtest.end_date", "must be a date in YYYY-MM-DD format, got %q", b.EndDate)
+	}
+	if startErr == nil && endErr == nil && !end.After(start) {
+		v.add("backtest.end_date", "must be after start_date (%s), got %s", b.StartDate, b.EndDate)
+	}
+
+	if b.InitialCapital <= 0 {
+		v.add("backtest.initial_capital", "must be positive, got %v", b.InitialCapital)
+	}
+	if b.ExecutionLag < 0 {
+		v.add("backtest.execution_lag", "must be non-negative, got %d", b.ExecutionLag)
+	}
+	v.nonNegative("backtest.orders.min_value", b.Orders.MinValue)
+	v.nonNegative("backtest.cost_gate.max_cost_ratio", b.CostGate.MaxCostRatio)
+	if b.MaxVolumePct != 0 {
+		v.fraction("backtest.max_volume_pct", b.MaxVolumePct)
+	}
+	v.fraction("backtest.stop.max_drawdown", b.Stop.MaxDrawdown)
+	v.fraction("backtest.kill_switch.max_drawdown", b.KillSwitch.MaxDrawdown)
+	if b.Limits.MaxWallTime != "" {
+		if _, err := time.ParseDuration(b.Limits.MaxWallTime); err != nil {
+			v.add("backtest.limits.max_wall_time", "must be a duration such as 30s or 5m, got %q", b.Limits.MaxWallTime)
+		}
+	}
+	v.fraction("backtest.behavior.skip_probability", b.Behavior.SkipProbability)
+	v.nonNegative("backtest.contributions.monthly", b.Contributions.Monthly)
+	c.validateShocks(v)
+}
+
+// validateShocks 检查合成冲击：日期、受冲击标的、冲击幅度
+func (c *Config) validateShocks(v *validator) {
+	symbols := make(map[string]bool, len(c.Assets))
+	classes := make(map[string]bool)
+	for _, asset := range c.Assets {
+		symbols[asset.Symbol] = true
+		if class := c.classOf(asset); class != "" {
+			classes[class] = true
+		}
+	}
+	for i, s := range c.Backtest.Shocks {
+		p := fmt.Sprintf("backtest.shocks[%d]", i)
+		if _, err := time.Parse("2006-01-02", s.Start); err != nil {
+			v.add(p+".start", "must be a date in YYYY-MM-DD format, got %q", s.Start)
+		}
+		if s.Days < 0 {
+			v.add(p+".days", "must be non-negative, got %d", s.Days)
+		}
+		if s.Class == "" && len(s.Symbols) == 0 {
+			v.add(p, "class or symbols is required")
+		}
+		if s.Class != "" && !classes[s.Class] {
+			v.add(p+".class", "no asset has class %s", s.Class)
+		}
+		for _, symbol := range s.Symbols {
+			if !symbols[symbol] {
+				v.add(p+".symbols", "%s is not defined in assets", symbol)
+			}
+		}
+		switch {
+		case s.Return != 0 && s.YieldChangeBP != 0:
+			v.add(p, "return and yield_change_bp cannot both be set")
+		case s.YieldChangeBP != 0 && s.Duration <= 0:
+			v.add(p+".duration", "must be positive when yield_change_bp is set, got %v", s.Duration)
+		case s.Return == 0 && s.YieldChangeBP == 0:
+			v.add(p, "return or yield_change_bp is required")
+		case s.Return <= -1:
+			v.add(p+".return", "must be greater than -1, got %v", s.Return)
+		case s.YieldChangeBP != 0 && s.Duration*s.YieldChangeBP >= 10000:
+			v.add(p, "duration × yield_change_bp implies a price drop of 100%% or more")
+		}
+	}
+}
+
+// validateCosts 检查成本参数非负
+func (c *Config) validateCosts(v *validator, path string, costs CostsSection) {
+	v.nonNegative(path+".commission_rate", costs.CommissionRate)
+	v.nonNegative(path+".min_commission", costs.MinCommission)
+	v.nonNegative(path+".slippage_rate", costs.SlippageRate)
+	v.nonNegative(path+".tax_rate", costs.TaxRate)
+	v.nonNegative(path+".margin_rate", costs.MarginRate)
+	v.nonNegative(path+".short_borrow_rate", costs.ShortBorrowRate)
+	v.nonNegative(path+".per_share", costs.PerShare)
+}
+
+// validateConstraints 检查权重约束的上下限
+func (c *Config) validateConstraints(v *validator) {
+	if c.Constraints.MaxWeight != 0 {
+		v.fraction("constraints.max_weight", c.Constraints.MaxWeight)
+	}
+	check := func(path string, bounds map[string]WeightBoundsYAML) {
+		for _, name := range sortedNames(bounds) {
+			b := bounds[name]
+			if b.Max > 0 && b.Min > b.Max {
+				v.add(path+"."+name, "min (%v) must not exceed max (%v)", b.Min, b.Max)
+			}
+		}
+	}
+	check("constraints.symbols", c.Constraints.Symbols)
+	check("constraints.classes", c.Constraints.Classes)
+}
+
+// validateOptimize 检查参数优化目标函数和约束中的指标名称
+func (c *Config) validateOptimize(v *validator) {
+	known := make(map[string]bool, len(OptimizeMetrics))
+	for _, name := range OptimizeMetrics {
+		known[name] = true
+	}
+	expected := strings.Join(OptimizeMetrics, ", ")
+	for _, name := range sortedNames(c.Optimize.Objective) {
+		if !known[name] {
+			v.add("optimize.objective."+name, "unknown metric (expected one of %s)", expected)
+		}
+	}
+	for i, constraint := range c.Optimize.Constraints {
+		path := fmt.Sprintf("optimize.constraints[%d]", i)
+		if !known[constraint.Metric] {
+			v.add(path+".metric", "unknown metric %q (expected one of %s)", constraint.Metric, expected)
+		}
+		if constraint.Min == nil && constraint.Max == nil {
+			v.add(path, "requires min or max")
+		}
+		if constraint.Min != nil && constraint.Max != nil && *constraint.Min > *constraint.Max {
+			v.add(path, "min (%v) must not exceed max (%v)", *constraint.Min, *constraint.Max)
+		}
+	}
+	v.nonNegative("optimize.penalty", c.Optimize.Penalty)
+}
+
+// validateAssets 检查标的定义
+func (c *Config) validateAssets(v *validator, path string, assets []AssetConfig) {
+	if len(assets) == 0 {
+		v.add(path, "at least one asset is required")
+	}
+	seen := make(map[string]bool, len(assets))
+	for i, asset := range assets {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		if asset.Symbol == "" {
+			v.add(p+".symbol", "is required")
+		} else if asset.Symbol == types.CashSymbol {
+			v.add(p+".symbol", "%s is reserved for the cash weight", types.CashSymbol)
+		} else if seen[asset.Symbol] {
+			v.add(p+".symbol", "duplicate symbol %s", asset.Symbol)
+		}
+		seen[asset.Symbol] = true
+		if asset.Haircut < 0 || asset.Haircut >= 1 {
+			v.add(p+".haircut", "must be in [0, 1), got %v", asset.Haircut)
+		}
+		v.nonNegative(p+".expense_ratio", asset.ExpenseRatio)
+		v.nonNegative(p+".spread", asset.Spread)
+		v.nonNegative(p+".lot_size", asset.LotSize)
+		if asset.MutualFund != nil {
+			validateMutualFund(v, p+".mutual_fund", *asset.MutualFund)
+		}
+	}
+}
+
+// validateMutualFund 检查场外基金的到账天数和费率表：费率在 [0, 1) 之间，分档上限递增，只有最后一档可以不设上限
+func validateMutualFund(v *validator, path string, fund MutualFundSection) {
+	if fund.SettlementDays < 0 {
+		v.add(path+".settlement_days", "must be non-negative, got %d", fund.SettlementDays)
+	}
+	for i, tier := range fund.SubscriptionFees {
+		p := fmt.Sprintf("%s.subscription_fees[%d]", path, i)
+		if tier.Rate < 0 || tier.Rate >= 1 {
+			v.add(p+".rate", "must be in [0, 1), got %v", tier.Rate)
+		}
+		v.nonNegative(p+".below", tier.Below)
+		v.nonNegative(p+".fixed", tier.Fixed)
+		if i > 0 {
+			if prev := fund.SubscriptionFees[i-1].Below; prev <= 0 {
+				v.add(p, "unreachable: previous tier has no upper bound")
+			} else if tier.Below > 0 && tier.Below <= prev {
+				v.add(p+".below", "must be greater than previous tier (%v), got %v", prev, tier.Below)
+			}
+		}
+	}
+	for i, tier := range fund.RedemptionFees {
+		p := fmt.Sprintf("%s.redemption_fees[%d]", path, i)
+		if tier.Rate < 0 || tier.Rate >= 1 {
+			v.add(p+".rate", "must be in [0, 1), got %v", tier.Rate)
+		}
+		if tier.Days < 0 {
+			v.add(p+".days", "must be non-negative, got %d", tier.Days)
+		}
+		if i > 0 {
+			if prev := fund.RedemptionFees[i-1].Days; prev <= 0 {
+				v.add(p, "unreachable: previous tier has no upper bound")
+			} else if tier.Days > 0 && tier.Days <= prev {
+				v.add(p+".days", "must be greater than previous tier (%d), got %d", prev, tier.Days)
+			}
+		}
+	}
+}
+
+// validateStrategy 检查策略参数；owner 为策略所属的完整配置 (子账户为合并后的配置)，
+// 组合/状态切换策略的子策略未设置 target_weights 时继承外层权重，不重复检查
+func (c *Config) validateStrategy(v *validator, path string, section StrategySection, owner *Config) {
+	if section.Type == "" {
+		v.add(path+".type", "is required")
+	}
+	params := section.Params
+	p := path + ".params"
+
+	symbols := map[string]bool{types.CashSymbol: true}
+	for _, asset := range owner.Assets {
+		symbols[asset.Symbol] = true
+	}
+	for _, symbol := range sortedNames(params.TargetWeights) {
+		w := params.TargetWeights[symbol]
+		if !symbols[symbol] {
+			v.add(p+".target_weights."+symbol, "symbol is not defined in assets")
+		}
+		if w < 0 && !owner.Backtest.Margin.AllowShort {
+			v.add(p+".target_weights."+symbol, "must be non-negative unless backtest.margin.allow_short is set, got %v", w)
+		}
+	}
+	if len(params.TargetWeights) > 0 || len(params.AssetClasses) > 0 {
+		scoped := *owner
+		scoped.Strategy = section
+		sum := 0.0
+		for _, w := range types.ExpandClassWeights(scoped.assetClasses(), params.TargetWeights) {
+			sum += w
+		}
+		if math.Abs(sum-1) > weightSumTolerance {
+			hint := p + ".target_weights"
+			if len(params.AssetClasses) > 0 {
+				hint = p + ".asset_classes"
+			}
+			v.add(hint, "weights must sum to 1.0, got %.4f", sum)
+		}
+	}
+	for _, name := range sortedNames(params.AssetClasses) {
+		for _, symbol := range params.AssetClasses[name].Symbols {
+			if !symbols[symbol] {
+				v.add(p+".asset_classes."+name+".symbols", "symbol %s is not defined in assets", symbol)
+			}
+		}
+	}
+
+	if params.Threshold < 0 || params.Threshold >= 1 {
+		v.add(p+".threshold", "must be in [0, 1), got %v", params.Threshold)
+	}
+	for _, symbol := range sortedNames(params.SymbolThresholds) {
+		if t := params.SymbolThresholds[symbol]; t < 0 || t >= 1 {
+			v.add(p+".target_weights."+symbol+".threshold", "must be in [0, 1), got %v", t)
+		}
+	}
+	for _, symbol := range sortedNames(params.SymbolMinTradeValues) {
+		v.nonNegative(p+".target_weights."+symbol+".min_trade_value", params.SymbolMinTradeValues[symbol])
+	}
+	switch params.RebalanceMode {
+	case "", "target", "band", "halfway", "worst":
+	default:
+		v.add(p+".rebalance_mode", "must be one of target, band, halfway, worst, got %q", params.RebalanceMode)
+	}
+	if params.RebalanceTopK < 0 {
+		v.add(p+".rebalance_top_k", "must be non-negative, got %d", params.RebalanceTopK)
+	}
+	switch params.ThresholdMode {
+	case "", "absolute", "relative":
+	default:
+		v.add(p+".threshold_mode", "must be absolute or relative, got %q", params.ThresholdMode)
+	}
+	if params.RebalanceInterval < 0 {
+		v.add(p+".rebalance_interval", "must be non-negative, got %d", params.RebalanceInterval)
+	}
+	if params.MinRebalanceInterval < 0 {
+		v.add(p+".min_rebalance_interval", "must be non-negative, got %d", params.MinRebalanceInterval)
+	}
+	v.nonNegative(p+".min_trade_value", params.MinTradeValue)
+	if params.MinCashWeight < 0 || params.MinCashWeight >= 1 {
+		v.add(p+".min_cash_weight", "must be in [0, 1), got %v", params.MinCashWeight)
+	}
+
+	if params.Valuation != nil {
+		val := params.Valuation.Params(section.Type)
+		for _, rank := range []struct {
+			key   string
+			value float64
+		}{
+			{"extreme_high_pe_rank", val.ExtremeHighPERank},
+			{"high_pe_rank", val.HighPERank},
+			{"low_pe_rank", val.LowPERank},
+			{"core_low_pe_rank", val.CoreLowPERank},
+			{"high_yield_rank", val.HighYieldRank},
+			{"low_yield_rank", val.LowYieldRank},
+			{"extreme_low_yield_rank", val.ExtremeLowYieldRank},
+		} {
+			if rank.value < 0 || rank.value > 100 {
+				v.add(p+".valuation."+rank.key, "must be a percentile between 0 and 100, got %v", rank.value)
+			}
+		}
+		if val.LowPERank > 0 && val.HighPERank > 0 && val.LowPERank >= val.HighPERank {
+			v.add(p+".valuation.low_pe_rank", "must be below high_pe_rank (%v), got %v", val.HighPERank, val.LowPERank)
+		}
+		for _, ratio := range []struct {
+			key   string
+			value float64
+		}{
+			{"trim_ratio", val.TrimRatio},
+			{"reduce_ratio", val.ReduceRatio},
+			{"sell_ratio", val.SellRatio},
+			{"buy_ratio", val.BuyRatio},
+		} {
+			if ratio.value < 0 || ratio.value > 1 {
+				v.add(p+".valuation."+ratio.key, "must be between 0 and 1, got %v", ratio.value)
+			}
+		}
+	}
+
+	for i, component := range params.Components {
+		cp := fmt.Sprintf("%s.components[%d]", p, i)
+		if component.Weight < 0 {
+			v.add(cp+".weight", "must be non-negative, got %v", component.Weight)
+		}
+		c.validateStrategy(v, cp, component.StrategySection, owner)
+	}
+	if r := params.Regime; r != nil {
+		if r.RiskOn != nil {
+			c.validateStrategy(v, p+".regime.risk_on", *r.RiskOn, owner)
+		}
+		if r.RiskOff != nil {
+			c.validateStrategy(v, p+".regime.risk_off", *r.RiskOff, owner)
+		}
+	}
+}
+
+// sortedNames 按名称排序的键，使错误顺序稳定
+func sortedNames(m interface{}) []string {
+	var names []string
+	switch v := m.(type) {
+	case map[string]float64:
+		for name := range v {
+			names = append(names, name)
+		}
+	case map[string]WeightBoundsYAML:
+		for name := range v {
+			names = append(names, name)
+		}
+	case map[string]AssetClassYAML:
+		for name := range v {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
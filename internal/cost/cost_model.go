@@ -1,7 +1,9 @@
 package cost
 
 import (
+	"fmt"
 	"math"
+	"strings"
 
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
 )
@@ -15,21 +17,59 @@ type CostModel interface {
 	CalculateSlippage(price float64, side string) float64
 }
 
+// HoldingCostModel 按持仓计提管理费的成本模型 (可选，未实现时不计提)
+type HoldingCostModel interface {
+	CalculateHoldingCost(positions map[string]types.Position, days int) float64
+}
+
+// FinancingCostModel 计提融资利息和融券费用的成本模型 (可选，未实现时不计提)
+type FinancingCostModel interface {
+	CalculateFinancing(cash, shortValue float64, days int) float64
+}
+
+// New 根据成本配置中的模型名称创建成本模型
+func New(config types.CostConfig) (CostModel, error) {
+	switch strings.ToLower(config.Model) {
+	case "", "default":
+		return NewDefaultCostModel(config), nil
+	case "per_share", "pershare":
+		return NewPerShareCostModel(config), nil
+	case "zero":
+		return NewZeroCostModel(), nil
+	default:
+		return nil, fmt.Errorf("unknown cost model: %s", config.Model)
+	}
+}
+
+// TotalCost 交易成本加滑点损失
+func TotalCost(model CostModel, trade types.Trade) float64 {
+	slipped := model.CalculateSlippage(trade.Price, trade.Side)
+	return model.CalculateCost(trade) + math.Abs(trade.Quantity*(slipped-trade.Price))
+}
+
 // DefaultCostModel 默认成本模型
 type DefaultCostModel struct {
-	CommissionRate float64 // 佣金率
-	MinCommission  float64 // 最低佣金
-	SlippageRate   float64 // 滑点率
-	TaxRate        float64 // 税率 (卖出时收取)
+	CommissionRate  float64            // 佣金率
+	MinCommission   float64            // 最低佣金
+	SlippageRate    float64            // 滑点率
+	TaxRate         float64            // 税率 (卖出时收取)
+	MarginRate      float64            // 融资年利率
+	ShortBorrowRate float64            // 融券年费率
+	Spreads         map[string]float64 // 按标的的单边价差成本
+	ExpenseRatios   map[string]float64 // 按标的的年管理费率
 }
 
 // NewDefaultCostModel 创建默认成本模型
 func NewDefaultCostModel(config types.CostConfig) *DefaultCostModel {
 	return &DefaultCostModel{
-		CommissionRate: config.CommissionRate,
-		MinCommission:  config.MinCommission,
-		SlippageRate:   config.SlippageRate,
-		TaxRate:        config.TaxRate,
+		CommissionRate:  config.CommissionRate,
+		MinCommission:   config.MinCommission,
+		SlippageRate:    config.SlippageRate,
+		TaxRate:         config.TaxRate,
+		MarginRate:      config.MarginRate,
+		ShortBorrowRate: config.ShortBorrowRate,
+		Spreads:         config.Spreads,
+		ExpenseRatios:   config.ExpenseRatios,
 	}
 }
 
@@ -53,13 +93,20 @@ func (m *DefaultCostModel) CalculateCost(trade types.Trade) float64 {
 		commission = m.MinCommission
 	}
 
-	// 税费 (仅卖出时收取)
+	return commission + m.taxAndSpread(trade, tradeValue)
+}
+
+// taxAndSpread 税费 (仅卖出时收取) 和价差成本
+func (m *DefaultCostModel) taxAndSpread(trade types.Trade, tradeValue float64) float64 {
 	var tax float64
 	if trade.Side == "SELL" {
 		tax = tradeValue * m.TaxRate
 	}
 
-	return commission + tax
+	// 价差 (同类ETF间流动性差异主要体现在价差上)
+	spread := tradeValue * m.Spreads[trade.Symbol]
+
+	return tax + spread
 }
 
 // CalculateSlippage 计算滑点调整后的价格
@@ -78,3 +125,63 @@ func (m *DefaultCostModel) CalculateTotalCost(trade types.Trade) float64 {
 	slippageCost := math.Abs(trade.Quantity * trade.Price * m.SlippageRate)
 	return baseCost + slippageCost
 }
+
+// CalculateHoldingCost 计算持有 days 个自然日的管理费 (按多头市值计提)
+func (m *DefaultCostModel) CalculateHoldingCost(positions map[string]types.Position, days int) float64 {
+	if days <= 0 || len(m.ExpenseRatios) == 0 {
+		return 0
+	}
+	cost := 0.0
+	for symbol, pos := range positions {
+		if pos.Value > 0 {
+			cost += pos.Value * m.ExpenseRatios[symbol] * float64(days) / 365
+		}
+	}
+	return cost
+}
+
+// CalculateFinancing 计算持有 days 个自然日的融资利息和融券费用
+// cash 为负时按借入金额计息，shortValue 为空头市值 (正数)
+func (m *DefaultCostModel) CalculateFinancing(cash, shortValue float64, days int) float64 {
+	if days <= 0 {
+		return 0
+	}
+	cost := 0.0
+	if cash < 0 {
+		cost += -cash * m.MarginRate * float64(days) / 365
+	}
+	cost += shortValue * m.ShortBorrowRate * float64(days) / 365
+	return cost
+}
+
+// PerShareCostModel 按股数收取佣金的成本模型 (如美股券商的每股佣金)，
+// 佣金不低于最低佣金，税费、价差、滑点、管理费和融资费用同默认模型
+type PerShareCostModel struct {
+	DefaultCostModel
+	PerShare float64 // 每股佣金
+}
+
+// NewPerShareCostModel 创建按股数收取佣金的成本模型
+func NewPerShareCostModel(config types.CostConfig) *PerShareCostModel {
+	return &PerShareCostModel{
+		DefaultCostModel: *NewDefaultCostModel(config),
+		PerShare:         config.PerShare,
+	}
+}
+
+// CalculateCost 计算交易成本
+func (m *PerShareCostModel) CalculateCost(trade types.Trade) float64 {
+	tradeValue := math.Abs(trade.Quantity * trade.Price)
+
+	commission := math.Abs(trade.Quantity) * m.PerShare
+	if commission < m.MinCommission && tradeValue > 0 {
+		commission = m.MinCommission
+	}
+
+	return commission + m.taxAndSpread(trade, tradeValue)
+}
+
+// CalculateTotalCost 计算总成本 (包括滑点损失)
+func (m *PerShareCostModel) CalculateTotalCost(trade types.Trade) float64 {
+	return TotalCost(m, trade)
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/calendar"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// AlignToCalendar 将已加载的各标的数据对齐到交易日历：非交易日的行丢弃，标的首末日期之间缺少数据的交易日
+// 用前一交易日的数据填充 (成交量记为0)，交易日改为日历中覆盖各标的数据范围的交易日。
+// 日历没有假日数据的年份只排除周末，缺少数据的工作日可能是未知的假日，不填充，
+// 交易日只保留至少一个标的有数据的工作日，避免在休市日生成可成交的合成行情。
+// 返回有不一致行的标的 (按标的排序)
+func (l *CSVLoader) AlignToCalendar(cal *calendar.Calendar) []types.CalendarMisalignment {
+	symbols := make([]string, 0, len(l.priceData))
+	for symbol := range l.priceData {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var first, last time.Time
+	uncovered := make(map[string]bool) // 没有假日数据的年份中有数据的工作日
+	misaligned := make([]types.CalendarMisalignment, 0)
+	for _, symbol := range symbols {
+		prices := l.priceData[symbol]
+		if len(prices) == 0 {
+			continue
+		}
+		fundamentals := l.fundamentalData[symbol]
+		m := types.CalendarMisalignment{Symbol: symbol}
+
+		days := cal.Days(prices[0].Timestamp, prices[len(prices)-1].Timestamp)
+		alignedPrices := make([]types.PriceData, 0, len(days))
+		alignedFunds := make([]types.FundamentalData, 0, len(days))
+		j := 0
+		for _, day := range days {
+			for j < len(prices) && prices[j].Timestamp.Before(day) {
+				m.Dropped = append(m.Dropped, prices[j].Timestamp)
+				j++
+			}
+			if j < len(prices) && prices[j].Timestamp.Equal(day) {
+				alignedPrices = append(alignedPrices, prices[j])
+				if j < len(fundamentals) {
+					alignedFunds = append(alignedFunds, fundamentals[j])
+				}
+				if !cal.Covers(day.Year()) {
+					uncovered[day.Format("2006-01-02")] = true
+				}
+				j++
+				continue
+			}
+			if len(alignedPrices) == 0 || !cal.Covers(day.Year()) {
+				continue
+			}
+			filled := alignedPrices[len(alignedPrices)-1]
+			filled.Timestamp = day
+			filled.Open, filled.High, filled.Low = filled.Close, filled.Close, filled.Close
+			filled.Volume = 0
+			alignedPrices = append(alignedPrices, filled)
+			if n := len(alignedFunds); n > 0 {
+				fund := alignedFunds[n-1]
+				fund.Timestamp = day
+				alignedFunds = append(alignedFunds, fund)
+			}
+			m.Filled = append(m.Filled, day)
+		}
+		for ; j < len(prices); j++ {
+			m.Dropped = append(m.Dropped, prices[j].Timestamp)
+		}
+
+		l.priceData[symbol] = alignedPrices
+		l.fundamentalData[symbol] = alignedFunds
+		if len(m.Dropped) > 0 || len(m.Filled) > 0 {
+			misaligned = append(misaligned, m)
+		}
+		if len(alignedPrices) > 0 {
+			if first.IsZero() || alignedPrices[0].Timestamp.Before(first) {
+				first = alignedPrices[0].Timestamp
+			}
+			if last.Before(alignedPrices[len(alignedPrices)-1].Timestamp) {
+				last = alignedPrices[len(alignedPrices)-1].Timestamp
+			}
+		}
+	}
+
+	l.allDates = nil
+	if !first.IsZero() {
+		for _, day := range cal.Days(first, last) {
+			if cal.Covers(day.Year()) || uncovered[day.Format("2006-01-02")] {
+				l.allDates = append(l.allDates, day)
+			}
+		}
+	}
+	l.buildDateIndex()
+	return misaligned
+}
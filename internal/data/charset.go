@@ -0,0 +1,26 @@
+package data
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// utf8BOM 部分软件导出的UTF-8文件开头的字节顺序标记
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// toUTF8 将数据文件内容统一为UTF-8：去掉BOM，不是合法UTF-8时按GB18030 (兼容GBK/GB2312) 解码，
+// 同花顺、东方财富等导出的CSV多为GBK编码
+func toUTF8(content []byte) ([]byte, error) {
+	content = bytes.TrimPrefix(content, utf8BOM)
+	if utf8.Valid(content) {
+		return content, nil
+	}
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("file is neither UTF-8 nor GBK: %w", err)
+	}
+	return decoded, nil
+}
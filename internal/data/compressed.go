@@ -0,0 +1,81 @@
+package data
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// dataExtensions 数据文件支持的扩展名，按查找顺序排列 (同名文件同时存在时优先未压缩的 .csv)
+var dataExtensions = []string{".csv", ".csv.gz", ".csv.zst"}
+
+// dataFile 返回 dir 下名为 name 的数据文件路径：依次查找 .csv、.csv.gz 和 .csv.zst，
+// 都不存在时返回 .csv 路径 (打开时报告文件不存在)
+func dataFile(dir, name string) string {
+	for _, ext := range dataExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, name+".csv")
+}
+
+// openData 打开数据文件，按扩展名透明解压：.gz 使用 gzip，.zst 使用 zstd
+func openData(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read gzip file %s: %w", path, err)
+		}
+		return &decompressed{Reader: zr, closers: []io.Closer{zr, file}}, nil
+	case ".zst":
+		zr, err := zstd.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read zstd file %s: %w", path, err)
+		}
+		return &decompressed{Reader: zr, closers: []io.Closer{zstdCloser{zr}, file}}, nil
+	}
+	return file, nil
+}
+
+// decompressed 解压后的数据流，关闭时释放解压器和底层文件
+type decompressed struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close 关闭数据流
+func (d *decompressed) Close() error {
+	var err error
+	for _, c := range d.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// zstdCloser 适配 zstd.Decoder 的 Close (没有返回值)
+type zstdCloser struct {
+	decoder *zstd.Decoder
+}
+
+// Close 释放解压器
+func (z zstdCloser) Close() error {
+	z.decoder.Close()
+	return nil
+}
@@ -1,14 +1,15 @@
 package data
 
 import (
+	"bytes"
 	"encoding/csv"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/ioutil"
 	"sort"
 	"strconv"
 	"time"
 
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
 )
 
@@ -18,6 +19,19 @@ type CSVLoader struct {
 	priceData       map[string][]types.PriceData
 	fundamentalData map[string][]types.FundamentalData
 	allDates        []time.Time
+	rankWindow      int                          // 由原始PE/PB计算滚动百分位的窗口年数，0表示不计算
+	history         map[string][]types.PriceData // 含回测区间之前的价格 (计算均线用)
+	indicators      *indicators.Cache            // 基于 history 的技术指标缓存
+
+	// 按交易日的价格和基本面索引 (见 buildDateIndex)，各交易日的map在查询间共享
+	priceIndex       map[int64]map[string]float64 // 键为 dayNumber
+	fundamentalIndex map[int64]map[string]*types.FundamentalData
+	rowIndex         map[string]dayRows // 各标的每个交易日在 priceData/fundamentalData 中的行号
+
+	combined map[string]*fundamentalSeries // fundamentals.csv 按标的分组 (首次使用时读取)
+
+	// source 代替CSV文件提供标的数据 (合成数据)，为空时读取 dataDir 中的CSV
+	source func(symbol string, start, end time.Time, rankWindow int) ([]types.PriceData, []types.FundamentalData, error)
 }
 
 // NewCSVLoader 创建CSV加载器
@@ -26,9 +40,16 @@ func NewCSVLoader(dataDir string) *CSVLoader {
 		dataDir:         dataDir,
 		priceData:       make(map[string][]types.PriceData),
 		fundamentalData: make(map[string][]types.FundamentalData),
+		history:         make(map[string][]types.PriceData),
 	}
 }
 
+// SetRankWindow 设置滚动百分位窗口 (年)
+// 设置后，CSV中有PE/PB但没有对应百分位列的标的，按过去 years 年的数据计算百分位
+func (l *CSVLoader) SetRankWindow(years int) {
+	l.rankWindow = years
+}
+
 // SourceType 返回数据源类型
 func (l *CSVLoader) SourceType() string {
 	return "csv"
@@ -40,10 +61,21 @@ func (l *CSVLoader) LoadPrices(symbols []string, start, end time.Time) (map[stri
 	dateSet := make(map[time.Time]bool)
 
 	for _, symbol := range symbols {
-		priceData, fundData, err := l.loadSymbolData(symbol, start, end)
+		history, fundHistory, err := l.loadSymbolData(symbol, time.Time{}, end)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load data for %s: %w", symbol, err)
 		}
+		l.history[symbol] = history
+
+		// 回测区间内的数据
+		var priceData []types.PriceData
+		var fundData []types.FundamentalData
+		for i := range history {
+			if !history[i].Timestamp.Before(start) {
+				priceData = append(priceData, history[i])
+				fundData = append(fundData, fundHistory[i])
+			}
+		}
 		result[symbol] = priceData
 		l.priceData[symbol] = priceData
 		l.fundamentalData[symbol] = fundData
@@ -54,6 +86,8 @@ func (l *CSVLoader) LoadPrices(symbols []string, start, end time.Time) (map[stri
 		}
 	}
 
+	l.indicators = indicators.NewCache(l.history)
+
 	// 整理所有日期
 	l.allDates = make([]time.Time, 0, len(dateSet))
 	for d := range dateSet {
@@ -62,76 +96,161 @@ func (l *CSVLoader) LoadPrices(symbols []string, start, end time.Time) (map[stri
 	sort.Slice(l.allDates, func(i, j int) bool {
 		return l.allDates[i].Before(l.allDates[j])
 	})
+	l.buildDateIndex()
 
 	return result, nil
 }
 
-// loadSymbolData 加载单个标的数据
-func (l *CSVLoader) loadSymbolData(symbol string, start, end time.Time) ([]types.PriceData, []types.FundamentalData, error) {
-	filePath := filepath.Join(l.dataDir, symbol+".csv")
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
+// LoadReferences 加载不参与交易的参考标的 (如各资产的基准指数) 截至 end 的历史，
+// 只用于指标查询，不计入交易日期；已加载的标的跳过
+func (l *CSVLoader) LoadReferences(symbols []string, end time.Time) error {
+	for _, symbol := range symbols {
+		if _, ok := l.history[symbol]; ok {
+			continue
+		}
+		history, _, err := l.loadSymbolData(symbol, time.Time{}, end)
+		if err != nil {
+			return fmt.Errorf("failed to load reference %s: %w", symbol, err)
+		}
+		l.history[symbol] = history
 	}
-	defer file.Close()
+	l.indicators = indicators.NewCache(l.history)
+	return nil
+}
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+// loadSymbolData 加载单个标的数据，时间戳统一为当日零点 (UTC)，查询时无需再截断
+func (l *CSVLoader) loadSymbolData(symbol string, start, end time.Time) ([]types.PriceData, []types.FundamentalData, error) {
+	var prices []types.PriceData
+	var fundamentals []types.FundamentalData
+	var err error
+	if l.source != nil {
+		prices, fundamentals, err = l.source(symbol, start, end, l.rankWindow)
+	} else {
+		var series *fundamentalSeries
+		if series, err = l.fundamentalFile(symbol); err != nil {
+			return nil, nil, err
+		}
+		prices, fundamentals, err = loadFile(dataFile(l.dataDir, symbol), symbol, start, end, l.rankWindow, series)
+	}
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read CSV: %w", err)
+		return nil, nil, err
+	}
+	for i := range prices {
+		prices[i].Timestamp = dateKey(prices[i].Timestamp)
 	}
+	for i := range fundamentals {
+		fundamentals[i].Timestamp = dateKey(fundamentals[i].Timestamp)
+	}
+	return prices, fundamentals, nil
+}
 
-	if len(records) < 2 {
-		return nil, nil, fmt.Errorf("CSV file has no data rows")
+// loadFile 从指定CSV文件 (可为 .csv.gz/.csv.zst 压缩文件) 加载数据，rankWindow 大于0时为缺少百分位列的PE/PB计算滚动百分位
+func loadFile(filePath, symbol string, start, end time.Time, rankWindow int, fundamentals *fundamentalSeries) ([]types.PriceData, []types.FundamentalData, error) {
+	records, err := readRecords(filePath)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// 解析表头，找到各列的索引
 	header := records[0]
 	colIndex := parseHeader(header)
 
-	var priceResult []types.PriceData
-	var fundResult []types.FundamentalData
+	var priceRows []types.PriceData
+	var fundRows []types.FundamentalData
 	for i := 1; i < len(records); i++ {
 		row := records[i]
 		priceData, fundData, err := parseRow(row, colIndex, symbol)
 		if err != nil {
 			continue // 跳过解析错误的行
 		}
-
-		// 过滤日期范围
-		if !priceData.Timestamp.Before(start) && !priceData.Timestamp.After(end) {
-			priceResult = append(priceResult, priceData)
-			fundResult = append(fundResult, fundData)
-		}
+		priceRows = append(priceRows, priceData)
+		fundRows = append(fundRows, fundData)
 	}
 
 	// 按日期排序
-	sort.Slice(priceResult, func(i, j int) bool {
-		return priceResult[i].Timestamp.Before(priceResult[j].Timestamp)
+	sort.Slice(priceRows, func(i, j int) bool {
+		return priceRows[i].Timestamp.Before(priceRows[j].Timestamp)
 	})
-	sort.Slice(fundResult, func(i, j int) bool {
-		return fundResult[i].Timestamp.Before(fundResult[j].Timestamp)
+	sort.Slice(fundRows, func(i, j int) bool {
+		return fundRows[i].Timestamp.Before(fundRows[j].Timestamp)
 	})
 
+	// 单独的基本面数据向前填充到各交易日
+	if fundamentals != nil {
+		fundamentals.fill(fundRows)
+	}
+
+	// 百分位使用回测区间之前的历史数据，需在过滤日期前计算
+	if rankWindow > 0 {
+		if _, ok := colIndex["pe_rank"]; !ok && !fundamentals.has("pe_rank") {
+			rollingRanks(fundRows, rankWindow, peValue, setPERank)
+		}
+		if _, ok := colIndex["pb_rank"]; !ok && !fundamentals.has("pb_rank") {
+			rollingRanks(fundRows, rankWindow, pbValue, setPBRank)
+		}
+	}
+
+	// 过滤日期范围
+	var priceResult []types.PriceData
+	var fundResult []types.FundamentalData
+	for i := range priceRows {
+		if !priceRows[i].Timestamp.Before(start) && !priceRows[i].Timestamp.After(end) {
+			priceResult = append(priceResult, priceRows[i])
+			fundResult = append(fundResult, fundRows[i])
+		}
+	}
+
 	return priceResult, fundResult, nil
 }
 
+// readRecords 读取CSV文件 (可为压缩文件，UTF-8或GBK编码) 的全部行，至少需要表头和一行数据
+func readRecords(filePath string) ([][]string, error) {
+	file, err := openData(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadAll(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if content, err = toUTF8(content); err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	if len(records) < 2 {
+		return nil, fmt.Errorf("CSV file has no data rows")
+	}
+	return records, nil
+}
+
 // parseHeader 解析CSV表头
 func parseHeader(header []string) map[string]int {
 	colIndex := make(map[string]int)
 	for i, col := range header {
 		switch col {
-		case "Date", "date", "DATE", "Timestamp", "timestamp":
+		case "Date", "date", "DATE", "Timestamp", "timestamp", "日期", "交易日期", "时间":
 			colIndex["date"] = i
-		case "Open", "open", "OPEN":
+		case "Symbol", "symbol", "Code", "code", "代码":
+			colIndex["symbol"] = i
+		case "Open", "open", "OPEN", "开盘", "开盘价":
 			colIndex["open"] = i
-		case "High", "high", "HIGH":
+		case "High", "high", "HIGH", "最高", "最高价":
 			colIndex["high"] = i
-		case "Low", "low", "LOW":
+		case "Low", "low", "LOW", "最低", "最低价":
 			colIndex["low"] = i
-		case "Close", "close", "CLOSE":
+		case "Close", "close", "CLOSE", "收盘", "收盘价":
 			colIndex["close"] = i
-		case "Volume", "volume", "VOLUME":
+		case "Volume", "volume", "VOLUME", "成交量":
 			colIndex["volume"] = i
 		case "Adj Close", "adj_close", "AdjClose", "Adj_Close":
 			colIndex["adj_close"] = i
@@ -142,16 +261,28 @@ func parseHeader(header []string) map[string]int {
 			colIndex["pe_rank"] = i
 		case "PEG", "peg":
 			colIndex["peg"] = i
+		case "PB", "pb":
+			colIndex["pb"] = i
+		case "PB_Rank", "pb_rank", "PBRank":
+			colIndex["pb_rank"] = i
 		case "ROE", "roe":
 			colIndex["roe"] = i
+		case "Yield", "yield", "YTM", "ytm":
+			colIndex["yield"] = i
+		case "Dividend_Yield", "dividend_yield", "DividendYield":
+			colIndex["dividend_yield"] = i
+		case "DY_Rank", "dy_rank", "Dividend_Yield_Rank", "dividend_yield_rank", "DividendYieldRank":
+			colIndex["dividend_yield_rank"] = i
 		case "Asset_Type", "asset_type", "AssetType":
 			colIndex["asset_type"] = i
-		case "Name", "name":
+		case "Name", "name", "名称":
 			colIndex["name"] = i
 		case "Is_Core", "is_core", "IsCore":
 			colIndex["is_core"] = i
 		case "Is_Tech", "is_tech", "IsTech":
 			colIndex["is_tech"] = i
+		case "Is_Dividend", "is_dividend", "IsDividend":
+			colIndex["is_dividend"] = i
 		}
 	}
 	return colIndex
@@ -197,32 +328,55 @@ func parseRow(row []string, colIndex map[string]int, symbol string) (types.Price
 	}
 
 	// 解析基本面数据
+	parseFundamental(row, colIndex, &fundData)
+
+	return priceData, fundData, nil
+}
+
+// parseFundamental 将行中存在的基本面列写入 fund (不存在的列保持原值)
+func parseFundamental(row []string, colIndex map[string]int, fund *types.FundamentalData) {
 	if idx, ok := colIndex["pe"]; ok && idx < len(row) {
-		fundData.PE, _ = strconv.ParseFloat(row[idx], 64)
+		fund.PE, _ = strconv.ParseFloat(row[idx], 64)
 	}
 	if idx, ok := colIndex["pe_rank"]; ok && idx < len(row) {
-		fundData.PERank, _ = strconv.ParseFloat(row[idx], 64)
+		fund.PERank, _ = strconv.ParseFloat(row[idx], 64)
 	}
 	if idx, ok := colIndex["peg"]; ok && idx < len(row) {
-		fundData.PEG, _ = strconv.ParseFloat(row[idx], 64)
+		fund.PEG, _ = strconv.ParseFloat(row[idx], 64)
+	}
+	if idx, ok := colIndex["pb"]; ok && idx < len(row) {
+		fund.PB, _ = strconv.ParseFloat(row[idx], 64)
+	}
+	if idx, ok := colIndex["pb_rank"]; ok && idx < len(row) {
+		fund.PBRank, _ = strconv.ParseFloat(row[idx], 64)
 	}
 	if idx, ok := colIndex["roe"]; ok && idx < len(row) {
-		fundData.ROE, _ = strconv.ParseFloat(row[idx], 64)
+		fund.ROE, _ = strconv.ParseFloat(row[idx], 64)
+	}
+	if idx, ok := colIndex["yield"]; ok && idx < len(row) {
+		fund.Yield, _ = strconv.ParseFloat(row[idx], 64)
+	}
+	if idx, ok := colIndex["dividend_yield"]; ok && idx < len(row) {
+		fund.DividendYield, _ = strconv.ParseFloat(row[idx], 64)
+	}
+	if idx, ok := colIndex["dividend_yield_rank"]; ok && idx < len(row) {
+		fund.DividendYieldRank, _ = strconv.ParseFloat(row[idx], 64)
 	}
 	if idx, ok := colIndex["asset_type"]; ok && idx < len(row) {
-		fundData.AssetType = types.AssetType(row[idx])
+		fund.AssetType = types.AssetType(row[idx])
 	}
 	if idx, ok := colIndex["name"]; ok && idx < len(row) {
-		fundData.Name = row[idx]
+		fund.Name = row[idx]
 	}
 	if idx, ok := colIndex["is_core"]; ok && idx < len(row) {
-		fundData.IsCoreETF = row[idx] == "true" || row[idx] == "1" || row[idx] == "TRUE"
+		fund.IsCoreETF = row[idx] == "true" || row[idx] == "1" || row[idx] == "TRUE"
 	}
 	if idx, ok := colIndex["is_tech"]; ok && idx < len(row) {
-		fundData.IsTechETF = row[idx] == "true" || row[idx] == "1" || row[idx] == "TRUE"
+		fund.IsTechETF = row[idx] == "true" || row[idx] == "1" || row[idx] == "TRUE"
+	}
+	if idx, ok := colIndex["is_dividend"]; ok && idx < len(row) {
+		fund.IsDividendETF = row[idx] == "true" || row[idx] == "1" || row[idx] == "TRUE"
 	}
-
-	return priceData, fundData, nil
 }
 
 // parseDate 解析日期字符串
@@ -233,6 +387,7 @@ func parseDate(dateStr string) (time.Time, error) {
 		"01/02/2006",
 		"02-01-2006",
 		"2006-01-02 15:04:05",
+		"20060102",
 	}
 
 	for _, format := range formats {
@@ -258,76 +413,111 @@ func (l *CSVLoader) GetAllDates() []time.Time {
 	return l.allDates
 }
 
-// GetPriceOnDate 获取指定日期的价格
-func (l *CSVLoader) GetPriceOnDate(symbol string, date time.Time) (types.PriceData, bool) {
-	data, ok := l.priceData[symbol]
-	if !ok {
-		return types.PriceData{}, false
+// Coverage 统计各标的在全部交易日上的数据覆盖情况
+func (l *CSVLoader) Coverage() []types.SymbolCoverage {
+	coverage := make([]types.SymbolCoverage, 0, len(l.priceData))
+	if len(l.allDates) == 0 {
+		return coverage
 	}
+	first, last := l.allDates[0], l.allDates[len(l.allDates)-1]
 
-	// 二分查找
-	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
-	idx := sort.Search(len(data), func(i int) bool {
-		d := data[i].Timestamp
-		dOnly := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
-		return !dOnly.Before(dateOnly)
-	})
+	symbols := make([]string, 0, len(l.priceData))
+	for symbol := range l.priceData {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
 
-	if idx < len(data) {
-		d := data[idx].Timestamp
-		dOnly := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
-		if dOnly.Equal(dateOnly) {
-			return data[idx], true
+	for _, symbol := range symbols {
+		data := l.priceData[symbol]
+		c := types.SymbolCoverage{Symbol: symbol, Partial: true}
+		// 只统计仍在交易日列表内的数据行
+		for _, d := range data {
+			if d.Timestamp.Before(first) || d.Timestamp.After(last) {
+				continue
+			}
+			if c.Rows == 0 {
+				c.FirstDate = d.Timestamp
+			}
+			c.LastDate = d.Timestamp
+			c.Rows++
 		}
+		if c.Rows > 0 {
+			c.Coverage = float64(c.Rows) / float64(len(l.allDates))
+			c.Gaps = l.gaps(symbol, c.FirstDate, c.LastDate)
+			c.Partial = c.FirstDate.After(first) || c.LastDate.Before(last) || len(c.Gaps) > 0
+		}
+		coverage = append(coverage, c)
 	}
+	return coverage
+}
 
-	return types.PriceData{}, false
+// gaps 标的在 [first, last] 内缺少数据的交易日，合并为连续区间
+func (l *CSVLoader) gaps(symbol string, first, last time.Time) []types.CoverageGap {
+	var gaps []types.CoverageGap
+	open := false
+	for _, d := range l.allDates {
+		if d.Before(first) || d.After(last) {
+			continue
+		}
+		if _, ok := l.rowOf(symbol, d); ok {
+			open = false
+			continue
+		}
+		if !open {
+			gaps = append(gaps, types.CoverageGap{Start: d})
+			open = true
+		}
+		gap := &gaps[len(gaps)-1]
+		gap.End = d
+		gap.Days++
+	}
+	return gaps
 }
 
-// GetPricesOnDate 获取指定日期所有标的的价格
-func (l *CSVLoader) GetPricesOnDate(date time.Time) map[string]float64 {
-	prices := make(map[string]float64)
-	for symbol := range l.priceData {
-		if data, ok := l.GetPriceOnDate(symbol, date); ok {
-			prices[symbol] = data.AdjClose
+// ShrinkDates 将交易日期限制在 [start, end] 区间内
+func (l *CSVLoader) ShrinkDates(start, end time.Time) {
+	dates := make([]time.Time, 0, len(l.allDates))
+	for _, d := range l.allDates {
+		if !d.Before(start) && !d.After(end) {
+			dates = append(dates, d)
 		}
 	}
-	return prices
+	l.allDates = dates
 }
 
-// GetFundamentalOnDate 获取指定日期的基本面数据
-func (l *CSVLoader) GetFundamentalOnDate(symbol string, date time.Time) (types.FundamentalData, bool) {
-	data, ok := l.fundamentalData[symbol]
+// GetPriceOnDate 获取指定日期的价格
+func (l *CSVLoader) GetPriceOnDate(symbol string, date time.Time) (types.PriceData, bool) {
+	i, ok := l.rowOf(symbol, date)
 	if !ok {
-		return types.FundamentalData{}, false
+		return types.PriceData{}, false
 	}
+	return l.priceData[symbol][i], true
+}
 
-	// 二分查找
-	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
-	idx := sort.Search(len(data), func(i int) bool {
-		d := data[i].Timestamp
-		dOnly := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
-		return !dOnly.Before(dateOnly)
-	})
-
-	if idx < len(data) {
-		d := data[idx].Timestamp
-		dOnly := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
-		if dOnly.Equal(dateOnly) {
-			return data[idx], true
-		}
+// GetPricesOnDate 获取指定日期所有标的的价格 (复权收盘价)
+// 返回的map为加载时建立的索引，在多次查询间共享，调用方不得修改
+func (l *CSVLoader) GetPricesOnDate(date time.Time) map[string]float64 {
+	if prices, ok := l.priceIndex[dayNumber(date)]; ok {
+		return prices
 	}
+	return map[string]float64{}
+}
 
-	return types.FundamentalData{}, false
+// GetFundamentalOnDate 获取指定日期的基本面数据
+func (l *CSVLoader) GetFundamentalOnDate(symbol string, date time.Time) (types.FundamentalData, bool) {
+	data := l.fundamentalData[symbol]
+	i, ok := l.rowOf(symbol, date)
+	if !ok || i >= len(data) {
+		return types.FundamentalData{}, false
+	}
+	return data[i], true
 }
 
 // GetFundamentalsOnDate 获取指定日期所有标的的基本面数据
+// 返回的map及其指向的数据为加载时建立的索引，在多次查询间共享，调用方不得修改
 func (l *CSVLoader) GetFundamentalsOnDate(date time.Time) map[string]*types.FundamentalData {
-	fundMap := make(map[string]*types.FundamentalData)
-	for symbol := range l.fundamentalData {
-		if data, ok := l.GetFundamentalOnDate(symbol, date); ok {
-			fundMap[symbol] = &data
-		}
+	if fundamentals, ok := l.fundamentalIndex[dayNumber(date)]; ok {
+		return fundamentals
 	}
-	return fundMap
+	return map[string]*types.FundamentalData{}
 }
@@ -0,0 +1,93 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// benchLoader 10年、20个标的的合成日频数据
+func benchLoader(b *testing.B) (*CSVLoader, []string) {
+	b.Helper()
+	cfg := SyntheticConfig{
+		Start: time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		Seed:  1,
+	}
+	symbols := make([]string, 20)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("S%02d", i)
+		cfg.Assets = append(cfg.Assets, SyntheticAsset{Symbol: symbols[i], Drift: 0.05, Vol: 0.2, PE: 15})
+	}
+	s, err := NewSyntheticLoader(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if _, err := s.LoadPrices(symbols, cfg.Start, cfg.End); err != nil {
+		b.Fatal(err)
+	}
+	return s.CSVLoader, symbols
+}
+
+// legacyPriceOnDate 改为按日期索引查询之前的实现：每次比较都将两侧时间截断为日期
+func legacyPriceOnDate(data []types.PriceData, date time.Time) (types.PriceData, bool) {
+	dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	idx := sort.Search(len(data), func(i int) bool {
+		d := data[i].Timestamp
+		dOnly := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+		return !dOnly.Before(dateOnly)
+	})
+	if idx < len(data) {
+		d := data[idx].Timestamp
+		dOnly := time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)
+		if dOnly.Equal(dateOnly) {
+			return data[idx], true
+		}
+	}
+	return types.PriceData{}, false
+}
+
+func BenchmarkGetPriceOnDate(b *testing.B) {
+	l, symbols := benchLoader(b)
+	dates := l.GetAllDates()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, symbol := range symbols {
+			l.GetPriceOnDate(symbol, dates[n%len(dates)])
+		}
+	}
+}
+
+func BenchmarkGetPriceOnDateSearch(b *testing.B) {
+	l, symbols := benchLoader(b)
+	dates := l.GetAllDates()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, symbol := range symbols {
+			searchDate(l.priceData[symbol], dates[n%len(dates)])
+		}
+	}
+}
+
+func BenchmarkGetPriceOnDateLegacy(b *testing.B) {
+	l, symbols := benchLoader(b)
+	dates := l.GetAllDates()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, symbol := range symbols {
+			legacyPriceOnDate(l.priceData[symbol], dates[n%len(dates)])
+		}
+	}
+}
+
+func BenchmarkGetPricesOnDate(b *testing.B) {
+	l, _ := benchLoader(b)
+	dates := l.GetAllDates()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		l.GetPricesOnDate(dates[n%len(dates)])
+	}
+}
@@ -0,0 +1,101 @@
+package data
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// dateKey 日期索引的键 (UTC 零点)，加载的数据时间戳已统一为该形式
+func dateKey(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayNumber 日期在其所在时区的日序号 (自1970-01-01起的天数)，作为索引的键，比 time.Time 作键快
+func dayNumber(date time.Time) int64 {
+	_, offset := date.Zone()
+	secs := date.Unix() + int64(offset)
+	day := secs / 86400
+	if secs < 0 && secs%86400 != 0 {
+		day--
+	}
+	return day
+}
+
+// dayRows 标的的按日行号表：rows[d] 为日序号 first+d 当日的数据行号，无数据为 -1
+type dayRows struct {
+	first int64
+	rows  []int32
+}
+
+// newDayRows 按已排序、已归一化的数据建立行号表
+func newDayRows(data []types.PriceData) dayRows {
+	if len(data) == 0 {
+		return dayRows{}
+	}
+	r := dayRows{first: dayNumber(data[0].Timestamp)}
+	r.rows = make([]int32, dayNumber(data[len(data)-1].Timestamp)-r.first+1)
+	for d := range r.rows {
+		r.rows[d] = -1
+	}
+	for i := range data {
+		r.rows[dayNumber(data[i].Timestamp)-r.first] = int32(i)
+	}
+	return r
+}
+
+// row 日期当日的行号
+func (r dayRows) row(date time.Time) (int, bool) {
+	d := dayNumber(date) - r.first
+	if d < 0 || d >= int64(len(r.rows)) || r.rows[d] < 0 {
+		return 0, false
+	}
+	return int(r.rows[d]), true
+}
+
+// rowOf 标的在 date 当日的数据行号：已建立日期索引时直接查表，否则在已归一化的时间戳上二分查找
+func (l *CSVLoader) rowOf(symbol string, date time.Time) (int, bool) {
+	if rows, ok := l.rowIndex[symbol]; ok {
+		return rows.row(date)
+	}
+	return searchDate(l.priceData[symbol], dateKey(date))
+}
+
+// searchDate 在按时间升序排列的数据中二分查找 key 当日的行
+func searchDate(data []types.PriceData, key time.Time) (int, bool) {
+	i := sort.Search(len(data), func(i int) bool {
+		return !data[i].Timestamp.Before(key)
+	})
+	return i, i < len(data) && data[i].Timestamp.Equal(key)
+}
+
+// buildDateIndex 按交易日建立全部标的的价格和基本面索引，加载数据或修改价格 (如施加冲击) 后调用
+func (l *CSVLoader) buildDateIndex() {
+	l.priceIndex = make(map[int64]map[string]float64, len(l.allDates))
+	l.fundamentalIndex = make(map[int64]map[string]*types.FundamentalData, len(l.allDates))
+	l.rowIndex = make(map[string]dayRows, len(l.priceData))
+	for symbol, data := range l.priceData {
+		l.rowIndex[symbol] = newDayRows(data)
+		for i := range data {
+			key := dayNumber(data[i].Timestamp)
+			prices, ok := l.priceIndex[key]
+			if !ok {
+				prices = make(map[string]float64, len(l.priceData))
+				l.priceIndex[key] = prices
+			}
+			prices[symbol] = data[i].AdjClose
+		}
+	}
+	for symbol, data := range l.fundamentalData {
+		for i := range data {
+			key := dayNumber(data[i].Timestamp)
+			fundamentals, ok := l.fundamentalIndex[key]
+			if !ok {
+				fundamentals = make(map[string]*types.FundamentalData, len(l.fundamentalData))
+				l.fundamentalIndex[key] = fundamentals
+			}
+			fundamentals[symbol] = &data[i]
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package data
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Fingerprints 已加载标的的数据指纹：回测区间内数据的首末日期和行数，以及数据文件内容的SHA256
+// (合成数据或文件无法读取时为空)，按标的排序
+func (l *CSVLoader) Fingerprints() []types.DataFingerprint {
+	symbols := make([]string, 0, len(l.priceData))
+	for symbol := range l.priceData {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	prints := make([]types.DataFingerprint, 0, len(symbols))
+	for _, symbol := range symbols {
+		rows := l.priceData[symbol]
+		fp := types.DataFingerprint{Symbol: symbol, Rows: len(rows)}
+		if len(rows) > 0 {
+			fp.FirstDate = rows[0].Timestamp
+			fp.LastDate = rows[len(rows)-1].Timestamp
+		}
+		if l.source == nil {
+			fp.SHA256 = fileSHA256(dataFile(l.dataDir, symbol))
+		}
+		prints = append(prints, fp)
+	}
+	return prints
+}
+
+// fileSHA256 文件内容的SHA256，读取失败时返回空字符串
+func fileSHA256(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package data
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 单独的基本面数据文件：<标的>_fundamental.csv 只含一个标的，fundamentals.csv 含多个标的 (需有 symbol 列)，
+// 均可为压缩文件。基本面数据的频率 (月度/季度等) 与价格无关，加载时向前填充到各交易日
+const (
+	fundamentalSuffix   = "_fundamental"
+	combinedFundamental = "fundamentals"
+)
+
+// fundamentalSeries 一个标的按日期升序的基本面数据原始行
+type fundamentalSeries struct {
+	path     string
+	colIndex map[string]int
+	dates    []time.Time
+	rows     [][]string
+}
+
+// fill 将每个交易日之前 (含当日) 最近一期的基本面数据写入 fundamentals，文件中没有的列保持价格文件中的值，
+// 第一期之前的交易日不变
+func (s *fundamentalSeries) fill(fundamentals []types.FundamentalData) {
+	j := -1
+	for i := range fundamentals {
+		for j+1 < len(s.dates) && !s.dates[j+1].After(fundamentals[i].Timestamp) {
+			j++
+		}
+		if j >= 0 {
+			parseFundamental(s.rows[j], s.colIndex, &fundamentals[i])
+		}
+	}
+}
+
+// has 文件中是否有该列
+func (s *fundamentalSeries) has(col string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.colIndex[col]
+	return ok
+}
+
+// fundamentalFile 标的的单独基本面数据：优先 <标的>_fundamental.csv，其次 fundamentals.csv 中该标的的行，都没有时返回 nil
+func (l *CSVLoader) fundamentalFile(symbol string) (*fundamentalSeries, error) {
+	if path := dataFile(l.dataDir, symbol+fundamentalSuffix); fileExists(path) {
+		series, err := readFundamentals(path)
+		if err != nil {
+			return nil, err
+		}
+		return series[""], nil
+	}
+
+	if l.combined == nil {
+		l.combined = make(map[string]*fundamentalSeries)
+		if path := dataFile(l.dataDir, combinedFundamental); fileExists(path) {
+			series, err := readFundamentals(path)
+			if err != nil {
+				return nil, err
+			}
+			l.combined = series
+		}
+	}
+	return l.combined[symbol], nil
+}
+
+// readFundamentals 读取基本面数据文件，按 symbol 列分组 (没有 symbol 列时全部行归入空字符串)，跳过日期无法解析的行
+func readFundamentals(path string) (map[string]*fundamentalSeries, error) {
+	records, err := readRecords(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fundamentals %s: %w", path, err)
+	}
+	colIndex := parseHeader(records[0])
+	dateCol, ok := colIndex["date"]
+	if !ok {
+		return nil, fmt.Errorf("fundamentals %s has no date column", path)
+	}
+	symbolCol, bySymbol := colIndex["symbol"]
+
+	series := make(map[string]*fundamentalSeries)
+	for _, row := range records[1:] {
+		if dateCol >= len(row) || (bySymbol && symbolCol >= len(row)) {
+			continue
+		}
+		date, err := parseDate(row[dateCol])
+		if err != nil {
+			continue
+		}
+		symbol := ""
+		if bySymbol {
+			symbol = row[symbolCol]
+		}
+		s, ok := series[symbol]
+		if !ok {
+			s = &fundamentalSeries{path: path, colIndex: colIndex}
+			series[symbol] = s
+		}
+		s.dates = append(s.dates, dateKey(date))
+		s.rows = append(s.rows, row)
+	}
+	for _, s := range series {
+		sort.Stable(byDate{s})
+	}
+	return series, nil
+}
+
+// byDate 按日期排序基本面数据行
+type byDate struct{ s *fundamentalSeries }
+
+func (b byDate) Len() int           { return len(b.s.dates) }
+func (b byDate) Less(i, j int) bool { return b.s.dates[i].Before(b.s.dates[j]) }
+func (b byDate) Swap(i, j int) {
+	b.s.dates[i], b.s.dates[j] = b.s.dates[j], b.s.dates[i]
+	b.s.rows[i], b.s.rows[j] = b.s.rows[j], b.s.rows[i]
+}
+
+// fileExists 文件是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,56 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// FXLoader 汇率数据加载器
+// 汇率文件命名为 <币种><基础币种>.csv (如 USDCNY.csv)，Close列为1单位外币兑换的基础币种数量
+type FXLoader struct {
+	fxDir string
+	rates map[string][]types.PriceData
+}
+
+// NewFXLoader 创建汇率加载器
+func NewFXLoader(fxDir string) *FXLoader {
+	return &FXLoader{
+		fxDir: fxDir,
+		rates: make(map[string][]types.PriceData),
+	}
+}
+
+// LoadRates 加载指定币种兑基础币种的汇率序列
+func (l *FXLoader) LoadRates(currency, base string) error {
+	pair := currency + base
+	// 汇率需要向前填充，因此不按回测区间截断
+	rates, _, err := loadFile(dataFile(l.fxDir, pair), pair, time.Time{}, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), 0, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load fx rates for %s: %w", pair, err)
+	}
+	if len(rates) == 0 {
+		return fmt.Errorf("no fx rates for %s", pair)
+	}
+	l.rates[currency] = rates
+	return nil
+}
+
+// GetRateOnDate 获取指定日期的汇率 (无当日数据时使用最近一个交易日的汇率)
+func (l *FXLoader) GetRateOnDate(currency string, date time.Time) (float64, bool) {
+	rates, ok := l.rates[currency]
+	if !ok {
+		return 0, false
+	}
+
+	// 找到第一个晚于date的位置，前一个即为最近汇率
+	idx := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Timestamp.After(date)
+	})
+	if idx == 0 {
+		return 0, false
+	}
+	return rates[idx-1].Close, true
+}
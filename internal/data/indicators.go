@@ -0,0 +1,31 @@
+package data
+
+import (
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+)
+
+// Indicator 返回标的截至 date (含) 最近一个交易日的技术指标值
+// 指标使用回测区间之前的历史数据计算，历史不足或未加载数据时返回 false
+func (l *CSVLoader) Indicator(symbol string, kind indicators.Kind, window int, date time.Time) (float64, bool) {
+	if l.indicators == nil {
+		return 0, false
+	}
+	return l.indicators.At(symbol, kind, window, date)
+}
+
+// MovingAverages 返回各标的截至 date (含) 最近 window 个交易日的复权收盘价均线
+// 历史不足 window 个交易日的标的不返回
+func (l *CSVLoader) MovingAverages(date time.Time, window int) map[string]float64 {
+	result := make(map[string]float64)
+	if window <= 0 {
+		return result
+	}
+	for symbol := range l.history {
+		if ma, ok := l.Indicator(symbol, indicators.SMA, window, date); ok {
+			result[symbol] = ma
+		}
+	}
+	return result
+}
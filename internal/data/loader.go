@@ -3,6 +3,7 @@ package data
 import (
 	"time"
 
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
 )
 
@@ -20,3 +21,9 @@ type DataLoader interface {
 	// GetAllDates 获取所有交易日期
 	GetAllDates() []time.Time
 }
+
+// IndicatorSource 可提供技术指标的数据源 (供规则类策略使用)
+type IndicatorSource interface {
+	// Indicator 返回标的截至 date (含) 的指标值，历史不足时返回 false
+	Indicator(symbol string, kind indicators.Kind, window int, date time.Time) (float64, bool)
+}
@@ -0,0 +1,114 @@
+package data
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SymbolInfo 数据目录中一个标的数据文件的概况
+type SymbolInfo struct {
+	Symbol       string
+	File         string
+	FirstDate    time.Time
+	LastDate     time.Time
+	Rows         int      // 可解析的数据行数
+	Skipped      int      // 日期无法解析而跳过的行数
+	Columns      []string // 识别出的列 (规范名称，按文件中的顺序)
+	Unknown      []string // 未识别的列 (加载时忽略)
+	Warnings     []string // 缺少日期列、价格列等问题
+	Fundamentals string   // 单独的基本面数据文件 (<标的>_fundamental.csv 或 fundamentals.csv)
+	Error        string   // 文件无法读取时的错误
+}
+
+// Manifest 扫描数据目录，列出其中全部数据文件 (.csv/.csv.gz/.csv.zst) 的标的、日期范围和识别出的列，按标的排序。
+// 同一标的有多个文件时只列出加载时实际使用的文件，单独的基本面数据文件记录在对应标的中
+func (l *CSVLoader) Manifest() ([]SymbolInfo, error) {
+	entries, err := ioutil.ReadDir(l.dataDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data dir: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	infos := make([]SymbolInfo, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		symbol, ok := dataSymbol(entry.Name())
+		if !ok || seen[symbol] || symbol == combinedFundamental || strings.HasSuffix(symbol, fundamentalSuffix) {
+			continue
+		}
+		seen[symbol] = true
+		info := inspectFile(dataFile(l.dataDir, symbol), symbol)
+		if series, err := l.fundamentalFile(symbol); err != nil {
+			info.Warnings = append(info.Warnings, err.Error())
+		} else if series != nil {
+			info.Fundamentals = series.path
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Symbol < infos[j].Symbol
+	})
+	return infos, nil
+}
+
+// dataSymbol 由数据文件名得到标的代码，不是数据文件时返回 false
+func dataSymbol(name string) (string, bool) {
+	for _, ext := range dataExtensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) && len(name) > len(ext) {
+			return name[:len(name)-len(ext)], true
+		}
+	}
+	return "", false
+}
+
+// inspectFile 读取数据文件并统计日期范围、行数和列
+func inspectFile(path, symbol string) SymbolInfo {
+	info := SymbolInfo{Symbol: symbol, File: path}
+	records, err := readRecords(path)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+
+	colIndex := parseHeader(records[0])
+	known := make(map[int]string, len(colIndex))
+	for name, i := range colIndex {
+		known[i] = name
+	}
+	for i, col := range records[0] {
+		if name, ok := known[i]; ok {
+			info.Columns = append(info.Columns, name)
+		} else {
+			info.Unknown = append(info.Unknown, col)
+		}
+	}
+	if _, ok := colIndex["date"]; !ok {
+		info.Warnings = append(info.Warnings, "no date column")
+	}
+	_, hasClose := colIndex["close"]
+	_, hasAdjClose := colIndex["adj_close"]
+	if !hasClose && !hasAdjClose {
+		info.Warnings = append(info.Warnings, "no close or adj close column")
+	}
+
+	for _, row := range records[1:] {
+		price, _, err := parseRow(row, colIndex, symbol)
+		if err != nil {
+			info.Skipped++
+			continue
+		}
+		if info.Rows == 0 || price.Timestamp.Before(info.FirstDate) {
+			info.FirstDate = price.Timestamp
+		}
+		if price.Timestamp.After(info.LastDate) {
+			info.LastDate = price.Timestamp
+		}
+		info.Rows++
+	}
+	return info
+}
@@ -0,0 +1,57 @@
+package data
+
+import (
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// minRankHistory 计算百分位所需的最短历史 (年)，窗口更短时以窗口为准
+const minRankHistory = 1
+
+func peValue(f *types.FundamentalData) float64      { return f.PE }
+func pbValue(f *types.FundamentalData) float64      { return f.PB }
+func setPERank(f *types.FundamentalData, r float64) { f.PERank = r }
+func setPBRank(f *types.FundamentalData, r float64) { f.PBRank = r }
+
+// rollingRanks 按过去 years 年的数据计算每日取值的百分位 (0-100)，rows 需按日期升序
+// 非正值 (亏损或缺失) 不参与计算；历史不足 minRankHistory 年时百分位留空 (0)
+func rollingRanks(rows []types.FundamentalData, years int, value func(*types.FundamentalData) float64, set func(*types.FundamentalData, float64)) {
+	if len(rows) == 0 {
+		return
+	}
+	minHistory := minRankHistory
+	if years < minHistory {
+		minHistory = years
+	}
+	first := rows[0].Timestamp
+
+	start := 0
+	for i := range rows {
+		current := value(&rows[i])
+		if current <= 0 {
+			continue
+		}
+		date := rows[i].Timestamp
+		if date.Before(first.AddDate(minHistory, 0, 0)) {
+			continue
+		}
+
+		// 窗口起点随日期前移
+		windowStart := date.AddDate(-years, 0, 0)
+		for start < i && rows[start].Timestamp.Before(windowStart) {
+			start++
+		}
+
+		count, below := 0, 0
+		for j := start; j <= i; j++ {
+			v := value(&rows[j])
+			if v <= 0 {
+				continue
+			}
+			count++
+			if v <= current {
+				below++
+			}
+		}
+		set(&rows[i], float64(below)/float64(count)*100)
+	}
+}
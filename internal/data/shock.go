@@ -0,0 +1,80 @@
+package data
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// ApplyShock 对已加载的标的数据施加合成冲击：从 start (含) 起的 days 个交易日内价格按相同日收益率累计变动 ret，
+// 之后的价格维持冲击后的水平。PE/PB/PEG 随价格同比例变动、股息率反向变动，百分位不重新计算。
+// 返回冲击的首末交易日，start 之后没有该标的数据时 ok 为 false
+func (l *CSVLoader) ApplyShock(symbol string, start time.Time, days int, ret float64) (first, last time.Time, ok bool) {
+	if days < 1 {
+		days = 1
+	}
+	factor := func(k int) float64 {
+		if k >= days {
+			k = days - 1
+		}
+		return math.Pow(1+ret, float64(k+1)/float64(days))
+	}
+
+	prices := l.priceData[symbol]
+	fundamentals := l.fundamentalData[symbol]
+	k := 0
+	for i := range prices {
+		if prices[i].Timestamp.Before(start) {
+			continue
+		}
+		if k == 0 {
+			first = prices[i].Timestamp
+		}
+		if k < days {
+			last = prices[i].Timestamp
+		}
+		f := factor(k)
+		shockPrice(&prices[i], f)
+		if i < len(fundamentals) {
+			shockFundamental(&fundamentals[i], f)
+		}
+		k++
+	}
+	if k == 0 {
+		return first, last, false
+	}
+
+	k = 0
+	history := l.history[symbol]
+	for i := range history {
+		if history[i].Timestamp.Before(start) {
+			continue
+		}
+		shockPrice(&history[i], factor(k))
+		k++
+	}
+	l.indicators = indicators.NewCache(l.history)
+	l.buildDateIndex()
+	return first, last, true
+}
+
+// shockPrice 按比例调整价格
+func shockPrice(p *types.PriceData, f float64) {
+	p.Open *= f
+	p.High *= f
+	p.Low *= f
+	p.Close *= f
+	p.AdjClose *= f
+}
+
+// shockFundamental 按价格变动比例调整估值指标
+func shockFundamental(d *types.FundamentalData, f float64) {
+	d.PE *= f
+	d.PB *= f
+	d.PEG *= f
+	if f > 0 {
+		d.DividendYield /= f
+	}
+}
@@ -0,0 +1,276 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/rng"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// tradingDaysPerYear 年化参数换算为日参数的交易日数
+const tradingDaysPerYear = 252
+
+// SyntheticAsset 合成标的的参数 (年化)
+type SyntheticAsset struct {
+	Symbol string
+	Drift  float64 // 年化漂移 (如0.07)
+	Vol    float64 // 年化波动率 (如0.15)
+	Price  float64 // 初始价格，默认100
+	PE     float64 // 初始PE (大于0时PE随价格同比例变动，配合 SetRankWindow 计算百分位)
+}
+
+// SyntheticRegime 市场状态：状态内各标的使用该状态的漂移和波动率 (按 Assets 顺序，未设置的沿用标的参数)
+type SyntheticRegime struct {
+	Name         string
+	Drift        []float64
+	Vol          []float64
+	ExpectedDays float64 // 平均持续交易日数，每个交易日以 1/ExpectedDays 的概率切换到其他状态
+}
+
+// SyntheticConfig 合成数据配置：Start 至 End 的每个工作日生成一行数据
+type SyntheticConfig struct {
+	Start  time.Time
+	End    time.Time
+	Assets []SyntheticAsset
+
+	// Correlations 日收益的相关系数矩阵 (按 Assets 顺序)，为空表示各标的独立
+	Correlations [][]float64
+
+	// Regimes 状态切换，为空时所有交易日使用标的参数 (几何布朗运动)；从第一个状态开始
+	Regimes []SyntheticRegime
+
+	Seed int64 // 随机数种子 (与 backtest.seed 相同的派生方式)，相同种子生成相同数据
+}
+
+// SyntheticLoader 合成数据加载器：按几何布朗运动 (可带状态切换和相关性) 生成价格序列，
+// 不需要CSV文件即可运行和测试策略。嵌入的 CSVLoader 提供与CSV数据相同的查询接口，
+// 引擎通过 SetDataLoader(loader.CSVLoader) 使用
+type SyntheticLoader struct {
+	*CSVLoader
+	prices       map[string][]types.PriceData
+	fundamentals map[string][]types.FundamentalData
+	dates        []time.Time
+	regimes      []string // 各交易日的状态
+}
+
+// NewSyntheticLoader 按配置生成全部价格序列 (相同 Seed 生成相同数据)
+func NewSyntheticLoader(cfg SyntheticConfig) (*SyntheticLoader, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	n := len(cfg.Assets)
+	chol := identity(n)
+	if len(cfg.Correlations) > 0 {
+		var err error
+		if chol, err = cholesky(cfg.Correlations); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &SyntheticLoader{
+		prices:       make(map[string][]types.PriceData, n),
+		fundamentals: make(map[string][]types.FundamentalData, n),
+	}
+	r := rng.New(cfg.Seed, "synthetic")
+	price := make([]float64, n)
+	for i, asset := range cfg.Assets {
+		price[i] = asset.Price
+		if price[i] <= 0 {
+			price[i] = 100
+		}
+	}
+
+	regime := 0
+	dt := 1.0 / tradingDaysPerYear
+	z := make([]float64, n)
+	for date := cfg.Start; !date.After(cfg.End); date = date.AddDate(0, 0, 1) {
+		if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			continue
+		}
+		first := len(s.dates) == 0
+		if !first && len(cfg.Regimes) > 1 {
+			if d := cfg.Regimes[regime].ExpectedDays; d > 0 && r.Float64() < 1/d {
+				next := r.Intn(len(cfg.Regimes) - 1)
+				if next >= regime {
+					next++
+				}
+				regime = next
+			}
+		}
+		s.dates = append(s.dates, date)
+		if len(cfg.Regimes) > 0 {
+			s.regimes = append(s.regimes, cfg.Regimes[regime].Name)
+		}
+
+		for i := range z {
+			z[i] = r.NormFloat64()
+		}
+		for i, asset := range cfg.Assets {
+			open := price[i]
+			if !first {
+				drift, vol := cfg.params(regime, i)
+				shock := 0.0
+				for j := 0; j <= i; j++ {
+					shock += chol[i][j] * z[j]
+				}
+				price[i] *= math.Exp((drift-vol*vol/2)*dt + vol*math.Sqrt(dt)*shock)
+			}
+			s.prices[asset.Symbol] = append(s.prices[asset.Symbol], types.PriceData{
+				Symbol:    asset.Symbol,
+				Timestamp: date,
+				Open:      open,
+				High:      math.Max(open, price[i]),
+				Low:       math.Min(open, price[i]),
+				Close:     price[i],
+				AdjClose:  price[i],
+			})
+			fund := types.FundamentalData{Symbol: asset.Symbol, Timestamp: date, Name: asset.Symbol}
+			if asset.PE > 0 {
+				initial := asset.Price
+				if initial <= 0 {
+					initial = 100
+				}
+				fund.PE = asset.PE * price[i] / initial
+			}
+			s.fundamentals[asset.Symbol] = append(s.fundamentals[asset.Symbol], fund)
+		}
+	}
+
+	s.CSVLoader = NewCSVLoader("")
+	s.CSVLoader.source = s.load
+	return s, nil
+}
+
+// SourceType 返回数据源类型
+func (s *SyntheticLoader) SourceType() string {
+	return "synthetic"
+}
+
+// Regime 返回 date 当日的状态名称，未配置状态切换或不是交易日时返回 false
+func (s *SyntheticLoader) Regime(date time.Time) (string, bool) {
+	if len(s.regimes) == 0 {
+		return "", false
+	}
+	i := sort.Search(len(s.dates), func(i int) bool { return !s.dates[i].Before(date) })
+	if i < len(s.dates) && s.dates[i].Equal(date) {
+		return s.regimes[i], true
+	}
+	return "", false
+}
+
+// load 代替CSV文件返回标的在 [start, end] 内的数据，rankWindow 大于0时计算PE滚动百分位
+func (s *SyntheticLoader) load(symbol string, start, end time.Time, rankWindow int) ([]types.PriceData, []types.FundamentalData, error) {
+	prices, ok := s.prices[symbol]
+	if !ok {
+		return nil, nil, fmt.Errorf("no synthetic series for %s", symbol)
+	}
+	fundamentals := make([]types.FundamentalData, len(s.fundamentals[symbol]))
+	copy(fundamentals, s.fundamentals[symbol])
+	if rankWindow > 0 {
+		rollingRanks(fundamentals, rankWindow, peValue, setPERank)
+	}
+
+	var priceResult []types.PriceData
+	var fundResult []types.FundamentalData
+	for i := range prices {
+		if !prices[i].Timestamp.Before(start) && !prices[i].Timestamp.After(end) {
+			priceResult = append(priceResult, prices[i])
+			fundResult = append(fundResult, fundamentals[i])
+		}
+	}
+	return priceResult, fundResult, nil
+}
+
+// params 状态 regime 下标的 i 的漂移和波动率
+func (cfg SyntheticConfig) params(regime, i int) (drift, vol float64) {
+	drift, vol = cfg.Assets[i].Drift, cfg.Assets[i].Vol
+	if len(cfg.Regimes) == 0 {
+		return drift, vol
+	}
+	r := cfg.Regimes[regime]
+	if i < len(r.Drift) {
+		drift = r.Drift[i]
+	}
+	if i < len(r.Vol) {
+		vol = r.Vol[i]
+	}
+	return drift, vol
+}
+
+// validate 检查合成数据配置
+func (cfg SyntheticConfig) validate() error {
+	if len(cfg.Assets) == 0 {
+		return fmt.Errorf("synthetic data needs at least one asset")
+	}
+	if !cfg.End.After(cfg.Start) {
+		return fmt.Errorf("synthetic end %s must be after start %s", cfg.End.Format("2006-01-02"), cfg.Start.Format("2006-01-02"))
+	}
+	seen := make(map[string]bool, len(cfg.Assets))
+	for _, asset := range cfg.Assets {
+		if asset.Symbol == "" || seen[asset.Symbol] {
+			return fmt.Errorf("synthetic asset symbol %q is empty or duplicated", asset.Symbol)
+		}
+		seen[asset.Symbol] = true
+		if asset.Vol < 0 {
+			return fmt.Errorf("synthetic asset %s: vol must be non-negative, got %v", asset.Symbol, asset.Vol)
+		}
+	}
+	if len(cfg.Correlations) > 0 && len(cfg.Correlations) != len(cfg.Assets) {
+		return fmt.Errorf("correlation matrix has %d rows, expected %d", len(cfg.Correlations), len(cfg.Assets))
+	}
+	for _, r := range cfg.Regimes {
+		if len(r.Drift) > len(cfg.Assets) || len(r.Vol) > len(cfg.Assets) {
+			return fmt.Errorf("regime %s has more parameters than assets", r.Name)
+		}
+		if len(cfg.Regimes) > 1 && r.ExpectedDays < 1 {
+			return fmt.Errorf("regime %s: expected days must be at least 1, got %v", r.Name, r.ExpectedDays)
+		}
+	}
+	return nil
+}
+
+// identity n阶单位矩阵
+func identity(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		m[i][i] = 1
+	}
+	return m
+}
+
+// cholesky 相关系数矩阵的Cholesky分解 (下三角)，矩阵需对称正定
+func cholesky(corr [][]float64) ([][]float64, error) {
+	n := len(corr)
+	l := make([][]float64, n)
+	for i := range l {
+		if len(corr[i]) != n {
+			return nil, fmt.Errorf("correlation matrix row %d has %d columns, expected %d", i, len(corr[i]), n)
+		}
+		l[i] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			if math.Abs(corr[i][j]-corr[j][i]) > 1e-9 {
+				return nil, fmt.Errorf("correlation matrix is not symmetric at (%d, %d)", i, j)
+			}
+			sum := corr[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, fmt.Errorf("correlation matrix is not positive definite")
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
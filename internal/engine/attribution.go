@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// AttributionOutcome 一次回测的收益和风险指标
+type AttributionOutcome struct {
+	Strategy     string
+	FinalValue   float64
+	TotalReturn  float64
+	AnnualReturn float64
+	Volatility   float64
+	MaxDrawdown  float64
+	Sharpe       float64 // 无风险利率为0
+	TotalTrades  int
+	TotalFees    float64
+}
+
+// SignalAttribution 估值信号的增量贡献：带估值信号的回测减去关闭信号 (纯偏离阈值再平衡) 的回测
+type SignalAttribution struct {
+	Signals    AttributionOutcome
+	SignalsOff AttributionOutcome
+
+	ExcessReturn       float64 // 总收益率之差
+	ExcessAnnualReturn float64 // 年化收益率之差
+	VolatilityChange   float64 // 年化波动率之差 (负值为估值信号降低了波动)
+	DrawdownChange     float64 // 最大回撤之差 (负值为估值信号降低了回撤)
+	SharpeChange       float64
+	ExtraTrades        int
+	ExtraFees          float64
+}
+
+// CompareSignalsOff 在同一数据上分别运行估值策略和关闭估值信号的对照策略，归因估值信号带来的增量收益和风险
+func CompareSignalsOff(cfg *config.Config) (*SignalAttribution, error) {
+	off, err := cfg.SignalsOffVariant()
+	if err != nil {
+		return nil, err
+	}
+	signalsSleeve, err := NewSleeve("signals", cfg)
+	if err != nil {
+		return nil, err
+	}
+	offSleeve, err := NewSleeve("signals-off", off)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Running backtest with valuation signals")
+	signalsResult, err := signalsSleeve.Engine.Run()
+	if err != nil {
+		return nil, fmt.Errorf("valuation backtest failed: %w", err)
+	}
+	fmt.Println("Running backtest with signals off")
+	offResult, err := offSleeve.Engine.Run()
+	if err != nil {
+		return nil, fmt.Errorf("signals-off backtest failed: %w", err)
+	}
+
+	with := attributionOutcome(signalsSleeve.Engine.strategy.Name(), signalsResult)
+	without := attributionOutcome(offSleeve.Engine.strategy.Name(), offResult)
+	return &SignalAttribution{
+		Signals:            with,
+		SignalsOff:         without,
+		ExcessReturn:       with.TotalReturn - without.TotalReturn,
+		ExcessAnnualReturn: with.AnnualReturn - without.AnnualReturn,
+		VolatilityChange:   with.Volatility - without.Volatility,
+		DrawdownChange:     with.MaxDrawdown - without.MaxDrawdown,
+		SharpeChange:       with.Sharpe - without.Sharpe,
+		ExtraTrades:        with.TotalTrades - without.TotalTrades,
+		ExtraFees:          with.TotalFees - without.TotalFees,
+	}, nil
+}
+
+// attributionOutcome 提取回测结果的收益和风险指标
+func attributionOutcome(name string, result *types.BacktestResult) AttributionOutcome {
+	metrics := store.Metrics(result)
+	return AttributionOutcome{
+		Strategy:     name,
+		FinalValue:   result.FinalValue,
+		TotalReturn:  result.TotalReturn,
+		AnnualReturn: metrics["annual_return"],
+		Volatility:   metrics["volatility"],
+		MaxDrawdown:  metrics["max_drawdown"],
+		Sharpe:       metrics["sharpe"],
+		TotalTrades:  result.TotalTrades,
+		TotalFees:    result.TotalFees,
+	}
+}
+
+// PrintSignalAttribution 打印估值信号归因
+func PrintSignalAttribution(a *SignalAttribution) {
+	fmt.Println("\n========== Valuation Signal Attribution ==========")
+	fmt.Printf("%-36s %14s %9s %9s %9s %9s %7s %7s %10s\n",
+		"Strategy", "Final", "Return", "Annual", "Vol", "MaxDD", "Sharpe", "Trades", "Fees")
+	for _, o := range []AttributionOutcome{a.Signals, a.SignalsOff} {
+		fmt.Printf("%-36s %14.2f %8.2f%% %8.2f%% %8.2f%% %8.2f%% %7.2f %7d %10.2f\n",
+			o.Strategy, o.FinalValue, o.TotalReturn*100, o.AnnualReturn*100, o.Volatility*100,
+			o.MaxDrawdown*100, o.Sharpe, o.TotalTrades, o.TotalFees)
+	}
+	fmt.Printf("Valuation overlay: return %+.2f%% (annual %+.2f%%), volatility %+.2f%%, max drawdown %+.2f%%, sharpe %+.2f, trades %+d, fees %+.2f\n",
+		a.ExcessReturn*100, a.ExcessAnnualReturn*100, a.VolatilityChange*100, a.DrawdownChange*100,
+		a.SharpeChange, a.ExtraTrades, a.ExtraFees)
+	fmt.Println("==================================================")
+}
+
+// ExportSignalAttribution 导出估值信号归因
+func ExportSignalAttribution(a *SignalAttribution, filepath string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Signal attribution exported to: %s\n", filepath)
+	return nil
+}
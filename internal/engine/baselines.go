@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// RunBaselines 按主策略首次建仓的目标权重运行买入持有和每年再平衡两个对照回测，
+// 结果和再平衡收益 (主策略收益率 - 对照收益率) 写入 result.Baselines
+func RunBaselines(cfg *config.Config, result *types.BacktestResult) error {
+	weights := initialWeights(result)
+	if len(weights) == 0 {
+		return fmt.Errorf("baselines: main backtest has no initial weights")
+	}
+	first := result.Snapshots[0].Timestamp
+	last := result.Snapshots[len(result.Snapshots)-1].Timestamp
+	intervals := []struct {
+		name     types.Baseline
+		interval int
+	}{
+		// 买入持有的间隔超过回测区间，建仓后不会到达再平衡日
+		{types.BaselineBuyAndHold, int(last.Sub(first).Hours()/24) + 1},
+		{types.BaselineAnnual, 365},
+	}
+
+	mainMetrics := store.Metrics(result)
+	baselines := make([]types.BaselineResult, 0, len(intervals))
+	for _, b := range intervals {
+		fmt.Printf("Running baseline: %s\n", b.name)
+		sleeve, err := NewSleeve(string(b.name), cfg.BaselineVariant(string(b.name), weights, b.interval))
+		if err != nil {
+			return fmt.Errorf("baseline %s: %w", b.name, err)
+		}
+		sleeve.Engine.SetLogOutput(ioutil.Discard)
+		r, err := sleeve.Engine.Run()
+		if err != nil {
+			return fmt.Errorf("baseline %s failed: %w", b.name, err)
+		}
+		metrics := store.Metrics(r)
+		baselines = append(baselines, types.BaselineResult{
+			Name:         b.name,
+			Weights:      weights,
+			FinalValue:   r.FinalValue,
+			TotalReturn:  r.TotalReturn,
+			AnnualReturn: metrics["annual_return"],
+			MaxDrawdown:  metrics["max_drawdown"],
+			TotalTrades:  r.TotalTrades,
+			TotalFees:    r.TotalFees,
+			Bonus:        result.TotalReturn - r.TotalReturn,
+			AnnualBonus:  mainMetrics["annual_return"] - metrics["annual_return"],
+		})
+	}
+	result.Baselines = baselines
+	return nil
+}
+
+// initialWeights 主策略首次建仓的目标权重，没有目标权重记录时取首个快照的持仓权重
+func initialWeights(result *types.BacktestResult) map[string]float64 {
+	if len(result.TargetWeights) > 0 {
+		return result.TargetWeights[0].Weights
+	}
+	if len(result.Snapshots) == 0 || result.Snapshots[0].TotalValue <= 0 {
+		return nil
+	}
+	snapshot := result.Snapshots[0]
+	weights := make(map[string]float64, len(snapshot.Positions)+1)
+	for symbol, pos := range snapshot.Positions {
+		weights[symbol] = pos.Value / snapshot.TotalValue
+	}
+	weights[types.CashSymbol] = (snapshot.Cash + snapshot.Receivable) / snapshot.TotalValue
+	return weights
+}
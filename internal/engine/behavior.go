@@ -0,0 +1,201 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/rng"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// behaviorOverlay 模拟投资者的行为偏差：随机跳过再平衡、拖延执行、亏损月后不敢买入
+type behaviorOverlay struct {
+	config types.BehaviorOverlay
+	rng    *rand.Rand
+	stats  types.BehaviorStats
+
+	monthKey        int
+	monthStartValue float64
+	lastValue       float64
+	lastMonthReturn float64
+}
+
+// newBehaviorOverlay 创建行为偏差模拟，随机序列由运行种子派生 (设置了 behavior.seed 时使用该种子)
+func newBehaviorOverlay(config types.BehaviorOverlay, seed int64) *behaviorOverlay {
+	r := rng.New(seed, "behavior")
+	if config.Seed != 0 {
+		r = rand.New(rand.NewSource(config.Seed))
+	}
+	return &behaviorOverlay{
+		config: config,
+		rng:    r,
+	}
+}
+
+// skip 按概率决定是否跳过本次再平衡
+func (b *behaviorOverlay) skip() bool {
+	if b.config.SkipProbability <= 0 || b.rng.Float64() >= b.config.SkipProbability {
+		return false
+	}
+	b.stats.SkippedRebalances++
+	return true
+}
+
+// delay 本次再平衡额外推迟的交易日数
+func (b *behaviorOverlay) delay() int {
+	if b.config.DelayDays > 0 {
+		b.stats.DelayedRebalances++
+	}
+	return b.config.DelayDays
+}
+
+// record 根据当日快照结算月度收益
+func (b *behaviorOverlay) record(snapshot types.PortfolioSnapshot) {
+	key := monthKey(snapshot.Timestamp)
+	if b.monthKey == 0 {
+		b.monthKey = key
+		b.monthStartValue = snapshot.TotalValue
+	} else if key != b.monthKey {
+		b.lastMonthReturn = b.previousMonthReturn(snapshot.Timestamp)
+		b.monthKey = key
+		b.monthStartValue = b.lastValue
+	}
+	b.lastValue = snapshot.TotalValue
+}
+
+// previousMonthReturn 截至 date 最近一个完整月份的收益 (date 为新月份首日时当日快照尚未结算)
+func (b *behaviorOverlay) previousMonthReturn(date time.Time) float64 {
+	if b.monthKey != 0 && monthKey(date) != b.monthKey && b.monthStartValue > 0 {
+		return b.lastValue/b.monthStartValue - 1
+	}
+	return b.lastMonthReturn
+}
+
+// filterBuys 上月亏损超过阈值时拒绝所有买单
+func (b *behaviorOverlay) filterBuys(orders []types.Order, date time.Time) []types.Order {
+	if b.config.NoBuyAfterLoss <= 0 || b.previousMonthReturn(date) > -b.config.NoBuyAfterLoss {
+		return orders
+	}
+	kept := orders[:0]
+	for _, order := range orders {
+		if order.Side == "BUY" {
+			b.stats.RefusedBuys++
+			b.stats.RefusedBuyValue += order.Quantity * order.Price
+			continue
+		}
+		kept = append(kept, order)
+	}
+	return kept
+}
+
+// result 行为偏差影响统计，未启用时为nil
+func (b *behaviorOverlay) result() *types.BehaviorStats {
+	if !b.config.Enabled() {
+		return nil
+	}
+	stats := b.stats
+	return &stats
+}
+
+// validateBehavior 验证行为偏差配置
+func validateBehavior(config types.BehaviorOverlay) error {
+	if config.SkipProbability < 0 || config.SkipProbability > 1 {
+		return fmt.Errorf("behavior skip probability must be between 0 and 1")
+	}
+	if config.DelayDays < 0 || config.NoBuyAfterLoss < 0 {
+		return fmt.Errorf("behavior delay and loss threshold must be non-negative")
+	}
+	return nil
+}
+
+// monthKey 月份序号
+func monthKey(date time.Time) int {
+	return date.Year()*12 + int(date.Month())
+}
+
+// BehaviorComparison 行为偏差回测与理想回测的对比
+type BehaviorComparison struct {
+	IdealValue   float64
+	IdealReturn  float64
+	ActualValue  float64
+	ActualReturn float64
+	Cost         float64 // 理想回测与行为偏差回测的期末价值差
+	CostReturn   float64 // 收益率差
+	Stats        types.BehaviorStats
+}
+
+// CompareBehavior 分别运行理想回测和带行为偏差的回测，计算纪律执行不到位的代价
+func CompareBehavior(cfg *config.Config) (*BehaviorComparison, error) {
+	backtestConfig, err := cfg.ToBacktestConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !backtestConfig.Behavior.Enabled() {
+		return nil, fmt.Errorf("no behavior overlay configured")
+	}
+
+	ideal := *cfg
+	ideal.Backtest.Behavior = config.BehaviorSection{}
+	idealSleeve, err := NewSleeve("ideal", &ideal)
+	if err != nil {
+		return nil, err
+	}
+	actualSleeve, err := NewSleeve("behavior", cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Running idealized backtest")
+	idealResult, err := idealSleeve.Engine.Run()
+	if err != nil {
+		return nil, fmt.Errorf("ideal backtest failed: %w", err)
+	}
+	fmt.Println("Running backtest with behavior overlay")
+	actualResult, err := actualSleeve.Engine.Run()
+	if err != nil {
+		return nil, fmt.Errorf("behavior backtest failed: %w", err)
+	}
+
+	comparison := &BehaviorComparison{
+		IdealValue:   idealResult.FinalValue,
+		IdealReturn:  idealResult.TotalReturn,
+		ActualValue:  actualResult.FinalValue,
+		ActualReturn: actualResult.TotalReturn,
+		Cost:         idealResult.FinalValue - actualResult.FinalValue,
+		CostReturn:   idealResult.TotalReturn - actualResult.TotalReturn,
+	}
+	if actualResult.Behavior != nil {
+		comparison.Stats = *actualResult.Behavior
+	}
+	return comparison, nil
+}
+
+// PrintBehaviorComparison 打印行为偏差对比结果
+func PrintBehaviorComparison(c *BehaviorComparison) {
+	fmt.Println("\n========== Behavior Cost ==========")
+	fmt.Printf("Idealized:  $%.2f (%.2f%%)\n", c.IdealValue, c.IdealReturn*100)
+	fmt.Printf("Behavioral: $%.2f (%.2f%%)\n", c.ActualValue, c.ActualReturn*100)
+	fmt.Printf("Cost:       $%.2f (%.2f%%)\n", c.Cost, c.CostReturn*100)
+	fmt.Printf("Skipped Rebalances: %d, Delayed: %d, Refused Buys: %d ($%.2f)\n",
+		c.Stats.SkippedRebalances, c.Stats.DelayedRebalances, c.Stats.RefusedBuys, c.Stats.RefusedBuyValue)
+	fmt.Println("===================================")
+}
+
+// ExportBehaviorComparison 导出行为偏差对比结果
+func ExportBehaviorComparison(c *BehaviorComparison, filepath string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Behavior comparison exported to: %s\n", filepath)
+	return nil
+}
@@ -0,0 +1,215 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// benchmarkTracker 基准净值：按成分权重买入，按再平衡频率调回权重，追加投入按权重买入
+type benchmarkTracker struct {
+	result     types.BenchmarkResult
+	symbols    []string           // 成分 (按标的排序)
+	units      map[string]float64 // 各成分持有的份额
+	value      float64            // 建仓前的资金 (初始资金或断点之前的基准净值)
+	started    bool
+	rebalanced time.Time // 最近一次再平衡的交易日
+}
+
+// benchmarkWeights 基准成分权重：设置了混合基准时按配置 (归一化为合计1)，否则为单一基准标的
+func benchmarkWeights(config types.BacktestConfig) (map[string]float64, error) {
+	if len(config.BenchmarkBlend) == 0 {
+		if config.Benchmark == "" {
+			return nil, nil
+		}
+		return map[string]float64{config.Benchmark: 1}, nil
+	}
+	total := 0.0
+	for symbol, w := range config.BenchmarkBlend {
+		if w <= 0 {
+			return nil, fmt.Errorf("benchmark blend weight for %s must be positive", symbol)
+		}
+		total += w
+	}
+	weights := make(map[string]float64, len(config.BenchmarkBlend))
+	for symbol, w := range config.BenchmarkBlend {
+		weights[symbol] = w / total
+	}
+	return weights, nil
+}
+
+// benchmarkName 基准名称，混合基准按权重从大到小列出成分
+func benchmarkName(weights map[string]float64) string {
+	if len(weights) == 1 {
+		for symbol := range weights {
+			return symbol
+		}
+	}
+	symbols := make([]string, 0, len(weights))
+	for symbol := range weights {
+		symbols = append(symbols, symbol)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if weights[symbols[i]] != weights[symbols[j]] {
+			return weights[symbols[i]] > weights[symbols[j]]
+		}
+		return symbols[i] < symbols[j]
+	})
+	parts := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		parts[i] = fmt.Sprintf("%.0f%% %s", weights[symbol]*100, symbol)
+	}
+	return strings.Join(parts, " + ")
+}
+
+// newBenchmark 加载基准成分的价格并创建基准净值跟踪，未设置基准时返回 nil
+// 单一基准缺少数据时给出警告并返回 nil，混合基准缺少成分数据时报错
+func (e *BacktestEngine) newBenchmark() (*benchmarkTracker, error) {
+	weights, err := benchmarkWeights(e.config)
+	if err != nil || weights == nil {
+		return nil, err
+	}
+	switch e.config.BenchmarkRebalance {
+	case "", types.BenchmarkRebalancePortfolio, types.BenchmarkRebalanceMonthly, types.BenchmarkRebalanceQuarterly,
+		types.BenchmarkRebalanceYearly, types.BenchmarkRebalanceNone:
+	default:
+		return nil, fmt.Errorf("unknown benchmark rebalance: %s", e.config.BenchmarkRebalance)
+	}
+
+	symbols := make([]string, 0, len(weights))
+	for symbol := range weights {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	if err := e.dataLoader.LoadReferences(symbols, e.config.EndDate); err != nil {
+		if len(e.config.BenchmarkBlend) == 0 {
+			e.logf("Warning: benchmark skipped: %v\n", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load benchmark: %w", err)
+	}
+
+	rebalance := e.config.BenchmarkRebalance
+	if rebalance == "" {
+		rebalance = types.BenchmarkRebalancePortfolio
+	}
+	b := &benchmarkTracker{
+		result: types.BenchmarkResult{
+			Name:      benchmarkName(weights),
+			Weights:   weights,
+			Rebalance: rebalance,
+		},
+		symbols: symbols,
+		units:   make(map[string]float64),
+		value:   e.config.InitialCapital,
+	}
+	if e.previous != nil && e.previous.Benchmark != nil {
+		if n := len(e.previous.Benchmark.Values); n > 0 {
+			b.value = e.previous.Benchmark.Values[n-1].Value
+		}
+	}
+	return b, nil
+}
+
+// benchmarkPrices 各成分当日 (或之前最近一个交易日) 的复权价格，换算为基础币种；有成分没有价格时返回 false
+func (e *BacktestEngine) benchmarkPrices(b *benchmarkTracker, date time.Time) (map[string]float64, bool) {
+	prices := make(map[string]float64, len(b.symbols))
+	for _, symbol := range b.symbols {
+		price, ok := e.dataLoader.Indicator(symbol, indicators.Price, 0, date)
+		if !ok || price <= 0 {
+			return nil, false
+		}
+		if e.fx != nil {
+			if price, ok = e.fx.convertPrice(symbol, price, date); !ok {
+				return nil, false
+			}
+		}
+		prices[symbol] = price
+	}
+	return prices, true
+}
+
+// updateBenchmark 按交易日更新基准净值：所有成分都有价格后建仓，追加投入按权重买入，到达再平衡日时调回权重
+// rebalanced 为组合当日是否再平衡 (portfolio 频率时基准同日再平衡)
+func (e *BacktestEngine) updateBenchmark(b *benchmarkTracker, date time.Time, deposit float64, rebalanced bool) {
+	if b == nil {
+		return
+	}
+	prices, ok := e.benchmarkPrices(b, date)
+	if !ok {
+		if !b.started {
+			b.value += deposit
+		}
+		return
+	}
+
+	value := b.value
+	if b.started {
+		value = 0
+		for symbol, units := range b.units {
+			value += units * prices[symbol]
+		}
+	}
+	value += deposit
+
+	switch {
+	case !b.started || b.due(date, rebalanced):
+		if b.started {
+			b.result.Rebalances++
+		}
+		b.started = true
+		b.rebalanced = date
+		for symbol, w := range b.result.Weights {
+			b.units[symbol] = value * w / prices[symbol]
+		}
+	case deposit > 0:
+		// 追加投入按权重买入，已有份额不变
+		for symbol, w := range b.result.Weights {
+			b.units[symbol] += deposit * w / prices[symbol]
+		}
+	}
+	b.result.Values = append(b.result.Values, types.BenchmarkPoint{Timestamp: date, Value: value})
+}
+
+// due 是否到达再平衡日
+func (b *benchmarkTracker) due(date time.Time, rebalanced bool) bool {
+	switch b.result.Rebalance {
+	case types.BenchmarkRebalancePortfolio:
+		return rebalanced
+	case types.BenchmarkRebalanceMonthly:
+		return date.Year() != b.rebalanced.Year() || date.Month() != b.rebalanced.Month()
+	case types.BenchmarkRebalanceQuarterly:
+		return date.Year() != b.rebalanced.Year() || (date.Month()-1)/3 != (b.rebalanced.Month()-1)/3
+	case types.BenchmarkRebalanceYearly:
+		return date.Year() != b.rebalanced.Year()
+	}
+	return false
+}
+
+// prependPrevious 增量回测时在净值前拼接断点之前的基准净值
+func (b *benchmarkTracker) prependPrevious(prev *types.BenchmarkResult) {
+	if b == nil || prev == nil {
+		return
+	}
+	b.result.Values = append(append([]types.BenchmarkPoint(nil), prev.Values...), b.result.Values...)
+	b.result.Rebalances += prev.Rebalances
+}
+
+// benchmarkResult 基准收益 (本金含追加投入，与组合收益率口径相同) 和超额收益
+func (b *benchmarkTracker) benchmarkResult(invested, portfolioReturn float64) *types.BenchmarkResult {
+	if b == nil || len(b.result.Values) == 0 || invested <= 0 {
+		return nil
+	}
+	result := b.result
+	result.TotalReturn = result.Values[len(result.Values)-1].Value/invested - 1
+	result.ExcessReturn = portfolioReturn - result.TotalReturn
+	if math.IsNaN(result.TotalReturn) {
+		return nil
+	}
+	return &result
+}
@@ -0,0 +1,103 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// tradeAnalytics 汇总成交记录：平均成交金额、年均笔数、买卖不平衡、最大单笔成交占组合比例和再平衡间隔分布
+// 首次建仓的成交只计入笔数和平均金额，不计入买卖不平衡和最大单笔 (否则总是由建仓主导)
+func tradeAnalytics(trades []types.Trade, snapshots []types.PortfolioSnapshot, targets []types.TargetWeightRecord) *types.TradeAnalytics {
+	if len(trades) == 0 {
+		return nil
+	}
+
+	values := make(map[int64]float64, len(snapshots)) // 各交易日的组合价值
+	for _, s := range snapshots {
+		values[s.Timestamp.Unix()] = s.TotalValue
+	}
+
+	a := &types.TradeAnalytics{}
+	total := 0.0
+	for _, trade := range trades {
+		total += trade.Value
+		if trade.Trigger == types.TriggerInitial {
+			continue
+		}
+		if trade.Side == "BUY" {
+			a.BuyValue += trade.Value
+		} else {
+			a.SellValue += trade.Value
+		}
+		if trade.Value > a.LargestTrade.Value {
+			a.LargestTrade = trade
+		}
+	}
+	a.AvgTradeValue = total / float64(len(trades))
+	if turnover := a.BuyValue + a.SellValue; turnover > 0 {
+		a.Imbalance = (a.BuyValue - a.SellValue) / turnover
+	}
+	if v := values[a.LargestTrade.Timestamp.Unix()]; v > 0 && a.LargestTrade.Value > 0 {
+		a.LargestTradeWeight = a.LargestTrade.Value / v
+	}
+	if n := len(snapshots); n > 1 {
+		if years := snapshots[n-1].Timestamp.Sub(snapshots[0].Timestamp).Hours() / 24 / 365.25; years > 0 {
+			a.TradesPerYear = float64(len(trades)) / years
+		}
+	}
+
+	gaps := make([]float64, 0, len(targets))
+	for i := 1; i < len(targets); i++ {
+		gaps = append(gaps, targets[i].Timestamp.Sub(targets[i-1].Timestamp).Hours()/24)
+	}
+	a.RebalanceGaps = gapStats(gaps)
+	return a
+}
+
+// gapStats 间隔天数的分位数和均值 (分位数按线性插值)
+func gapStats(gaps []float64) types.GapStats {
+	stats := types.GapStats{Count: len(gaps)}
+	if len(gaps) == 0 {
+		return stats
+	}
+	sorted := append([]float64(nil), gaps...)
+	sort.Float64s(sorted)
+	quantile := func(q float64) float64 {
+		pos := q * float64(len(sorted)-1)
+		lo := int(math.Floor(pos))
+		hi := int(math.Ceil(pos))
+		return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+	}
+	sum := 0.0
+	for _, g := range sorted {
+		sum += g
+	}
+	stats.Min = sorted[0]
+	stats.P25 = quantile(0.25)
+	stats.Median = quantile(0.5)
+	stats.P75 = quantile(0.75)
+	stats.Max = sorted[len(sorted)-1]
+	stats.Mean = sum / float64(len(sorted))
+	return stats
+}
+
+// printTradeAnalytics 打印成交记录统计
+func (e *BacktestEngine) printTradeAnalytics() {
+	a := e.result.TradeAnalytics
+	if a == nil {
+		return
+	}
+	fmt.Printf("Trades: avg $%.2f, %.1f/year, bought $%.2f / sold $%.2f after initial build (imbalance %+.1f%%)\n",
+		a.AvgTradeValue, a.TradesPerYear, a.BuyValue, a.SellValue, a.Imbalance*100)
+	if t := a.LargestTrade; t.Value > 0 {
+		fmt.Printf("Largest Trade: %s %s $%.2f on %s (%.2f%% of portfolio)\n",
+			t.Side, t.Symbol, t.Value, t.Timestamp.Format("2006-01-02"), a.LargestTradeWeight*100)
+	}
+	if g := a.RebalanceGaps; g.Count > 0 {
+		fmt.Printf("Days Between Rebalances: min %.0f, p25 %.0f, median %.0f, p75 %.0f, max %.0f, mean %.1f (%d gaps)\n",
+			g.Min, g.P25, g.Median, g.P75, g.Max, g.Mean, g.Count)
+	}
+}
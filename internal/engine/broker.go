@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 券商导入格式
+const (
+	OrderFormatCSV = "csv" // 通用CSV：symbol,side,quantity,price,amount
+	OrderFormatCN  = "cn"  // A股券商批量下单：按整手取整的证券代码/买卖方向/委托价格/委托数量
+)
+
+// DefaultLotSize A股每手股数
+const DefaultLotSize = 100
+
+// RoundLots 将订单数量按标的的交易单位向下取整，lotSizes 中未设置的标的按 lotSize (默认一手100股)
+// 卖出全部持仓时按持有数量 (取整到整股，交易单位小于1时取整到交易单位) 卖出，零股只能一次性卖出；取整后为0的订单被丢弃
+func RoundLots(orders []types.Order, lotSizes map[string]float64, lotSize float64, held map[string]float64) []types.Order {
+	if lotSize <= 0 {
+		lotSize = DefaultLotSize
+	}
+	rounded := make([]types.Order, 0, len(orders))
+	for _, order := range orders {
+		lot := lotSize
+		if l := lotSizes[order.Symbol]; l > 0 {
+			lot = l
+		}
+		quantity := types.RoundLot(order.Quantity, lot)
+		if order.Side == "SELL" {
+			if position := held[order.Symbol]; position > 0 && order.Quantity >= position*(1-1e-9) {
+				quantity = types.RoundLot(position, math.Min(lot, 1))
+			}
+		}
+		if quantity <= 0 {
+			continue
+		}
+		order.Quantity = quantity
+		rounded = append(rounded, order)
+	}
+	return rounded
+}
+
+// FormatOrders 将订单转换为券商导入格式的表格 (首行为表头)
+// lotSizes (按标的，配置的 assets[].lot_size)、lotSize (未设置的标的) 和当前持仓数量 held 仅 cn 格式取整时使用
+func FormatOrders(orders []types.Order, format string, lotSizes map[string]float64, lotSize float64, held map[string]float64) ([][]string, error) {
+	switch format {
+	case "", OrderFormatCSV:
+		rows := [][]string{{"symbol", "side", "quantity", "price", "amount"}}
+		for _, order := range orders {
+			rows = append(rows, []string{
+				order.Symbol,
+				order.Side,
+				strconv.FormatFloat(order.Quantity, 'f', 4, 64),
+				strconv.FormatFloat(order.Price, 'f', 4, 64),
+				strconv.FormatFloat(order.Quantity*order.Price, 'f', 2, 64),
+			})
+		}
+		return rows, nil
+	case OrderFormatCN:
+		rows := [][]string{{"证券代码", "买卖方向", "委托价格", "委托数量", "委托金额"}}
+		for _, order := range RoundLots(orders, lotSizes, lotSize, held) {
+			side := "买入"
+			if order.Side == "SELL" {
+				side = "卖出"
+			}
+			rows = append(rows, []string{
+				order.Symbol,
+				side,
+				strconv.FormatFloat(order.Price, 'f', 3, 64),
+				strconv.FormatFloat(order.Quantity, 'f', -1, 64),
+				strconv.FormatFloat(order.Quantity*order.Price, 'f', 2, 64),
+			})
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unknown order format: %s", format)
+}
+
+// ExportOrders 按券商导入格式导出订单
+func ExportOrders(orders []types.Order, format string, lotSizes map[string]float64, lotSize float64, held map[string]float64, filepath string) error {
+	rows, err := FormatOrders(orders, format, lotSizes, lotSize, held)
+	if err != nil {
+		return err
+	}
+
+	if err := writeCSV(filepath, rows); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d orders exported to: %s\n", len(rows)-1, filepath)
+	return nil
+}
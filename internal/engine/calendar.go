@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/calendar"
+)
+
+// alignCalendar 按配置的交易日历对齐各标的数据，记录与日历不一致的行
+func (e *BacktestEngine) alignCalendar() error {
+	cal, err := calendar.New(e.config.Calendar, e.config.CalendarHolidays)
+	if err != nil {
+		return err
+	}
+	if years := cal.UncoveredYears(e.config.StartDate, e.config.EndDate); len(years) > 0 {
+		e.logf("Warning: calendar %s has no holiday data for %v, only weekends are excluded and missing days are not filled (set calendar_holidays to cover them)\n", cal.Name, years)
+	}
+
+	e.calendar = cal.Name
+	e.misalignments = e.dataLoader.AlignToCalendar(cal)
+	for _, m := range e.misalignments {
+		e.logf("Calendar %s: %s dropped %d rows on non-trading days, filled %d missing trading days\n",
+			cal.Name, m.Symbol, len(m.Dropped), len(m.Filled))
+	}
+	return nil
+}
+
+// calendarSummary 摘要中的交易日历说明
+func calendarSummary(name string, misaligned int, dropped int, filled int) string {
+	if misaligned == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%d symbols misaligned: %d rows dropped, %d filled)", name, misaligned, dropped, filled)
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Resume 设置断点，Run 从断点之后的交易日继续回测
+// 持仓、现金、策略内部状态和追加投入进度从断点恢复，结果只包含断点之后的快照和交易
+func (e *BacktestEngine) Resume(cp *types.Checkpoint) {
+	e.resume = cp
+}
+
+// restoreCheckpoint 按断点恢复组合和策略状态
+func (e *BacktestEngine) restoreCheckpoint() error {
+	cp := e.resume
+	if err := e.loadStrategyState(cp); err != nil {
+		return err
+	}
+	e.portfolioManager.Restore(cp.Portfolio)
+	if e.config.Contributions.Monthly > 0 {
+		e.contributions.monthKey = monthKey(cp.Date)
+	}
+	e.contributions.total = cp.Contributed
+	e.stops.restore(cp.Stops)
+	e.kill.restore(cp.KillSwitch)
+	e.logf("Resuming from checkpoint at %s\n", cp.Date.Format("2006-01-02"))
+	return nil
+}
+
+// loadStrategyState 按断点恢复策略内部状态
+func (e *BacktestEngine) loadStrategyState(cp *types.Checkpoint) error {
+	if cp.Strategy != e.strategy.Name() {
+		return fmt.Errorf("checkpoint was saved by strategy %q, not %q", cp.Strategy, e.strategy.Name())
+	}
+	if len(cp.StrategyState) == 0 {
+		return nil
+	}
+	saver, ok := e.strategy.(strategy.StateSaver)
+	if !ok {
+		return fmt.Errorf("strategy %q cannot restore saved state", e.strategy.Name())
+	}
+	if err := saver.LoadState(cp.StrategyState); err != nil {
+		return fmt.Errorf("failed to restore strategy state: %w", err)
+	}
+	return nil
+}
+
+// skipResumed 是否为断点 (含) 之前已处理的交易日
+func (e *BacktestEngine) skipResumed(date time.Time) bool {
+	return e.resume != nil && !date.After(e.resume.Date)
+}
+
+// recordCheckpoint 记录期末清仓前的组合状态，unsettled 为未成交的延迟订单、挂单和限价单数
+func (e *BacktestEngine) recordCheckpoint(date time.Time, unsettled int) {
+	e.checkpointDate = date
+	e.endPortfolio = *e.portfolioManager.GetPortfolio()
+	e.endPortfolio.Positions = make(map[string]types.Position, len(e.endPortfolio.Positions))
+	for symbol, pos := range e.portfolioManager.GetPortfolio().Positions {
+		e.endPortfolio.Positions[symbol] = pos
+	}
+	e.unsettledOrders = unsettled
+}
+
+// Checkpoint 返回回测最后一个交易日收盘后 (期末清仓前) 的断点
+func (e *BacktestEngine) Checkpoint() (*types.Checkpoint, error) {
+	if e.checkpointDate.IsZero() {
+		return nil, fmt.Errorf("no checkpoint available, run the backtest first")
+	}
+	cp := &types.Checkpoint{
+		Date:        e.checkpointDate,
+		Strategy:    e.strategy.Name(),
+		Portfolio:   e.endPortfolio,
+		Contributed: e.contributions.total,
+		Stops:       e.stops.state(),
+		KillSwitch:  e.kill.state(),
+	}
+	if saver, ok := e.strategy.(strategy.StateSaver); ok {
+		state, err := saver.SaveState()
+		if err != nil {
+			return nil, fmt.Errorf("failed to save strategy state: %w", err)
+		}
+		cp.StrategyState = state
+	}
+	return cp, nil
+}
+
+// SaveCheckpoint 将断点保存为JSON文件，未成交的订单不保存
+func (e *BacktestEngine) SaveCheckpoint(filepath string) error {
+	cp, err := e.Checkpoint()
+	if err != nil {
+		return err
+	}
+	if e.unsettledOrders > 0 {
+		e.logf("Warning: %d unsettled orders are not saved in the checkpoint\n", e.unsettledOrders)
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	e.logf("Checkpoint saved to: %s\n", filepath)
+	return nil
+}
+
+// LoadCheckpoint 读取 SaveCheckpoint 保存的断点
+func LoadCheckpoint(filepath string) (*types.Checkpoint, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp types.Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return &cp, nil
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+)
+
+// runKillSwitch 以示例配置加止损开关运行回测，endDate 非空时提前结束，setup 非空时在运行前设置引擎 (如从断点继续)
+func runKillSwitch(t *testing.T, maxDrawdown float64, endDate string, setup func(*BacktestEngine)) *BacktestEngine {
+	t.Helper()
+	cfg, err := config.LoadConfig("../../examples/configs/fixed_weight.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Backtest.DataDir = "../../examples/data"
+	cfg.Backtest.KillSwitch.MaxDrawdown = maxDrawdown
+	cfg.Backtest.KillSwitch.SafeWeights = map[string]float64{"TLT": 1}
+	if endDate != "" {
+		cfg.Backtest.EndDate = endDate
+	}
+	sleeve, err := NewSleeve("test", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleeve.Engine.SetLogOutput(ioutil.Discard)
+	if setup != nil {
+		setup(sleeve.Engine)
+	}
+	if _, err := sleeve.Engine.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return sleeve.Engine
+}
+
+// 在断点处拆分的回测与一次完成的回测在同一天触发止损开关
+func TestCheckpointKeepsKillSwitch(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxDrawdown float64
+		split       string
+	}{
+		{name: "trips after split", maxDrawdown: 0.12, split: "2022-06-30"},
+		{name: "tripped before split", maxDrawdown: 0.05, split: "2021-12-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whole := runKillSwitch(t, tt.maxDrawdown, "", nil).result.KillSwitch
+			if whole == nil {
+				t.Fatal("single run did not trip the kill switch")
+			}
+
+			cp, err := runKillSwitch(t, tt.maxDrawdown, tt.split, nil).Checkpoint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			resumed := runKillSwitch(t, tt.maxDrawdown, "", func(e *BacktestEngine) { e.Resume(cp) })
+			split := resumed.result.KillSwitch
+			if split == nil {
+				t.Fatal("split run did not trip the kill switch")
+			}
+			if !split.Timestamp.Equal(whole.Timestamp) {
+				t.Errorf("split run tripped on %s, single run on %s",
+					split.Timestamp.Format("2006-01-02"), whole.Timestamp.Format("2006-01-02"))
+			}
+			if !resumed.kill.executed {
+				t.Error("safe allocation was not kept after resuming")
+			}
+		})
+	}
+}
+
+// 增量回测沿用已有结果中的止损开关状态，合并后的结果与一次完成的回测一致
+func TestExtendKeepsKillSwitch(t *testing.T) {
+	whole := runKillSwitch(t, 0.12, "", nil).result
+
+	first := runKillSwitch(t, 0.12, "2022-06-30", nil)
+	cp, err := first.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &State{Checkpoint: cp, Result: first.result}
+	extended := runKillSwitch(t, 0.12, "", func(e *BacktestEngine) {
+		if err := e.Extend(state); err != nil {
+			t.Fatal(err)
+		}
+	}).result
+
+	if extended.KillSwitch == nil || !extended.KillSwitch.Timestamp.Equal(whole.KillSwitch.Timestamp) {
+		t.Errorf("extended run kill switch = %+v, single run %+v", extended.KillSwitch, whole.KillSwitch)
+	}
+	if len(extended.Snapshots) != len(whole.Snapshots) {
+		t.Errorf("extended run has %d snapshots, single run %d", len(extended.Snapshots), len(whole.Snapshots))
+	}
+}
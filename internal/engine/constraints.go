@@ -0,0 +1,169 @@
+package engine
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// constraintEpsilon 权重比较容差
+const constraintEpsilon = 1e-9
+
+// applyConstraints 对策略目标权重施加单标的和资产类别的上下限
+// 触及约束的标的固定在约束值，差额按权重比例分配给其余标的，保持总仓位不变；
+// 所有标的都受约束时差额留作现金
+func (e *BacktestEngine) applyConstraints(target map[string]float64, date time.Time) map[string]float64 {
+	c := e.config.Constraints
+	if c.MaxWeight <= 0 && len(c.Symbols) == 0 && len(c.Classes) == 0 {
+		return target
+	}
+
+	weights := make(map[string]float64, len(target))
+	symbols := make([]string, 0, len(target))
+	total := 0.0
+	for symbol, w := range target {
+		weights[symbol] = w
+		if symbol == types.CashSymbol {
+			continue
+		}
+		symbols = append(symbols, symbol)
+		total += w
+	}
+	// 设置了下限的类别中不在目标权重里的标的也参与分配
+	for _, symbol := range e.config.Symbols {
+		if _, ok := weights[symbol]; ok {
+			continue
+		}
+		if bounds, ok := c.Classes[e.config.AssetClasses[symbol]]; ok && bounds.Min > 0 {
+			weights[symbol] = 0
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	classes := make([]string, 0, len(c.Classes))
+	for class := range c.Classes {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	recorded := make(map[string]bool)
+	record := func(target, bound string, limit, weight float64) {
+		key := target + "/" + bound
+		if recorded[key] {
+			return
+		}
+		recorded[key] = true
+		e.bindings = append(e.bindings, types.ConstraintBinding{
+			Timestamp: date,
+			Target:    target,
+			Bound:     bound,
+			Limit:     limit,
+			Weight:    weight,
+		})
+	}
+
+	fixed := make(map[string]bool)
+	for iter := 0; iter < 50; iter++ {
+		changed := false
+
+		// 单标的上下限
+		for _, symbol := range symbols {
+			lo, hi := e.symbolBounds(symbol)
+			w := weights[symbol]
+			if hi > 0 && w > hi+constraintEpsilon {
+				record(symbol, "max", hi, w)
+				weights[symbol] = hi
+				fixed[symbol] = true
+				changed = true
+			} else if w < lo-constraintEpsilon {
+				record(symbol, "min", lo, w)
+				weights[symbol] = lo
+				fixed[symbol] = true
+				changed = true
+			}
+		}
+
+		// 资产类别上下限 (类别内按比例缩放，类别权重为0时下限在成员间平均分配)
+		for _, class := range classes {
+			bounds := c.Classes[class]
+			members := make([]string, 0)
+			sum := 0.0
+			for _, symbol := range symbols {
+				if e.config.AssetClasses[symbol] == class {
+					members = append(members, symbol)
+					sum += weights[symbol]
+				}
+			}
+			if sum <= 0 {
+				if len(members) == 0 || bounds.Min <= constraintEpsilon {
+					continue
+				}
+				record("class:"+class, "min", bounds.Min, sum)
+				for _, symbol := range members {
+					weights[symbol] = bounds.Min / float64(len(members))
+					fixed[symbol] = true
+				}
+				changed = true
+				continue
+			}
+
+			limit, bound := 0.0, ""
+			if bounds.Max > 0 && sum > bounds.Max+constraintEpsilon {
+				limit, bound = bounds.Max, "max"
+			} else if sum < bounds.Min-constraintEpsilon {
+				limit, bound = bounds.Min, "min"
+			}
+			if bound == "" {
+				continue
+			}
+
+			record("class:"+class, bound, limit, sum)
+			for _, symbol := range members {
+				weights[symbol] *= limit / sum
+				fixed[symbol] = true
+			}
+			changed = true
+		}
+
+		// 差额分配给未受约束的标的
+		sum := 0.0
+		free := 0.0
+		for _, symbol := range symbols {
+			sum += weights[symbol]
+			if !fixed[symbol] {
+				free += weights[symbol]
+			}
+		}
+		diff := total - sum
+		if math.Abs(diff) > constraintEpsilon && free > 0 {
+			for _, symbol := range symbols {
+				if !fixed[symbol] {
+					weights[symbol] += diff * weights[symbol] / free
+				}
+			}
+			changed = true
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	return weights
+}
+
+// symbolBounds 标的的权重上下限，单独配置的上限优先于通用上限
+func (e *BacktestEngine) symbolBounds(symbol string) (float64, float64) {
+	c := e.config.Constraints
+	lo, hi := 0.0, c.MaxWeight
+	if b, ok := c.Symbols[symbol]; ok {
+		lo = b.Min
+		if b.Max > 0 {
+			hi = b.Max
+		}
+	}
+	return lo, hi
+}
@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// contributionPlan 每月首个交易日追加投入，按条件规则调整投入金额
+type contributionPlan struct {
+	schedule  types.ContributionSchedule
+	benchmark string
+	source    data.IndicatorSource
+	monthKey  int
+	records   []types.ContributionRecord
+	total     float64
+}
+
+// newContributionPlan 创建追加投入计划，基准回撤按数据源的回撤指标 (相对历史最高价) 判断
+func newContributionPlan(schedule types.ContributionSchedule, benchmark string, source data.IndicatorSource) *contributionPlan {
+	if schedule.BoostFactor <= 0 {
+		schedule.BoostFactor = 2
+	}
+	return &contributionPlan{schedule: schedule, benchmark: benchmark, source: source}
+}
+
+// due 返回当日应投入的金额 (回测首月不投入)，cash 为投入前的账户现金
+func (p *contributionPlan) due(date time.Time, cash float64) float64 {
+	if p.schedule.Monthly <= 0 {
+		return 0
+	}
+	key := monthKey(date)
+	if p.monthKey == 0 {
+		p.monthKey = key
+		return 0
+	}
+	if key == p.monthKey {
+		return 0
+	}
+	p.monthKey = key
+
+	amount, rule := p.schedule.Monthly, ""
+	if s := p.schedule; s.PauseCashAbove > 0 && cash > s.PauseCashAbove {
+		amount, rule = 0, types.ContributionPause
+	} else if s.BoostDrawdown > 0 {
+		if dd, ok := p.source.Indicator(p.benchmark, indicators.Drawdown, 0, date); ok && dd > s.BoostDrawdown {
+			amount, rule = amount*s.BoostFactor, types.ContributionBoost
+		}
+	}
+
+	p.records = append(p.records, types.ContributionRecord{
+		Timestamp: date,
+		Scheduled: p.schedule.Monthly,
+		Amount:    amount,
+		Rule:      rule,
+	})
+	p.total += amount
+	return amount
+}
+
+// validateContributions 验证追加投入配置
+func validateContributions(schedule types.ContributionSchedule, benchmark string, symbols []string) error {
+	if schedule.Monthly < 0 || schedule.BoostDrawdown < 0 || schedule.BoostFactor < 0 || schedule.PauseCashAbove < 0 {
+		return fmt.Errorf("contribution settings must be non-negative")
+	}
+	if schedule.BoostDrawdown <= 0 {
+		return nil
+	}
+	for _, symbol := range symbols {
+		if symbol == benchmark {
+			return nil
+		}
+	}
+	return fmt.Errorf("contribution boost requires benchmark %q to be one of the symbols", benchmark)
+}
+
+// ContributionOutcome 一种投入方式的回测结果
+type ContributionOutcome struct {
+	Contributed float64 // 累计追加投入
+	FinalValue  float64
+	Gain        float64 // 期末价值 - 初始资金 - 追加投入
+	TotalReturn float64 // 相对初始资金+追加投入的收益率
+}
+
+// ContributionComparison 条件规则投入与固定计划投入的对比
+type ContributionComparison struct {
+	Fixed       ContributionOutcome
+	Conditional ContributionOutcome
+	Boosted     int // 加大投入的月数
+	Paused      int // 暂停投入的月数
+}
+
+// CompareContributions 分别按固定计划和条件规则追加投入运行回测
+func CompareContributions(cfg *config.Config) (*ContributionComparison, error) {
+	section := cfg.Backtest.Contributions
+	if section.Monthly <= 0 {
+		return nil, fmt.Errorf("no monthly contribution configured")
+	}
+	if section.BoostDrawdown <= 0 && section.PauseCashAbove <= 0 {
+		return nil, fmt.Errorf("no conditional contribution rules configured")
+	}
+
+	fixed := *cfg
+	fixed.Backtest.Contributions = config.ContributionsSection{Monthly: section.Monthly}
+	fixedSleeve, err := NewSleeve("fixed", &fixed)
+	if err != nil {
+		return nil, err
+	}
+	conditionalSleeve, err := NewSleeve("conditional", cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Running backtest with fixed contributions")
+	fixedResult, err := fixedSleeve.Engine.Run()
+	if err != nil {
+		return nil, fmt.Errorf("fixed contribution backtest failed: %w", err)
+	}
+	fmt.Println("Running backtest with conditional contributions")
+	conditionalResult, err := conditionalSleeve.Engine.Run()
+	if err != nil {
+		return nil, fmt.Errorf("conditional contribution backtest failed: %w", err)
+	}
+
+	comparison := &ContributionComparison{
+		Fixed:       contributionOutcome(fixedResult),
+		Conditional: contributionOutcome(conditionalResult),
+	}
+	for _, record := range conditionalResult.Contributions {
+		switch record.Rule {
+		case types.ContributionBoost:
+			comparison.Boosted++
+		case types.ContributionPause:
+			comparison.Paused++
+		}
+	}
+	return comparison, nil
+}
+
+// contributionOutcome 从回测结果提取投入效果
+func contributionOutcome(result *types.BacktestResult) ContributionOutcome {
+	return ContributionOutcome{
+		Contributed: result.TotalContributed,
+		FinalValue:  result.FinalValue,
+		Gain:        result.FinalValue - result.Config.InitialCapital - result.TotalContributed,
+		TotalReturn: result.TotalReturn,
+	}
+}
+
+// PrintContributionComparison 打印追加投入方式对比
+func PrintContributionComparison(c *ContributionComparison) {
+	fmt.Println("\n========== Contribution Rules ==========")
+	for _, row := range []struct {
+		name    string
+		outcome ContributionOutcome
+	}{{"Fixed", c.Fixed}, {"Conditional", c.Conditional}} {
+		fmt.Printf("%-12s Contributed: %.2f  Final: %.2f  Gain: %.2f  Return: %.2f%%\n",
+			row.name, row.outcome.Contributed, row.outcome.FinalValue, row.outcome.Gain, row.outcome.TotalReturn*100)
+	}
+	fmt.Printf("Boosted Months: %d, Paused Months: %d, Gain Difference: %.2f\n",
+		c.Boosted, c.Paused, c.Conditional.Gain-c.Fixed.Gain)
+	fmt.Println("========================================")
+}
+
+// ExportContributionComparison 导出追加投入方式对比
+func ExportContributionComparison(c *ContributionComparison, filepath string) error {
+	encoded, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, encoded, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Contribution comparison exported to: %s\n", filepath)
+	return nil
+}
@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/cost"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// costGateAllows 再平衡成本收益检查：按成本模型估算订单的交易成本 (佣金、税费、价差和滑点)，
+// 与订单执行后目标权重偏离的减少 (Σ|当前-目标| 的减少 × 组合价值) 比较，
+// 成本超过偏离减少的 MaxCostRatio 时跳过本次再平衡并记录。未启用时总是返回 true
+func (e *BacktestEngine) costGateAllows(pf *types.Portfolio, target map[string]float64, orders []types.Order, date time.Time) bool {
+	ratio := e.config.CostGate.MaxCostRatio
+	if ratio <= 0 || pf.TotalValue <= 0 || len(orders) == 0 {
+		return true
+	}
+
+	values := make(map[string]float64, len(pf.Positions))
+	for symbol, pos := range pf.Positions {
+		values[symbol] = pos.Value
+	}
+	estimated := 0.0
+	for _, order := range orders {
+		value := order.Quantity * order.Price
+		if order.Side == "SELL" {
+			value = -value
+		}
+		values[order.Symbol] += value
+		if _, fund := e.config.MutualFunds[order.Symbol]; fund {
+			estimated += e.portfolioManager.EstimateFee(order, date)
+			continue
+		}
+		estimated += cost.TotalCost(e.costModel, types.Trade{
+			Symbol:   order.Symbol,
+			Side:     order.Side,
+			Quantity: order.Quantity,
+			Price:    order.Price,
+		})
+	}
+
+	before, after := 0.0, 0.0
+	for symbol, w := range target {
+		if symbol == types.CashSymbol {
+			continue
+		}
+		before += math.Abs(pf.Positions[symbol].Value/pf.TotalValue - w)
+		after += math.Abs(values[symbol]/pf.TotalValue - w)
+	}
+	benefit := (before - after) * pf.TotalValue
+	if estimated <= ratio*benefit {
+		return true
+	}
+
+	e.costGateSkips = append(e.costGateSkips, types.CostGateSkip{
+		Timestamp:     date,
+		EstimatedCost: estimated,
+		Benefit:       benefit,
+	})
+	return false
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// applyCoveragePolicy 检查各标的数据覆盖情况，按配置的策略处理部分覆盖的标的；
+// 首末日期之间的缺失 (停牌区间之外) 无法通过收缩区间或分批建仓处理，任何策略下都报错
+func (e *BacktestEngine) applyCoveragePolicy() error {
+	e.coverage = e.dataLoader.Coverage()
+
+	partial := make([]string, 0)
+	gaps := make([]symbolGap, 0)
+	var first, last, start, end time.Time
+	if dates := e.dataLoader.GetAllDates(); len(dates) > 0 {
+		first, last = dates[0], dates[len(dates)-1]
+	}
+	for _, c := range e.coverage {
+		if c.Rows == 0 {
+			return fmt.Errorf("no data for %s in requested range", c.Symbol)
+		}
+		for _, g := range c.Gaps {
+			if !e.haltExplains(c.Symbol, g) {
+				gaps = append(gaps, symbolGap{symbol: c.Symbol, gap: g})
+			}
+		}
+		// 配置了退市或停牌至回测结束的标的，数据提前结束是预期的
+		if e.endExplained(c.Symbol) {
+			if c.FirstDate.After(first) {
+				partial = append(partial, fmt.Sprintf("%s (%s ~ %s)",
+					c.Symbol, c.FirstDate.Format("2006-01-02"), c.LastDate.Format("2006-01-02")))
+			}
+			if start.IsZero() || c.FirstDate.After(start) {
+				start = c.FirstDate
+			}
+			continue
+		}
+		if c.FirstDate.After(first) || c.LastDate.Before(last) {
+			partial = append(partial, fmt.Sprintf("%s (%s ~ %s)",
+				c.Symbol, c.FirstDate.Format("2006-01-02"), c.LastDate.Format("2006-01-02")))
+		}
+		if start.IsZero() || c.FirstDate.After(start) {
+			start = c.FirstDate
+		}
+		if end.IsZero() || c.LastDate.Before(end) {
+			end = c.LastDate
+		}
+	}
+	if len(partial) == 0 {
+		return gapError(gaps, first, last)
+	}
+
+	switch e.config.CoveragePolicy {
+	case types.CoverageShrink:
+		if end.Before(start) {
+			return fmt.Errorf("symbols have no overlapping data range")
+		}
+		if err := gapError(gaps, start, end); err != nil {
+			return err
+		}
+		e.logf("Shrinking backtest window to %s ~ %s (partial coverage: %s)\n",
+			start.Format("2006-01-02"), end.Format("2006-01-02"), strings.Join(partial, ", "))
+		e.dataLoader.ShrinkDates(start, end)
+	case types.CoverageStage:
+		if err := gapError(gaps, first, last); err != nil {
+			return err
+		}
+		e.logf("Staged entry for partially covered symbols: %s\n", strings.Join(partial, ", "))
+	default:
+		return fmt.Errorf("partial data coverage for %s; set coverage_policy to shrink or stage",
+			strings.Join(partial, ", "))
+	}
+	return nil
+}
+
+// symbolGap 标的首末日期之间缺少数据的区间
+type symbolGap struct {
+	symbol string
+	gap    types.CoverageGap
+}
+
+// gapError 与 [start, end] 相交的缺失区间，没有时返回 nil
+func gapError(gaps []symbolGap, start, end time.Time) error {
+	found := make([]string, 0)
+	for _, g := range gaps {
+		if g.gap.End.Before(start) || g.gap.Start.After(end) {
+			continue
+		}
+		found = append(found, fmt.Sprintf("%s %s ~ %s (%d days)", g.symbol,
+			g.gap.Start.Format("2006-01-02"), g.gap.End.Format("2006-01-02"), g.gap.Days))
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return fmt.Errorf("missing data inside the covered range: %s; set backtest.calendar to align (and fill) the data, "+
+		"declare the halts in assets[].halts or fill the data files", strings.Join(found, ", "))
+}
+
+// haltExplains 缺失区间是否在配置的停牌区间 [start, end) 内
+func (e *BacktestEngine) haltExplains(symbol string, gap types.CoverageGap) bool {
+	for _, h := range e.config.Halts[symbol] {
+		if !gap.Start.Before(h.Start) && (h.End.IsZero() || gap.End.Before(h.End)) {
+			return true
+		}
+	}
+	return false
+}
+
+// endExplained 标的是否配置了退市日期或停牌至回测结束 (数据提前结束不视为覆盖不完整)
+func (e *BacktestEngine) endExplained(symbol string) bool {
+	if _, ok := e.config.Delistings[symbol]; ok {
+		return true
+	}
+	for _, h := range e.config.Halts[symbol] {
+		if h.End.IsZero() {
+			return true
+		}
+	}
+	return false
+}
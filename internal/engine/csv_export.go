@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// ExportCSV 将交易记录和每日净值 (含各标的权重) 导出为 dir 下的 trades.csv 和 snapshots.csv
+func (e *BacktestEngine) ExportCSV(dir string) error {
+	if e.result == nil {
+		return fmt.Errorf("no results to export, run backtest first")
+	}
+
+	trades := [][]string{{"date", "symbol", "side", "quantity", "price", "value", "fee", "trigger", "tag"}}
+	for _, t := range e.result.Trades {
+		trades = append(trades, []string{
+			t.Timestamp.Format("2006-01-02"),
+			t.Symbol,
+			t.Side,
+			formatFloat(t.Quantity, 4),
+			formatFloat(t.Price, 4),
+			formatFloat(t.Value, 2),
+			formatFloat(t.Fee, 2),
+			string(t.Trigger),
+			t.Tag,
+		})
+	}
+	if err := writeCSV(filepath.Join(dir, "trades.csv"), trades); err != nil {
+		return err
+	}
+
+	symbolSet := make(map[string]bool)
+	for _, s := range e.result.Snapshots {
+		for symbol := range s.Weights {
+			symbolSet[symbol] = true
+		}
+	}
+	symbols := make([]string, 0, len(symbolSet))
+	for symbol := range symbolSet {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	header := []string{"date", "total_value", "cash"}
+	for _, symbol := range symbols {
+		header = append(header, "weight_"+symbol)
+	}
+	snapshots := [][]string{header}
+	for _, s := range e.result.Snapshots {
+		row := []string{s.Timestamp.Format("2006-01-02"), formatFloat(s.TotalValue, 2), formatFloat(s.Cash, 2)}
+		for _, symbol := range symbols {
+			row = append(row, formatFloat(s.Weights[symbol], 6))
+		}
+		snapshots = append(snapshots, row)
+	}
+	return writeCSV(filepath.Join(dir, "snapshots.csv"), snapshots)
+}
+
+// writeCSV 写入CSV文件
+func writeCSV(path string, rows [][]string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := csv.NewWriter(file).WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// formatFloat 按固定小数位格式化
+func formatFloat(v float64, prec int) string {
+	return strconv.FormatFloat(v, 'f', prec, 64)
+}
@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/notify"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// defaultRunAt 默认每日运行时间 (收盘后)
+const defaultRunAt = "16:30"
+
+// daemon 每个工作日定时刷新数据、按配置的持仓生成信号，触发再平衡时发送通知
+type daemon struct {
+	cfg      *config.Config
+	sleeve   *Sleeve
+	notifier notify.Multi
+	location *time.Location
+	runAt    time.Duration // 运行时间距当日零点的时长
+	lastDate time.Time     // 上次生成信号的数据交易日
+	metrics  *daemonMetrics
+}
+
+// RunDaemon 按配置中的 live 段定时运行，once 为 true 时立即运行一次后返回
+// 同一策略对象逐日复用，策略内部状态 (如上次再平衡日期) 在两次运行之间保留
+func RunDaemon(cfg *config.Config, once bool) error {
+	live := cfg.Live
+	if live.Holdings == "" {
+		return fmt.Errorf("live.holdings is required")
+	}
+	if len(cfg.Sleeves) > 0 {
+		return fmt.Errorf("daemon mode does not support sleeves, use one config per account")
+	}
+
+	d := &daemon{cfg: cfg, notifier: NewNotifier(cfg.Notify), location: time.Local, metrics: newDaemonMetrics()}
+	if live.Timezone != "" {
+		loc, err := time.LoadLocation(live.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid live.timezone: %w", err)
+		}
+		d.location = loc
+	}
+	runAt := live.RunAt
+	if runAt == "" {
+		runAt = defaultRunAt
+	}
+	clock, err := time.Parse("15:04", runAt)
+	if err != nil {
+		return fmt.Errorf("invalid live.run_at: %w", err)
+	}
+	d.runAt = time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+
+	d.sleeve, err = NewSleeve(cfg.Strategy.Name, cfg)
+	if err != nil {
+		return err
+	}
+	if live.Checkpoint != "" {
+		cp, err := LoadCheckpoint(live.Checkpoint)
+		if err != nil {
+			return err
+		}
+		d.sleeve.Engine.Resume(cp)
+	}
+	if len(d.notifier) == 0 {
+		fmt.Println("Warning: no notification channel configured, signals are only printed")
+	}
+	if live.MetricsAddr != "" {
+		d.metrics.serve(live.MetricsAddr)
+	}
+
+	for {
+		if !once {
+			next := nextRun(time.Now().In(d.location), d.runAt)
+			fmt.Printf("Next run at %s\n", next.Format("2006-01-02 15:04 MST"))
+			time.Sleep(time.Until(next))
+		}
+		d.runDay()
+		if once {
+			return nil
+		}
+	}
+}
+
+// runDay 运行一次：刷新数据、生成信号并按需通知，失败时发送失败通知
+func (d *daemon) runDay() {
+	start := time.Now()
+	signal, err := d.signal()
+	if err != nil {
+		d.metrics.recordRun("error", start)
+		fmt.Printf("Daily run failed: %v\n", err)
+		d.send(fmt.Sprintf("再平衡检查失败 %s", time.Now().In(d.location).Format("2006-01-02")), err.Error())
+		return
+	}
+	if signal == nil {
+		d.metrics.recordRun("skipped", start)
+		return
+	}
+	d.metrics.recordRun("ok", start)
+	d.metrics.recordSignal(signal)
+
+	PrintLiveSignal(signal)
+	if signal.Rebalance || d.cfg.Live.NotifyAlways {
+		title := fmt.Sprintf("%s %s", signal.Date.Format("2006-01-02"), signal.Strategy)
+		if signal.Rebalance {
+			title = "再平衡提醒 " + title
+		} else {
+			title = "无需再平衡 " + title
+		}
+		d.send(title, FormatLiveSignal(signal))
+	}
+}
+
+// signal 刷新数据并按当前持仓生成信号，数据没有新的交易日时返回nil
+func (d *daemon) signal() (*types.LiveSignal, error) {
+	if command := d.cfg.Live.RefreshCommand; command != "" {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("refresh command failed: %w", err)
+		}
+	}
+
+	// 持仓文件中的日期、价格和估值数据可能已过期，定时运行时一律使用数据目录中最新的交易日
+	holdings, err := config.LoadHoldings(d.cfg.Live.Holdings)
+	if err != nil {
+		return nil, err
+	}
+	holdings.Date = time.Time{}
+	holdings.Prices = nil
+	holdings.Fundamentals = nil
+
+	e := d.sleeve.Engine
+	e.SetDataLoader(data.NewCSVLoader(d.cfg.GetDataDir()))
+	signal, err := e.LiveSignal(holdings)
+	if err != nil {
+		return nil, err
+	}
+	d.metrics.recordDataDate(signal.Date)
+	if !signal.Date.After(d.lastDate) {
+		fmt.Printf("No new trading data since %s, skipped\n", d.lastDate.Format("2006-01-02"))
+		return nil, nil
+	}
+	d.lastDate = signal.Date
+	return signal, nil
+}
+
+// send 发送通知，失败时只打印错误
+func (d *daemon) send(title, body string) {
+	if len(d.notifier) == 0 {
+		return
+	}
+	err := d.notifier.Notify(title, body)
+	d.metrics.recordNotification(err)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// nextRun 下一个工作日 (周一至周五) 的运行时间
+func nextRun(now time.Time, runAt time.Duration) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for {
+		next := day.Add(runAt)
+		if next.After(now) && next.Weekday() != time.Saturday && next.Weekday() != time.Sunday {
+			return next
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/metrics"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// daemonMetrics 定时运行的监控指标
+type daemonMetrics struct {
+	registry *metrics.Registry
+
+	mu       sync.Mutex
+	dataDate time.Time // 数据中最新的交易日
+}
+
+// newDaemonMetrics 创建并声明定时运行的监控指标
+func newDaemonMetrics() *daemonMetrics {
+	m := &daemonMetrics{registry: metrics.NewRegistry()}
+	r := m.registry
+	r.Register("backtest_daemon_runs_total", metrics.Counter, "Daily runs by result (ok, skipped, error).")
+	r.Register("backtest_daemon_run_duration_seconds", metrics.Gauge, "Duration of the last daily run including data refresh.")
+	r.Register("backtest_daemon_last_run_timestamp_seconds", metrics.Gauge, "Unix time of the last daily run.")
+	r.Register("backtest_notifications_total", metrics.Counter, "Notifications sent by result (ok, error).")
+	r.Register("backtest_data_last_date_timestamp_seconds", metrics.Gauge, "Latest trading date in the data directory.")
+	r.GaugeFunc("backtest_data_staleness_seconds", "Time since the latest trading date in the data directory.", m.staleness)
+	r.Register("backtest_portfolio_value", metrics.Gauge, "Portfolio value at the latest signal.")
+	r.Register("backtest_rebalance_signal", metrics.Gauge, "1 if the latest signal suggests a rebalance.")
+	r.Register("backtest_last_rebalance_signal_timestamp_seconds", metrics.Gauge, "Trading date of the latest suggested rebalance.")
+	r.Register("backtest_weight", metrics.Gauge, "Current and target weight by symbol at the latest signal.")
+	r.Register("backtest_signal_score", metrics.Gauge, "Valuation signal by symbol: signed strength, positive for buy, negative for sell.")
+	return m
+}
+
+// serve 在 addr 上提供 /metrics
+func (m *daemonMetrics) serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.registry)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Printf("Warning: metrics server stopped: %v\n", err)
+		}
+	}()
+	fmt.Printf("Serving metrics on %s/metrics\n", addr)
+}
+
+// staleness 距数据最新交易日的秒数，尚无数据时为0
+func (m *daemonMetrics) staleness() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.dataDate.IsZero() {
+		return 0
+	}
+	return time.Since(m.dataDate).Seconds()
+}
+
+// recordRun 记录一次运行
+func (m *daemonMetrics) recordRun(result string, start time.Time) {
+	m.registry.Add("backtest_daemon_runs_total", metrics.Labels{"result": result}, 1)
+	m.registry.Set("backtest_daemon_run_duration_seconds", nil, time.Since(start).Seconds())
+	m.registry.Set("backtest_daemon_last_run_timestamp_seconds", nil, float64(start.Unix()))
+}
+
+// recordDataDate 记录数据最新交易日
+func (m *daemonMetrics) recordDataDate(date time.Time) {
+	m.mu.Lock()
+	m.dataDate = date
+	m.mu.Unlock()
+	m.registry.Set("backtest_data_last_date_timestamp_seconds", nil, float64(date.Unix()))
+}
+
+// recordSignal 记录当日信号
+func (m *daemonMetrics) recordSignal(s *types.LiveSignal) {
+	r := m.registry
+	r.Set("backtest_portfolio_value", nil, s.TotalValue)
+	rebalance := 0.0
+	if s.Rebalance {
+		rebalance = 1
+		r.Set("backtest_last_rebalance_signal_timestamp_seconds", nil, float64(s.Date.Unix()))
+	}
+	r.Set("backtest_rebalance_signal", nil, rebalance)
+
+	r.Reset("backtest_weight")
+	for symbol, w := range s.Weights {
+		r.Set("backtest_weight", metrics.Labels{"symbol": symbol, "kind": "current"}, w)
+	}
+	for symbol, w := range s.TargetWeights {
+		r.Set("backtest_weight", metrics.Labels{"symbol": symbol, "kind": "target"}, w)
+	}
+
+	r.Reset("backtest_signal_score")
+	for symbol, signal := range s.Signals {
+		score := 0.0
+		switch signal.Direction {
+		case types.DirectionBuy:
+			score = float64(signal.Strength)
+		case types.DirectionSell:
+			score = -float64(signal.Strength)
+		}
+		r.Set("backtest_signal_score", metrics.Labels{"symbol": symbol}, score)
+	}
+}
+
+// recordNotification 记录一次通知
+func (m *daemonMetrics) recordNotification(err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.registry.Add("backtest_notifications_total", metrics.Labels{"result": result}, 1)
+}
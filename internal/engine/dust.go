@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// addDustOrders 为碎仓生成清仓订单 (标记为dust)，替换该标的原有的卖出订单
+// 策略仍在买入的标的不视为碎仓
+func (e *BacktestEngine) addDustOrders(pf *types.Portfolio, orders []types.Order, prices map[string]float64) []types.Order {
+	policy := e.config.Dust
+	if policy.MaxWeight <= 0 && policy.MaxValue <= 0 {
+		return orders
+	}
+
+	buying := make(map[string]bool)
+	for _, order := range orders {
+		if order.Side == "BUY" {
+			buying[order.Symbol] = true
+		}
+	}
+
+	dust := make(map[string]bool)
+	symbols := make([]string, 0)
+	for symbol, pos := range pf.Positions {
+		if buying[symbol] || pos.Quantity <= 0 || prices[symbol] <= 0 {
+			continue
+		}
+		weight := 0.0
+		if pf.TotalValue > 0 {
+			weight = pos.Value / pf.TotalValue
+		}
+		if (policy.MaxWeight > 0 && weight < policy.MaxWeight) ||
+			(policy.MaxValue > 0 && pos.Value < policy.MaxValue) {
+			dust[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		return orders
+	}
+	sort.Strings(symbols)
+
+	// 卖单在前，买单在后
+	result := make([]types.Order, 0, len(orders)+len(symbols))
+	for _, symbol := range symbols {
+		result = append(result, types.Order{
+			Symbol:   symbol,
+			Side:     "SELL",
+			Quantity: pf.Positions[symbol].Quantity,
+			Price:    prices[symbol],
+			Tag:      types.TagDust,
+		})
+	}
+	for _, order := range orders {
+		if !dust[order.Symbol] {
+			result = append(result, order)
+		}
+	}
+	return result
+}
@@ -3,7 +3,10 @@ package engine
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
+	"sort"
 	"time"
 
 	"github.com/opsxjacky/Rebalance-backtest/internal/cost"
@@ -18,10 +21,51 @@ type BacktestEngine struct {
 	config           types.BacktestConfig
 	dataLoader       *data.CSVLoader
 	strategy         strategy.RebalanceStrategy
-	costModel        *cost.DefaultCostModel
+	costModel        cost.CostModel
 	portfolioManager *portfolio.Manager
+	fxLoader         *data.FXLoader
+	fx               *fxTracker
 	snapshots        []types.PortfolioSnapshot
 	result           *types.BacktestResult
+	stopReason       string
+	abortReason      string // 超出运行限制的中止原因
+	signals          []types.SignalRecord
+	markedValue      float64 // 清仓前的盯市价值
+	coverage         []types.SymbolCoverage
+	volumeDate       time.Time          // volumeUsed 对应的交易日
+	volumeUsed       map[string]float64 // 当日各标的已成交数量 (成交量限制用)
+	limitBook        []types.Order      // 等待成交的限价单
+	expiredOrders    []types.Order      // 到期撤销的限价单
+	pnl              *pnlTracker
+	financingCost    float64 // 累计融资利息和融券费用
+	holdingCost      float64 // 累计管理费
+	bindings         []types.ConstraintBinding
+	rebalanceCounts  map[types.RebalanceTrigger]int // 各触发类型的再平衡次数
+	finalPrices      map[string]float64             // 期末价格 (清仓前)
+	targetWeights    []types.TargetWeightRecord
+	trendAdjustments []types.TrendAdjustment
+	killEvent        *types.KillSwitchEvent
+	stops            *stopTracker // 提前终止条件 (断点保存其跟踪状态)
+	kill             *killSwitch  // 止损开关 (断点保存其状态)
+	behaviorStats    *types.BehaviorStats
+	costGateSkips    []types.CostGateSkip
+	shockEvents      []types.ShockEvent
+	calendar         string                       // 使用的交易日历
+	misalignments    []types.CalendarMisalignment // 与交易日历不一致的数据行
+	inceptions       []types.InceptionEvent       // 回测期间上市的标的的建仓记录
+	status           *statusTracker               // 退市和停牌状态
+	statusEvents     []types.StatusEvent          // 退市、停牌和复牌记录
+	benchmark        *benchmarkTracker            // 基准净值
+	configHash       string                       // 生效配置内容的SHA256 (写入结果元数据)
+	snapshotStream   string                       // 完整快照的流式输出文件，为空时快照全部保留在内存中
+	contributions    *contributionPlan
+	resume           *types.Checkpoint     // 继续回测的断点
+	previous         *types.BacktestResult // 增量回测时断点之前的结果
+	priorTrades      []types.Trade         // 断点之前的成交
+	checkpointDate   time.Time             // 期末断点的交易日
+	endPortfolio     types.Portfolio       // 期末清仓前的组合
+	unsettledOrders  int                   // 期末未成交的订单数 (不保存到断点)
+	log              io.Writer             // 运行日志 (进度、警告)，默认标准输出
 }
 
 // New 创建回测引擎
@@ -29,21 +73,42 @@ func New(config types.BacktestConfig) *BacktestEngine {
 	return &BacktestEngine{
 		config:    config,
 		snapshots: make([]types.PortfolioSnapshot, 0),
+		log:       os.Stdout,
 	}
 }
 
+// SetLogOutput 设置运行日志的输出 (如 ioutil.Discard 关闭日志)，摘要仍由 PrintSummary 输出到标准输出
+func (e *BacktestEngine) SetLogOutput(w io.Writer) {
+	e.log = w
+}
+
+// logf 输出运行日志
+func (e *BacktestEngine) logf(format string, args ...interface{}) {
+	fmt.Fprintf(e.log, format, args...)
+}
+
 // SetDataLoader 设置数据加载器
 func (e *BacktestEngine) SetDataLoader(loader *data.CSVLoader) {
 	e.dataLoader = loader
 }
 
+// SetConfigHash 设置生效配置内容的SHA256，写入结果元数据
+func (e *BacktestEngine) SetConfigHash(hash string) {
+	e.configHash = hash
+}
+
+// SetFXLoader 设置汇率加载器 (多币种组合时需要)
+func (e *BacktestEngine) SetFXLoader(loader *data.FXLoader) {
+	e.fxLoader = loader
+}
+
 // SetStrategy 设置策略
 func (e *BacktestEngine) SetStrategy(s strategy.RebalanceStrategy) {
 	e.strategy = s
 }
 
 // SetCostModel 设置成本模型
-func (e *BacktestEngine) SetCostModel(model *cost.DefaultCostModel) {
+func (e *BacktestEngine) SetCostModel(model cost.CostModel) {
 	e.costModel = model
 }
 
@@ -55,34 +120,145 @@ func (e *BacktestEngine) Run() (*types.BacktestResult, error) {
 	}
 
 	// 加载数据
-	fmt.Printf("Loading data for symbols: %v\n", e.config.Symbols)
+	e.logf("Loading data for symbols: %v\n", e.config.Symbols)
+	e.dataLoader.SetRankWindow(e.config.RankWindowYears)
 	_, err := e.dataLoader.LoadPrices(e.config.Symbols, e.config.StartDate, e.config.EndDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load prices: %w", err)
 	}
+	if consumer, ok := e.strategy.(strategy.BenchmarkConsumer); ok {
+		if symbols := consumer.ReferenceSymbols(); len(symbols) > 0 {
+			if err := e.dataLoader.LoadReferences(symbols, e.config.EndDate); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if consumer, ok := e.strategy.(strategy.IndicatorConsumer); ok {
+		consumer.SetIndicatorSource(e.dataLoader)
+	}
+
+	if e.config.Calendar != "" {
+		if err := e.alignCalendar(); err != nil {
+			return nil, fmt.Errorf("failed to align calendar: %w", err)
+		}
+	}
+
+	if err := e.applyShocks(); err != nil {
+		return nil, err
+	}
+	if e.benchmark, err = e.newBenchmark(); err != nil {
+		return nil, err
+	}
+
+	// 检查数据覆盖情况
+	if err := e.applyCoveragePolicy(); err != nil {
+		return nil, fmt.Errorf("coverage check failed: %w", err)
+	}
+
+	// 加载汇率数据
+	e.fx, err = newFXTracker(e.config, e.fxLoader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init fx: %w", err)
+	}
 
 	// 初始化投资组合管理器
 	e.portfolioManager = portfolio.NewManager(e.config.InitialCapital, e.costModel)
+	e.portfolioManager.SetHaircuts(e.config.Haircuts)
+	e.portfolioManager.SetLotSizes(e.config.LotSizes)
+	e.portfolioManager.SetMutualFunds(e.config.MutualFunds)
+	e.portfolioManager.SetMargin(e.config.Margin)
 
 	// 获取所有交易日期
 	dates := e.dataLoader.GetAllDates()
 	if len(dates) == 0 {
 		return nil, fmt.Errorf("no trading dates found")
 	}
+	if e.resume != nil && !dates[len(dates)-1].After(e.resume.Date) {
+		return nil, fmt.Errorf("no trading dates after checkpoint %s", e.resume.Date.Format("2006-01-02"))
+	}
 
-	fmt.Printf("Running backtest from %s to %s (%d trading days)\n",
+	e.logf("Running backtest from %s to %s (%d trading days)\n",
 		dates[0].Format("2006-01-02"),
 		dates[len(dates)-1].Format("2006-01-02"),
 		len(dates))
 
+	e.stops = newStopTracker(e.config.StopConditions)
+	limits := newLimitTracker(e.config.Limits)
+	e.kill = newKillSwitch(e.config.KillSwitch)
+	behavior := newBehaviorOverlay(e.config.Behavior, e.config.Seed)
+	e.contributions = newContributionPlan(e.config.Contributions, e.config.Benchmark, e.dataLoader)
+	listingStart := dates[0]
+	if e.resume != nil {
+		listingStart = e.resume.Date // 断点之前上市的标的视为已完成建仓
+	}
+	listing := newListingSchedule(e.config, e.coverage, listingStart)
+	e.status = newStatusTracker(e.config)
+	if e.resume != nil {
+		e.status.skipDelisted(e.resume.Date, listing)
+	}
+	e.warnDataEnds(dates[len(dates)-1])
+	e.pnl = newPnLTracker()
+	e.rebalanceCounts = make(map[types.RebalanceTrigger]int)
+	lastPrices := make(map[string]float64)
+	var lastDate time.Time
+
+	policy, lag := e.executionSettings()
+	var pending []pendingOrders
+	built := false
+	if e.resume != nil {
+		if err := e.restoreCheckpoint(); err != nil {
+			return nil, err
+		}
+		built = true
+		lastDate = e.resume.Date
+	}
+
+	var stream *snapshotStream
+	if e.snapshotStream != "" {
+		if stream, err = openSnapshotStream(e.snapshotStream, e.previous != nil); err != nil {
+			return nil, err
+		}
+		defer stream.close()
+		e.pnl.aggregate = true
+	}
+
 	// 按日期遍历
 	for i, date := range dates {
+		if e.skipResumed(date) {
+			continue
+		}
+
 		// 获取当日价格
 		prices := e.dataLoader.GetPricesOnDate(date)
 		if len(prices) == 0 {
 			continue
 		}
 
+		// 换算为基础币种
+		if e.fx != nil {
+			prices = e.fx.convert(prices, date)
+		}
+
+		// 计提上一交易日至今的融资利息、融券费用和管理费
+		if !lastDate.IsZero() {
+			days := int(date.Sub(lastDate).Hours() / 24)
+			e.accrueFinancing(days)
+			e.accrueHoldingCost(days)
+		}
+
+		for symbol, price := range prices {
+			lastPrices[symbol] = price
+		}
+		lastDate = date
+		e.updateStatus(e.status, listing, date, lastPrices)
+		tranche := listing.advance(date, prices)
+
+		// 场外基金到期的赎回款到账，执行到期的延迟订单和未成交的挂单
+		e.portfolioManager.Settle()
+		pending = e.executePending(pending, date, policy)
+		e.executeWorking(date, policy)
+		e.processLimitOrders(date)
+
 		// 获取当日基本面数据
 		fundamentals := e.dataLoader.GetFundamentalsOnDate(date)
 
@@ -90,44 +266,178 @@ func (e *BacktestEngine) Run() (*types.BacktestResult, error) {
 		e.portfolioManager.UpdatePrices(prices, date)
 		e.portfolioManager.UpdateFundamentals(fundamentals)
 
-		// 判断是否需要再平衡
+		// 追加投入 (投入当日按策略目标权重投资新增资金)
+		deposited := false
+		amount := e.contributions.due(date, e.portfolioManager.GetPortfolio().Cash)
+		if amount > 0 {
+			e.portfolioManager.Deposit(amount)
+			deposited = true
+		}
+
+		// 判断是否需要再平衡 (仍有未成交的延迟订单或限价单时不重复决策)
+		// 首次建仓由引擎统一在首个交易日按目标权重完成，除非配置为交由策略决定
+		// 止损开关触发后转为避险配置一次，此后不再按策略再平衡
+		// 回测期间上市的标的到达建仓批次时强制再平衡，策略按未上市部分已按目标持有的视图判断偏离
+		// 停牌和已退市的标的不参与决策和下单
 		pf := e.portfolioManager.GetPortfolio()
-		if e.strategy.ShouldRebalance(pf, prices) {
+		tradable := e.status.tradable(prices)
+		ctx := &strategy.Context{Portfolio: pf, Prices: tradable}
+		firstBuild := !built && e.config.InitialBuild != types.InitialBuildStrategy
+		capitulate := e.kill.due()
+		phaseIn := tranche && built && !e.kill.tripped()
+		rebalance := len(pending) == 0 && len(e.limitBook) == 0 &&
+			(capitulate || (!e.kill.tripped() && (firstBuild || deposited || phaseIn ||
+				e.strategy.ShouldRebalance(date, &strategy.Context{Portfolio: listing.view(pf), Prices: tradable}))))
+
+		// 行为偏差只作用于策略发起的再平衡，被跳过时视同已处理，策略等待下一次触发
+		discretionary := rebalance && !capitulate && !firstBuild && !phaseIn
+		if discretionary && behavior.skip() {
+			e.strategy.OnRebalance(date)
+			rebalance = false
+		}
+		var targetWeights map[string]float64
+		var orders []types.Order
+		if rebalance {
 			// 计算目标权重
-			targetWeights := e.strategy.TargetWeights(pf, prices)
-
-			// 生成交易订单
-			orders := e.strategy.GenerateOrders(pf, targetWeights, prices)
-
-			// 执行订单
-			for _, order := range orders {
-				_, err := e.portfolioManager.ExecuteOrder(order, date)
-				if err != nil {
-					// 记录错误但继续执行
-					fmt.Printf("Warning: failed to execute order %v: %v\n", order, err)
-				}
+			if capitulate {
+				targetWeights = e.kill.targetWeights(pf)
+			} else {
+				targetWeights = e.strategy.TargetWeights(date, ctx)
+				targetWeights = e.applyTrend(pf, targetWeights, date)
+				targetWeights = listing.adjust(targetWeights)
+				targetWeights = e.applyConstraints(targetWeights, date)
+			}
+
+			// 生成交易订单 (新订单按当前持仓计算，取代未成交的挂单)
+			orders = e.strategy.GenerateOrders(date, ctx, targetWeights)
+			orders = e.addDustOrders(pf, orders, prices)
+			orders = e.netOrders(orders)
+
+			// 策略发起的再平衡交易成本超过预期收益时跳过，视同已处理
+			if discretionary && !e.costGateAllows(pf, targetWeights, orders, date) {
+				e.strategy.OnRebalance(date)
+				rebalance = false
+			}
+		}
+		if rebalance {
+			built = true
+			trigger := e.rebalanceTrigger(firstBuild)
+			if capitulate {
+				trigger = types.TriggerKillSwitch
+			} else if deposited && !firstBuild {
+				trigger = types.TriggerCashFlow
+			} else if phaseIn {
+				trigger = types.TriggerInception
+			}
+			e.rebalanceCounts[trigger]++
+			if trigger == types.TriggerRegime {
+				e.logRegimeChange()
+			}
+
+			// 记录再平衡前的持仓信号和目标权重
+			e.recordSignals(pf, date)
+			e.recordTargetWeights(date, trigger, targetWeights)
+
+			e.portfolioManager.CancelWorkingOrders()
+			orders = e.applyOrderType(orders)
+			orders = tagTrigger(orders, trigger)
+			orderLag := lag
+			if discretionary {
+				orders = behavior.filterBuys(orders, date)
+				orderLag += behavior.delay()
+			}
+
+			// 执行订单 (有执行延迟时留待后续交易日成交)
+			if orderLag > 0 {
+				pending = append(pending, pendingOrders{orders: orders, daysLeft: orderLag})
+			} else {
+				e.executeOrders(orders, date, policy)
 			}
 
 			// 更新持仓价值
 			e.portfolioManager.UpdatePrices(prices, date)
 
 			// 回调策略
-			e.strategy.OnRebalance()
+			e.strategy.OnRebalance(date)
+			if !capitulate {
+				listing.record(date)
+			}
 		}
 
+		e.updateBenchmark(e.benchmark, date, amount, rebalance)
+
 		// 记录快照
 		snapshot := e.portfolioManager.TakeSnapshot()
-		e.snapshots = append(e.snapshots, snapshot)
+		if reporter, ok := e.strategy.(strategy.SignalReporter); ok {
+			snapshot.Signals = reporter.GetSignals(e.portfolioManager.GetPortfolio())
+		}
+		if stream != nil {
+			if err := stream.write(snapshot); err != nil {
+				return nil, err
+			}
+			snapshot.Signals = nil // 已写入流文件，后续只用于汇总
+			e.snapshots = append(e.snapshots, aggregateSnapshot(snapshot))
+		} else {
+			e.snapshots = append(e.snapshots, snapshot)
+		}
+		if e.fx != nil {
+			e.fx.record(snapshot)
+		}
+		e.pnl.record(snapshot, prices, e.portfolioManager.GetTrades())
+		behavior.record(snapshot)
 
 		// 打印进度
 		if (i+1)%100 == 0 || i == len(dates)-1 {
-			fmt.Printf("Progress: %d/%d days, Portfolio Value: %.2f\n",
+			e.logf("Progress: %d/%d days, Portfolio Value: %.2f\n",
 				i+1, len(dates), snapshot.TotalValue)
 		}
+
+		// 检查止损开关
+		if e.kill.check(snapshot) {
+			e.logf("Kill switch triggered on %s: %s\n", date.Format("2006-01-02"), e.kill.event.Reason)
+		}
+
+		// 检查提前终止条件
+		if reason, stop := e.stops.check(snapshot); stop {
+			e.logf("Backtest stopped on %s: %s\n", date.Format("2006-01-02"), reason)
+			e.stopReason = reason
+			break
+		}
+
+		// 检查运行限制
+		if reason, abort := limits.check(len(e.portfolioManager.GetTrades()), e.rebalances()); abort {
+			e.abortReason = fmt.Sprintf("%s on %s after %d/%d trading days", reason, date.Format("2006-01-02"), i+1, len(dates))
+			e.logf("Backtest aborted: %s\n", e.abortReason)
+			break
+		}
+	}
+
+	e.killEvent = e.kill.event
+	e.inceptions = listing.result()
+	e.statusEvents = e.status.result()
+	e.behaviorStats = behavior.result()
+
+	// 期末清仓
+	e.recordCheckpoint(lastDate, len(pending)+len(e.limitBook)+len(e.portfolioManager.WorkingOrders()))
+	e.markedValue = e.portfolioManager.GetPortfolio().TotalValue
+	e.finalPrices = lastPrices
+	if e.config.LiquidateAtEnd {
+		e.liquidate(lastPrices, lastDate)
+	}
+
+	if stream != nil {
+		if err := stream.close(); err != nil {
+			return nil, err
+		}
+		e.logf("Snapshots streamed to: %s\n", e.snapshotStream)
 	}
 
 	// 生成结果
+	e.appendPrevious()
 	e.result = e.generateResult()
+	if e.abortReason != "" {
+		return e.result, fmt.Errorf("%w: %s", ErrRunLimitExceeded, e.abortReason)
+	}
 	return e.result, nil
 }
 
@@ -136,6 +446,54 @@ func (e *BacktestEngine) validate() error {
 	if e.dataLoader == nil {
 		return fmt.Errorf("data loader not set")
 	}
+	switch e.config.CoveragePolicy {
+	case "", types.CoverageError, types.CoverageShrink, types.CoverageStage:
+	default:
+		return fmt.Errorf("unknown coverage policy: %s", e.config.CoveragePolicy)
+	}
+	switch e.config.InitialBuild {
+	case "", types.InitialBuildTarget, types.InitialBuildStrategy:
+	default:
+		return fmt.Errorf("unknown initial build policy: %s", e.config.InitialBuild)
+	}
+	switch e.config.OrderType {
+	case "", types.OrderMarket, types.OrderLimit:
+	default:
+		return fmt.Errorf("unknown order type: %s", e.config.OrderType)
+	}
+	switch e.config.ExecutionPolicy {
+	case "", types.ExecutionClose, types.ExecutionNextOpen, types.ExecutionVWAP:
+	case types.ExecutionOpen:
+		if e.config.ExecutionLag < 1 {
+			return fmt.Errorf("execution at open requires execution lag >= 1")
+		}
+	default:
+		return fmt.Errorf("unknown execution policy: %s", e.config.ExecutionPolicy)
+	}
+	if e.config.Margin.MaxGrossExposure < 0 {
+		return fmt.Errorf("max gross exposure must be non-negative")
+	}
+	if e.config.ExecutionLag < 0 {
+		return fmt.Errorf("execution lag must be non-negative")
+	}
+	if l := e.config.Limits; l.MaxWallTime < 0 || l.MaxTrades < 0 || l.MaxRebalances < 0 {
+		return fmt.Errorf("run limits must be non-negative")
+	}
+	if err := validateKillSwitch(e.config.KillSwitch, e.config.Symbols); err != nil {
+		return err
+	}
+	if err := validateBehavior(e.config.Behavior); err != nil {
+		return err
+	}
+	if err := validateContributions(e.config.Contributions, e.config.Benchmark, e.config.Symbols); err != nil {
+		return err
+	}
+	if e.config.RankWindowYears < 0 {
+		return fmt.Errorf("rank window must be non-negative")
+	}
+	if p := e.config.Inception; p.Steps < 0 || p.Interval < 0 {
+		return fmt.Errorf("inception phase-in steps and interval must be non-negative")
+	}
 	if e.strategy == nil {
 		return fmt.Errorf("strategy not set")
 	}
@@ -153,7 +511,7 @@ func (e *BacktestEngine) validate() error {
 
 // generateResult 生成回测结果
 func (e *BacktestEngine) generateResult() *types.BacktestResult {
-	trades := e.portfolioManager.GetTrades()
+	trades := append(append([]types.Trade(nil), e.priorTrades...), e.portfolioManager.GetTrades()...)
 	pf := e.portfolioManager.GetPortfolio()
 
 	// 计算总费用
@@ -162,17 +520,77 @@ func (e *BacktestEngine) generateResult() *types.BacktestResult {
 		totalFees += trade.Fee
 	}
 
-	// 计算收益率
-	totalReturn := (pf.TotalValue - e.config.InitialCapital) / e.config.InitialCapital
+	// 计算收益率 (盯市，本金含追加投入)
+	invested := e.config.InitialCapital + e.contributions.total
+	totalReturn := (e.markedValue - invested) / invested
 
 	result := &types.BacktestResult{
 		Config:      e.config,
 		Trades:      trades,
 		Snapshots:   e.snapshots,
-		FinalValue:  pf.TotalValue,
+		FinalValue:  e.markedValue,
 		TotalReturn: totalReturn,
 		TotalTrades: len(trades),
 		TotalFees:   totalFees,
+		Stopped:     e.stopReason != "",
+		StopReason:  e.stopReason,
+		Aborted:     e.abortReason != "",
+		AbortReason: e.abortReason,
+		Signals:     e.signals,
+	}
+
+	policy, lag := e.executionSettings()
+	result.ExecutionMode = fmt.Sprintf("T+%d %s", lag, policy)
+	result.Coverage = e.coverage
+	result.UnfilledOrders = append(e.portfolioManager.WorkingOrders(), e.limitBook...)
+	result.ExpiredOrders = e.expiredOrders
+	result.DailyPnL = e.pnl.records
+	result.FinancingCost = e.financingCost
+	result.HoldingCost = e.holdingCost
+	result.ConstraintBindings = e.bindings
+	result.TriggerStats = e.triggerStats(trades, e.finalPrices)
+	result.TradeAnalytics = tradeAnalytics(trades, e.snapshots, e.targetWeights)
+	result.SignalAccuracy = e.signalAccuracy()
+	result.ClosedLots = closedLots(trades)
+	result.SymbolPnL = symbolPnL(result.ClosedLots)
+	result.TargetWeights = e.targetWeights
+	result.Exposures, result.ClassExposures = e.exposures()
+	result.TrendAdjustments = e.trendAdjustments
+	result.KillSwitch = e.killEvent
+	result.Behavior = e.behaviorStats
+	result.CostGateSkips = e.costGateSkips
+	result.Shocks = e.shockEvents
+	result.Calendar = e.calendar
+	result.Misalignments = e.misalignments
+	result.Inceptions = e.inceptions
+	result.StatusEvents = e.statusEvents
+	result.Benchmark = e.benchmark.benchmarkResult(invested, totalReturn)
+	result.Seed = e.config.Seed
+	result.Metadata = e.metadata()
+	result.SnapshotStream = e.snapshotStream
+	result.Contributions = e.contributions.records
+	result.TotalContributed = e.contributions.total
+	result.RegimeChanges = e.regimeChanges()
+	if reporter, ok := e.strategy.(strategy.CashReserveReporter); ok {
+		result.CashViolations = reporter.CashViolations()
+	}
+	if e.previous != nil {
+		result.CashViolations = append(append([]types.CashViolation(nil), e.previous.CashViolations...), result.CashViolations...)
+		result.RegimeChanges = mergeRegimeChanges(e.previous.RegimeChanges, result.RegimeChanges)
+	}
+
+	if e.config.LiquidateAtEnd {
+		result.Liquidated = true
+		result.LiquidationValue = pf.TotalValue
+		result.LiquidationReturn = (pf.TotalValue - invested) / invested
+	}
+
+	if e.fx != nil {
+		result.BaseCurrency = e.config.BaseCurrency
+		result.CurrencyReturns = e.fx.results()
+		if e.previous != nil {
+			result.CurrencyReturns = mergeCurrencyReturns(e.previous.CurrencyReturns, result.CurrencyReturns)
+		}
 	}
 
 	if len(e.snapshots) > 0 {
@@ -188,6 +606,11 @@ func (e *BacktestEngine) GetResult() *types.BacktestResult {
 	return e.result
 }
 
+// RestoreResult 使用已有结果 (如缓存命中) 代替运行回测，之后可直接输出或导出
+func (e *BacktestEngine) RestoreResult(result *types.BacktestResult) {
+	e.result = result
+}
+
 // ExportResults 导出结果到JSON文件
 func (e *BacktestEngine) ExportResults(filepath string) error {
 	if e.result == nil {
@@ -196,15 +619,67 @@ func (e *BacktestEngine) ExportResults(filepath string) error {
 
 	// 创建输出结构
 	output := struct {
-		Summary   ResultSummary                `json:"summary"`
-		Trades    []types.Trade                `json:"trades"`
-		Snapshots []types.PortfolioSnapshot    `json:"snapshots"`
-		Config    types.BacktestConfig         `json:"config"`
+		Summary            ResultSummary                `json:"summary"`
+		Trades             []types.Trade                `json:"trades"`
+		Snapshots          []types.PortfolioSnapshot    `json:"snapshots"`
+		Signals            []types.SignalRecord         `json:"signals,omitempty"`
+		SignalAccuracy     []types.SignalAccuracy       `json:"signal_accuracy,omitempty"`
+		Coverage           []types.SymbolCoverage       `json:"coverage"`
+		CashViolations     []types.CashViolation        `json:"cash_violations,omitempty"`
+		DailyPnL           []types.DailyPnL             `json:"daily_pnl"`
+		ConstraintBindings []types.ConstraintBinding    `json:"constraint_bindings,omitempty"`
+		TriggerStats       []types.TriggerStat          `json:"trigger_stats"`
+		TradeAnalytics     *types.TradeAnalytics        `json:"trade_analytics,omitempty"`
+		SymbolPnL          []types.SymbolPnL            `json:"symbol_pnl"`
+		ClosedLots         []types.ClosedLot            `json:"closed_lots"`
+		TargetWeights      []types.TargetWeightRecord   `json:"target_weights"`
+		Exposures          []types.ExposureStat         `json:"exposures,omitempty"`
+		ClassExposures     []types.ClassExposure        `json:"class_exposures,omitempty"`
+		TrendAdjustments   []types.TrendAdjustment      `json:"trend_adjustments,omitempty"`
+		Contributions      []types.ContributionRecord   `json:"contributions,omitempty"`
+		RegimeChanges      []types.RegimeChange         `json:"regime_changes,omitempty"`
+		CostGateSkips      []types.CostGateSkip         `json:"cost_gate_skips,omitempty"`
+		Shocks             []types.ShockEvent           `json:"shocks,omitempty"`
+		Calendar           string                       `json:"calendar,omitempty"`
+		Misalignments      []types.CalendarMisalignment `json:"calendar_misalignments,omitempty"`
+		Inceptions         []types.InceptionEvent       `json:"inceptions,omitempty"`
+		StatusEvents       []types.StatusEvent          `json:"status_events,omitempty"`
+		Benchmark          *types.BenchmarkResult       `json:"benchmark,omitempty"`
+		Baselines          []types.BaselineResult       `json:"baselines,omitempty"`
+		Metadata           types.ResultMetadata         `json:"metadata"`
+		SnapshotStream     string                       `json:"snapshot_stream,omitempty"`
+		Config             types.BacktestConfig         `json:"config"`
 	}{
-		Summary:   e.getSummary(),
-		Trades:    e.result.Trades,
-		Snapshots: e.result.Snapshots,
-		Config:    e.result.Config,
+		Summary:            e.getSummary(),
+		Trades:             e.result.Trades,
+		Snapshots:          e.result.Snapshots,
+		Signals:            e.result.Signals,
+		SignalAccuracy:     e.result.SignalAccuracy,
+		Coverage:           e.result.Coverage,
+		CashViolations:     e.result.CashViolations,
+		DailyPnL:           e.result.DailyPnL,
+		ConstraintBindings: e.result.ConstraintBindings,
+		TriggerStats:       e.result.TriggerStats,
+		TradeAnalytics:     e.result.TradeAnalytics,
+		SymbolPnL:          e.result.SymbolPnL,
+		ClosedLots:         e.result.ClosedLots,
+		TargetWeights:      e.result.TargetWeights,
+		Exposures:          e.result.Exposures,
+		ClassExposures:     e.result.ClassExposures,
+		TrendAdjustments:   e.result.TrendAdjustments,
+		Contributions:      e.result.Contributions,
+		RegimeChanges:      e.result.RegimeChanges,
+		CostGateSkips:      e.result.CostGateSkips,
+		Shocks:             e.result.Shocks,
+		Calendar:           e.result.Calendar,
+		Misalignments:      e.result.Misalignments,
+		Inceptions:         e.result.Inceptions,
+		StatusEvents:       e.result.StatusEvents,
+		Benchmark:          e.result.Benchmark,
+		Baselines:          e.result.Baselines,
+		Metadata:           e.result.Metadata,
+		SnapshotStream:     e.result.SnapshotStream,
+		Config:             e.result.Config,
 	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
@@ -223,27 +698,52 @@ func (e *BacktestEngine) ExportResults(filepath string) error {
 
 // ResultSummary 结果摘要
 type ResultSummary struct {
-	StrategyName   string    `json:"strategy_name"`
-	StartDate      time.Time `json:"start_date"`
-	EndDate        time.Time `json:"end_date"`
-	InitialCapital float64   `json:"initial_capital"`
-	FinalValue     float64   `json:"final_value"`
-	TotalReturn    float64   `json:"total_return"`
-	TotalTrades    int       `json:"total_trades"`
-	TotalFees      float64   `json:"total_fees"`
+	StrategyName      string                 `json:"strategy_name"`
+	StartDate         time.Time              `json:"start_date"`
+	EndDate           time.Time              `json:"end_date"`
+	InitialCapital    float64                `json:"initial_capital"`
+	FinalValue        float64                `json:"final_value"`
+	TotalReturn       float64                `json:"total_return"`
+	TotalTrades       int                    `json:"total_trades"`
+	TotalFees         float64                `json:"total_fees"`
+	StopReason        string                 `json:"stop_reason,omitempty"`
+	KillSwitch        *types.KillSwitchEvent `json:"kill_switch,omitempty"`
+	Behavior          *types.BehaviorStats   `json:"behavior,omitempty"`
+	AbortReason       string                 `json:"abort_reason,omitempty"`
+	ExecutionMode     string                 `json:"execution_mode"`
+	LiquidationValue  float64                `json:"liquidation_value,omitempty"`
+	LiquidationReturn float64                `json:"liquidation_return,omitempty"`
+	BaseCurrency      string                 `json:"base_currency,omitempty"`
+	CurrencyReturns   []types.CurrencyReturn `json:"currency_returns,omitempty"`
+	Seed              int64                  `json:"seed"`
 }
 
 // getSummary 获取结果摘要
 func (e *BacktestEngine) getSummary() ResultSummary {
+	return summarize(e.strategy.Name(), e.result)
+}
+
+// summarize 根据回测结果生成摘要
+func summarize(strategyName string, result *types.BacktestResult) ResultSummary {
 	return ResultSummary{
-		StrategyName:   e.strategy.Name(),
-		StartDate:      e.result.StartDate,
-		EndDate:        e.result.EndDate,
-		InitialCapital: e.config.InitialCapital,
-		FinalValue:     e.result.FinalValue,
-		TotalReturn:    e.result.TotalReturn,
-		TotalTrades:    e.result.TotalTrades,
-		TotalFees:      e.result.TotalFees,
+		StrategyName:      strategyName,
+		StartDate:         result.StartDate,
+		EndDate:           result.EndDate,
+		InitialCapital:    result.Config.InitialCapital,
+		FinalValue:        result.FinalValue,
+		TotalReturn:       result.TotalReturn,
+		TotalTrades:       result.TotalTrades,
+		TotalFees:         result.TotalFees,
+		StopReason:        result.StopReason,
+		KillSwitch:        result.KillSwitch,
+		Behavior:          result.Behavior,
+		AbortReason:       result.AbortReason,
+		ExecutionMode:     result.ExecutionMode,
+		LiquidationValue:  result.LiquidationValue,
+		LiquidationReturn: result.LiquidationReturn,
+		BaseCurrency:      result.BaseCurrency,
+		CurrencyReturns:   result.CurrencyReturns,
+		Seed:              result.Seed,
 	}
 }
 
@@ -259,10 +759,222 @@ func (e *BacktestEngine) PrintSummary() {
 	fmt.Printf("Period: %s to %s\n",
 		e.result.StartDate.Format("2006-01-02"),
 		e.result.EndDate.Format("2006-01-02"))
+	fmt.Printf("Execution: %s\n", e.result.ExecutionMode)
 	fmt.Printf("Initial Capital: $%.2f\n", e.config.InitialCapital)
 	fmt.Printf("Final Value: $%.2f\n", e.result.FinalValue)
 	fmt.Printf("Total Return: %.2f%%\n", e.result.TotalReturn*100)
+	if b := e.result.Benchmark; b != nil {
+		rebalance := ""
+		if len(b.Weights) > 1 {
+			rebalance = fmt.Sprintf(", rebalance %s x%d", b.Rebalance, b.Rebalances)
+		}
+		fmt.Printf("Benchmark: %s%s: %.2f%% (excess %+.2f%%)\n", b.Name, rebalance, b.TotalReturn*100, b.ExcessReturn*100)
+	}
+	for _, b := range e.result.Baselines {
+		fmt.Printf("Baseline %s: %.2f%% (rebalancing bonus %+.2f%%, annualized %+.2f%%)\n",
+			b.Name, b.TotalReturn*100, b.Bonus*100, b.AnnualBonus*100)
+	}
 	fmt.Printf("Total Trades: %d\n", e.result.TotalTrades)
 	fmt.Printf("Total Fees: $%.2f\n", e.result.TotalFees)
+	partial, dust := 0, 0
+	for _, trade := range e.result.Trades {
+		if trade.Partial {
+			partial++
+		}
+		if trade.Tag == types.TagDust {
+			dust++
+		}
+	}
+	if n := len(e.result.ConstraintBindings); n > 0 {
+		fmt.Printf("Weight Constraints Bound: %d times\n", n)
+	}
+	if n := len(e.result.TrendAdjustments); n > 0 {
+		fmt.Printf("Trend Filter Adjustments: %d\n", n)
+	}
+	if e.result.TotalContributed > 0 {
+		fmt.Printf("Contributions: $%.2f over %d months\n", e.result.TotalContributed, len(e.result.Contributions))
+	}
+	if n := len(e.result.RegimeChanges); n > 0 {
+		fmt.Printf("Regime Changes: %d\n", n)
+	}
+	if e.result.HoldingCost > 0 {
+		fmt.Printf("Holding Cost (expense ratio): $%.2f\n", e.result.HoldingCost)
+	}
+	if e.result.FinancingCost > 0 {
+		fmt.Printf("Financing Cost: $%.2f\n", e.result.FinancingCost)
+	}
+	if n := len(e.result.ExpiredOrders); n > 0 {
+		fmt.Printf("Expired Limit Orders: %d\n", n)
+	}
+	if dust > 0 {
+		fmt.Printf("Dust Cleanups: %d\n", dust)
+	}
+	if partial > 0 || len(e.result.UnfilledOrders) > 0 {
+		fmt.Printf("Partial Fills: %d (unfilled at end: %d)\n", partial, len(e.result.UnfilledOrders))
+	}
+	if n := len(e.result.CostGateSkips); n > 0 {
+		fmt.Printf("Rebalances Skipped by Cost Gate: %d\n", n)
+	}
+	for _, shock := range e.result.Shocks {
+		fmt.Printf("Synthetic Shock: %s %+.1f%% on %v (%s ~ %s)\n", shock.Name, shock.Return*100, shock.Symbols,
+			shock.Start.Format("2006-01-02"), shock.End.Format("2006-01-02"))
+	}
+	for _, event := range e.result.Inceptions {
+		phased := "phase-in incomplete"
+		if !event.PhasedIn.IsZero() {
+			phased = "fully weighted " + event.PhasedIn.Format("2006-01-02")
+		}
+		fmt.Printf("Inception: %s listed %s, %s after %d step(s)\n", event.Symbol, event.Date.Format("2006-01-02"), phased, event.Steps)
+	}
+	for _, event := range e.result.StatusEvents {
+		switch event.Status {
+		case types.StatusDelisted:
+			fmt.Printf("Delisted: %s on %s, liquidated %.4f at %.4f\n", event.Symbol, event.Timestamp.Format("2006-01-02"), event.Quantity, event.Price)
+		case types.StatusResumed:
+			fmt.Printf("Resumed: %s on %s (%d orders blocked while halted)\n", event.Symbol, event.Timestamp.Format("2006-01-02"), event.Blocked)
+		default:
+			fmt.Printf("Halted: %s on %s\n", event.Symbol, event.Timestamp.Format("2006-01-02"))
+		}
+	}
+	if e.result.Calendar != "" {
+		dropped, filled := 0, 0
+		for _, m := range e.result.Misalignments {
+			dropped += len(m.Dropped)
+			filled += len(m.Filled)
+		}
+		fmt.Printf("Calendar: %s\n", calendarSummary(e.result.Calendar, len(e.result.Misalignments), dropped, filled))
+	}
+	if n := len(e.result.CashViolations); n > 0 {
+		fmt.Printf("Cash Constraint Violations: %d (buys scaled down)\n", n)
+	}
+	if e.result.Liquidated {
+		fmt.Printf("Liquidation Value: $%.2f (%.2f%%)\n", e.result.LiquidationValue, e.result.LiquidationReturn*100)
+	}
+	for _, cr := range e.result.CurrencyReturns {
+		fmt.Printf("FX %s/%s: %.4f -> %.4f (%.2f%%, contribution %.2f%%)\n",
+			cr.Currency, e.result.BaseCurrency, cr.StartRate, cr.EndRate,
+			cr.FXReturn*100, cr.Contribution*100)
+	}
+	if e.result.Stopped {
+		fmt.Printf("Stopped Early: %s\n", e.result.StopReason)
+	}
+	if e.result.Aborted {
+		fmt.Printf("Aborted: %s\n", e.result.AbortReason)
+	}
+	if k := e.result.KillSwitch; k != nil {
+		fmt.Printf("Kill Switch: triggered on %s at $%.2f (%s)\n", k.Timestamp.Format("2006-01-02"), k.Value, k.Reason)
+	}
+	if b := e.result.Behavior; b != nil {
+		fmt.Printf("Behavior: skipped %d rebalances, delayed %d, refused %d buys ($%.2f)\n",
+			b.SkippedRebalances, b.DelayedRebalances, b.RefusedBuys, b.RefusedBuyValue)
+	}
+	e.printTriggerStats()
+	e.printTradeAnalytics()
+	e.printSymbolPnL()
+	e.printExposures()
+	e.printSignalAccuracy()
+	e.printSignals()
 	fmt.Println("========================================")
 }
+
+// recordTargetWeights 记录再平衡日的目标权重 (复制一份，避免策略后续修改)
+func (e *BacktestEngine) recordTargetWeights(date time.Time, trigger types.RebalanceTrigger, weights map[string]float64) {
+	copied := make(map[string]float64, len(weights))
+	for symbol, w := range weights {
+		copied[symbol] = w
+	}
+	e.targetWeights = append(e.targetWeights, types.TargetWeightRecord{
+		Timestamp: date,
+		Trigger:   trigger,
+		Weights:   copied,
+	})
+}
+
+// recordSignals 记录当日持仓信号 (仅支持输出信号的策略)
+func (e *BacktestEngine) recordSignals(pf *types.Portfolio, date time.Time) {
+	reporter, ok := e.strategy.(strategy.SignalReporter)
+	if !ok {
+		return
+	}
+
+	signals := reporter.GetSignals(pf)
+	symbols := make([]string, 0, len(signals))
+	for symbol := range signals {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		e.signals = append(e.signals, types.SignalRecord{
+			Timestamp: date,
+			Symbol:    symbol,
+			Signal:    signals[symbol],
+		})
+	}
+}
+
+// printSignals 打印期末持仓信号 (仅支持输出信号的策略)
+func (e *BacktestEngine) printSignals() {
+	reporter, ok := e.strategy.(strategy.SignalReporter)
+	if !ok || e.portfolioManager == nil {
+		return
+	}
+
+	signals := reporter.GetSignals(e.portfolioManager.GetPortfolio())
+	symbols := make([]string, 0, len(signals))
+	for symbol := range signals {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	fmt.Println("Final Signals:")
+	for _, symbol := range symbols {
+		signal := signals[symbol]
+		fmt.Printf("  %-10s %s (%s, strength %d, %s)\n", symbol, signal.Type.Label(types.LocaleZH), signal.Direction, signal.Strength, signal.Reason)
+	}
+}
+
+// accrueHoldingCost 按管理费率计提持有成本
+func (e *BacktestEngine) accrueHoldingCost(days int) {
+	model, ok := e.costModel.(cost.HoldingCostModel)
+	if !ok {
+		return
+	}
+	positions := e.portfolioManager.GetPortfolio().Positions
+	cost := model.CalculateHoldingCost(positions, days)
+	if cost > 0 {
+		for symbol, pos := range positions {
+			if c := model.CalculateHoldingCost(map[string]types.Position{symbol: pos}, days); c != 0 {
+				e.pnl.accrue(symbol, -c)
+			}
+		}
+		e.portfolioManager.AccrueHoldingCost(cost)
+		e.holdingCost += cost
+	}
+}
+
+// accrueFinancing 按持有天数计提融资利息 (现金为负时) 和融券费用
+func (e *BacktestEngine) accrueFinancing(days int) {
+	model, ok := e.costModel.(cost.FinancingCostModel)
+	if !ok {
+		return
+	}
+	pf := e.portfolioManager.GetPortfolio()
+	cost := model.CalculateFinancing(pf.Cash, e.portfolioManager.ShortValue(), days)
+	if cost > 0 {
+		// 融资利息计入现金，融券费用计入各空头标的
+		if c := model.CalculateFinancing(pf.Cash, 0, days); c != 0 {
+			e.pnl.accrue(types.CashSymbol, -c)
+		}
+		for symbol, pos := range pf.Positions {
+			if pos.Value >= 0 {
+				continue
+			}
+			if c := model.CalculateFinancing(0, -pos.Value, days); c != 0 {
+				e.pnl.accrue(symbol, -c)
+			}
+		}
+		e.portfolioManager.AccrueFinancing(cost)
+		e.financingCost += cost
+	}
+}
@@ -0,0 +1,121 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// EquivalentRun 同类标的比较中的一次回测 (将组内持有标的替换为 Symbol)
+type EquivalentRun struct {
+	Group  string
+	Symbol string
+	Sleeve *Sleeve
+}
+
+// EquivalentResult 持有某一同类标的的回测结果
+type EquivalentResult struct {
+	Group       string
+	Symbol      string
+	FinalValue  float64
+	TotalReturn float64
+	TotalFees   float64               // 含价差成本
+	HoldingCost float64               // 管理费
+	Shortfall   float64               // 与组内最优标的的期末价值差距
+	Result      *types.BacktestResult `json:"-"`
+}
+
+// NewEquivalentRunsFromConfig 为配置中每组同类标的的每个标的创建一次回测
+func NewEquivalentRunsFromConfig(cfg *config.Config) ([]*EquivalentRun, error) {
+	runs := make([]*EquivalentRun, 0)
+	for i, group := range cfg.Equivalents {
+		name := group.Name
+		if name == "" {
+			name = fmt.Sprintf("group-%d", i+1)
+		}
+		for j, inst := range group.Instruments {
+			ec, err := cfg.EquivalentConfig(i, j)
+			if err != nil {
+				return nil, err
+			}
+			sleeve, err := NewSleeve(inst.Symbol, ec)
+			if err != nil {
+				return nil, fmt.Errorf("equivalent %s/%s: %w", name, inst.Symbol, err)
+			}
+			runs = append(runs, &EquivalentRun{Group: name, Symbol: inst.Symbol, Sleeve: sleeve})
+		}
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no equivalents configured")
+	}
+	return runs, nil
+}
+
+// CompareEquivalents 依次运行各替换方案，并计算每组内相对最优标的的差距
+func CompareEquivalents(runs []*EquivalentRun) ([]EquivalentResult, error) {
+	results := make([]EquivalentResult, 0, len(runs))
+	best := make(map[string]float64)
+	for _, run := range runs {
+		fmt.Printf("Running equivalent: %s -> %s\n", run.Group, run.Symbol)
+		result, err := run.Sleeve.Engine.Run()
+		if err != nil {
+			return nil, fmt.Errorf("equivalent %s/%s failed: %w", run.Group, run.Symbol, err)
+		}
+		results = append(results, EquivalentResult{
+			Group:       run.Group,
+			Symbol:      run.Symbol,
+			FinalValue:  result.FinalValue,
+			TotalReturn: result.TotalReturn,
+			TotalFees:   result.TotalFees,
+			HoldingCost: result.HoldingCost,
+			Result:      result,
+		})
+		if v, ok := best[run.Group]; !ok || result.FinalValue > v {
+			best[run.Group] = result.FinalValue
+		}
+	}
+
+	for i := range results {
+		results[i].Shortfall = best[results[i].Group] - results[i].FinalValue
+	}
+	return results, nil
+}
+
+// PrintEquivalentResults 打印同类标的比较结果
+func PrintEquivalentResults(results []EquivalentResult) {
+	fmt.Println("\n========== Equivalent Instruments ==========")
+	group := ""
+	for _, r := range results {
+		if r.Group != group {
+			group = r.Group
+			fmt.Printf("%s:\n", group)
+		}
+		marker := ""
+		if r.Shortfall == 0 {
+			marker = " (best)"
+		}
+		fmt.Printf("  %-10s Final: %.2f  Return: %.2f%%  Fees: %.2f  Holding: %.2f  Shortfall: %.2f%s\n",
+			r.Symbol, r.FinalValue, r.TotalReturn*100, r.TotalFees, r.HoldingCost, r.Shortfall, marker)
+	}
+	fmt.Println("============================================")
+}
+
+// ExportEquivalentResults 导出同类标的比较结果
+func ExportEquivalentResults(results []EquivalentResult, filepath string) error {
+	data, err := json.MarshalIndent(struct {
+		Equivalents []EquivalentResult `json:"equivalents"`
+	}{results}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Equivalent comparison exported to: %s\n", filepath)
+	return nil
+}
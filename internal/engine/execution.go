@@ -0,0 +1,229 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// pendingOrders 等待延迟成交的订单
+type pendingOrders struct {
+	orders   []types.Order
+	daysLeft int // 剩余等待的交易日数
+}
+
+// executionSettings 返回实际使用的成交价格和执行延迟 (next_open 展开为 open + 延迟1日)
+func (e *BacktestEngine) executionSettings() (types.ExecutionPolicy, int) {
+	policy := e.config.ExecutionPolicy
+	lag := e.config.ExecutionLag
+	switch policy {
+	case "":
+		policy = types.ExecutionClose
+	case types.ExecutionNextOpen:
+		policy = types.ExecutionOpen
+		if lag < 1 {
+			lag = 1
+		}
+	}
+	return policy, lag
+}
+
+// executePending 推进延迟订单，执行当日到期的订单，返回仍在等待的订单
+func (e *BacktestEngine) executePending(pending []pendingOrders, date time.Time, policy types.ExecutionPolicy) []pendingOrders {
+	remaining := pending[:0]
+	for _, p := range pending {
+		p.daysLeft--
+		if p.daysLeft <= 0 {
+			e.executeOrders(p.orders, date, policy)
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	return remaining
+}
+
+// adjustedBar 获取标的当日复权并换算为基础币种的开/高/低/收价格
+func (e *BacktestEngine) adjustedBar(symbol string, date time.Time) (types.PriceData, bool) {
+	pd, ok := e.dataLoader.GetPriceOnDate(symbol, date)
+	if !ok {
+		return types.PriceData{}, false
+	}
+
+	// 开盘价/最高/最低价按收盘复权比例调整
+	adj := 1.0
+	if pd.Close > 0 && pd.AdjClose > 0 {
+		adj = pd.AdjClose / pd.Close
+	}
+
+	bar := pd
+	bar.Open = pd.Open * adj
+	bar.High = pd.High * adj
+	bar.Low = pd.Low * adj
+	bar.Close = pd.AdjClose
+	if bar.Close <= 0 {
+		return types.PriceData{}, false
+	}
+
+	if e.fx != nil {
+		rate, ok := e.fx.convertPrice(symbol, 1, date)
+		if !ok {
+			return types.PriceData{}, false
+		}
+		bar.Open *= rate
+		bar.High *= rate
+		bar.Low *= rate
+		bar.Close *= rate
+		bar.AdjClose = bar.Close
+	}
+	return bar, true
+}
+
+// executionPrice 按成交价格策略获取标的在指定日期的成交价 (复权、换算为基础币种)
+// 场外基金总是按当日净值 (收盘价) 成交
+func (e *BacktestEngine) executionPrice(symbol string, date time.Time, policy types.ExecutionPolicy) (float64, bool) {
+	bar, ok := e.adjustedBar(symbol, date)
+	if !ok {
+		return 0, false
+	}
+	if _, fund := e.config.MutualFunds[symbol]; fund {
+		return bar.Close, true
+	}
+
+	price := bar.Close
+	switch policy {
+	case types.ExecutionOpen:
+		if bar.Open > 0 {
+			price = bar.Open
+		}
+	case types.ExecutionVWAP:
+		if bar.High > 0 && bar.Low > 0 {
+			price = (bar.High + bar.Low + bar.Close) / 3
+		}
+	}
+	return price, true
+}
+
+// executeOrders 按成交价格策略执行订单，无成交价的订单跳过
+// 卖单先于买单执行；开启ScaleBuys时买单总成本 (含滑点和费用) 超过现金则按比例缩放
+func (e *BacktestEngine) executeOrders(orders []types.Order, date time.Time, policy types.ExecutionPolicy) {
+	buys := make([]types.Order, 0)
+	for _, order := range orders {
+		// 限价单进入限价簿，按后续K线判断成交
+		if order.OrderType == types.OrderLimit {
+			e.limitBook = append(e.limitBook, order)
+			continue
+		}
+
+		price, ok := e.executionPrice(order.Symbol, date, policy)
+		if !ok {
+			e.logf("Warning: no execution price for %s on %s, order skipped\n",
+				order.Symbol, date.Format("2006-01-02"))
+			continue
+		}
+		order.Price = price
+
+		if order.Side == "BUY" {
+			buys = append(buys, order)
+			continue
+		}
+		e.executeOrder(order, date)
+	}
+
+	if e.config.ScaleBuys {
+		buys = e.scaleBuys(buys)
+	}
+	for _, order := range buys {
+		e.executeOrder(order, date)
+	}
+}
+
+// executeOrder 执行单个订单，超出当日成交量上限的部分转为挂单
+func (e *BacktestEngine) executeOrder(order types.Order, date time.Time) {
+	// 停牌和已退市的标的不成交，订单丢弃
+	if e.status.blocks(order.Symbol) {
+		return
+	}
+	if order.Quantity = e.roundLot(order); order.Quantity <= 0 {
+		return
+	}
+	var err error
+	if fill := e.fillableQuantity(order, date); fill < order.Quantity {
+		_, err = e.portfolioManager.ExecutePartial(order, fill, date)
+	} else {
+		_, err = e.portfolioManager.ExecuteOrder(order, date)
+	}
+	if err != nil {
+		// 记录错误但继续执行
+		e.logf("Warning: failed to execute order %v: %v\n", order, err)
+	}
+}
+
+// roundLot 订单数量按标的交易单位向下取整 (缩放和部分成交后的数量)，卖出全部持仓时按持有数量卖出
+func (e *BacktestEngine) roundLot(order types.Order) float64 {
+	lot := e.config.LotSizes[order.Symbol]
+	if lot <= 0 {
+		return order.Quantity
+	}
+	if order.Side == "SELL" {
+		if pos, ok := e.portfolioManager.GetPortfolio().Positions[order.Symbol]; ok && pos.Quantity > 0 && order.Quantity >= pos.Quantity*(1-1e-9) {
+			return pos.Quantity
+		}
+	}
+	return types.RoundLot(order.Quantity, lot)
+}
+
+// scaleBuys 买单总成本超过可用现金时，所有买单按同一比例缩放
+func (e *BacktestEngine) scaleBuys(buys []types.Order) []types.Order {
+	total := 0.0
+	for _, order := range buys {
+		total += e.portfolioManager.EstimateBuyCost(order)
+	}
+
+	cash := e.portfolioManager.GetPortfolio().Cash
+	if total <= cash || total <= 0 {
+		return buys
+	}
+
+	ratio := cash / total
+	scaled := make([]types.Order, 0, len(buys))
+	for _, order := range buys {
+		order.Quantity *= ratio
+		scaled = append(scaled, order)
+	}
+	return scaled
+}
+
+// fillableQuantity 按当日成交量上限计算订单可成交数量 (同一标的当日多笔订单共享额度)
+// 未配置上限、数据缺少成交量或场外基金时不限制
+func (e *BacktestEngine) fillableQuantity(order types.Order, date time.Time) float64 {
+	if _, fund := e.config.MutualFunds[order.Symbol]; fund || e.config.MaxVolumePct <= 0 {
+		return order.Quantity
+	}
+	pd, ok := e.dataLoader.GetPriceOnDate(order.Symbol, date)
+	if !ok || pd.Volume <= 0 {
+		return order.Quantity
+	}
+
+	if !e.volumeDate.Equal(date) {
+		e.volumeDate = date
+		e.volumeUsed = make(map[string]float64)
+	}
+
+	available := pd.Volume*e.config.MaxVolumePct - e.volumeUsed[order.Symbol]
+	if available < 0 {
+		available = 0
+	}
+	fill := order.Quantity
+	if fill > available {
+		fill = types.RoundLot(available, e.config.LotSizes[order.Symbol])
+	}
+	e.volumeUsed[order.Symbol] += fill
+	return fill
+}
+
+// executeWorking 提交前一交易日剩余的挂单
+func (e *BacktestEngine) executeWorking(date time.Time, policy types.ExecutionPolicy) {
+	if working := e.portfolioManager.TakeWorkingOrders(); len(working) > 0 {
+		e.executeOrders(working, date, policy)
+	}
+}
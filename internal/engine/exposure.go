@@ -0,0 +1,176 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// bandTolerance 判断超出偏离区间时忽略的浮点误差
+const bandTolerance = 1e-9
+
+// exposureAccumulator 单个标的或资产类别的权重累计
+type exposureAccumulator struct {
+	stat       types.ExposureStat
+	weightDays float64 // 权重 × 持续天数
+	targetDays float64 // 目标权重 × 持续天数
+	seen       bool
+}
+
+// add 累计一个交易日的权重，days 为该权重持续的自然日数
+func (a *exposureAccumulator) add(weight, days float64) {
+	if !a.seen {
+		a.stat.MinWeight, a.stat.MaxWeight = weight, weight
+		a.seen = true
+	}
+	a.stat.MinWeight = math.Min(a.stat.MinWeight, weight)
+	a.stat.MaxWeight = math.Max(a.stat.MaxWeight, weight)
+	a.weightDays += weight * days
+}
+
+// exposures 按每日快照统计各标的和资产类别的实际权重 (快照只含汇总数据时返回 nil)
+func (e *BacktestEngine) exposures() ([]types.ExposureStat, []types.ClassExposure) {
+	reporter, hasBand := e.strategy.(strategy.BandReporter)
+	symbols := make(map[string]*exposureAccumulator)
+	classes := make(map[string]*exposureAccumulator)
+	account := func(m map[string]*exposureAccumulator, key string) *exposureAccumulator {
+		a, ok := m[key]
+		if !ok {
+			a = &exposureAccumulator{stat: types.ExposureStat{Symbol: key}}
+			m[key] = a
+		}
+		return a
+	}
+
+	totalDays, targetedDays := 0.0, 0.0
+	next := 0 // 下一条未生效的目标权重记录
+	var target map[string]float64
+	for i, snapshot := range e.snapshots {
+		if snapshot.Weights == nil {
+			continue
+		}
+		for next < len(e.targetWeights) && !e.targetWeights[next].Timestamp.After(snapshot.Timestamp) {
+			target = e.targetWeights[next].Weights
+			next++
+		}
+		days := 1.0 // 最后一个交易日按1天计
+		if i+1 < len(e.snapshots) {
+			days = exposureDays(snapshot.Timestamp, e.snapshots[i+1].Timestamp)
+		}
+		totalDays += days
+		if target != nil {
+			targetedDays += days
+		}
+
+		classWeights := make(map[string]float64)
+		classTargets := make(map[string]float64)
+		for symbol := range unionKeys(snapshot.Weights, target) {
+			w := snapshot.Weights[symbol]
+			a := account(symbols, symbol)
+			a.add(w, days)
+			if class, ok := e.config.AssetClasses[symbol]; ok {
+				classWeights[class] += w
+				classTargets[class] += target[symbol]
+			}
+			if target == nil {
+				continue
+			}
+			t := target[symbol]
+			a.targetDays += t * days
+			a.stat.MaxOverweight = math.Max(a.stat.MaxOverweight, w-t)
+			a.stat.MaxUnderweight = math.Max(a.stat.MaxUnderweight, t-w)
+			if hasBand && symbol != types.CashSymbol {
+				if band := reporter.BandWidth(symbol, t); band > 0 && math.Abs(w-t) > band+bandTolerance {
+					a.stat.DaysOutsideBand++
+				}
+			}
+		}
+		for class, w := range classWeights {
+			a := account(classes, class)
+			a.add(w, days)
+			a.targetDays += classTargets[class] * days
+		}
+	}
+	if totalDays == 0 {
+		return nil, nil
+	}
+
+	stats := make([]types.ExposureStat, 0, len(symbols))
+	for _, a := range symbols {
+		stat := a.stat
+		stat.AvgWeight = a.weightDays / totalDays
+		if targetedDays > 0 {
+			stat.AvgTarget = a.targetDays / targetedDays
+		}
+		if hasBand && stat.Symbol != types.CashSymbol {
+			stat.Band = reporter.BandWidth(stat.Symbol, stat.AvgTarget)
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Symbol < stats[j].Symbol })
+
+	classStats := make([]types.ClassExposure, 0, len(classes))
+	for class, a := range classes {
+		stat := types.ClassExposure{
+			Class:     class,
+			AvgWeight: a.weightDays / totalDays,
+			MinWeight: a.stat.MinWeight,
+			MaxWeight: a.stat.MaxWeight,
+		}
+		if targetedDays > 0 {
+			stat.AvgTarget = a.targetDays / targetedDays
+		}
+		classStats = append(classStats, stat)
+	}
+	sort.Slice(classStats, func(i, j int) bool { return classStats[i].Class < classStats[j].Class })
+	if len(classStats) == 0 {
+		classStats = nil
+	}
+	return stats, classStats
+}
+
+// unionKeys 两个权重表的标的并集
+func unionKeys(a, b map[string]float64) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return keys
+}
+
+// printExposures 打印各标的和资产类别的实际权重统计
+func (e *BacktestEngine) printExposures() {
+	if len(e.result.Exposures) == 0 {
+		return
+	}
+	fmt.Println("Exposure by Symbol:")
+	for _, stat := range e.result.Exposures {
+		band := ""
+		if stat.Band > 0 {
+			band = fmt.Sprintf(", %d days outside ±%.2f%%", stat.DaysOutsideBand, stat.Band*100)
+		}
+		fmt.Printf("  %-10s avg %6.2f%% [%6.2f%% ~ %6.2f%%], target %6.2f%%, over %+.2f%% / under %+.2f%%%s\n",
+			stat.Symbol, stat.AvgWeight*100, stat.MinWeight*100, stat.MaxWeight*100, stat.AvgTarget*100,
+			stat.MaxOverweight*100, -stat.MaxUnderweight*100, band)
+	}
+	if len(e.result.ClassExposures) == 0 {
+		return
+	}
+	fmt.Println("Exposure by Class:")
+	for _, stat := range e.result.ClassExposures {
+		fmt.Printf("  %-10s avg %6.2f%% [%6.2f%% ~ %6.2f%%], target %6.2f%%\n",
+			stat.Class, stat.AvgWeight*100, stat.MinWeight*100, stat.MaxWeight*100, stat.AvgTarget*100)
+	}
+}
+
+// exposureDays 两个交易日之间的自然日数
+func exposureDays(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24
+}
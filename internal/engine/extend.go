@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// State 增量回测的状态文件：最后一个交易日收盘后的断点 (含止损开关和终止条件的跟踪状态) 和截至该日的完整结果
+type State struct {
+	Checkpoint *types.Checkpoint
+	Result     *types.BacktestResult
+}
+
+// Extend 在已有结果的基础上继续回测：从状态中的断点恢复持仓和策略状态，只运行断点之后的新交易日，
+// 结果中的交易、快照、信号和各项记录追加在已有结果之后，按完整区间重新汇总
+func (e *BacktestEngine) Extend(state *State) error {
+	if state.Checkpoint == nil || state.Result == nil {
+		return fmt.Errorf("state has no checkpoint or result")
+	}
+	if state.Result.Stopped || state.Result.Aborted {
+		return fmt.Errorf("previous run ended early (%s%s), cannot extend it", state.Result.StopReason, state.Result.AbortReason)
+	}
+	e.resume = state.Checkpoint
+	e.previous = state.Result
+	return nil
+}
+
+// appendPrevious 将已有结果的记录放在本次运行的记录之前 (生成结果前调用)
+func (e *BacktestEngine) appendPrevious() {
+	prev := e.previous
+	if prev == nil {
+		return
+	}
+	e.priorTrades = prev.Trades
+	e.snapshots = append(append([]types.PortfolioSnapshot(nil), prev.Snapshots...), e.snapshots...)
+	e.signals = append(append([]types.SignalRecord(nil), prev.Signals...), e.signals...)
+	e.pnl.records = append(append([]types.DailyPnL(nil), prev.DailyPnL...), e.pnl.records...)
+	e.bindings = append(append([]types.ConstraintBinding(nil), prev.ConstraintBindings...), e.bindings...)
+	e.targetWeights = append(append([]types.TargetWeightRecord(nil), prev.TargetWeights...), e.targetWeights...)
+	e.trendAdjustments = append(append([]types.TrendAdjustment(nil), prev.TrendAdjustments...), e.trendAdjustments...)
+	e.costGateSkips = append(append([]types.CostGateSkip(nil), prev.CostGateSkips...), e.costGateSkips...)
+	e.expiredOrders = append(append([]types.Order(nil), prev.ExpiredOrders...), e.expiredOrders...)
+	e.contributions.records = append(append([]types.ContributionRecord(nil), prev.Contributions...), e.contributions.records...)
+	e.benchmark.prependPrevious(prev.Benchmark)
+	e.financingCost += prev.FinancingCost
+	e.holdingCost += prev.HoldingCost
+	for _, stat := range prev.TriggerStats {
+		e.rebalanceCounts[stat.Trigger] += stat.Rebalances
+	}
+	e.inceptions = append(append([]types.InceptionEvent(nil), prev.Inceptions...), e.inceptions...)
+	e.statusEvents = append(append([]types.StatusEvent(nil), prev.StatusEvents...), e.statusEvents...)
+	e.shockEvents = mergeShocks(prev.Shocks, e.shockEvents)
+	e.misalignments = mergeMisalignments(prev.Misalignments, e.misalignments)
+	e.behaviorStats = mergeBehavior(prev.Behavior, e.behaviorStats)
+}
+
+// mergeShocks 合并冲击记录：本次按完整数据重新施加的冲击替换同名同起点的已有记录
+func mergeShocks(prev, current []types.ShockEvent) []types.ShockEvent {
+	merged := append([]types.ShockEvent(nil), prev...)
+	for _, event := range current {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == event.Name && merged[i].Start.Equal(event.Start) {
+				merged[i] = event
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, event)
+		}
+	}
+	return merged
+}
+
+// mergeMisalignments 按标的合并与交易日历不一致的数据行，日期去重并排序
+func mergeMisalignments(prev, current []types.CalendarMisalignment) []types.CalendarMisalignment {
+	merged := append([]types.CalendarMisalignment(nil), prev...)
+	for _, m := range current {
+		found := false
+		for i := range merged {
+			if merged[i].Symbol == m.Symbol {
+				merged[i].Dropped = mergeDates(merged[i].Dropped, m.Dropped)
+				merged[i].Filled = mergeDates(merged[i].Filled, m.Filled)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, m)
+		}
+	}
+	return merged
+}
+
+// mergeDates 合并两组日期，去重后按先后排序
+func mergeDates(a, b []time.Time) []time.Time {
+	seen := make(map[int64]bool, len(a)+len(b))
+	var dates []time.Time
+	for _, list := range [][]time.Time{a, b} {
+		for _, d := range list {
+			if !seen[d.Unix()] {
+				seen[d.Unix()] = true
+				dates = append(dates, d)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// mergeBehavior 累加行为偏差的统计
+func mergeBehavior(prev, current *types.BehaviorStats) *types.BehaviorStats {
+	if prev == nil {
+		return current
+	}
+	if current == nil {
+		return prev
+	}
+	return &types.BehaviorStats{
+		SkippedRebalances: prev.SkippedRebalances + current.SkippedRebalances,
+		DelayedRebalances: prev.DelayedRebalances + current.DelayedRebalances,
+		RefusedBuys:       prev.RefusedBuys + current.RefusedBuys,
+		RefusedBuyValue:   prev.RefusedBuyValue + current.RefusedBuyValue,
+	}
+}
+
+// mergeRegimeChanges 合并状态切换记录：策略状态已随断点恢复历史记录时，本次记录已包含断点之前的切换，
+// 只补上本次第一条记录之前的已有记录
+func mergeRegimeChanges(prev, current []types.RegimeChange) []types.RegimeChange {
+	var merged []types.RegimeChange
+	for _, c := range prev {
+		if len(current) == 0 || c.Timestamp.Before(current[0].Timestamp) {
+			merged = append(merged, c)
+		}
+	}
+	return append(merged, current...)
+}
+
+// mergeCurrencyReturns 合并各外币的汇率收益：起始汇率取已有结果，期末汇率取本次，汇率收益按完整区间计算，贡献累加
+func mergeCurrencyReturns(prev, current []types.CurrencyReturn) []types.CurrencyReturn {
+	merged := append([]types.CurrencyReturn(nil), prev...)
+	for _, r := range current {
+		found := false
+		for i := range merged {
+			if merged[i].Currency != r.Currency {
+				continue
+			}
+			merged[i].EndRate = r.EndRate
+			if merged[i].StartRate > 0 {
+				merged[i].FXReturn = r.EndRate/merged[i].StartRate - 1
+			}
+			merged[i].Contribution += r.Contribution
+			found = true
+			break
+		}
+		if !found {
+			merged = append(merged, r)
+		}
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Currency < merged[j].Currency })
+	return merged
+}
+
+// SaveState 保存增量回测的状态文件 (断点和完整结果)，未成交的订单不保存
+func (e *BacktestEngine) SaveState(filepath string) error {
+	cp, err := e.Checkpoint()
+	if err != nil {
+		return err
+	}
+	if e.unsettledOrders > 0 {
+		e.logf("Warning: %d unsettled orders are not saved in the state\n", e.unsettledOrders)
+	}
+
+	data, err := json.Marshal(State{Checkpoint: cp, Result: e.result})
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	e.logf("State saved to: %s\n", filepath)
+	return nil
+}
+
+// LoadState 读取 SaveState 保存的状态文件
+func LoadState(filepath string) (*State, error) {
+	data, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return &state, nil
+}
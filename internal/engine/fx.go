@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// fxTracker 汇率换算与汇率收益跟踪
+type fxTracker struct {
+	base         string
+	currencies   map[string]string // symbol -> currency
+	foreign      []string          // 去重后的外币列表
+	loader       *data.FXLoader
+	startRates   map[string]float64
+	lastRates    map[string]float64
+	prevWeights  map[string]float64 // currency -> 上一日持仓权重
+	contribution map[string]float64
+}
+
+// newFXTracker 创建汇率跟踪器，未配置外币标的时返回nil
+func newFXTracker(config types.BacktestConfig, loader *data.FXLoader) (*fxTracker, error) {
+	foreign := make(map[string]string)
+	for symbol, currency := range config.Currencies {
+		if currency != "" && currency != config.BaseCurrency {
+			foreign[symbol] = currency
+		}
+	}
+	if len(foreign) == 0 {
+		return nil, nil
+	}
+	if config.BaseCurrency == "" {
+		return nil, fmt.Errorf("base currency required for multi-currency portfolio")
+	}
+	if loader == nil {
+		return nil, fmt.Errorf("fx loader not set")
+	}
+
+	loaded := make(map[string]bool)
+	list := make([]string, 0)
+	for _, currency := range foreign {
+		if loaded[currency] {
+			continue
+		}
+		if err := loader.LoadRates(currency, config.BaseCurrency); err != nil {
+			return nil, err
+		}
+		loaded[currency] = true
+		list = append(list, currency)
+	}
+
+	return &fxTracker{
+		base:         config.BaseCurrency,
+		currencies:   foreign,
+		foreign:      list,
+		loader:       loader,
+		startRates:   make(map[string]float64),
+		lastRates:    make(map[string]float64),
+		prevWeights:  make(map[string]float64),
+		contribution: make(map[string]float64),
+	}, nil
+}
+
+// convert 将当日价格换算为基础币种，缺少汇率的标的当日不参与交易
+func (t *fxTracker) convert(prices map[string]float64, date time.Time) map[string]float64 {
+	converted := make(map[string]float64, len(prices))
+	for symbol, price := range prices {
+		if p, ok := t.convertPrice(symbol, price, date); ok {
+			converted[symbol] = p
+		}
+	}
+	return converted
+}
+
+// convertPrice 将单个标的价格换算为基础币种
+func (t *fxTracker) convertPrice(symbol string, price float64, date time.Time) (float64, bool) {
+	currency, ok := t.currencies[symbol]
+	if !ok {
+		return price, true
+	}
+	rate, ok := t.loader.GetRateOnDate(currency, date)
+	if !ok {
+		return 0, false
+	}
+	return price * rate, true
+}
+
+// record 按当日快照累计汇率收益贡献
+func (t *fxTracker) record(snapshot types.PortfolioSnapshot) {
+	weights := make(map[string]float64)
+	for symbol, w := range snapshot.Weights {
+		if currency, ok := t.currencies[symbol]; ok {
+			weights[currency] += w
+		}
+	}
+
+	for _, currency := range t.foreign {
+		rate, ok := t.loader.GetRateOnDate(currency, snapshot.Timestamp)
+		if !ok {
+			continue
+		}
+		if _, ok := t.startRates[currency]; !ok {
+			t.startRates[currency] = rate
+		}
+		if last, ok := t.lastRates[currency]; ok && last > 0 {
+			t.contribution[currency] += t.prevWeights[currency] * (rate/last - 1)
+		}
+		t.lastRates[currency] = rate
+		t.prevWeights[currency] = weights[currency]
+	}
+}
+
+// results 汇总各外币的汇率收益
+func (t *fxTracker) results() []types.CurrencyReturn {
+	returns := make([]types.CurrencyReturn, 0, len(t.startRates))
+	for currency, start := range t.startRates {
+		end := t.lastRates[currency]
+		returns = append(returns, types.CurrencyReturn{
+			Currency:     currency,
+			StartRate:    start,
+			EndRate:      end,
+			FXReturn:     end/start - 1,
+			Contribution: t.contribution[currency],
+		})
+	}
+	sort.Slice(returns, func(i, j int) bool {
+		return returns[i].Currency < returns[j].Currency
+	})
+	return returns
+}
@@ -0,0 +1,257 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+)
+
+// GridAxis 二维参数扫描的一个维度：配置项路径 (同命令行覆盖项，如 strategy.params.threshold) 和取值列表
+type GridAxis struct {
+	Key    string
+	Values []string
+}
+
+// ParseGridAxis 解析 key=v1,v2,v3 形式的扫描维度
+func ParseGridAxis(arg string) (GridAxis, error) {
+	o, err := config.ParseOverride(arg)
+	if err != nil {
+		return GridAxis{}, err
+	}
+	axis := GridAxis{Key: o.Key}
+	for _, v := range strings.Split(o.Value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			axis.Values = append(axis.Values, v)
+		}
+	}
+	if len(axis.Values) == 0 {
+		return GridAxis{}, fmt.Errorf("no values for %s", o.Key)
+	}
+	return axis, nil
+}
+
+// GridCell 参数网格中一组取值的回测结果
+type GridCell struct {
+	X            string
+	Y            string
+	FinalValue   float64
+	TotalReturn  float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+	Sharpe       float64 // 无风险利率为0
+	TotalTrades  int
+	Neighborhood float64 // 该格及相邻格 (含对角) 夏普的均值，用于识别稳健的参数区域
+}
+
+// GridResult 二维参数扫描结果，Cells[i][j] 为 Y 的第 i 个取值和 X 的第 j 个取值
+type GridResult struct {
+	X       GridAxis
+	Y       GridAxis
+	Cells   [][]GridCell
+	Best    GridCell // 夏普最高的一格
+	Plateau GridCell // 相邻格夏普均值最高的一格
+}
+
+// RunGrid 对 x、y 两个配置项的每组取值运行一次回测 (不输出运行日志)，
+// load 按追加的覆盖项重新加载配置
+func RunGrid(load func(...config.Override) (*config.Config, error), x, y GridAxis) (*GridResult, error) {
+	if x.Key == y.Key {
+		return nil, fmt.Errorf("grid axes must be different config keys, got %s twice", x.Key)
+	}
+
+	result := &GridResult{X: x, Y: y, Cells: make([][]GridCell, len(y.Values))}
+	for i, yv := range y.Values {
+		result.Cells[i] = make([]GridCell, len(x.Values))
+		for j, xv := range x.Values {
+			fmt.Printf("Running grid cell: %s=%s, %s=%s\n", x.Key, xv, y.Key, yv)
+			cfg, err := load(config.Override{Key: x.Key, Value: xv}, config.Override{Key: y.Key, Value: yv})
+			if err != nil {
+				return nil, err
+			}
+			sleeve, err := NewSleeve(xv+"/"+yv, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("grid cell %s=%s, %s=%s: %w", x.Key, xv, y.Key, yv, err)
+			}
+			sleeve.Engine.SetLogOutput(ioutil.Discard)
+			run, err := sleeve.Engine.Run()
+			if err != nil {
+				return nil, fmt.Errorf("grid cell %s=%s, %s=%s: %w", x.Key, xv, y.Key, yv, err)
+			}
+			metrics := store.Metrics(run)
+			result.Cells[i][j] = GridCell{
+				X:            xv,
+				Y:            yv,
+				FinalValue:   run.FinalValue,
+				TotalReturn:  run.TotalReturn,
+				AnnualReturn: metrics["annual_return"],
+				MaxDrawdown:  metrics["max_drawdown"],
+				Sharpe:       metrics["sharpe"],
+				TotalTrades:  run.TotalTrades,
+			}
+		}
+	}
+
+	first := true
+	for i := range result.Cells {
+		for j := range result.Cells[i] {
+			cell := &result.Cells[i][j]
+			cell.Neighborhood = result.neighborhood(i, j)
+			if first || cell.Sharpe > result.Best.Sharpe {
+				result.Best = *cell
+			}
+			if first || cell.Neighborhood > result.Plateau.Neighborhood {
+				result.Plateau = *cell
+			}
+			first = false
+		}
+	}
+	return result, nil
+}
+
+// neighborhood 第 i 行第 j 列及其相邻格的夏普均值
+func (r *GridResult) neighborhood(i, j int) float64 {
+	sum, n := 0.0, 0
+	for di := -1; di <= 1; di++ {
+		for dj := -1; dj <= 1; dj++ {
+			a, b := i+di, j+dj
+			if a < 0 || a >= len(r.Cells) || b < 0 || b >= len(r.Cells[a]) {
+				continue
+			}
+			sum += r.Cells[a][b].Sharpe
+			n++
+		}
+	}
+	return sum / float64(n)
+}
+
+// PrintGridResult 打印夏普矩阵和最优/最稳健的参数
+func PrintGridResult(result *GridResult) {
+	fmt.Println("\n========== Parameter Grid (Sharpe) ==========")
+	fmt.Printf("Rows: %s, columns: %s\n", result.Y.Key, result.X.Key)
+	fmt.Printf("%12s", "")
+	for _, xv := range result.X.Values {
+		fmt.Printf(" %9s", xv)
+	}
+	fmt.Println()
+	for i, yv := range result.Y.Values {
+		fmt.Printf("%12s", yv)
+		for _, cell := range result.Cells[i] {
+			fmt.Printf(" %9.2f", cell.Sharpe)
+		}
+		fmt.Println()
+	}
+	b, p := result.Best, result.Plateau
+	fmt.Printf("Best:    %s=%s, %s=%s (sharpe %.2f, final %.2f)\n",
+		result.X.Key, b.X, result.Y.Key, b.Y, b.Sharpe, b.FinalValue)
+	fmt.Printf("Plateau: %s=%s, %s=%s (sharpe %.2f, neighborhood %.2f)\n",
+		result.X.Key, p.X, result.Y.Key, p.Y, p.Sharpe, p.Neighborhood)
+	fmt.Println("=============================================")
+}
+
+// ExportGridResult 将扫描结果写入 dir：grid.json、夏普和期末价值的CSV矩阵 (grid_sharpe.csv、grid_final_value.csv)
+// 和热力图 grid.html
+func ExportGridResult(result *GridResult, dir string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "grid.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	sharpe := func(c GridCell) float64 { return c.Sharpe }
+	finalValue := func(c GridCell) float64 { return c.FinalValue }
+	if err := writeGridCSV(result, filepath.Join(dir, "grid_sharpe.csv"), sharpe); err != nil {
+		return err
+	}
+	if err := writeGridCSV(result, filepath.Join(dir, "grid_final_value.csv"), finalValue); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Parameter Grid</title>\n")
+	buf.WriteString("<style>body{font-family:sans-serif}table{border-collapse:collapse;margin-bottom:24px}" +
+		"th,td{border:1px solid #ccc;padding:6px 10px;text-align:right}.best{outline:3px solid #000}</style>\n</head>\n<body>\n")
+	fmt.Fprintf(&buf, "<h1>%s &times; %s</h1>\n", html.EscapeString(result.X.Key), html.EscapeString(result.Y.Key))
+	writeGridTable(&buf, result, "Sharpe", sharpe, "%.2f")
+	writeGridTable(&buf, result, "Final Value", finalValue, "%.2f")
+	buf.WriteString("<p>Outlined cell: highest Sharpe. Color scale: red (lowest) to green (highest) within each table.</p>\n</body>\n</html>\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, "grid.html"), buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Parameter grid exported to: %s\n", dir)
+	return nil
+}
+
+// writeGridCSV 写出一个指标的矩阵：首行为 X 的取值，首列为 Y 的取值
+func writeGridCSV(result *GridResult, path string, value func(GridCell) float64) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := append([]string{result.Y.Key + `\` + result.X.Key}, result.X.Values...)
+	w.Write(header)
+	for i, yv := range result.Y.Values {
+		row := []string{yv}
+		for _, cell := range result.Cells[i] {
+			row = append(row, strconv.FormatFloat(value(cell), 'f', 6, 64))
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+// writeGridTable 写出一个指标的热力图表格
+func writeGridTable(buf *bytes.Buffer, result *GridResult, title string, value func(GridCell) float64, format string) {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, row := range result.Cells {
+		for _, cell := range row {
+			lo = math.Min(lo, value(cell))
+			hi = math.Max(hi, value(cell))
+		}
+	}
+
+	fmt.Fprintf(buf, "<h2>%s</h2>\n<table>\n<tr><th>%s \\ %s</th>", title,
+		html.EscapeString(result.Y.Key), html.EscapeString(result.X.Key))
+	for _, xv := range result.X.Values {
+		fmt.Fprintf(buf, "<th>%s</th>", html.EscapeString(xv))
+	}
+	buf.WriteString("</tr>\n")
+	for i, yv := range result.Y.Values {
+		fmt.Fprintf(buf, "<tr><th>%s</th>", html.EscapeString(yv))
+		for _, cell := range result.Cells[i] {
+			class := ""
+			if cell.X == result.Best.X && cell.Y == result.Best.Y {
+				class = ` class="best"`
+			}
+			fmt.Fprintf(buf, "<td%s style=\"background:%s\">"+format+"</td>", class, heatColor(value(cell), lo, hi), value(cell))
+		}
+		buf.WriteString("</tr>\n")
+	}
+	buf.WriteString("</table>\n")
+}
+
+// heatColor 在 lo 至 hi 之间由红到绿的背景色
+func heatColor(v, lo, hi float64) string {
+	t := 0.5
+	if hi > lo {
+		t = (v - lo) / (hi - lo)
+	}
+	return fmt.Sprintf("hsl(%.0f, 70%%, 75%%)", t*120)
+}
@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// killSwitch 跟踪止损开关：触发后下一次决策转为避险配置，之后不再按策略再平衡
+type killSwitch struct {
+	config   types.KillSwitch
+	tracker  *stopTracker
+	event    *types.KillSwitchEvent
+	executed bool // 避险配置是否已下单
+}
+
+// newKillSwitch 创建止损开关
+func newKillSwitch(config types.KillSwitch) *killSwitch {
+	return &killSwitch{config: config, tracker: newStopTracker(config.Conditions)}
+}
+
+// state 返回止损开关状态 (保存断点用)
+func (k *killSwitch) state() types.KillSwitchState {
+	return types.KillSwitchState{Event: k.event, Executed: k.executed, Tracker: k.tracker.state()}
+}
+
+// restore 按断点恢复止损开关状态，断点之前已触发的开关继续生效
+func (k *killSwitch) restore(s types.KillSwitchState) {
+	k.event = s.Event
+	k.executed = s.Executed
+	k.tracker.restore(s.Tracker)
+}
+
+// check 根据当日快照检查是否触发，返回是否为本次新触发
+func (k *killSwitch) check(snapshot types.PortfolioSnapshot) bool {
+	if k.event != nil {
+		return false
+	}
+	reason, tripped := k.tracker.check(snapshot)
+	if !tripped {
+		return false
+	}
+	k.event = &types.KillSwitchEvent{
+		Timestamp: snapshot.Timestamp,
+		Reason:    reason,
+		Value:     snapshot.TotalValue,
+	}
+	return true
+}
+
+// tripped 是否已触发
+func (k *killSwitch) tripped() bool {
+	return k.event != nil
+}
+
+// due 已触发但避险配置尚未下单
+func (k *killSwitch) due() bool {
+	return k.event != nil && !k.executed
+}
+
+// targetWeights 避险目标权重，未列入避险配置的持仓全部卖出
+func (k *killSwitch) targetWeights(pf *types.Portfolio) map[string]float64 {
+	weights := make(map[string]float64, len(pf.Positions)+len(k.config.SafeWeights))
+	for symbol := range pf.Positions {
+		weights[symbol] = 0
+	}
+	for symbol, w := range k.config.SafeWeights {
+		weights[symbol] = w
+	}
+	k.executed = true
+	return weights
+}
+
+// validateKillSwitch 验证避险配置
+func validateKillSwitch(config types.KillSwitch, symbols []string) error {
+	known := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		known[symbol] = true
+	}
+	total := 0.0
+	for symbol, w := range config.SafeWeights {
+		if !known[symbol] {
+			return fmt.Errorf("kill switch safe asset %s is not in symbols", symbol)
+		}
+		if w < 0 {
+			return fmt.Errorf("kill switch weight for %s must be non-negative", symbol)
+		}
+		total += w
+	}
+	if total > 1+1e-9 {
+		return fmt.Errorf("kill switch weights sum to %.4f, must not exceed 1", total)
+	}
+	return nil
+}
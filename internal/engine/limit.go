@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// applyOrderType 按配置将策略生成的市价单转换为限价单
+// 买单限价 = 决策价 × (1 - LimitOffset)，卖单限价 = 决策价 × (1 + LimitOffset)
+func (e *BacktestEngine) applyOrderType(orders []types.Order) []types.Order {
+	if e.config.OrderType != types.OrderLimit {
+		return orders
+	}
+
+	ttl := e.config.LimitTTL
+	if ttl <= 0 {
+		ttl = 1
+	}
+	for i := range orders {
+		// 场外基金按当日净值申赎，不挂限价单
+		if _, fund := e.config.MutualFunds[orders[i].Symbol]; fund {
+			continue
+		}
+		orders[i].OrderType = types.OrderLimit
+		orders[i].TTL = ttl
+		if orders[i].Side == "BUY" {
+			orders[i].LimitPrice = orders[i].Price * (1 - e.config.LimitOffset)
+		} else {
+			orders[i].LimitPrice = orders[i].Price * (1 + e.config.LimitOffset)
+		}
+	}
+	return orders
+}
+
+// processLimitOrders 按当日K线撮合限价单，未成交的有效期减一，到期撤销
+// 买单在最低价触及限价时成交 (开盘即低于限价则按开盘价)，卖单同理
+func (e *BacktestEngine) processLimitOrders(date time.Time) {
+	if len(e.limitBook) == 0 {
+		return
+	}
+
+	// 卖单先于买单撮合，释放现金
+	book := make([]types.Order, 0, len(e.limitBook))
+	for _, order := range e.limitBook {
+		if order.Side != "BUY" {
+			book = append(book, order)
+		}
+	}
+	for _, order := range e.limitBook {
+		if order.Side == "BUY" {
+			book = append(book, order)
+		}
+	}
+	e.limitBook = nil
+
+	remaining := make([]types.Order, 0, len(book))
+	for _, order := range book {
+		bar, ok := e.adjustedBar(order.Symbol, date)
+		if ok {
+			price, filled := limitFillPrice(order, bar)
+			order.Price = price
+			// 现金不足的买单视为未成交，继续等待卖单释放现金
+			if filled && (order.Side != "BUY" || e.portfolioManager.AffordableQuantity(order) > 0) {
+				e.executeOrder(order, date)
+				continue
+			}
+		}
+
+		order.TTL--
+		if order.TTL <= 0 {
+			e.expiredOrders = append(e.expiredOrders, order)
+			continue
+		}
+		remaining = append(remaining, order)
+	}
+
+	// 部分成交的剩余部分会经挂单重新进入限价簿
+	e.limitBook = append(remaining, e.limitBook...)
+}
+
+// limitFillPrice 判断限价单在当日K线上能否成交并返回成交价
+func limitFillPrice(order types.Order, bar types.PriceData) (float64, bool) {
+	open := bar.Open
+	if open <= 0 {
+		open = bar.Close
+	}
+	low := bar.Low
+	if low <= 0 {
+		low = math.Min(open, bar.Close)
+	}
+	high := bar.High
+	if high <= 0 {
+		high = math.Max(open, bar.Close)
+	}
+
+	if order.Side == "BUY" {
+		if low > order.LimitPrice {
+			return 0, false
+		}
+		return math.Min(open, order.LimitPrice), true
+	}
+
+	if high < order.LimitPrice {
+		return 0, false
+	}
+	return math.Max(open, order.LimitPrice), true
+}
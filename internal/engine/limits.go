@@ -0,0 +1,49 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// ErrRunLimitExceeded 回测超出运行限制而中止，此时 Run 仍返回已完成部分的结果
+var ErrRunLimitExceeded = errors.New("run limit exceeded")
+
+// limitTracker 跟踪运行限制
+type limitTracker struct {
+	limits types.RunLimits
+	start  time.Time
+}
+
+// newLimitTracker 创建运行限制跟踪器，从创建时开始计时
+func newLimitTracker(limits types.RunLimits) *limitTracker {
+	return &limitTracker{limits: limits, start: time.Now()}
+}
+
+// check 检查是否超出运行限制，返回中止原因
+func (t *limitTracker) check(trades, rebalances int) (string, bool) {
+	l := t.limits
+	if l.MaxWallTime > 0 {
+		if elapsed := time.Since(t.start); elapsed > l.MaxWallTime {
+			return fmt.Sprintf("wall time %s exceeds limit %s", elapsed.Round(time.Millisecond), l.MaxWallTime), true
+		}
+	}
+	if l.MaxTrades > 0 && trades > l.MaxTrades {
+		return fmt.Sprintf("%d trades exceed limit %d", trades, l.MaxTrades), true
+	}
+	if l.MaxRebalances > 0 && rebalances > l.MaxRebalances {
+		return fmt.Sprintf("%d rebalances exceed limit %d", rebalances, l.MaxRebalances), true
+	}
+	return "", false
+}
+
+// rebalances 已执行的再平衡次数
+func (e *BacktestEngine) rebalances() int {
+	total := 0
+	for _, n := range e.rebalanceCounts {
+		total += n
+	}
+	return total
+}
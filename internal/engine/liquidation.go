@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// liquidate 期末按最后价格卖出全部持仓 (计入佣金、税费和滑点)
+func (e *BacktestEngine) liquidate(prices map[string]float64, date time.Time) {
+	pf := e.portfolioManager.GetPortfolio()
+
+	symbols := make([]string, 0, len(pf.Positions))
+	for symbol := range pf.Positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		price, ok := prices[symbol]
+		if !ok || price <= 0 {
+			e.logf("Warning: no price to liquidate %s\n", symbol)
+			continue
+		}
+		order := types.Order{
+			Symbol:   symbol,
+			Side:     "SELL",
+			Quantity: pf.Positions[symbol].Quantity,
+			Price:    price,
+		}
+		if _, err := e.portfolioManager.ExecuteOrder(order, date); err != nil {
+			e.logf("Warning: failed to liquidate %s: %v\n", symbol, err)
+		}
+	}
+
+	e.portfolioManager.UpdatePrices(prices, date)
+}
@@ -0,0 +1,237 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// listingSchedule 回测期间上市和退市的标的的目标权重调整：
+// coverage_policy 为 stage 时，上市前其目标权重按比例分配给其他资产 (含现金目标)，上市当日起按批次提高到完整目标权重，
+// 每批由引擎强制再平衡；退市的标的此后目标权重为0，同样分配给其他资产
+type listingSchedule struct {
+	steps    int
+	interval int
+	symbols  []string             // 回测期间上市或配置了退市日期的标的 (按标的排序)
+	dates    map[string]time.Time // 回测期间上市的标的首个有数据的交易日
+	delisted map[string]bool      // 已退市的标的
+	days     map[string]int       // 上市以来经过的交易日数 (上市当日为0)，未上市的标的没有记录
+	targets  map[string]float64   // 策略最近一次给出的目标权重 (调整前)
+	scale    float64              // 最近一次调整中其他资产目标权重的放大倍数
+	events   map[string]*types.InceptionEvent
+}
+
+// newListingSchedule 按数据覆盖情况创建分批建仓计划，stage 策略下首个交易日之后才有数据的标的视为回测期间上市
+// 没有回测期间上市的标的、也没有配置退市日期时返回 nil
+func newListingSchedule(config types.BacktestConfig, coverage []types.SymbolCoverage, first time.Time) *listingSchedule {
+	s := &listingSchedule{
+		steps:    config.Inception.Steps,
+		interval: config.Inception.Interval,
+		dates:    make(map[string]time.Time),
+		delisted: make(map[string]bool),
+		days:     make(map[string]int),
+		targets:  make(map[string]float64),
+		scale:    1,
+		events:   make(map[string]*types.InceptionEvent),
+	}
+	if s.steps <= 0 {
+		s.steps = 1
+	}
+	if s.interval <= 0 {
+		s.interval = 5
+	}
+	for _, c := range coverage {
+		_, delists := config.Delistings[c.Symbol]
+		if config.CoveragePolicy == types.CoverageStage && c.Rows > 0 && c.FirstDate.After(first) {
+			s.dates[c.Symbol] = c.FirstDate
+		} else if !delists {
+			continue
+		}
+		s.symbols = append(s.symbols, c.Symbol)
+	}
+	if len(s.symbols) == 0 {
+		return nil
+	}
+	sort.Strings(s.symbols)
+	return s
+}
+
+// advance 推进到新的交易日，返回是否有标的到达尚未执行的建仓批次 (需要再平衡)
+// 批次当日因有未成交订单等原因没有再平衡时，下一交易日继续视为到期
+func (s *listingSchedule) advance(date time.Time, prices map[string]float64) bool {
+	if s == nil {
+		return false
+	}
+	due := false
+	for _, symbol := range s.symbols {
+		if _, late := s.dates[symbol]; !late {
+			continue
+		}
+		n, listed := s.days[symbol]
+		if !listed {
+			if _, ok := prices[symbol]; !ok || date.Before(s.dates[symbol]) {
+				continue
+			}
+			n = -1
+			s.events[symbol] = &types.InceptionEvent{Symbol: symbol, Date: date}
+		}
+		s.days[symbol] = n + 1
+		if s.events[symbol].Steps < s.step(symbol) {
+			due = true
+		}
+	}
+	return due
+}
+
+// step 标的已到达的批次 (未上市为0)
+func (s *listingSchedule) step(symbol string) int {
+	n, listed := s.days[symbol]
+	if !listed {
+		return 0
+	}
+	if step := n/s.interval + 1; step < s.steps {
+		return step
+	}
+	return s.steps
+}
+
+// fraction 标的当前可配置的目标权重比例 (未上市和已退市为0，分批期间按已到达的批次)
+func (s *listingSchedule) fraction(symbol string) float64 {
+	if s == nil {
+		return 1
+	}
+	if s.delisted[symbol] {
+		return 0
+	}
+	if _, late := s.dates[symbol]; !late {
+		return 1
+	}
+	return float64(s.step(symbol)) / float64(s.steps)
+}
+
+// tracked 是否为需要调整目标权重的标的 (回测期间上市或配置了退市日期)
+func (s *listingSchedule) tracked(symbol string) bool {
+	i := sort.SearchStrings(s.symbols, symbol)
+	return i < len(s.symbols) && s.symbols[i] == symbol
+}
+
+// delist 标的退市，此后目标权重为0
+func (s *listingSchedule) delist(symbol string) {
+	if s != nil {
+		s.delisted[symbol] = true
+	}
+}
+
+// missing 未上市和分批期间的标的尚未配置的目标权重之和 (按策略最近一次的目标权重)
+func (s *listingSchedule) missing() float64 {
+	if s == nil {
+		return 0
+	}
+	m := 0.0
+	for _, symbol := range s.symbols {
+		if w := s.targets[symbol]; w > 0 {
+			m += w * (1 - s.fraction(symbol))
+		}
+	}
+	return m
+}
+
+// adjust 按建仓进度调整目标权重：回测期间上市的标的按比例降低、已退市的标的降为0，腾出的权重按比例分配给其他资产
+// 其他资产的目标权重都为0时腾出的权重留作现金
+func (s *listingSchedule) adjust(target map[string]float64) map[string]float64 {
+	if s == nil {
+		return target
+	}
+	for _, symbol := range s.symbols {
+		if w, ok := target[symbol]; ok {
+			s.targets[symbol] = w
+		}
+	}
+
+	s.scale = 1
+	m := s.missing()
+	if m <= 0 {
+		return target
+	}
+	others := 0.0
+	for symbol, w := range target {
+		if !s.tracked(symbol) && w > 0 {
+			others += w
+		}
+	}
+	if others > 0 {
+		s.scale = (others + m) / others
+	}
+
+	adjusted := make(map[string]float64, len(target))
+	for symbol, w := range target {
+		if s.tracked(symbol) {
+			if w > 0 {
+				w *= s.fraction(symbol)
+			}
+		} else if w > 0 {
+			w *= s.scale
+		}
+		adjusted[symbol] = w
+	}
+	return adjusted
+}
+
+// view 供策略判断是否再平衡的组合视图：其他资产按放大前的目标权重衡量，未配置的目标权重视为已按目标持有，
+// 避免尚未上市或已退市的标的持续偏离目标而每天触发再平衡
+func (s *listingSchedule) view(pf *types.Portfolio) *types.Portfolio {
+	if s == nil || s.scale <= 1 || pf.TotalValue <= 0 {
+		return pf
+	}
+	view := *pf
+	view.TotalValue = pf.TotalValue * s.scale
+	view.Positions = make(map[string]types.Position, len(pf.Positions)+len(s.symbols))
+	for symbol, pos := range pf.Positions {
+		view.Positions[symbol] = pos
+	}
+	for _, symbol := range s.symbols {
+		w := s.targets[symbol]
+		f := s.fraction(symbol)
+		if w <= 0 || f >= 1 {
+			continue
+		}
+		pos := view.Positions[symbol]
+		pos.Symbol = symbol
+		pos.Value += w*view.TotalValue - w*f*pf.TotalValue
+		view.Positions[symbol] = pos
+	}
+	return &view
+}
+
+// record 再平衡后记录各标的已执行的批次
+func (s *listingSchedule) record(date time.Time) {
+	if s == nil {
+		return
+	}
+	for _, symbol := range s.symbols {
+		event, ok := s.events[symbol]
+		if !ok || s.delisted[symbol] || event.Steps == s.step(symbol) {
+			continue
+		}
+		event.Steps = s.step(symbol)
+		if event.Steps == s.steps {
+			event.PhasedIn = date
+		}
+	}
+}
+
+// result 建仓记录 (按上市日期排序)
+func (s *listingSchedule) result() []types.InceptionEvent {
+	if s == nil {
+		return nil
+	}
+	events := make([]types.InceptionEvent, 0, len(s.events))
+	for _, symbol := range s.symbols {
+		if event, ok := s.events[symbol]; ok {
+			events = append(events, *event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+	return events
+}
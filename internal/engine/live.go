@@ -0,0 +1,217 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/portfolio"
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// LiveSignal 按实盘当前持仓生成当日的信号和建议订单，不运行历史回测
+// 数据目录中截至当日的历史用于估值百分位和技术指标，持仓文件中提供的价格和估值数据优先。
+// 设置了断点 (Resume) 时先恢复策略内部状态 (如上次再平衡日期，只恢复一次)；建议再平衡时会回调策略的 OnRebalance，
+// 同一引擎可逐日复用
+func (e *BacktestEngine) LiveSignal(holdings *types.Holdings) (*types.LiveSignal, error) {
+	if err := e.validate(); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	end := holdings.Date
+	if end.IsZero() {
+		end = time.Now()
+	}
+	e.dataLoader.SetRankWindow(e.config.RankWindowYears)
+	if _, err := e.dataLoader.LoadPrices(e.config.Symbols, e.config.StartDate, end); err != nil {
+		return nil, fmt.Errorf("failed to load prices: %w", err)
+	}
+	if consumer, ok := e.strategy.(strategy.BenchmarkConsumer); ok {
+		if symbols := consumer.ReferenceSymbols(); len(symbols) > 0 {
+			if err := e.dataLoader.LoadReferences(symbols, end); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if consumer, ok := e.strategy.(strategy.IndicatorConsumer); ok {
+		consumer.SetIndicatorSource(e.dataLoader)
+	}
+
+	// 数据中截至当日的最后一个交易日
+	dates := e.dataLoader.GetAllDates()
+	idx := sort.Search(len(dates), func(i int) bool {
+		return dates[i].After(end)
+	}) - 1
+	if idx < 0 {
+		return nil, fmt.Errorf("no trading dates found before %s", end.Format("2006-01-02"))
+	}
+	dataDate := dates[idx]
+	date := holdings.Date
+	if date.IsZero() {
+		date = dataDate
+	}
+
+	if e.resume != nil {
+		if err := e.loadStrategyState(e.resume); err != nil {
+			return nil, err
+		}
+		e.resume = nil
+	}
+
+	var err error
+	e.fx, err = newFXTracker(e.config, e.fxLoader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init fx: %w", err)
+	}
+	prices := make(map[string]float64)
+	for symbol, price := range e.dataLoader.GetPricesOnDate(dataDate) {
+		prices[symbol] = price
+	}
+	if e.fx != nil {
+		prices = e.fx.convert(prices, dataDate)
+	}
+	for symbol, price := range holdings.Prices {
+		prices[symbol] = price
+	}
+
+	pf, err := e.livePortfolio(holdings, prices, date)
+	if err != nil {
+		return nil, err
+	}
+
+	fundamentals := make(map[string]*types.FundamentalData)
+	for symbol, fund := range e.dataLoader.GetFundamentalsOnDate(dataDate) {
+		fundamentals[symbol] = fund
+	}
+	for symbol, fund := range holdings.Fundamentals {
+		fund := fund
+		if base, ok := fundamentals[symbol]; ok {
+			fund.AssetType, fund.Name = base.AssetType, base.Name
+			fund.IsCoreETF, fund.IsTechETF, fund.IsDividendETF = base.IsCoreETF, base.IsTechETF, base.IsDividendETF
+		}
+		fundamentals[symbol] = &fund
+	}
+	e.portfolioManager.UpdateFundamentals(fundamentals)
+
+	// 空仓账户与回测一致，首次建仓按目标权重完成 (除非配置为交由策略决定)
+	ctx := &strategy.Context{Portfolio: pf, Prices: prices}
+	firstBuild := len(pf.Positions) == 0 && e.config.InitialBuild != types.InitialBuildStrategy
+	rebalance := firstBuild || e.strategy.ShouldRebalance(date, ctx)
+
+	signal := &types.LiveSignal{
+		Date:       date,
+		Strategy:   e.strategy.Name(),
+		TotalValue: pf.TotalValue,
+		Weights:    pf.GetWeights(),
+		Rebalance:  rebalance,
+	}
+	if reporter, ok := e.strategy.(strategy.SignalReporter); ok {
+		signal.Signals = reporter.GetSignals(pf)
+	}
+
+	targetWeights := e.strategy.TargetWeights(date, ctx)
+	targetWeights = e.applyTrend(pf, targetWeights, dataDate)
+	signal.TargetWeights = e.applyConstraints(targetWeights, date)
+	if !rebalance {
+		return signal, nil
+	}
+
+	signal.Trigger = e.rebalanceTrigger(firstBuild)
+	orders := e.strategy.GenerateOrders(date, ctx, signal.TargetWeights)
+	orders = e.addDustOrders(pf, orders, prices)
+	orders = e.netOrders(orders)
+	orders = e.applyOrderType(orders)
+	signal.Orders = tagTrigger(orders, signal.Trigger)
+	e.strategy.OnRebalance(date)
+	return signal, nil
+}
+
+// livePortfolio 按实盘持仓和当日价格建立组合
+func (e *BacktestEngine) livePortfolio(holdings *types.Holdings, prices map[string]float64, date time.Time) (*types.Portfolio, error) {
+	positions := make(map[string]types.Position, len(holdings.Positions))
+	for symbol, quantity := range holdings.Positions {
+		if quantity == 0 {
+			continue
+		}
+		price, ok := prices[symbol]
+		if !ok || price <= 0 {
+			return nil, fmt.Errorf("no price for held symbol %s", symbol)
+		}
+		avgCost := holdings.AvgCost[symbol]
+		if avgCost <= 0 {
+			avgCost = price
+		}
+		positions[symbol] = types.Position{Symbol: symbol, Quantity: quantity, AvgCost: avgCost}
+	}
+
+	e.portfolioManager = portfolio.NewManager(holdings.Cash, e.costModel)
+	e.portfolioManager.SetHaircuts(e.config.Haircuts)
+	e.portfolioManager.SetLotSizes(e.config.LotSizes)
+	e.portfolioManager.SetMutualFunds(e.config.MutualFunds)
+	e.portfolioManager.SetMargin(e.config.Margin)
+	e.portfolioManager.Restore(types.Portfolio{Cash: holdings.Cash, Positions: positions})
+	e.portfolioManager.UpdatePrices(prices, date)
+	return e.portfolioManager.GetPortfolio(), nil
+}
+
+// PrintLiveSignal 打印当日信号和建议订单
+func PrintLiveSignal(s *types.LiveSignal) {
+	fmt.Printf("\n========== Signals %s ==========\n", s.Date.Format("2006-01-02"))
+	fmt.Print(FormatLiveSignal(s))
+	fmt.Println("=========================================")
+}
+
+// FormatLiveSignal 当日信号表 (当前/目标权重、信号) 和建议订单的文本，用于打印和通知
+func FormatLiveSignal(s *types.LiveSignal) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Strategy: %s\n", s.Strategy)
+	fmt.Fprintf(&b, "Portfolio Value: $%.2f\n", s.TotalValue)
+
+	symbols := make([]string, 0, len(s.TargetWeights))
+	for symbol := range s.TargetWeights {
+		symbols = append(symbols, symbol)
+	}
+	for symbol := range s.Weights {
+		if _, ok := s.TargetWeights[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		fmt.Fprintf(&b, "  %-8s current %6.2f%%  target %6.2f%%", symbol, s.Weights[symbol]*100, s.TargetWeights[symbol]*100)
+		if signal, ok := s.Signals[symbol]; ok {
+			fmt.Fprintf(&b, "  %s", signal.Type)
+		}
+		b.WriteString("\n")
+	}
+
+	if !s.Rebalance {
+		b.WriteString("No rebalance needed today\n")
+	} else {
+		fmt.Fprintf(&b, "Rebalance (%s), %d orders:\n", s.Trigger, len(s.Orders))
+		for _, order := range s.Orders {
+			fmt.Fprintf(&b, "  %-4s %-8s %12.4f @ %.4f ($%.2f)\n",
+				order.Side, order.Symbol, order.Quantity, order.Price, order.Quantity*order.Price)
+		}
+	}
+	return b.String()
+}
+
+// ExportLiveSignal 导出当日信号和建议订单
+func ExportLiveSignal(s *types.LiveSignal, filepath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal signals: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Signals exported to: %s\n", filepath)
+	return nil
+}
@@ -0,0 +1,124 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// openLot 尚未平仓的持仓批次 (Quantity 为负表示空头)
+type openLot struct {
+	date     time.Time
+	quantity float64
+	price    float64
+	fee      float64 // 未分摊的开仓费用
+}
+
+// closedLots 按先进先出将成交配对为已平仓批次：卖出依次平掉最早的多头批次，买入依次平掉最早的空头批次，
+// 超出部分开立新批次。开仓和平仓费用按数量比例分摊到批次的已实现盈亏中
+func closedLots(trades []types.Trade) []types.ClosedLot {
+	open := make(map[string][]openLot)
+	lots := make([]types.ClosedLot, 0)
+	for _, trade := range trades {
+		if trade.Quantity <= 0 {
+			continue
+		}
+		sign := 1.0
+		if trade.Side != "BUY" {
+			sign = -1
+		}
+		remaining := trade.Quantity
+		queue := open[trade.Symbol]
+		for remaining > 1e-9 && len(queue) > 0 && queue[0].quantity*sign < 0 {
+			lot := &queue[0]
+			matched := math.Min(remaining, math.Abs(lot.quantity))
+			openFee := lot.fee * matched / math.Abs(lot.quantity)
+			closeFee := trade.Fee * matched / trade.Quantity
+			gross := (trade.Price - lot.price) * matched
+			if lot.quantity < 0 {
+				gross = -gross
+			}
+			lots = append(lots, types.ClosedLot{
+				Symbol:      trade.Symbol,
+				OpenDate:    lot.date,
+				CloseDate:   trade.Timestamp,
+				Quantity:    matched,
+				Short:       lot.quantity < 0,
+				OpenPrice:   lot.price,
+				ClosePrice:  trade.Price,
+				RealizedPnL: gross - openFee - closeFee,
+				HoldingDays: int(trade.Timestamp.Sub(lot.date).Hours() / 24),
+			})
+
+			lot.fee -= openFee
+			if lot.quantity > 0 {
+				lot.quantity -= matched
+			} else {
+				lot.quantity += matched
+			}
+			remaining -= matched
+			if math.Abs(lot.quantity) <= 1e-9 {
+				queue = queue[1:]
+			}
+		}
+		if remaining > 1e-9 {
+			queue = append(queue, openLot{
+				date:     trade.Timestamp,
+				quantity: sign * remaining,
+				price:    trade.Price,
+				fee:      trade.Fee * remaining / trade.Quantity,
+			})
+		}
+		open[trade.Symbol] = queue
+	}
+	return lots
+}
+
+// symbolPnL 按标的汇总已平仓批次的已实现盈亏、胜率和平均持有天数 (按数量加权)
+func symbolPnL(lots []types.ClosedLot) []types.SymbolPnL {
+	stats := make(map[string]*types.SymbolPnL)
+	weightedDays := make(map[string]float64)
+	quantities := make(map[string]float64)
+	for _, lot := range lots {
+		stat, ok := stats[lot.Symbol]
+		if !ok {
+			stat = &types.SymbolPnL{Symbol: lot.Symbol}
+			stats[lot.Symbol] = stat
+		}
+		stat.RealizedPnL += lot.RealizedPnL
+		stat.ClosedLots++
+		if lot.RealizedPnL > 0 {
+			stat.WinningLots++
+		}
+		weightedDays[lot.Symbol] += float64(lot.HoldingDays) * lot.Quantity
+		quantities[lot.Symbol] += lot.Quantity
+	}
+
+	result := make([]types.SymbolPnL, 0, len(stats))
+	for symbol, stat := range stats {
+		stat.WinRate = float64(stat.WinningLots) / float64(stat.ClosedLots)
+		if quantities[symbol] > 0 {
+			stat.AvgHoldingDays = weightedDays[symbol] / quantities[symbol]
+		}
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Symbol < result[j].Symbol
+	})
+	return result
+}
+
+// printSymbolPnL 打印各标的已实现盈亏
+func (e *BacktestEngine) printSymbolPnL() {
+	if len(e.result.SymbolPnL) == 0 {
+		return
+	}
+	fmt.Println("Realized P&L by Symbol:")
+	for _, stat := range e.result.SymbolPnL {
+		fmt.Printf("  %-10s realized $%12.2f, closed lots %4d, win rate %5.1f%%, avg holding %6.1f days\n",
+			stat.Symbol, stat.RealizedPnL, stat.ClosedLots, stat.WinRate*100, stat.AvgHoldingDays)
+	}
+}
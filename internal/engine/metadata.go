@@ -0,0 +1,22 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/version"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// metadata 生成结果元数据：引擎构建信息、配置哈希和已加载数据的指纹
+func (e *BacktestEngine) metadata() types.ResultMetadata {
+	info := version.Get()
+	return types.ResultMetadata{
+		EngineVersion: info.Version,
+		GoVersion:     info.GoVersion,
+		Commit:        info.Commit,
+		Modified:      info.Modified,
+		ConfigSHA256:  e.configHash,
+		GeneratedAt:   time.Now(),
+		Data:          e.dataLoader.Fingerprints(),
+	}
+}
@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"math"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// netOrders 合并同一标的的订单：买卖数量相抵后保留一笔净订单 (沿用该方向第一笔订单的价格和标记)，
+// 净额为0或市值低于 MinOrderValue 的剩余订单丢弃 (碎仓清理订单除外)。结果中卖单在前，买单在后
+func (e *BacktestEngine) netOrders(orders []types.Order) []types.Order {
+	if len(orders) == 0 {
+		return orders
+	}
+
+	net := make(map[string]float64)
+	first := make(map[string]map[string]types.Order)
+	symbols := make([]string, 0, len(orders))
+	for _, order := range orders {
+		if _, seen := first[order.Symbol]; !seen {
+			first[order.Symbol] = make(map[string]types.Order)
+			symbols = append(symbols, order.Symbol)
+		}
+		if _, ok := first[order.Symbol][order.Side]; !ok {
+			first[order.Symbol][order.Side] = order
+		}
+		if order.Side == "BUY" {
+			net[order.Symbol] += order.Quantity
+		} else {
+			net[order.Symbol] -= order.Quantity
+		}
+	}
+
+	sells := make([]types.Order, 0, len(symbols))
+	buys := make([]types.Order, 0, len(symbols))
+	for _, symbol := range symbols {
+		quantity := net[symbol]
+		side := "BUY"
+		if quantity < 0 {
+			side = "SELL"
+		}
+		order, ok := first[symbol][side]
+		if !ok || math.Abs(quantity) < 1e-9 {
+			continue
+		}
+		order.Quantity = math.Abs(quantity)
+		if order.Tag != types.TagDust && order.Quantity*order.Price < e.config.MinOrderValue {
+			continue
+		}
+		if side == "SELL" {
+			sells = append(sells, order)
+		} else {
+			buys = append(buys, order)
+		}
+	}
+	return append(sells, buys...)
+}
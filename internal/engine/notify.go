@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/notify"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// NewNotifier 按配置创建通知渠道，未配置任何渠道时为空
+func NewNotifier(section config.NotifySection) notify.Multi {
+	notifiers := notify.Multi{}
+	if section.Webhook != "" {
+		notifiers = append(notifiers, &notify.Webhook{URL: section.Webhook})
+	}
+	if section.ServerChanKey != "" {
+		notifiers = append(notifiers, &notify.ServerChan{Key: section.ServerChanKey})
+	}
+	if t := section.Telegram; t != nil {
+		notifiers = append(notifiers, &notify.Telegram{Token: t.Token, ChatID: t.ChatID})
+	}
+	if section.WeCom != "" {
+		notifiers = append(notifiers, &notify.WeCom{URL: section.WeCom})
+	}
+	if email := section.Email; email != nil {
+		notifiers = append(notifiers, &notify.Email{
+			Host:     email.SMTPHost,
+			Port:     email.SMTPPort,
+			Username: email.Username,
+			Password: email.Password,
+			From:     email.From,
+			To:       email.To,
+		})
+	}
+	return notifiers
+}
+
+// NotifyCompletion 按配置发送回测完成通知 (未开启、未配置渠道或运行时间未超过阈值时不发送)
+// 失败只打印警告，不影响回测结果
+func NotifyCompletion(section config.NotifySection, name string, result *types.BacktestResult, runErr error, elapsed time.Duration) {
+	if !section.OnComplete {
+		return
+	}
+	if section.CompleteAfter != "" {
+		threshold, err := time.ParseDuration(section.CompleteAfter)
+		if err != nil {
+			fmt.Printf("Warning: invalid notify.complete_after: %v\n", err)
+			return
+		}
+		if elapsed < threshold {
+			return
+		}
+	}
+	notifier := NewNotifier(section)
+	if len(notifier) == 0 {
+		return
+	}
+
+	title := "回测完成 " + name
+	var b strings.Builder
+	if runErr != nil {
+		title = "回测中止 " + name
+		fmt.Fprintf(&b, "Error: %v\n", runErr)
+	}
+	if result != nil {
+		fmt.Fprintf(&b, "Period: %s to %s\n", result.StartDate.Format("2006-01-02"), result.EndDate.Format("2006-01-02"))
+		fmt.Fprintf(&b, "Final Value: $%.2f\n", result.FinalValue)
+		fmt.Fprintf(&b, "Total Return: %.2f%%\n", result.TotalReturn*100)
+		fmt.Fprintf(&b, "Total Trades: %d\n", result.TotalTrades)
+	}
+	fmt.Fprintf(&b, "Elapsed: %s\n", elapsed.Round(time.Second))
+
+	if err := notifier.Notify(title, b.String()); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,112 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// pnlTracker 按标的拆分每日盯市盈亏
+type pnlTracker struct {
+	prev       types.PortfolioSnapshot
+	prevPrices map[string]float64
+	tradeIndex int                // 已计入的成交记录数
+	income     map[string]float64 // 当日计提的现金收益 (管理费、融券费用为负，融资利息记在现金 CashSymbol 下)
+	started    bool
+	aggregate  bool // 只保留每日组合合计 (快照流式输出时控制内存)
+	records    []types.DailyPnL
+}
+
+// newPnLTracker 创建盈亏跟踪器
+func newPnLTracker() *pnlTracker {
+	return &pnlTracker{records: make([]types.DailyPnL, 0), income: make(map[string]float64)}
+}
+
+// accrue 记录标的当日计提的现金收益 (费用为负)，计入当日的 Income
+func (t *pnlTracker) accrue(symbol string, amount float64) {
+	t.income[symbol] += amount
+}
+
+// record 根据当日快照、价格和新增成交计算各标的当日盈亏
+// 价格效应 = 昨日持仓市值 × 当日涨跌幅；交易效应 = 持仓和成交的盈亏 - 价格效应 (成交价与收盘价之差及费用)；
+// 现金收益 = 当日计提的管理费、融资利息和融券费用；合计 = 价格效应 + 交易效应 + 现金收益
+func (t *pnlTracker) record(snapshot types.PortfolioSnapshot, prices map[string]float64, trades []types.Trade) {
+	newTrades := trades[t.tradeIndex:]
+	t.tradeIndex = len(trades)
+
+	if !t.started {
+		t.started = true
+		t.prev = types.PortfolioSnapshot{Positions: map[string]types.Position{}}
+	}
+
+	// 当日成交产生的现金流 (买入为负)
+	cashFlow := make(map[string]float64)
+	for _, trade := range newTrades {
+		if trade.Side == "BUY" {
+			cashFlow[trade.Symbol] -= trade.Value + trade.Fee
+		} else {
+			cashFlow[trade.Symbol] += trade.Value - trade.Fee
+		}
+	}
+
+	symbolSet := make(map[string]bool)
+	for symbol := range t.prev.Positions {
+		symbolSet[symbol] = true
+	}
+	for symbol := range snapshot.Positions {
+		symbolSet[symbol] = true
+	}
+	for symbol := range cashFlow {
+		symbolSet[symbol] = true
+	}
+	for symbol := range t.income {
+		symbolSet[symbol] = true
+	}
+	symbols := make([]string, 0, len(symbolSet))
+	for symbol := range symbolSet {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	total := types.DailyPnL{Timestamp: snapshot.Timestamp, Symbol: types.PnLTotalSymbol}
+	for _, symbol := range symbols {
+		prevValue := t.prev.Positions[symbol].Value
+		curValue := snapshot.Positions[symbol].Value
+
+		priceEffect := 0.0
+		if prevPrice := t.prevPrices[symbol]; prevPrice > 0 && prices[symbol] > 0 {
+			priceEffect = prevValue * (prices[symbol]/prevPrice - 1)
+		}
+		holding := curValue - prevValue + cashFlow[symbol]
+		income := t.income[symbol]
+
+		record := types.DailyPnL{
+			Timestamp:   snapshot.Timestamp,
+			Symbol:      symbol,
+			PriceEffect: priceEffect,
+			TradeEffect: holding - priceEffect,
+			Income:      income,
+			Total:       holding + income,
+		}
+		if !t.aggregate {
+			t.records = append(t.records, record)
+			continue
+		}
+		total.PriceEffect += record.PriceEffect
+		total.TradeEffect += record.TradeEffect
+		total.Income += record.Income
+		total.Total += record.Total
+	}
+	if t.aggregate && len(symbols) > 0 {
+		t.records = append(t.records, total)
+	}
+	t.income = make(map[string]float64)
+
+	t.prev = types.PortfolioSnapshot{Positions: snapshot.Positions}
+	if t.prevPrices == nil {
+		t.prevPrices = make(map[string]float64)
+	}
+	for symbol, price := range prices {
+		t.prevPrices[symbol] = price
+	}
+}
@@ -0,0 +1,25 @@
+package engine
+
+import (
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// regimeChanges 策略的状态切换记录 (非状态切换策略为nil)
+func (e *BacktestEngine) regimeChanges() []types.RegimeChange {
+	if reporter, ok := e.strategy.(strategy.RegimeReporter); ok {
+		return reporter.RegimeChanges()
+	}
+	return nil
+}
+
+// logRegimeChange 输出最近一次状态切换
+func (e *BacktestEngine) logRegimeChange() {
+	changes := e.regimeChanges()
+	if len(changes) == 0 {
+		return
+	}
+	c := changes[len(changes)-1]
+	e.logf("Regime changed on %s: %s -> %s (indicator %.4f)\n",
+		c.Timestamp.Format("2006-01-02"), c.From, c.To, c.Value)
+}
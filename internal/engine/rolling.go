@@ -0,0 +1,185 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// RollingWindow 滚动窗口中的一次回测
+type RollingWindow struct {
+	Years        int
+	Start        time.Time
+	End          time.Time
+	FinalValue   float64
+	TotalReturn  float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+}
+
+// RollingStats 同一窗口长度下各窗口收益和回撤的分布
+type RollingStats struct {
+	Years          int
+	Windows        int
+	MinReturn      float64 // 年化收益最小值
+	P25Return      float64
+	MedianReturn   float64
+	P75Return      float64
+	MaxReturn      float64
+	MeanReturn     float64
+	PositiveShare  float64 // 总收益为正的窗口占比
+	MedianDrawdown float64
+	WorstDrawdown  float64
+}
+
+// RollingResult 滚动窗口回测结果
+type RollingResult struct {
+	StepMonths int
+	Stats      []RollingStats
+	Windows    []RollingWindow
+}
+
+// RunRolling 在配置的回测区间内按 stepMonths 个月的间隔滚动起始日期，
+// 对每个窗口长度 (年) 的每个窗口运行一次相同策略的回测，并汇总收益和回撤的分布
+func RunRolling(cfg *config.Config, years []int, stepMonths int) (*RollingResult, error) {
+	if stepMonths <= 0 {
+		return nil, fmt.Errorf("step must be positive, got %d months", stepMonths)
+	}
+	start, err := time.Parse("2006-01-02", cfg.Backtest.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", cfg.Backtest.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %w", err)
+	}
+
+	result := &RollingResult{StepMonths: stepMonths}
+	for _, n := range years {
+		if n <= 0 {
+			return nil, fmt.Errorf("window length must be positive, got %d years", n)
+		}
+		windows := make([]RollingWindow, 0)
+		for from := start; !from.AddDate(n, 0, 0).After(end); from = from.AddDate(0, stepMonths, 0) {
+			to := from.AddDate(n, 0, 0)
+			fmt.Printf("Running window: %s to %s\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+			run, metrics, err := runPeriod(cfg, from, to)
+			if err != nil {
+				return nil, fmt.Errorf("window %dy %s: %w", n, from.Format("2006-01-02"), err)
+			}
+			windows = append(windows, RollingWindow{
+				Years:        n,
+				Start:        from,
+				End:          to,
+				FinalValue:   run.FinalValue,
+				TotalReturn:  run.TotalReturn,
+				AnnualReturn: metrics["annual_return"],
+				MaxDrawdown:  metrics["max_drawdown"],
+			})
+		}
+		if len(windows) == 0 {
+			fmt.Printf("Backtest period is shorter than %d years, skipped\n", n)
+			continue
+		}
+		result.Windows = append(result.Windows, windows...)
+		result.Stats = append(result.Stats, rollingStats(n, windows))
+	}
+	if len(result.Windows) == 0 {
+		return nil, fmt.Errorf("backtest period %s to %s is shorter than every window length", cfg.Backtest.StartDate, cfg.Backtest.EndDate)
+	}
+	return result, nil
+}
+
+// runPeriod 以配置中的策略回测 from 至 to 区间 (不输出运行日志)，返回结果和主要指标
+func runPeriod(cfg *config.Config, from, to time.Time) (*types.BacktestResult, map[string]float64, error) {
+	pc := *cfg
+	pc.Backtest.StartDate = from.Format("2006-01-02")
+	pc.Backtest.EndDate = to.Format("2006-01-02")
+	sleeve, err := NewSleeve(pc.Backtest.StartDate, &pc)
+	if err != nil {
+		return nil, nil, err
+	}
+	sleeve.Engine.SetLogOutput(ioutil.Discard)
+	result, err := sleeve.Engine.Run()
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, store.Metrics(result), nil
+}
+
+// rollingStats 汇总同一窗口长度的收益和回撤分布
+func rollingStats(years int, windows []RollingWindow) RollingStats {
+	returns := make([]float64, len(windows))
+	drawdowns := make([]float64, len(windows))
+	positive := 0
+	mean := 0.0
+	for i, w := range windows {
+		returns[i] = w.AnnualReturn
+		drawdowns[i] = w.MaxDrawdown
+		mean += w.AnnualReturn
+		if w.TotalReturn > 0 {
+			positive++
+		}
+	}
+	sort.Float64s(returns)
+	sort.Float64s(drawdowns)
+
+	return RollingStats{
+		Years:          years,
+		Windows:        len(windows),
+		MinReturn:      returns[0],
+		P25Return:      quantile(returns, 0.25),
+		MedianReturn:   quantile(returns, 0.5),
+		P75Return:      quantile(returns, 0.75),
+		MaxReturn:      returns[len(returns)-1],
+		MeanReturn:     mean / float64(len(windows)),
+		PositiveShare:  float64(positive) / float64(len(windows)),
+		MedianDrawdown: quantile(drawdowns, 0.5),
+		WorstDrawdown:  drawdowns[len(drawdowns)-1],
+	}
+}
+
+// quantile 已排序数据的分位数 (线性插值)
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// PrintRollingResult 打印滚动窗口回测的分布
+func PrintRollingResult(result *RollingResult) {
+	fmt.Println("\n========== Rolling Windows ==========")
+	fmt.Printf("Step: %d months\n", result.StepMonths)
+	fmt.Printf("%-6s %7s %9s %9s %9s %9s %9s %9s %9s %9s\n",
+		"Window", "Count", "Min", "P25", "Median", "P75", "Max", "Positive", "MedDD", "WorstDD")
+	for _, s := range result.Stats {
+		fmt.Printf("%-6s %7d %8.2f%% %8.2f%% %8.2f%% %8.2f%% %8.2f%% %8.1f%% %8.2f%% %8.2f%%\n",
+			fmt.Sprintf("%dy", s.Years), s.Windows, s.MinReturn*100, s.P25Return*100, s.MedianReturn*100,
+			s.P75Return*100, s.MaxReturn*100, s.PositiveShare*100, s.MedianDrawdown*100, s.WorstDrawdown*100)
+	}
+	fmt.Println("(returns are annualized)")
+	fmt.Println("=====================================")
+}
+
+// ExportRollingResult 导出滚动窗口回测结果
+func ExportRollingResult(result *RollingResult, filepath string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Rolling windows exported to: %s\n", filepath)
+	return nil
+}
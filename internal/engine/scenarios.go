@@ -0,0 +1,197 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Scenario 用于压力测试的命名历史区间
+type Scenario struct {
+	Name        string
+	Description string
+	Start       string
+	End         string
+}
+
+// CrisisScenarios 预置的危机区间
+var CrisisScenarios = []Scenario{
+	{Name: "2015-crash", Description: "2015年A股股灾", Start: "2015-06-01", End: "2016-02-29"},
+	{Name: "2018-bear", Description: "2018年熊市", Start: "2018-01-01", End: "2018-12-31"},
+	{Name: "2020-covid", Description: "2020年新冠疫情冲击", Start: "2020-01-01", End: "2020-06-30"},
+	{Name: "2022-drawdown", Description: "2022年回撤", Start: "2022-01-01", End: "2022-10-31"},
+}
+
+// ScenarioResult 策略在一个危机区间内的表现
+type ScenarioResult struct {
+	Scenario     Scenario
+	Skipped      bool // 区间内没有可用数据等原因未能运行
+	SkipReason   string
+	FinalValue   float64
+	TotalReturn  float64
+	MaxDrawdown  float64
+	PeakDate     time.Time // 最大回撤开始前的净值高点
+	TroughDate   time.Time // 最大回撤的最低点
+	Recovered    bool      // 区间结束前是否回到高点
+	RecoveryDays int       // 从最低点回到高点的自然日数，未恢复为 -1
+	Trades       int
+	Fees         float64
+}
+
+// RunScenarios 以配置中的策略依次回测各危机区间 (names 为空时运行全部预置区间)，
+// 区间内没有数据的情景记为跳过
+func RunScenarios(cfg *config.Config, names []string) ([]ScenarioResult, error) {
+	scenarios, err := selectScenarios(names)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ScenarioResult, 0, len(scenarios))
+	ran := 0
+	for _, sc := range scenarios {
+		from, err := time.Parse("2006-01-02", sc.Start)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %s: invalid start date: %w", sc.Name, err)
+		}
+		to, err := time.Parse("2006-01-02", sc.End)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %s: invalid end date: %w", sc.Name, err)
+		}
+
+		fmt.Printf("Running scenario %s: %s to %s\n", sc.Name, sc.Start, sc.End)
+		run, metrics, err := runPeriod(cfg, from, to)
+		if err != nil {
+			results = append(results, ScenarioResult{Scenario: sc, Skipped: true, SkipReason: err.Error(), RecoveryDays: -1})
+			continue
+		}
+		ran++
+
+		r := ScenarioResult{
+			Scenario:    sc,
+			FinalValue:  run.FinalValue,
+			TotalReturn: run.TotalReturn,
+			MaxDrawdown: metrics["max_drawdown"],
+			Trades:      run.TotalTrades,
+			Fees:        run.TotalFees,
+		}
+		r.PeakDate, r.TroughDate, r.RecoveryDays = drawdownRecovery(run.Snapshots)
+		r.Recovered = r.RecoveryDays >= 0
+		results = append(results, r)
+	}
+	if ran == 0 {
+		return nil, fmt.Errorf("no scenario has data in %s", cfg.Backtest.DataDir)
+	}
+	return results, nil
+}
+
+// selectScenarios 按名称选取预置区间
+func selectScenarios(names []string) ([]Scenario, error) {
+	if len(names) == 0 {
+		return CrisisScenarios, nil
+	}
+	selected := make([]Scenario, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, sc := range CrisisScenarios {
+			if sc.Name == name {
+				selected = append(selected, sc)
+				found = true
+				break
+			}
+		}
+		if !found {
+			known := make([]string, len(CrisisScenarios))
+			for i, sc := range CrisisScenarios {
+				known[i] = sc.Name
+			}
+			return nil, fmt.Errorf("unknown scenario %q (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// drawdownRecovery 找出最大回撤的高点和最低点，以及最低点之后回到高点所用的自然日数 (未恢复为 -1)
+func drawdownRecovery(snapshots []types.PortfolioSnapshot) (peakDate, troughDate time.Time, recoveryDays int) {
+	recoveryDays = -1
+	if len(snapshots) == 0 {
+		return
+	}
+	peak := snapshots[0]
+	maxDrawdown := 0.0
+	var maxPeak types.PortfolioSnapshot
+	troughIndex := -1
+	for i, s := range snapshots {
+		if s.TotalValue > peak.TotalValue {
+			peak = s
+		}
+		if peak.TotalValue > 0 {
+			if dd := 1 - s.TotalValue/peak.TotalValue; dd > maxDrawdown {
+				maxDrawdown = dd
+				maxPeak = peak
+				troughIndex = i
+			}
+		}
+	}
+	if troughIndex < 0 {
+		return
+	}
+
+	peakDate = maxPeak.Timestamp
+	troughDate = snapshots[troughIndex].Timestamp
+	for _, s := range snapshots[troughIndex+1:] {
+		if s.TotalValue >= maxPeak.TotalValue {
+			recoveryDays = int(s.Timestamp.Sub(troughDate).Hours() / 24)
+			break
+		}
+	}
+	return
+}
+
+// PrintScenarioResults 打印各危机区间的回撤、恢复时间和交易次数
+func PrintScenarioResults(results []ScenarioResult) {
+	fmt.Println("\n========== Crisis Scenarios ==========")
+	fmt.Printf("%-14s %-23s %9s %9s %-10s %-10s %10s %7s\n",
+		"Scenario", "Period", "Return", "MaxDD", "Peak", "Trough", "Recovery", "Trades")
+	for _, r := range results {
+		period := r.Scenario.Start + "~" + r.Scenario.End
+		if r.Skipped {
+			fmt.Printf("%-14s %-23s skipped: %s\n", r.Scenario.Name, period, r.SkipReason)
+			continue
+		}
+		recovery := "-"
+		if r.Recovered {
+			recovery = fmt.Sprintf("%dd", r.RecoveryDays)
+		} else if r.MaxDrawdown > 0 {
+			recovery = "none"
+		}
+		peak, trough := "-", "-"
+		if !r.TroughDate.IsZero() {
+			peak = r.PeakDate.Format("2006-01-02")
+			trough = r.TroughDate.Format("2006-01-02")
+		}
+		fmt.Printf("%-14s %-23s %8.2f%% %8.2f%% %-10s %-10s %10s %7d\n",
+			r.Scenario.Name, period, r.TotalReturn*100, r.MaxDrawdown*100, peak, trough, recovery, r.Trades)
+	}
+	fmt.Println("(recovery: calendar days from trough back to the prior peak within the scenario; none = not recovered)")
+	fmt.Println("======================================")
+}
+
+// ExportScenarioResults 导出危机区间结果
+func ExportScenarioResults(results []ScenarioResult, filepath string) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Scenario results exported to: %s\n", filepath)
+	return nil
+}
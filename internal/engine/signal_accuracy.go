@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// signalHorizons 评估信号的远期期限 (月)
+var signalHorizons = []int{3, 6, 12}
+
+// signalAccuracy 评估买入/卖出信号的质量：标的的信号变为买入或卖出方向的信号时计一次，
+// 按信号类型和原因统计此后3/6/12个月标的收益的命中率 (买入信号收益为正、卖出信号收益为负) 和平均收益
+// 远期收益按复权收盘价 (期限末日取当日或之前最近一个交易日)，期限超出标的数据范围的信号不计入该期限
+func (e *BacktestEngine) signalAccuracy() []types.SignalAccuracy {
+	if len(e.signals) == 0 || e.dataLoader == nil {
+		return nil
+	}
+
+	type key struct {
+		signal types.SignalType
+		reason types.ReasonCode
+	}
+	type tally struct {
+		stat    types.SignalAccuracy
+		hits    []int
+		samples []int
+		returns []float64
+	}
+	tallies := make(map[key]*tally)
+	last := make(map[string]types.SignalType) // 各标的上一次记录的信号
+
+	for _, record := range e.signals {
+		signal := record.Signal
+		previous, seen := last[record.Symbol]
+		last[record.Symbol] = signal.Type
+		if seen && previous == signal.Type {
+			continue
+		}
+		if signal.Direction != types.DirectionBuy && signal.Direction != types.DirectionSell {
+			continue
+		}
+
+		k := key{signal.Type, signal.Reason}
+		t, ok := tallies[k]
+		if !ok {
+			t = &tally{
+				stat:    types.SignalAccuracy{Type: signal.Type, Reason: signal.Reason, Direction: signal.Direction},
+				hits:    make([]int, len(signalHorizons)),
+				samples: make([]int, len(signalHorizons)),
+				returns: make([]float64, len(signalHorizons)),
+			}
+			tallies[k] = t
+		}
+		t.stat.Count++
+
+		start, ok := e.dataLoader.Indicator(record.Symbol, indicators.Price, 0, record.Timestamp)
+		if !ok || start <= 0 {
+			continue
+		}
+		_, end, err := e.dataLoader.GetDataRange(record.Symbol)
+		if err != nil {
+			continue
+		}
+		for i, months := range signalHorizons {
+			date := record.Timestamp.AddDate(0, months, 0)
+			if date.After(end) {
+				continue
+			}
+			price, ok := e.dataLoader.Indicator(record.Symbol, indicators.Price, 0, date)
+			if !ok {
+				continue
+			}
+			r := price/start - 1
+			t.samples[i]++
+			t.returns[i] += r
+			if (signal.Direction == types.DirectionBuy && r > 0) || (signal.Direction == types.DirectionSell && r < 0) {
+				t.hits[i]++
+			}
+		}
+	}
+
+	stats := make([]types.SignalAccuracy, 0, len(tallies))
+	for _, t := range tallies {
+		stat := t.stat
+		for i, months := range signalHorizons {
+			h := types.SignalHorizon{Months: months, Samples: t.samples[i]}
+			if h.Samples > 0 {
+				h.HitRate = float64(t.hits[i]) / float64(h.Samples)
+				h.AvgReturn = t.returns[i] / float64(h.Samples)
+			}
+			stat.Horizons = append(stat.Horizons, h)
+		}
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Direction != stats[j].Direction {
+			return stats[i].Direction < stats[j].Direction
+		}
+		if stats[i].Type != stats[j].Type {
+			return stats[i].Type < stats[j].Type
+		}
+		return stats[i].Reason < stats[j].Reason
+	})
+	if len(stats) == 0 {
+		return nil
+	}
+	return stats
+}
+
+// printSignalAccuracy 打印各类信号的远期收益命中率
+func (e *BacktestEngine) printSignalAccuracy() {
+	if len(e.result.SignalAccuracy) == 0 {
+		return
+	}
+	fmt.Println("Signal Accuracy (hit rate / avg forward return):")
+	for _, stat := range e.result.SignalAccuracy {
+		line := fmt.Sprintf("  %-12s %-18s x%-4d", string(stat.Type), stat.Reason, stat.Count)
+		for _, h := range stat.Horizons {
+			if h.Samples == 0 {
+				line += fmt.Sprintf("  %2dm: %-21s", h.Months, "n/a")
+				continue
+			}
+			line += fmt.Sprintf("  %2dm: %5.1f%% / %+6.2f%% (%d)", h.Months, h.HitRate*100, h.AvgReturn*100, h.Samples)
+		}
+		fmt.Println(line)
+	}
+}
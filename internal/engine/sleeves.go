@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/cost"
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/runs"
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Sleeve 子账户 (独立的策略、资金和成本模型)
+type Sleeve struct {
+	Name   string
+	Engine *BacktestEngine
+}
+
+// SleeveResult 子账户回测结果
+type SleeveResult struct {
+	Name   string
+	Result *types.BacktestResult
+}
+
+// HouseholdResult 多账户合并结果
+type HouseholdResult struct {
+	Sleeves  []SleeveResult
+	Combined *types.BacktestResult
+}
+
+// NewSleeve 根据配置创建子账户
+func NewSleeve(name string, cfg *config.Config) (*Sleeve, error) {
+	backtestConfig, err := cfg.ToBacktestConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := strategy.New(cfg.ToStrategyConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	costModel, err := cost.New(cfg.ToCostConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	e := New(backtestConfig)
+	e.SetDataLoader(data.NewCSVLoader(cfg.GetDataDir()))
+	e.SetCostModel(costModel)
+	e.SetStrategy(s)
+	if src := cfg.Source(); len(src) > 0 {
+		e.SetConfigHash(runs.ConfigHash(src))
+	}
+	if len(backtestConfig.Currencies) > 0 {
+		e.SetFXLoader(data.NewFXLoader(cfg.GetFXDir()))
+	}
+
+	return &Sleeve{Name: name, Engine: e}, nil
+}
+
+// NewSleevesFromConfig 根据配置文件中的sleeves段创建所有子账户
+func NewSleevesFromConfig(cfg *config.Config) ([]*Sleeve, error) {
+	sleeves := make([]*Sleeve, 0, len(cfg.Sleeves))
+	for i, section := range cfg.Sleeves {
+		name := section.Name
+		if name == "" {
+			name = fmt.Sprintf("sleeve-%d", i+1)
+		}
+		sleeve, err := NewSleeve(name, cfg.SleeveConfig(i))
+		if err != nil {
+			return nil, fmt.Errorf("sleeve %s: %w", name, err)
+		}
+		sleeves = append(sleeves, sleeve)
+	}
+	return sleeves, nil
+}
+
+// RunSleeves 依次运行各子账户并合并为家庭层面的结果
+func RunSleeves(sleeves []*Sleeve) (*HouseholdResult, error) {
+	if len(sleeves) == 0 {
+		return nil, fmt.Errorf("no sleeves configured")
+	}
+
+	household := &HouseholdResult{
+		Sleeves: make([]SleeveResult, 0, len(sleeves)),
+	}
+	for _, sleeve := range sleeves {
+		fmt.Printf("Running sleeve: %s\n", sleeve.Name)
+		result, err := sleeve.Engine.Run()
+		if err != nil {
+			return nil, fmt.Errorf("sleeve %s failed: %w", sleeve.Name, err)
+		}
+		household.Sleeves = append(household.Sleeves, SleeveResult{Name: sleeve.Name, Result: result})
+	}
+
+	household.Combined = combineResults(household.Sleeves)
+	return household, nil
+}
+
+// combineResults 合并各子账户结果，缺失日期沿用该账户最近一次快照
+func combineResults(sleeves []SleeveResult) *types.BacktestResult {
+	combined := &types.BacktestResult{}
+	symbolSet := make(map[string]bool)
+	dateSet := make(map[time.Time]bool)
+
+	for _, sr := range sleeves {
+		r := sr.Result
+		combined.Config.InitialCapital += r.Config.InitialCapital
+		if combined.Config.StartDate.IsZero() || r.Config.StartDate.Before(combined.Config.StartDate) {
+			combined.Config.StartDate = r.Config.StartDate
+		}
+		if r.Config.EndDate.After(combined.Config.EndDate) {
+			combined.Config.EndDate = r.Config.EndDate
+		}
+		for _, symbol := range r.Config.Symbols {
+			if !symbolSet[symbol] {
+				symbolSet[symbol] = true
+				combined.Config.Symbols = append(combined.Config.Symbols, symbol)
+			}
+		}
+		combined.Trades = append(combined.Trades, r.Trades...)
+		combined.TotalFees += r.TotalFees
+		combined.Signals = append(combined.Signals, r.Signals...)
+		for _, snap := range r.Snapshots {
+			dateSet[snap.Timestamp] = true
+		}
+	}
+
+	sort.SliceStable(combined.Trades, func(i, j int) bool {
+		return combined.Trades[i].Timestamp.Before(combined.Trades[j].Timestamp)
+	})
+
+	dates := make([]time.Time, 0, len(dateSet))
+	for d := range dateSet {
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool {
+		return dates[i].Before(dates[j])
+	})
+
+	cursors := make([]int, len(sleeves))
+	for _, date := range dates {
+		snapshot := types.PortfolioSnapshot{
+			Timestamp: date,
+			Positions: make(map[string]types.Position),
+		}
+		for i, sr := range sleeves {
+			snaps := sr.Result.Snapshots
+			for cursors[i] < len(snaps) && !snaps[cursors[i]].Timestamp.After(date) {
+				cursors[i]++
+			}
+			if cursors[i] == 0 {
+				// 该账户尚未开始，按初始资金计入现金
+				snapshot.Cash += sr.Result.Config.InitialCapital
+				snapshot.TotalValue += sr.Result.Config.InitialCapital
+				continue
+			}
+			mergeSnapshot(&snapshot, snaps[cursors[i]-1])
+		}
+		snapshot.Weights = snapshotWeights(snapshot)
+		combined.Snapshots = append(combined.Snapshots, snapshot)
+	}
+
+	combined.TotalTrades = len(combined.Trades)
+	if len(combined.Snapshots) > 0 {
+		combined.StartDate = combined.Snapshots[0].Timestamp
+		combined.EndDate = combined.Snapshots[len(combined.Snapshots)-1].Timestamp
+		combined.FinalValue = combined.Snapshots[len(combined.Snapshots)-1].TotalValue
+	}
+	if combined.Config.InitialCapital > 0 {
+		combined.TotalReturn = (combined.FinalValue - combined.Config.InitialCapital) / combined.Config.InitialCapital
+	}
+	return combined
+}
+
+// mergeSnapshot 将子账户快照累加到合并快照
+func mergeSnapshot(dst *types.PortfolioSnapshot, src types.PortfolioSnapshot) {
+	dst.Cash += src.Cash
+	dst.Receivable += src.Receivable
+	dst.TotalValue += src.TotalValue
+	for symbol, pos := range src.Positions {
+		existing, ok := dst.Positions[symbol]
+		if !ok {
+			dst.Positions[symbol] = pos
+			continue
+		}
+		quantity := existing.Quantity + pos.Quantity
+		if quantity > 0 {
+			existing.AvgCost = (existing.AvgCost*existing.Quantity + pos.AvgCost*pos.Quantity) / quantity
+		}
+		existing.Quantity = quantity
+		existing.Value += pos.Value
+		existing.ProfitLoss += pos.ProfitLoss
+		dst.Positions[symbol] = existing
+	}
+}
+
+// snapshotWeights 计算快照权重
+func snapshotWeights(snapshot types.PortfolioSnapshot) map[string]float64 {
+	weights := make(map[string]float64)
+	if snapshot.TotalValue == 0 {
+		return weights
+	}
+	for symbol, pos := range snapshot.Positions {
+		weights[symbol] = pos.Value / snapshot.TotalValue
+	}
+	weights[types.CashSymbol] = (snapshot.Cash + snapshot.Receivable) / snapshot.TotalValue
+	return weights
+}
+
+// ExportHouseholdResults 导出多账户结果到JSON文件
+func ExportHouseholdResults(household *HouseholdResult, filepath string) error {
+	type sleeveOutput struct {
+		Name    string        `json:"name"`
+		Summary ResultSummary `json:"summary"`
+	}
+
+	output := struct {
+		Summary   ResultSummary             `json:"summary"`
+		Sleeves   []sleeveOutput            `json:"sleeves"`
+		Trades    []types.Trade             `json:"trades"`
+		Snapshots []types.PortfolioSnapshot `json:"snapshots"`
+	}{
+		Summary:   summarize("Household", household.Combined),
+		Trades:    household.Combined.Trades,
+		Snapshots: household.Combined.Snapshots,
+	}
+	for _, sr := range household.Sleeves {
+		output.Sleeves = append(output.Sleeves, sleeveOutput{
+			Name:    sr.Name,
+			Summary: summarize(sr.Name, sr.Result),
+		})
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	err = ioutil.WriteFile(filepath, data, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Household results exported to: %s\n", filepath)
+	return nil
+}
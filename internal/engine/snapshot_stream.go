@@ -0,0 +1,122 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// SetSnapshotStream 设置快照流式输出文件：运行中将每日完整快照 (持仓、权重和信号) 写入该文件，
+// 结果中的 Snapshots 只保留日期、现金和总价值，用于长区间多标的回测控制内存。
+// 按扩展名选择格式：.csv 每个持仓一行，其他扩展名为每日一行JSON (JSONL)
+func (e *BacktestEngine) SetSnapshotStream(path string) {
+	e.snapshotStream = path
+}
+
+// snapshotStream 快照流式输出
+type snapshotStream struct {
+	path string
+	file *os.File
+	buf  *bufio.Writer
+	csv  *csv.Writer // CSV格式时非空
+	enc  *json.Encoder
+}
+
+// openSnapshotStream 创建快照输出文件，appendTo 为 true (增量回测) 时追加到已有文件之后
+func openSnapshotStream(path string, appendTo bool) (*snapshotStream, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendTo {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot stream: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open snapshot stream: %w", err)
+	}
+
+	s := &snapshotStream{path: path, file: file, buf: bufio.NewWriter(file)}
+	if strings.ToLower(filepath.Ext(path)) == ".csv" {
+		s.csv = csv.NewWriter(s.buf)
+		if info.Size() == 0 {
+			s.csv.Write([]string{"date", "symbol", "quantity", "avg_cost", "value", "weight", "profit_loss", "signal"})
+		}
+	} else {
+		s.enc = json.NewEncoder(s.buf)
+	}
+	return s, nil
+}
+
+// write 写入一个交易日的完整快照
+func (s *snapshotStream) write(snapshot types.PortfolioSnapshot) error {
+	if s.enc != nil {
+		if err := s.enc.Encode(snapshot); err != nil {
+			return fmt.Errorf("failed to write snapshot stream: %w", err)
+		}
+		return nil
+	}
+
+	date := snapshot.Timestamp.Format("2006-01-02")
+	symbols := make([]string, 0, len(snapshot.Positions))
+	for symbol := range snapshot.Positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		pos := snapshot.Positions[symbol]
+		s.csv.Write([]string{
+			date,
+			symbol,
+			formatFloat(pos.Quantity, 4),
+			formatFloat(pos.AvgCost, 4),
+			formatFloat(pos.Value, 2),
+			formatFloat(snapshot.Weights[symbol], 6),
+			formatFloat(pos.ProfitLoss, 2),
+			string(snapshot.Signals[symbol].Type),
+		})
+	}
+	s.csv.Write([]string{date, types.CashSymbol, "", "", formatFloat(snapshot.Cash, 2), formatFloat(snapshot.Weights[types.CashSymbol], 6), "", ""})
+	if err := s.csv.Error(); err != nil {
+		return fmt.Errorf("failed to write snapshot stream: %w", err)
+	}
+	return nil
+}
+
+// close 写出缓冲并关闭文件 (重复调用时不做任何操作)
+func (s *snapshotStream) close() error {
+	if s.file == nil {
+		return nil
+	}
+	if s.csv != nil {
+		s.csv.Flush()
+	}
+	err := s.buf.Flush()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close snapshot stream %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// aggregateSnapshot 快照的汇总部分 (日期、现金、在途赎回款和总价值)，流式输出时保留在内存中
+func aggregateSnapshot(snapshot types.PortfolioSnapshot) types.PortfolioSnapshot {
+	return types.PortfolioSnapshot{
+		Timestamp:  snapshot.Timestamp,
+		Cash:       snapshot.Cash,
+		Receivable: snapshot.Receivable,
+		TotalValue: snapshot.TotalValue,
+	}
+}
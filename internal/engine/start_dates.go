@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+)
+
+// StartDateRun 起始日期推迟若干个月后的一次回测 (结束日期不变)
+type StartDateRun struct {
+	ShiftMonths  int
+	Start        time.Time
+	FinalValue   float64
+	TotalReturn  float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+}
+
+// StartDateSensitivity 起始日期敏感性：各次回测的结果及年化收益和最大回撤的离散程度
+type StartDateSensitivity struct {
+	End              time.Time
+	Runs             []StartDateRun
+	MinAnnualReturn  float64
+	MaxAnnualReturn  float64
+	StdAnnualReturn  float64 // 年化收益的标准差
+	MinMaxDrawdown   float64
+	MaxMaxDrawdown   float64
+	StdMaxDrawdown   float64 // 最大回撤的标准差
+	AnnualReturnGap  float64 // 最好与最差起始日期的年化收益差
+	MaxDrawdownRange float64 // 最大回撤的极差
+}
+
+// RunStartDateSensitivity 将配置的起始日期依次推迟 0..months 个月 (结束日期不变) 重新回测，
+// 汇总期末收益和最大回撤对入场时点的敏感程度
+func RunStartDateSensitivity(cfg *config.Config, months int) (*StartDateSensitivity, error) {
+	if months <= 0 {
+		return nil, fmt.Errorf("months must be positive, got %d", months)
+	}
+	start, err := time.Parse("2006-01-02", cfg.Backtest.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", cfg.Backtest.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %w", err)
+	}
+
+	s := &StartDateSensitivity{End: end}
+	for shift := 0; shift <= months; shift++ {
+		from := start.AddDate(0, shift, 0)
+		if !from.Before(end) {
+			break
+		}
+		fmt.Printf("Running from %s\n", from.Format("2006-01-02"))
+		result, metrics, err := runPeriod(cfg, from, end)
+		if err != nil {
+			return nil, fmt.Errorf("start %s: %w", from.Format("2006-01-02"), err)
+		}
+		s.Runs = append(s.Runs, StartDateRun{
+			ShiftMonths:  shift,
+			Start:        from,
+			FinalValue:   result.FinalValue,
+			TotalReturn:  result.TotalReturn,
+			AnnualReturn: metrics["annual_return"],
+			MaxDrawdown:  metrics["max_drawdown"],
+		})
+	}
+
+	returns := make([]float64, len(s.Runs))
+	drawdowns := make([]float64, len(s.Runs))
+	for i, run := range s.Runs {
+		returns[i] = run.AnnualReturn
+		drawdowns[i] = run.MaxDrawdown
+	}
+	s.MinAnnualReturn, s.MaxAnnualReturn, s.StdAnnualReturn = spread(returns)
+	s.MinMaxDrawdown, s.MaxMaxDrawdown, s.StdMaxDrawdown = spread(drawdowns)
+	s.AnnualReturnGap = s.MaxAnnualReturn - s.MinAnnualReturn
+	s.MaxDrawdownRange = s.MaxMaxDrawdown - s.MinMaxDrawdown
+	return s, nil
+}
+
+// spread 最小值、最大值和标准差
+func spread(values []float64) (min, max, std float64) {
+	if len(values) == 0 {
+		return 0, 0, 0
+	}
+	min, max = values[0], values[0]
+	mean := 0.0
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		std += (v - mean) * (v - mean)
+	}
+	return min, max, math.Sqrt(std / float64(len(values)))
+}
+
+// PrintStartDateSensitivity 打印起始日期敏感性表格，年化收益附文本条形图
+func PrintStartDateSensitivity(s *StartDateSensitivity) {
+	fmt.Println("\n========== Start Date Sensitivity ==========")
+	fmt.Printf("End date: %s\n", s.End.Format("2006-01-02"))
+	fmt.Printf("%-6s %-10s %14s %9s %9s %9s\n", "Shift", "Start", "Final", "Return", "Annual", "MaxDD")
+
+	scale := math.Max(math.Abs(s.MinAnnualReturn), math.Abs(s.MaxAnnualReturn))
+	for _, run := range s.Runs {
+		bar := ""
+		if scale > 0 {
+			n := int(math.Round(math.Abs(run.AnnualReturn) / scale * 30))
+			mark := "#"
+			if run.AnnualReturn < 0 {
+				mark = "-"
+			}
+			bar = strings.Repeat(mark, n)
+		}
+		fmt.Printf("%-6s %-10s %14.2f %8.2f%% %8.2f%% %8.2f%%  %s\n",
+			fmt.Sprintf("+%dm", run.ShiftMonths), run.Start.Format("2006-01-02"), run.FinalValue,
+			run.TotalReturn*100, run.AnnualReturn*100, run.MaxDrawdown*100, bar)
+	}
+	fmt.Printf("Annual return: %.2f%% to %.2f%% (gap %.2f%%, std %.2f%%)\n",
+		s.MinAnnualReturn*100, s.MaxAnnualReturn*100, s.AnnualReturnGap*100, s.StdAnnualReturn*100)
+	fmt.Printf("Max drawdown:  %.2f%% to %.2f%% (range %.2f%%, std %.2f%%)\n",
+		s.MinMaxDrawdown*100, s.MaxMaxDrawdown*100, s.MaxDrawdownRange*100, s.StdMaxDrawdown*100)
+	fmt.Println("============================================")
+}
+
+// ExportStartDateSensitivity 导出起始日期敏感性结果
+func ExportStartDateSensitivity(s *StartDateSensitivity, filepath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Start date sensitivity exported to: %s\n", filepath)
+	return nil
+}
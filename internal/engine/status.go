@@ -0,0 +1,188 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// statusTracker 标的的退市和停牌状态：退市当日按最后价格清仓，此后不再交易；停牌期间不交易，仍按价格估值
+type statusTracker struct {
+	delistings map[string]time.Time
+	halts      map[string][]types.Halt
+	symbols    []string        // 配置了退市或停牌的标的 (按标的排序)
+	delisted   map[string]bool // 已退市
+	halted     map[string]bool // 当前停牌
+	blocked    map[string]int  // 停牌期间或退市后丢弃的订单数
+	events     []types.StatusEvent
+}
+
+// newStatusTracker 创建状态跟踪，没有配置退市和停牌时返回 nil
+func newStatusTracker(config types.BacktestConfig) *statusTracker {
+	if len(config.Delistings) == 0 && len(config.Halts) == 0 {
+		return nil
+	}
+	t := &statusTracker{
+		delistings: config.Delistings,
+		halts:      config.Halts,
+		delisted:   make(map[string]bool),
+		halted:     make(map[string]bool),
+		blocked:    make(map[string]int),
+	}
+	seen := make(map[string]bool)
+	for symbol := range config.Delistings {
+		seen[symbol] = true
+	}
+	for symbol := range config.Halts {
+		seen[symbol] = true
+	}
+	for symbol := range seen {
+		t.symbols = append(t.symbols, symbol)
+	}
+	sort.Strings(t.symbols)
+	return t
+}
+
+// haltedOn 标的在该日是否处于停牌区间
+func (t *statusTracker) haltedOn(symbol string, date time.Time) bool {
+	for _, h := range t.halts[symbol] {
+		if !date.Before(h.Start) && (h.End.IsZero() || date.Before(h.End)) {
+			return true
+		}
+	}
+	return false
+}
+
+// blocks 标的当前是否不能交易 (已退市或停牌)，不能交易时计入丢弃的订单数
+func (t *statusTracker) blocks(symbol string) bool {
+	if t == nil || (!t.delisted[symbol] && !t.halted[symbol]) {
+		return false
+	}
+	t.blocked[symbol]++
+	return true
+}
+
+// tradable 去掉不能交易的标的后的价格 (供策略决策和生成订单)，持仓估值仍使用完整价格
+func (t *statusTracker) tradable(prices map[string]float64) map[string]float64 {
+	if t == nil || (len(t.delisted) == 0 && len(t.halted) == 0) {
+		return prices
+	}
+	filtered := make(map[string]float64, len(prices))
+	for symbol, price := range prices {
+		if !t.delisted[symbol] && !t.halted[symbol] {
+			filtered[symbol] = price
+		}
+	}
+	return filtered
+}
+
+// skipDelisted 从断点继续时，断点之前已退市的标的直接标记为退市 (清仓已在断点之前完成)
+func (t *statusTracker) skipDelisted(date time.Time, listing *listingSchedule) {
+	if t == nil {
+		return
+	}
+	for symbol, delistDate := range t.delistings {
+		if !delistDate.After(date) {
+			t.delisted[symbol] = true
+			listing.delist(symbol)
+		}
+	}
+}
+
+// updateStatus 按交易日更新各标的的退市和停牌状态：到达退市日的标的按最后价格清仓 (计入交易成本)，
+// 停牌和复牌记录状态变更
+func (e *BacktestEngine) updateStatus(t *statusTracker, listing *listingSchedule, date time.Time, lastPrices map[string]float64) {
+	if t == nil {
+		return
+	}
+	for _, symbol := range t.symbols {
+		if t.delisted[symbol] {
+			continue
+		}
+		if delistDate, ok := t.delistings[symbol]; ok && !date.Before(delistDate) {
+			e.delist(t, symbol, date, lastPrices[symbol])
+			listing.delist(symbol)
+			continue
+		}
+
+		halted := t.haltedOn(symbol, date)
+		if halted == t.halted[symbol] {
+			continue
+		}
+		t.halted[symbol] = halted
+		event := types.StatusEvent{Timestamp: date, Symbol: symbol, Status: types.StatusHalted}
+		if !halted {
+			event.Status = types.StatusResumed
+			event.Blocked = t.blocked[symbol]
+			t.blocked[symbol] = 0
+		}
+		t.events = append(t.events, event)
+		e.logf("%s %s on %s\n", symbol, event.Status, date.Format("2006-01-02"))
+	}
+}
+
+// delist 标的退市：按最后价格卖出全部持仓，此后该标的的订单一律丢弃
+func (e *BacktestEngine) delist(t *statusTracker, symbol string, date time.Time, price float64) {
+	t.delisted[symbol] = true
+	t.halted[symbol] = false
+	event := types.StatusEvent{Timestamp: date, Symbol: symbol, Status: types.StatusDelisted, Price: price, Blocked: t.blocked[symbol]}
+	t.blocked[symbol] = 0
+
+	pos, held := e.portfolioManager.GetPortfolio().Positions[symbol]
+	if held && pos.Quantity != 0 {
+		if price <= 0 {
+			e.logf("Warning: no price to liquidate delisted %s\n", symbol)
+		} else {
+			side := "SELL"
+			quantity := pos.Quantity
+			if quantity < 0 {
+				side, quantity = "BUY", -quantity
+			}
+			order := types.Order{Symbol: symbol, Side: side, Quantity: quantity, Price: price, Tag: types.TagDelisted}
+			if _, err := e.portfolioManager.ExecuteOrder(order, date); err != nil {
+				e.logf("Warning: failed to liquidate delisted %s: %v\n", symbol, err)
+			} else {
+				event.Quantity = pos.Quantity
+			}
+		}
+	}
+	t.events = append(t.events, event)
+	e.logf("%s delisted on %s, liquidated %.4f at %.4f\n", symbol, date.Format("2006-01-02"), event.Quantity, price)
+}
+
+// warnDataEnds 价格数据在回测结束前中断且未配置退市或停牌的标的给出警告 (持仓将一直按最后价格估值)
+func (e *BacktestEngine) warnDataEnds(last time.Time) {
+	for _, c := range e.coverage {
+		if c.Rows == 0 || !c.LastDate.Before(last) {
+			continue
+		}
+		if _, ok := e.config.Delistings[c.Symbol]; ok {
+			continue
+		}
+		if _, ok := e.config.Halts[c.Symbol]; ok {
+			continue
+		}
+		e.logf("Warning: price data for %s ends on %s; set assets[].delisted or halts to liquidate or freeze it\n",
+			c.Symbol, c.LastDate.Format("2006-01-02"))
+	}
+}
+
+// result 状态变更记录，回测结束时仍在停牌的标的记录丢弃的订单数
+func (t *statusTracker) result() []types.StatusEvent {
+	if t == nil {
+		return nil
+	}
+	events := append([]types.StatusEvent(nil), t.events...)
+	for _, symbol := range t.symbols {
+		if t.halted[symbol] && t.blocked[symbol] > 0 {
+			for i := len(events) - 1; i >= 0; i-- {
+				if events[i].Symbol == symbol && events[i].Status == types.StatusHalted {
+					events[i].Blocked = t.blocked[symbol]
+					break
+				}
+			}
+		}
+	}
+	return events
+}
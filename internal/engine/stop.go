@@ -0,0 +1,82 @@
+package engine
+
+import (
+	"fmt"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// stopTracker 跟踪提前终止条件
+type stopTracker struct {
+	conditions      types.StopConditions
+	peakValue       float64
+	monthKey        int
+	monthStartValue float64
+	lastValue       float64
+	losingMonths    int
+}
+
+// newStopTracker 创建终止条件跟踪器
+func newStopTracker(conditions types.StopConditions) *stopTracker {
+	return &stopTracker{conditions: conditions}
+}
+
+// state 返回跟踪状态 (保存断点用)
+func (t *stopTracker) state() types.StopState {
+	return types.StopState{
+		PeakValue:       t.peakValue,
+		MonthKey:        t.monthKey,
+		MonthStartValue: t.monthStartValue,
+		LastValue:       t.lastValue,
+		LosingMonths:    t.losingMonths,
+	}
+}
+
+// restore 按断点恢复跟踪状态
+func (t *stopTracker) restore(s types.StopState) {
+	t.peakValue = s.PeakValue
+	t.monthKey = s.MonthKey
+	t.monthStartValue = s.MonthStartValue
+	t.lastValue = s.LastValue
+	t.losingMonths = s.LosingMonths
+}
+
+// check 根据当日快照检查是否需要终止，返回终止原因
+func (t *stopTracker) check(snapshot types.PortfolioSnapshot) (string, bool) {
+	value := snapshot.TotalValue
+	key := snapshot.Timestamp.Year()*12 + int(snapshot.Timestamp.Month())
+
+	// 月份切换时结算上一个月的盈亏
+	if t.monthKey == 0 {
+		t.monthKey = key
+		t.monthStartValue = value
+	} else if key != t.monthKey {
+		if t.lastValue < t.monthStartValue {
+			t.losingMonths++
+		} else {
+			t.losingMonths = 0
+		}
+		t.monthKey = key
+		t.monthStartValue = t.lastValue
+	}
+	t.lastValue = value
+
+	if value > t.peakValue {
+		t.peakValue = value
+	}
+
+	c := t.conditions
+	if c.MaxDrawdown > 0 && t.peakValue > 0 {
+		drawdown := (t.peakValue - value) / t.peakValue
+		if drawdown > c.MaxDrawdown {
+			return fmt.Sprintf("drawdown %.2f%% exceeds limit %.2f%%", drawdown*100, c.MaxDrawdown*100), true
+		}
+	}
+	if c.ValueFloor > 0 && value < c.ValueFloor {
+		return fmt.Sprintf("portfolio value %.2f below floor %.2f", value, c.ValueFloor), true
+	}
+	if c.MaxLosingMonths > 0 && t.losingMonths >= c.MaxLosingMonths {
+		return fmt.Sprintf("%d consecutive losing months", t.losingMonths), true
+	}
+	return "", false
+}
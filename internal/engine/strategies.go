@@ -0,0 +1,126 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// StrategyRun 多策略对比中的一个策略 (共用配置中的回测、标的和成本)
+type StrategyRun struct {
+	Name   string
+	Type   string
+	Sleeve *Sleeve
+}
+
+// StrategyComparison 一个策略的对比指标
+type StrategyComparison struct {
+	Name         string
+	Type         string
+	FinalValue   float64
+	TotalReturn  float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+	Volatility   float64
+	Sharpe       float64 // 无风险利率为0
+	TotalTrades  int
+	TotalFees    float64
+	Rank         int                   // 按期末价值的名次
+	Result       *types.BacktestResult `json:"-"`
+}
+
+// NewStrategyRunsFromConfig 为配置中 strategies 段的每个策略创建一次回测
+func NewStrategyRunsFromConfig(cfg *config.Config) ([]*StrategyRun, error) {
+	if len(cfg.Strategies) == 0 {
+		return nil, fmt.Errorf("no strategies configured")
+	}
+	runs := make([]*StrategyRun, 0, len(cfg.Strategies))
+	names := make(map[string]bool)
+	for i := range cfg.Strategies {
+		vc := cfg.StrategyVariant(i)
+		name := vc.Strategy.Name
+		if names[name] {
+			return nil, fmt.Errorf("duplicate strategy name: %s", name)
+		}
+		names[name] = true
+		sleeve, err := NewSleeve(name, vc)
+		if err != nil {
+			return nil, fmt.Errorf("strategy %s: %w", name, err)
+		}
+		runs = append(runs, &StrategyRun{Name: name, Type: vc.Strategy.Type, Sleeve: sleeve})
+	}
+	return runs, nil
+}
+
+// CompareStrategies 依次运行各策略并汇总对比指标，名次按期末价值排列
+func CompareStrategies(runs []*StrategyRun) ([]StrategyComparison, error) {
+	results := make([]StrategyComparison, 0, len(runs))
+	for _, run := range runs {
+		fmt.Printf("Running strategy: %s (%s)\n", run.Name, run.Type)
+		result, err := run.Sleeve.Engine.Run()
+		if err != nil {
+			return nil, fmt.Errorf("strategy %s failed: %w", run.Name, err)
+		}
+		metrics := store.Metrics(result)
+		results = append(results, StrategyComparison{
+			Name:         run.Name,
+			Type:         run.Type,
+			FinalValue:   result.FinalValue,
+			TotalReturn:  result.TotalReturn,
+			AnnualReturn: metrics["annual_return"],
+			MaxDrawdown:  metrics["max_drawdown"],
+			Volatility:   metrics["volatility"],
+			Sharpe:       metrics["sharpe"],
+			TotalTrades:  result.TotalTrades,
+			TotalFees:    result.TotalFees,
+			Result:       result,
+		})
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return results[order[a]].FinalValue > results[order[b]].FinalValue
+	})
+	for rank, i := range order {
+		results[i].Rank = rank + 1
+	}
+	return results, nil
+}
+
+// PrintStrategyComparison 打印多策略对比
+func PrintStrategyComparison(results []StrategyComparison) {
+	fmt.Println("\n========== Strategy Comparison ==========")
+	fmt.Printf("%-4s %-24s %14s %9s %9s %9s %7s %7s %10s\n",
+		"Rank", "Strategy", "Final", "Return", "Annual", "MaxDD", "Sharpe", "Trades", "Fees")
+	for _, r := range results {
+		fmt.Printf("%-4d %-24s %14.2f %8.2f%% %8.2f%% %8.2f%% %7.2f %7d %10.2f\n",
+			r.Rank, r.Name, r.FinalValue, r.TotalReturn*100, r.AnnualReturn*100, r.MaxDrawdown*100,
+			r.Sharpe, r.TotalTrades, r.TotalFees)
+	}
+	fmt.Println("=========================================")
+}
+
+// ExportStrategyComparison 导出多策略对比
+func ExportStrategyComparison(results []StrategyComparison, filepath string) error {
+	data, err := json.MarshalIndent(struct {
+		Strategies []StrategyComparison `json:"strategies"`
+	}{results}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Strategy comparison exported to: %s\n", filepath)
+	return nil
+}
@@ -0,0 +1,178 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// applyShocks 对已加载的价格数据施加配置的合成冲击，记录每个冲击实际的首末交易日
+func (e *BacktestEngine) applyShocks() error {
+	for _, shock := range e.config.Shocks {
+		event := types.ShockEvent{Name: shock.Name, Return: shock.Return}
+		for _, symbol := range shock.Symbols {
+			first, last, ok := e.dataLoader.ApplyShock(symbol, shock.Start, shock.Days, shock.Return)
+			if !ok {
+				return fmt.Errorf("shock %s: no data for %s on or after %s", shock.Name, symbol, shock.Start.Format("2006-01-02"))
+			}
+			event.Symbols = append(event.Symbols, symbol)
+			if event.Start.IsZero() || first.Before(event.Start) {
+				event.Start = first
+			}
+			if last.After(event.End) {
+				event.End = last
+			}
+		}
+		e.shockEvents = append(e.shockEvents, event)
+		e.logf("Applied shock %s: %+.1f%% on %v from %s to %s\n", shock.Name, shock.Return*100, event.Symbols,
+			event.Start.Format("2006-01-02"), event.End.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// ShockResponse 组合在一个冲击期间及之后一个月的表现 (与未施加冲击的基线对比)
+type ShockResponse struct {
+	Name           string
+	Symbols        []string
+	Start          time.Time
+	End            time.Time
+	Return         float64 // 冲击幅度
+	PortfolioMove  float64 // 冲击期间组合的收益 (冲击前一交易日至冲击最后一日)
+	BaselineMove   float64 // 基线同期收益
+	Trades         int     // 冲击开始至结束后一个月内的成交笔数
+	BaselineTrades int
+}
+
+// StressReport 压力测试结果
+type StressReport struct {
+	Baseline  map[string]float64 // 基线的主要指标
+	Shocked   map[string]float64 // 施加冲击后的主要指标
+	Responses []ShockResponse
+}
+
+// RunStress 以配置中的策略分别运行不施加冲击的基线和施加全部冲击的回测，
+// 报告冲击期间组合的收益、之后的交易响应和整体指标的变化
+func RunStress(cfg *config.Config) (*StressReport, error) {
+	if len(cfg.Backtest.Shocks) == 0 {
+		return nil, fmt.Errorf("no shocks configured (backtest.shocks)")
+	}
+
+	bc := *cfg
+	bc.Backtest.Shocks = nil
+	fmt.Println("Running baseline")
+	baseline, err := runStressed(&bc)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	fmt.Println("Running with shocks")
+	shocked, err := runStressed(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("shocked: %w", err)
+	}
+
+	report := &StressReport{
+		Baseline: store.Metrics(baseline),
+		Shocked:  store.Metrics(shocked),
+	}
+	for _, event := range shocked.Shocks {
+		responseEnd := event.End.AddDate(0, 1, 0)
+		report.Responses = append(report.Responses, ShockResponse{
+			Name:           event.Name,
+			Symbols:        event.Symbols,
+			Start:          event.Start,
+			End:            event.End,
+			Return:         event.Return,
+			PortfolioMove:  windowReturn(shocked.Snapshots, event.Start, event.End),
+			BaselineMove:   windowReturn(baseline.Snapshots, event.Start, event.End),
+			Trades:         tradesBetween(shocked.Trades, event.Start, responseEnd),
+			BaselineTrades: tradesBetween(baseline.Trades, event.Start, responseEnd),
+		})
+	}
+	return report, nil
+}
+
+// runStressed 运行一次回测 (不输出运行日志)
+func runStressed(cfg *config.Config) (*types.BacktestResult, error) {
+	sleeve, err := NewSleeve("stress", cfg)
+	if err != nil {
+		return nil, err
+	}
+	sleeve.Engine.SetLogOutput(ioutil.Discard)
+	return sleeve.Engine.Run()
+}
+
+// windowReturn 组合从 start 前一交易日收盘至 end 收盘的收益 (start 为首个交易日时以当日为起点)
+func windowReturn(snapshots []types.PortfolioSnapshot, start, end time.Time) float64 {
+	var from, to float64
+	for i, s := range snapshots {
+		if from == 0 && !s.Timestamp.Before(start) {
+			if i > 0 {
+				from = snapshots[i-1].TotalValue
+			} else {
+				from = s.TotalValue
+			}
+		}
+		if !s.Timestamp.After(end) {
+			to = s.TotalValue
+		}
+	}
+	if from <= 0 {
+		return 0
+	}
+	return to/from - 1
+}
+
+// tradesBetween 统计 [start, end] 内的成交笔数
+func tradesBetween(trades []types.Trade, start, end time.Time) int {
+	n := 0
+	for _, trade := range trades {
+		if !trade.Timestamp.Before(start) && !trade.Timestamp.After(end) {
+			n++
+		}
+	}
+	return n
+}
+
+// PrintStressReport 打印压力测试结果
+func PrintStressReport(report *StressReport) {
+	fmt.Println("\n========== Stress Test ==========")
+	fmt.Printf("%-20s %-10s %-10s %9s %10s %10s %13s\n",
+		"Shock", "Start", "End", "Shock", "Portfolio", "Baseline", "Trades (1m)")
+	for _, r := range report.Responses {
+		fmt.Printf("%-20s %-10s %-10s %8.2f%% %9.2f%% %9.2f%% %6d vs %3d\n",
+			r.Name, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), r.Return*100,
+			r.PortfolioMove*100, r.BaselineMove*100, r.Trades, r.BaselineTrades)
+	}
+	fmt.Println()
+	fmt.Printf("%-14s %14s %14s\n", "", "Baseline", "Shocked")
+	fmt.Printf("%-14s %14.2f %14.2f\n", "Final Value", report.Baseline["final_value"], report.Shocked["final_value"])
+	for _, m := range []struct{ label, key string }{
+		{"Total Return", "total_return"},
+		{"Annual Return", "annual_return"},
+		{"Max Drawdown", "max_drawdown"},
+	} {
+		fmt.Printf("%-14s %13.2f%% %13.2f%%\n", m.label, report.Baseline[m.key]*100, report.Shocked[m.key]*100)
+	}
+	fmt.Printf("%-14s %14.0f %14.0f\n", "Trades", report.Baseline["total_trades"], report.Shocked["total_trades"])
+	fmt.Println("=================================")
+}
+
+// ExportStressReport 导出压力测试结果
+func ExportStressReport(report *StressReport, filepath string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Stress test exported to: %s\n", filepath)
+	return nil
+}
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// applyTrend 策略启用均线过滤时调整目标权重并记录调整
+// 价格与均线均使用数据源的原始币种价格，避免汇率换算影响比较
+func (e *BacktestEngine) applyTrend(pf *types.Portfolio, targetWeights map[string]float64, date time.Time) map[string]float64 {
+	filter, ok := e.strategy.(strategy.TrendFilterer)
+	if !ok || filter.TrendWindow() <= 0 {
+		return targetWeights
+	}
+
+	prices := e.dataLoader.GetPricesOnDate(date)
+	ma := e.dataLoader.MovingAverages(date, filter.TrendWindow())
+	adjusted := filter.ApplyTrend(pf, targetWeights, prices, ma)
+
+	symbols := make([]string, 0, len(adjusted))
+	for symbol := range adjusted {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		if math.Abs(adjusted[symbol]-targetWeights[symbol]) < 1e-9 {
+			continue
+		}
+		e.trendAdjustments = append(e.trendAdjustments, types.TrendAdjustment{
+			Timestamp: date,
+			Symbol:    symbol,
+			Price:     prices[symbol],
+			MA:        ma[symbol],
+			Target:    targetWeights[symbol],
+			Adjusted:  adjusted[symbol],
+		})
+	}
+	return adjusted
+}
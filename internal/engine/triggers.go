@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/strategy"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// rebalanceTrigger 本次再平衡的触发类型
+func (e *BacktestEngine) rebalanceTrigger(firstBuild bool) types.RebalanceTrigger {
+	if firstBuild {
+		return types.TriggerInitial
+	}
+	if reporter, ok := e.strategy.(strategy.TriggerReporter); ok {
+		return reporter.Trigger()
+	}
+	return types.TriggerOther
+}
+
+// tagTrigger 为订单标记触发类型
+func tagTrigger(orders []types.Order, trigger types.RebalanceTrigger) []types.Order {
+	for i := range orders {
+		orders[i].Trigger = trigger
+	}
+	return orders
+}
+
+// triggerStats 按触发类型汇总交易，贡献按成交后持有至期末价格计算
+func (e *BacktestEngine) triggerStats(trades []types.Trade, finalPrices map[string]float64) []types.TriggerStat {
+	stats := make(map[types.RebalanceTrigger]*types.TriggerStat)
+	get := func(trigger types.RebalanceTrigger) *types.TriggerStat {
+		if trigger == "" {
+			trigger = types.TriggerOther
+		}
+		stat, ok := stats[trigger]
+		if !ok {
+			stat = &types.TriggerStat{Trigger: trigger}
+			stats[trigger] = stat
+		}
+		return stat
+	}
+
+	for trigger, n := range e.rebalanceCounts {
+		get(trigger).Rebalances = n
+	}
+
+	for _, trade := range trades {
+		stat := get(trade.Trigger)
+		stat.Trades++
+		stat.Turnover += trade.Value
+		stat.Fees += trade.Fee
+
+		if price, ok := finalPrices[trade.Symbol]; ok {
+			gain := trade.Quantity * (price - trade.Price)
+			if trade.Side != "BUY" {
+				gain = -gain
+			}
+			stat.GrossContribution += gain
+		}
+	}
+
+	years := e.config.EndDate.Sub(e.config.StartDate).Hours() / 24 / 365
+	if len(e.snapshots) > 1 {
+		years = e.snapshots[len(e.snapshots)-1].Timestamp.Sub(e.snapshots[0].Timestamp).Hours() / 24 / 365
+	}
+
+	result := make([]types.TriggerStat, 0, len(stats))
+	for _, stat := range stats {
+		stat.NetContribution = stat.GrossContribution - stat.Fees
+		if years > 0 && e.config.InitialCapital > 0 {
+			stat.AnnualContribution = stat.NetContribution / e.config.InitialCapital / years
+		}
+		result = append(result, *stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Trigger < result[j].Trigger
+	})
+	return result
+}
+
+// printTriggerStats 打印按触发类型汇总的统计
+func (e *BacktestEngine) printTriggerStats() {
+	if len(e.result.TriggerStats) == 0 {
+		return
+	}
+	fmt.Println("By Trigger:")
+	for _, stat := range e.result.TriggerStats {
+		fmt.Printf("  %-11s rebalances %4d, trades %5d, fees $%.2f, net contribution $%.2f (%+.2f%%/yr)\n",
+			stat.Trigger, stat.Rebalances, stat.Trades, stat.Fees,
+			stat.NetContribution, stat.AnnualContribution*100)
+	}
+}
@@ -0,0 +1,96 @@
+// Package indicators 技术指标
+// 指标按标的的完整价格历史计算 (与价格序列逐日对齐)，首次使用时计算并缓存
+package indicators
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Kind 指标类型
+type Kind string
+
+const (
+	Price      Kind = "price"      // 复权收盘价 (不使用窗口)
+	SMA        Kind = "sma"        // 简单移动平均
+	EMA        Kind = "ema"        // 指数移动平均
+	RSI        Kind = "rsi"        // 相对强弱指数 (0-100，Wilder平滑)
+	ATR        Kind = "atr"        // 平均真实波幅 (Wilder平滑)
+	Volatility Kind = "volatility" // 日收益率滚动标准差 (年化)
+	Drawdown   Kind = "drawdown"   // 相对窗口内最高价的回撤比例，窗口为0表示相对历史最高价
+)
+
+// Compute 计算完整序列，数据不足的位置为 NaN；未知指标或窗口无效时返回nil
+func Compute(kind Kind, data []types.PriceData, window int) []float64 {
+	if window <= 0 && kind != Drawdown && kind != Price {
+		return nil
+	}
+	switch kind {
+	case Price:
+		return price(data)
+	case SMA:
+		return sma(data, window)
+	case EMA:
+		return ema(data, window)
+	case RSI:
+		return rsi(data, window)
+	case ATR:
+		return atr(data, window)
+	case Volatility:
+		return volatility(data, window)
+	case Drawdown:
+		return drawdown(data, window)
+	}
+	return nil
+}
+
+// seriesKey 缓存键
+type seriesKey struct {
+	symbol string
+	kind   Kind
+	window int
+}
+
+// Cache 按标的、指标和窗口缓存的指标序列
+type Cache struct {
+	history map[string][]types.PriceData
+	series  map[seriesKey][]float64
+}
+
+// NewCache 基于各标的按日期升序的价格历史创建指标缓存
+func NewCache(history map[string][]types.PriceData) *Cache {
+	return &Cache{
+		history: history,
+		series:  make(map[seriesKey][]float64),
+	}
+}
+
+// Series 返回与价格历史对齐的指标序列 (首次使用时计算)
+func (c *Cache) Series(symbol string, kind Kind, window int) []float64 {
+	key := seriesKey{symbol: symbol, kind: kind, window: window}
+	if values, ok := c.series[key]; ok {
+		return values
+	}
+	values := Compute(kind, c.history[symbol], window)
+	c.series[key] = values
+	return values
+}
+
+// At 返回标的截至 date (含) 最近一个交易日的指标值，数据不足时返回 false
+func (c *Cache) At(symbol string, kind Kind, window int, date time.Time) (float64, bool) {
+	data := c.history[symbol]
+	idx := sort.Search(len(data), func(i int) bool {
+		return data[i].Timestamp.After(date)
+	}) - 1
+	if idx < 0 {
+		return 0, false
+	}
+	values := c.Series(symbol, kind, window)
+	if idx >= len(values) || math.IsNaN(values[idx]) {
+		return 0, false
+	}
+	return values[idx], true
+}
@@ -0,0 +1,177 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// tradingDaysPerYear 年化波动率使用的年交易日数
+const tradingDaysPerYear = 252
+
+// newSeries 创建全部为 NaN 的序列
+func newSeries(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = math.NaN()
+	}
+	return values
+}
+
+// price 复权收盘价
+func price(data []types.PriceData) []float64 {
+	values := make([]float64, len(data))
+	for i := range data {
+		values[i] = data[i].AdjClose
+	}
+	return values
+}
+
+// sma 复权收盘价的简单移动平均
+func sma(data []types.PriceData, window int) []float64 {
+	values := newSeries(len(data))
+	sum := 0.0
+	for i := range data {
+		sum += data[i].AdjClose
+		if i >= window {
+			sum -= data[i-window].AdjClose
+		}
+		if i >= window-1 {
+			values[i] = sum / float64(window)
+		}
+	}
+	return values
+}
+
+// ema 复权收盘价的指数移动平均，以前 window 日的简单平均为初值
+func ema(data []types.PriceData, window int) []float64 {
+	values := newSeries(len(data))
+	if len(data) < window {
+		return values
+	}
+	alpha := 2 / float64(window+1)
+	sum := 0.0
+	for i := 0; i < window; i++ {
+		sum += data[i].AdjClose
+	}
+	prev := sum / float64(window)
+	values[window-1] = prev
+	for i := window; i < len(data); i++ {
+		prev = alpha*data[i].AdjClose + (1-alpha)*prev
+		values[i] = prev
+	}
+	return values
+}
+
+// rsi 相对强弱指数，涨跌幅按 Wilder 方法平滑
+func rsi(data []types.PriceData, window int) []float64 {
+	values := newSeries(len(data))
+	if len(data) <= window {
+		return values
+	}
+	gain, loss := 0.0, 0.0
+	for i := 1; i < len(data); i++ {
+		change := data[i].AdjClose - data[i-1].AdjClose
+		up, down := math.Max(change, 0), math.Max(-change, 0)
+		if i <= window {
+			gain += up / float64(window)
+			loss += down / float64(window)
+			if i < window {
+				continue
+			}
+		} else {
+			gain = (gain*float64(window-1) + up) / float64(window)
+			loss = (loss*float64(window-1) + down) / float64(window)
+		}
+		if loss == 0 {
+			values[i] = 100
+		} else {
+			values[i] = 100 - 100/(1+gain/loss)
+		}
+	}
+	return values
+}
+
+// atr 平均真实波幅，最高/最低价按复权因子调整 (缺少最高/最低价时以收盘价代替)
+func atr(data []types.PriceData, window int) []float64 {
+	values := newSeries(len(data))
+	if len(data) <= window {
+		return values
+	}
+	avg := 0.0
+	for i := 1; i < len(data); i++ {
+		high, low := adjustedRange(data[i])
+		prevClose := data[i-1].AdjClose
+		tr := math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+		if i <= window {
+			avg += tr / float64(window)
+			if i < window {
+				continue
+			}
+		} else {
+			avg = (avg*float64(window-1) + tr) / float64(window)
+		}
+		values[i] = avg
+	}
+	return values
+}
+
+// adjustedRange 按复权因子调整后的最高价和最低价
+func adjustedRange(p types.PriceData) (float64, float64) {
+	if p.Close <= 0 || p.High <= 0 || p.Low <= 0 {
+		return p.AdjClose, p.AdjClose
+	}
+	factor := p.AdjClose / p.Close
+	return p.High * factor, p.Low * factor
+}
+
+// volatility 最近 window 个日收益率的标准差 (年化)
+func volatility(data []types.PriceData, window int) []float64 {
+	values := newSeries(len(data))
+	returns := make([]float64, len(data))
+	for i := 1; i < len(data); i++ {
+		if prev := data[i-1].AdjClose; prev > 0 {
+			returns[i] = data[i].AdjClose/prev - 1
+		}
+	}
+	for i := window; i < len(data); i++ {
+		mean := 0.0
+		for _, r := range returns[i-window+1 : i+1] {
+			mean += r
+		}
+		mean /= float64(window)
+		variance := 0.0
+		for _, r := range returns[i-window+1 : i+1] {
+			variance += (r - mean) * (r - mean)
+		}
+		if window > 1 {
+			variance /= float64(window - 1)
+		}
+		values[i] = math.Sqrt(variance * tradingDaysPerYear)
+	}
+	return values
+}
+
+// drawdown 相对最近 window 日 (0表示全部历史) 最高复权收盘价的回撤比例
+func drawdown(data []types.PriceData, window int) []float64 {
+	values := newSeries(len(data))
+	peak := 0.0
+	for i := range data {
+		if window > 0 {
+			peak = 0
+			start := i - window + 1
+			if start < 0 {
+				start = 0
+			}
+			for _, p := range data[start : i+1] {
+				peak = math.Max(peak, p.AdjClose)
+			}
+		} else {
+			peak = math.Max(peak, data[i].AdjClose)
+		}
+		if peak > 0 {
+			values[i] = (peak - data[i].AdjClose) / peak
+		}
+	}
+	return values
+}
@@ -0,0 +1,151 @@
+// Package metrics 以 Prometheus 文本格式导出运行指标 (计数器、仪表盘)，供 daemon 模式的 /metrics 使用
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 指标类型
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Labels 指标标签
+type Labels map[string]string
+
+// family 同名指标的全部序列
+type family struct {
+	kind   string
+	help   string
+	series map[string]float64 // 渲染后的标签 -> 值
+	fn     func() float64     // 抓取时计算的仪表盘 (无标签)
+}
+
+// Registry 指标注册表，并发安全
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Register 声明指标的类型和说明
+func (r *Registry) Register(name, kind, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.family(name)
+	f.kind, f.help = kind, help
+}
+
+// GaugeFunc 注册抓取时计算的仪表盘
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := r.family(name)
+	f.kind, f.help, f.fn = Gauge, help, fn
+}
+
+// Add 计数器增加 delta
+func (r *Registry) Add(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name).series[render(labels)] += delta
+}
+
+// Set 设置仪表盘的值
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name).series[render(labels)] = value
+}
+
+// Reset 清除指标的全部序列 (如持仓标的变化后重新设置各标的的值)
+func (r *Registry) Reset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name).series = make(map[string]float64)
+}
+
+// family 返回指定名称的指标，不存在时创建 (调用方持有锁)
+func (r *Registry) family(name string) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{kind: Gauge, series: make(map[string]float64)}
+		r.families[name] = f
+	}
+	return f
+}
+
+// ServeHTTP 输出 Prometheus 文本格式
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, r.Text())
+}
+
+// Text 按名称排序的 Prometheus 文本格式
+func (r *Registry) Text() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		if f.fn == nil && len(f.series) == 0 {
+			continue
+		}
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, f.help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.kind)
+		if f.fn != nil {
+			fmt.Fprintf(&b, "%s %s\n", name, formatValue(f.fn()))
+			continue
+		}
+		keys := make([]string, 0, len(f.series))
+		for key := range f.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s%s %s\n", name, key, formatValue(f.series[key]))
+		}
+	}
+	return b.String()
+}
+
+// render 按标签名排序渲染为 {a="1",b="2"}
+func render(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + strconv.Quote(labels[key])
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// formatValue 格式化指标值
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
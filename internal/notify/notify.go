@@ -0,0 +1,186 @@
+// Package notify 再平衡提醒和回测完成的通知渠道 (邮件、Webhook、Server酱、Telegram、企业微信)
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Notifier 通知渠道
+type Notifier interface {
+	// Notify 发送通知，body 为纯文本
+	Notify(title, body string) error
+}
+
+// httpClient 通知请求使用的HTTP客户端
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Webhook 以JSON ({"title": ..., "text": ...}) POST 到指定地址
+type Webhook struct {
+	URL string
+}
+
+// Notify 发送通知
+func (w *Webhook) Notify(title, body string) error {
+	payload, err := json.Marshal(map[string]string{"title": title, "text": body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	resp, err := httpClient.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	return checkResponse("webhook", resp)
+}
+
+// ServerChan Server酱推送 (正文按Markdown渲染，信号表放在代码块中保持对齐)
+type ServerChan struct {
+	Key string // SendKey
+}
+
+// serverChanURL Server酱推送地址
+const serverChanURL = "https://sctapi.ftqq.com/%s.send"
+
+// Notify 发送通知
+func (s *ServerChan) Notify(title, body string) error {
+	form := url.Values{}
+	form.Set("title", title)
+	form.Set("desp", "```\n"+body+"\n```")
+	resp, err := httpClient.PostForm(fmt.Sprintf(serverChanURL, s.Key), form)
+	if err != nil {
+		return fmt.Errorf("serverchan request failed: %w", err)
+	}
+	return checkResponse("serverchan", resp)
+}
+
+// Telegram 通过 Telegram 机器人发送消息
+type Telegram struct {
+	Token  string
+	ChatID string
+}
+
+// telegramURL Telegram 机器人发送消息的地址
+const telegramURL = "https://api.telegram.org/bot%s/sendMessage"
+
+// Notify 发送通知
+func (t *Telegram) Notify(title, body string) error {
+	payload, err := json.Marshal(map[string]string{"chat_id": t.ChatID, "text": title + "\n\n" + body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram payload: %w", err)
+	}
+	resp, err := httpClient.Post(fmt.Sprintf(telegramURL, t.Token), "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("telegram request failed: %w", err)
+	}
+	return checkResponse("telegram", resp)
+}
+
+// WeCom 企业微信群机器人 (Webhook 地址含 key)
+type WeCom struct {
+	URL string
+}
+
+// Notify 发送通知，企业微信在响应体的 errcode 中返回错误
+func (w *WeCom) Notify(title, body string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": title + "\n\n" + body},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal wecom payload: %w", err)
+	}
+	resp, err := httpClient.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("wecom request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("wecom returned %s: %w", resp.Status, err)
+	}
+	if result.ErrCode != 0 {
+		return fmt.Errorf("wecom returned error %d: %s", result.ErrCode, result.ErrMsg)
+	}
+	return nil
+}
+
+// checkResponse 检查HTTP响应状态
+func checkResponse(channel string, resp *http.Response) error {
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned %s: %s", channel, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// Email 通过SMTP发送邮件 (用户名非空时使用 PLAIN 认证)
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Notify 发送通知
+func (e *Email) Notify(title, body string) error {
+	if len(e.To) == 0 {
+		return fmt.Errorf("email has no recipients")
+	}
+	port := e.Port
+	if port == 0 {
+		port = 587
+	}
+	from := e.From
+	if from == "" {
+		from = e.Username
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("utf-8", title))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	if err := smtp.SendMail(fmt.Sprintf("%s:%d", e.Host, port), auth, from, e.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// Multi 依次发送到多个渠道，某个渠道失败不影响其他渠道
+type Multi []Notifier
+
+// Notify 发送通知，返回各渠道错误的汇总
+func (m Multi) Notify(title, body string) error {
+	var failed []string
+	for _, n := range m {
+		if err := n.Notify(title, body); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("notification failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
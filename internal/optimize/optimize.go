@@ -0,0 +1,512 @@
+// Package optimize 用遗传算法搜索策略参数。参数个数较多时网格扫描的回测次数按维度指数增长，
+// 遗传算法在给定的种群规模和代数内只需 种群 × 代数 次回测，适合同时调整5个以上的估值参数
+package optimize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/engine"
+	"github.com/opsxjacky/Rebalance-backtest/internal/rng"
+	"github.com/opsxjacky/Rebalance-backtest/internal/store"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/split"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Objective 优化目标
+type Objective string
+
+const (
+	ObjectiveSharpe         Objective = "sharpe"          // 夏普比率 (无风险利率为0)
+	ObjectiveCalmar         Objective = "calmar"          // 年化收益 / 最大回撤
+	ObjectiveReturnTurnover Objective = "return_turnover" // 年化收益 / 年化换手率
+	ObjectiveConfig         Objective = "config"          // 配置 optimize.objective 中各指标的加权和
+)
+
+// minTurnover return_turnover 目标中年化换手率的下限，避免几乎不交易的参数得到无穷大的得分
+const minTurnover = 0.01
+
+// defaultPenalty 每单位约束超出量的默认扣分
+const defaultPenalty = 10
+
+// ParseObjective 解析优化目标名称
+func ParseObjective(name string) (Objective, error) {
+	switch o := Objective(strings.ToLower(name)); o {
+	case ObjectiveSharpe, ObjectiveCalmar, ObjectiveReturnTurnover, ObjectiveConfig:
+		return o, nil
+	}
+	return "", fmt.Errorf("unknown objective %q (expected sharpe, calmar, return_turnover or config)", name)
+}
+
+// Param 待优化的参数：配置项路径 (同命令行覆盖项) 和取值范围，Integer 时取整
+type Param struct {
+	Key     string
+	Min     float64
+	Max     float64
+	Integer bool
+}
+
+// ParseParam 解析 key=min:max 形式的参数范围，上下限都写成整数 (不含小数点) 时按整数搜索
+func ParseParam(arg string) (Param, error) {
+	o, err := config.ParseOverride(arg)
+	if err != nil {
+		return Param{}, err
+	}
+	bounds := strings.Split(o.Value, ":")
+	if len(bounds) != 2 {
+		return Param{}, fmt.Errorf("invalid range for %s: expected min:max, got %q", o.Key, o.Value)
+	}
+	p := Param{Key: o.Key, Integer: true}
+	for i, b := range bounds {
+		b = strings.TrimSpace(b)
+		v, err := strconv.ParseFloat(b, 64)
+		if err != nil {
+			return Param{}, fmt.Errorf("invalid range for %s: %w", o.Key, err)
+		}
+		if _, err := strconv.Atoi(b); err != nil {
+			p.Integer = false
+		}
+		if i == 0 {
+			p.Min = v
+		} else {
+			p.Max = v
+		}
+	}
+	if p.Max <= p.Min {
+		return Param{}, fmt.Errorf("invalid range for %s: max %v must exceed min %v", o.Key, p.Max, p.Min)
+	}
+	return p, nil
+}
+
+// format 参数取值写入配置覆盖项的形式
+func (p Param) format(v float64) string {
+	if p.Integer {
+		return strconv.Itoa(int(v))
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// clamp 将取值限制在范围内，整数参数四舍五入，其他参数保留4位小数
+func (p Param) clamp(v float64) float64 {
+	v = math.Max(p.Min, math.Min(p.Max, v))
+	if p.Integer {
+		return math.Round(v)
+	}
+	return math.Round(v*1e4) / 1e4
+}
+
+// Options 遗传算法设置
+type Options struct {
+	Objective   Objective
+	Population  int
+	Generations int
+	Seed        int64
+
+	// Formula 配置中的目标函数 (ObjectiveConfig 时使用) 和约束 (对所有目标生效)
+	Formula config.OptimizeSection
+
+	// Folds 交叉验证划分 (见 pkg/split)：设置时每组取值在全部训练区间上分别回测，得分和指标取均值，
+	// 搜索结束后在各测试区间上回测最优取值；未设置时在完整回测区间上搜索
+	Folds []split.Fold
+}
+
+// Candidate 一组参数取值及其回测指标 (交叉验证时为各训练区间的均值)，Feasible 为 false 表示加载配置或回测失败
+type Candidate struct {
+	Values       map[string]float64
+	Score        float64 // 目标值减去约束扣分
+	Penalty      float64 // 约束扣分
+	Feasible     bool
+	FinalValue   float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+	Volatility   float64
+	Sharpe       float64
+	Calmar       float64
+	Turnover     float64 // 年化换手率 (不含首次建仓)
+	genes        []float64
+	overrides    []config.Override
+}
+
+// Generation 一代种群的得分
+type Generation struct {
+	Index      int
+	Best       float64
+	Mean       float64 // 可行个体的平均得分
+	Infeasible int
+	BestValues map[string]float64
+}
+
+// FoldScore 最优取值在一个交叉验证测试区间上的结果
+type FoldScore struct {
+	Test         split.Range
+	Score        float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+	Sharpe       float64
+}
+
+// Result 优化结果
+type Result struct {
+	Objective   Objective
+	Params      []Param
+	Population  int
+	Generations []Generation
+	Evaluations int // 实际评估的取值组数 (相同取值只评估一次)
+	Best        Candidate
+
+	Validation []FoldScore // 各测试区间的样本外结果 (设置交叉验证时)
+	TestScore  float64     // 测试区间得分的均值
+}
+
+// Loader 按追加的覆盖项重新加载配置
+type Loader func(...config.Override) (*config.Config, error)
+
+// optimizer 一次优化的状态
+type optimizer struct {
+	load    Loader
+	params  []Param
+	opts    Options
+	rand    *rand.Rand
+	cache   map[string]Candidate
+	lastErr error
+}
+
+// Run 用遗传算法搜索参数：初始种群在范围内均匀抽样，每代保留得分最高的10% (至少1个)，
+// 其余个体由锦标赛选择的两个父代按混合交叉生成，并以 1/参数个数 的概率对每个参数做高斯变异 (标准差为范围的10%)
+func Run(load Loader, params []Param, opts Options) (*Result, error) {
+	if len(params) == 0 {
+		return nil, fmt.Errorf("no parameters to optimize")
+	}
+	if opts.Population < 2 {
+		return nil, fmt.Errorf("population must be at least 2, got %d", opts.Population)
+	}
+	if opts.Generations < 1 {
+		return nil, fmt.Errorf("generations must be positive, got %d", opts.Generations)
+	}
+	if opts.Objective == ObjectiveConfig && len(opts.Formula.Objective) == 0 {
+		return nil, fmt.Errorf("objective config requires optimize.objective in the config file")
+	}
+	if opts.Formula.Penalty == 0 {
+		opts.Formula.Penalty = defaultPenalty
+	}
+	o := &optimizer{
+		load:   load,
+		params: params,
+		opts:   opts,
+		rand:   rng.New(opts.Seed, "optimize"),
+		cache:  make(map[string]Candidate),
+	}
+
+	population := make([][]float64, opts.Population)
+	for i := range population {
+		genes := make([]float64, len(params))
+		for j, p := range params {
+			genes[j] = p.clamp(p.Min + o.rand.Float64()*(p.Max-p.Min))
+		}
+		population[i] = genes
+	}
+
+	result := &Result{Objective: opts.Objective, Params: params, Population: opts.Population}
+	best := Candidate{}
+	for g := 1; g <= opts.Generations; g++ {
+		candidates := make([]Candidate, len(population))
+		for i, genes := range population {
+			candidates[i] = o.evaluate(genes)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return better(candidates[i], candidates[j]) })
+
+		gen := Generation{Index: g, Best: candidates[0].Score, BestValues: candidates[0].Values}
+		feasible := 0
+		for _, c := range candidates {
+			if !c.Feasible {
+				gen.Infeasible++
+				continue
+			}
+			gen.Mean += c.Score
+			feasible++
+		}
+		if feasible > 0 {
+			gen.Mean /= float64(feasible)
+		}
+		result.Generations = append(result.Generations, gen)
+		if better(candidates[0], best) {
+			best = candidates[0]
+		}
+		fmt.Printf("Generation %d/%d: best %.4f, mean %.4f, infeasible %d, overall best %.4f\n",
+			g, opts.Generations, gen.Best, gen.Mean, gen.Infeasible, best.Score)
+
+		if g < opts.Generations {
+			population = o.breed(candidates)
+		}
+	}
+
+	if !best.Feasible {
+		return nil, fmt.Errorf("every candidate failed, last error: %w", o.lastErr)
+	}
+	result.Evaluations = len(o.cache)
+	result.Best = best
+
+	for _, fold := range opts.Folds {
+		metrics, err := o.measure(best.overrides, &fold.Test)
+		if err != nil {
+			return nil, fmt.Errorf("test period %s: %w", fold.Test, err)
+		}
+		score, _ := o.score(metrics)
+		result.Validation = append(result.Validation, FoldScore{
+			Test:         fold.Test,
+			Score:        score,
+			AnnualReturn: metrics["annual_return"],
+			MaxDrawdown:  metrics["max_drawdown"],
+			Sharpe:       metrics["sharpe"],
+		})
+		result.TestScore += score / float64(len(opts.Folds))
+	}
+	return result, nil
+}
+
+// better 可行个体优先，其次按得分
+func better(a, b Candidate) bool {
+	if a.Feasible != b.Feasible {
+		return a.Feasible
+	}
+	return a.Score > b.Score
+}
+
+// breed 由按得分排序的上一代生成下一代
+func (o *optimizer) breed(ranked []Candidate) [][]float64 {
+	elites := len(ranked) / 10
+	if elites < 1 {
+		elites = 1
+	}
+	next := make([][]float64, 0, len(ranked))
+	for i := 0; i < elites; i++ {
+		next = append(next, ranked[i].genes)
+	}
+	for len(next) < len(ranked) {
+		a, b := o.tournament(ranked), o.tournament(ranked)
+		child := make([]float64, len(o.params))
+		for j, p := range o.params {
+			// 混合交叉：在两个父代之间 (两侧各外延25%) 均匀取值
+			u := o.rand.Float64()*1.5 - 0.25
+			v := a.genes[j] + u*(b.genes[j]-a.genes[j])
+			if o.rand.Float64() < 1/float64(len(o.params)) {
+				v += o.rand.NormFloat64() * 0.1 * (p.Max - p.Min)
+			}
+			child[j] = p.clamp(v)
+		}
+		next = append(next, child)
+	}
+	return next
+}
+
+// tournament 随机抽取3个个体，返回其中最优的一个
+func (o *optimizer) tournament(ranked []Candidate) Candidate {
+	best := ranked[o.rand.Intn(len(ranked))]
+	for i := 1; i < 3; i++ {
+		if c := ranked[o.rand.Intn(len(ranked))]; better(c, best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// evaluate 回测一组参数取值 (不输出运行日志)，相同取值只回测一次
+func (o *optimizer) evaluate(genes []float64) Candidate {
+	overrides := make([]config.Override, len(o.params))
+	values := make(map[string]float64, len(o.params))
+	keys := make([]string, len(o.params))
+	for i, p := range o.params {
+		overrides[i] = config.Override{Key: p.Key, Value: p.format(genes[i])}
+		values[p.Key] = genes[i]
+		keys[i] = overrides[i].String()
+	}
+	key := strings.Join(keys, ",")
+	if c, ok := o.cache[key]; ok {
+		return c
+	}
+
+	c := Candidate{Values: values, genes: genes, overrides: overrides}
+	if err := o.backtest(overrides, &c); err != nil {
+		o.lastErr = err
+		fmt.Printf("Candidate %s failed: %v\n", key, err)
+	} else {
+		c.Feasible = true
+	}
+	o.cache[key] = c
+	return c
+}
+
+// backtest 在完整区间或各训练区间上回测，按优化目标计算得分 (多个区间时得分和指标取均值)
+func (o *optimizer) backtest(overrides []config.Override, c *Candidate) error {
+	periods := []*split.Range{nil}
+	if len(o.opts.Folds) > 0 {
+		periods = periods[:0]
+		for i := range o.opts.Folds {
+			for j := range o.opts.Folds[i].Train {
+				periods = append(periods, &o.opts.Folds[i].Train[j])
+			}
+		}
+	}
+
+	mean := make(map[string]float64)
+	for _, period := range periods {
+		metrics, err := o.measure(overrides, period)
+		if err != nil {
+			if period != nil {
+				return fmt.Errorf("train period %s: %w", period, err)
+			}
+			return err
+		}
+		score, penalty := o.score(metrics)
+		metrics["score"], metrics["penalty"] = score, penalty
+		for key, v := range metrics {
+			mean[key] += v / float64(len(periods))
+		}
+	}
+
+	c.Score = mean["score"]
+	c.Penalty = mean["penalty"]
+	c.FinalValue = mean["final_value"]
+	c.AnnualReturn = mean["annual_return"]
+	c.MaxDrawdown = mean["max_drawdown"]
+	c.Volatility = mean["volatility"]
+	c.Sharpe = mean["sharpe"]
+	c.Calmar = mean["calmar"]
+	c.Turnover = mean["turnover"]
+	return nil
+}
+
+// measure 运行一次回测 (period 不为 nil 时只回测该区间)，返回 config.OptimizeMetrics 中的各项指标
+func (o *optimizer) measure(overrides []config.Override, period *split.Range) (map[string]float64, error) {
+	if period != nil {
+		overrides = append(append([]config.Override(nil), overrides...),
+			config.Override{Key: "backtest.start_date", Value: period.Start.Format("2006-01-02")},
+			config.Override{Key: "backtest.end_date", Value: period.End.Format("2006-01-02")})
+	}
+	cfg, err := o.load(overrides...)
+	if err != nil {
+		return nil, err
+	}
+	sleeve, err := engine.NewSleeve("optimize", cfg)
+	if err != nil {
+		return nil, err
+	}
+	sleeve.Engine.SetLogOutput(ioutil.Discard)
+	run, err := sleeve.Engine.Run()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := store.Metrics(run)
+	if metrics["max_drawdown"] > 0 {
+		metrics["calmar"] = metrics["annual_return"] / metrics["max_drawdown"]
+	}
+	metrics["turnover"] = annualTurnover(run)
+	return metrics, nil
+}
+
+// score 按优化目标计算得分 (已减去约束扣分) 和约束扣分
+func (o *optimizer) score(metrics map[string]float64) (float64, float64) {
+	score := 0.0
+	switch o.opts.Objective {
+	case ObjectiveCalmar:
+		score = metrics["calmar"]
+	case ObjectiveReturnTurnover:
+		score = metrics["annual_return"] / math.Max(metrics["turnover"], minTurnover)
+	case ObjectiveConfig:
+		for metric, weight := range o.opts.Formula.Objective {
+			score += weight * metrics[metric]
+		}
+	default:
+		score = metrics["sharpe"]
+	}
+	p := penalty(o.opts.Formula, metrics)
+	return score - p, p
+}
+
+// penalty 各约束超出量之和 × 每单位扣分
+func penalty(formula config.OptimizeSection, metrics map[string]float64) float64 {
+	excess := 0.0
+	for _, constraint := range formula.Constraints {
+		v := metrics[constraint.Metric]
+		if constraint.Min != nil && v < *constraint.Min {
+			excess += *constraint.Min - v
+		}
+		if constraint.Max != nil && v > *constraint.Max {
+			excess += v - *constraint.Max
+		}
+	}
+	return excess * formula.Penalty
+}
+
+// annualTurnover 年化换手率：首次建仓以外的成交金额合计 / 平均组合价值 / 年数
+func annualTurnover(result *types.BacktestResult) float64 {
+	snapshots := result.Snapshots
+	if len(snapshots) < 2 {
+		return 0
+	}
+	years := snapshots[len(snapshots)-1].Timestamp.Sub(snapshots[0].Timestamp).Hours() / 24 / 365.25
+	mean := 0.0
+	for _, s := range snapshots {
+		mean += s.TotalValue
+	}
+	mean /= float64(len(snapshots))
+	if years <= 0 || mean <= 0 {
+		return 0
+	}
+	traded := 0.0
+	for _, trade := range result.Trades {
+		if trade.Trigger != types.TriggerInitial {
+			traded += trade.Value
+		}
+	}
+	return traded / mean / years
+}
+
+// PrintResult 打印最优参数及其指标
+func PrintResult(result *Result) {
+	fmt.Println("\n========== Optimization ==========")
+	fmt.Printf("Objective: %s, population %d, generations %d, backtests %d\n",
+		result.Objective, result.Population, len(result.Generations), result.Evaluations)
+	best := result.Best
+	for _, p := range result.Params {
+		fmt.Printf("  %-44s %12s  [%v, %v]\n", p.Key, p.format(best.Values[p.Key]), p.Min, p.Max)
+	}
+	if best.Penalty > 0 {
+		fmt.Printf("Score: %.4f (constraint penalty %.4f)\n", best.Score, best.Penalty)
+	} else {
+		fmt.Printf("Score: %.4f\n", best.Score)
+	}
+	fmt.Printf("Final Value: $%.2f, annual %.2f%%, max drawdown %.2f%%, sharpe %.2f, calmar %.2f, turnover %.1f%%/year\n",
+		best.FinalValue, best.AnnualReturn*100, best.MaxDrawdown*100, best.Sharpe, best.Calmar, best.Turnover*100)
+	if len(result.Validation) > 0 {
+		fmt.Printf("Out-of-sample (mean score %.4f, in-sample %.4f):\n", result.TestScore, best.Score)
+		for _, f := range result.Validation {
+			fmt.Printf("  %s  score %8.4f, annual %7.2f%%, max drawdown %6.2f%%, sharpe %5.2f\n",
+				f.Test, f.Score, f.AnnualReturn*100, f.MaxDrawdown*100, f.Sharpe)
+		}
+	}
+	fmt.Println("==================================")
+}
+
+// ExportResult 导出优化结果
+func ExportResult(result *Result, filepath string) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+
+	if err := ioutil.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	fmt.Printf("Optimization exported to: %s\n", filepath)
+	return nil
+}
@@ -0,0 +1,131 @@
+package portfolio
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// fundLot 一笔场外基金申购确认的份额，用于按持有天数计算赎回费
+type fundLot struct {
+	date     time.Time
+	quantity float64
+}
+
+// receivable 在途的赎回款
+type receivable struct {
+	amount   float64
+	daysLeft int // 剩余到账的交易日数
+}
+
+// SetMutualFunds 设置场外基金的申赎规则 (按净值成交、申赎费率表、赎回款延迟到账)
+func (m *Manager) SetMutualFunds(funds map[string]types.MutualFund) {
+	m.funds = funds
+}
+
+// Settle 推进一个交易日，到期的赎回款转入现金，返回到账金额；每个交易日开始时调用
+func (m *Manager) Settle() float64 {
+	settled := 0.0
+	remaining := m.receivables[:0]
+	for _, r := range m.receivables {
+		r.daysLeft--
+		if r.daysLeft <= 0 {
+			settled += r.amount
+			continue
+		}
+		remaining = append(remaining, r)
+	}
+	m.receivables = remaining
+	if settled != 0 {
+		m.portfolio.Cash += settled
+		m.portfolio.Receivable -= settled
+		if len(m.receivables) == 0 {
+			m.portfolio.Receivable = 0 // 消除累计的舍入误差
+		}
+	}
+	return settled
+}
+
+// executionPrice 成交价：场外基金按净值成交，其他标的按成本模型计入滑点
+func (m *Manager) executionPrice(symbol string, price float64, side string) float64 {
+	if _, ok := m.funds[symbol]; ok {
+		return price
+	}
+	return m.costModel.CalculateSlippage(price, side)
+}
+
+// fee 交易费用：场外基金按申购/赎回费率表计算，其他标的按成本模型
+func (m *Manager) fee(trade types.Trade) float64 {
+	fund, ok := m.funds[trade.Symbol]
+	if !ok {
+		return m.costModel.CalculateCost(trade)
+	}
+	if trade.Side == "BUY" {
+		return fund.SubscriptionCost(trade.Value)
+	}
+	return m.redemptionCost(fund, trade)
+}
+
+// redemptionCost 按先进先出计算赎回费：没有申购记录的份额 (如断点恢复或实盘录入的持仓) 最先赎回，视为已满最长持有期
+func (m *Manager) redemptionCost(fund types.MutualFund, trade types.Trade) float64 {
+	untracked := math.Min(m.untrackedShares(trade.Symbol), trade.Quantity)
+	fee := untracked * trade.Price * fund.RedemptionRate(math.MaxInt32)
+	remaining := trade.Quantity - untracked
+	for _, lot := range m.fundLots[trade.Symbol] {
+		if remaining <= 0 {
+			break
+		}
+		quantity := math.Min(lot.quantity, remaining)
+		days := int(trade.Timestamp.Sub(lot.date).Hours() / 24)
+		fee += quantity * trade.Price * fund.RedemptionRate(days)
+		remaining -= quantity
+	}
+	return fee
+}
+
+// untrackedShares 持仓中没有申购记录的份额
+func (m *Manager) untrackedShares(symbol string) float64 {
+	held := m.portfolio.Positions[symbol].Quantity
+	for _, lot := range m.fundLots[symbol] {
+		held -= lot.quantity
+	}
+	return math.Max(held, 0)
+}
+
+// subscribe 记录申购确认的份额
+func (m *Manager) subscribe(trade types.Trade) {
+	if _, ok := m.funds[trade.Symbol]; !ok {
+		return
+	}
+	m.fundLots[trade.Symbol] = append(m.fundLots[trade.Symbol], fundLot{date: trade.Timestamp, quantity: trade.Quantity})
+}
+
+// redeem 按先进先出扣减赎回的份额 (在更新持仓前调用)，赎回款按到账天数记为在途或直接转入现金
+func (m *Manager) redeem(trade types.Trade) {
+	proceeds := trade.Value - trade.Fee
+	fund, ok := m.funds[trade.Symbol]
+	if !ok {
+		m.portfolio.Cash += proceeds
+		return
+	}
+
+	remaining := trade.Quantity - math.Min(m.untrackedShares(trade.Symbol), trade.Quantity)
+	lots := m.fundLots[trade.Symbol]
+	for len(lots) > 0 && remaining > 1e-9 {
+		if lots[0].quantity > remaining {
+			lots[0].quantity -= remaining
+			break
+		}
+		remaining -= lots[0].quantity
+		lots = lots[1:]
+	}
+	m.fundLots[trade.Symbol] = lots
+
+	if fund.SettlementDays <= 0 {
+		m.portfolio.Cash += proceeds
+		return
+	}
+	m.receivables = append(m.receivables, receivable{amount: proceeds, daysLeft: fund.SettlementDays})
+	m.portfolio.Receivable += proceeds
+}
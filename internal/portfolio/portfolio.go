@@ -2,6 +2,7 @@ package portfolio
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/opsxjacky/Rebalance-backtest/internal/cost"
@@ -13,6 +14,14 @@ type Manager struct {
 	portfolio *types.Portfolio
 	costModel cost.CostModel
 	trades    []types.Trade
+	haircuts  map[string]float64 // 估值折扣
+	working   []types.Order      // 受成交量限制未成交的挂单
+	margin    types.MarginConfig // 融资融券设置
+	lotSizes  map[string]float64 // 按标的的交易单位
+
+	funds       map[string]types.MutualFund // 场外基金的申赎规则
+	fundLots    map[string][]fundLot        // 场外基金各笔申购的份额 (先进先出)
+	receivables []receivable                // 在途的赎回款
 }
 
 // NewManager 创建投资组合管理器
@@ -21,6 +30,7 @@ func NewManager(initialCash float64, costModel cost.CostModel) *Manager {
 		portfolio: types.NewPortfolio(initialCash),
 		costModel: costModel,
 		trades:    make([]types.Trade, 0),
+		fundLots:  make(map[string][]fundLot),
 	}
 }
 
@@ -34,9 +44,153 @@ func (m *Manager) GetTrades() []types.Trade {
 	return m.trades
 }
 
+// SetHaircuts 设置估值折扣 (仅影响持仓估值，不影响成交价格)
+func (m *Manager) SetHaircuts(haircuts map[string]float64) {
+	m.haircuts = haircuts
+}
+
+// SetLotSizes 设置按标的的交易单位 (资金不足按可成交数量成交时向下取整)
+func (m *Manager) SetLotSizes(lotSizes map[string]float64) {
+	m.lotSizes = lotSizes
+}
+
+// SetMargin 设置融资融券 (允许做空、总敞口上限)
+func (m *Manager) SetMargin(margin types.MarginConfig) {
+	m.margin = margin
+}
+
+// leveraged 是否允许融资 (总敞口上限超过1倍)
+func (m *Manager) leveraged() bool {
+	return m.margin.MaxGrossExposure > 1
+}
+
+// BuyingPower 可用于买入的资金 (现金 + 融资额度)
+func (m *Manager) BuyingPower() float64 {
+	if !m.leveraged() {
+		return m.portfolio.Cash
+	}
+	return m.portfolio.Cash + (m.margin.MaxGrossExposure-1)*m.portfolio.TotalValue
+}
+
+// withinLeverage 检查标的持仓变动 quantity 后总敞口是否不超过上限 (或较变动前下降)
+func (m *Manager) withinLeverage(symbol string, quantity, price, cashAfter float64) bool {
+	maxGross := m.margin.MaxGrossExposure
+	if maxGross <= 0 {
+		maxGross = 1
+	}
+
+	equity := cashAfter
+	gross := 0.0
+	grossBefore := 0.0
+	found := false
+	for s, pos := range m.portfolio.Positions {
+		grossBefore += math.Abs(pos.Value)
+		value := pos.Value
+		if s == symbol {
+			value = (pos.Quantity + quantity) * price
+			found = true
+		}
+		equity += value
+		gross += math.Abs(value)
+	}
+	if !found {
+		value := quantity * price
+		equity += value
+		gross += math.Abs(value)
+	}
+
+	if equity <= 0 {
+		return false
+	}
+	// 降低敞口的交易 (如回补空头) 总是允许
+	return gross <= maxGross*equity*(1+1e-9) || gross < grossBefore
+}
+
+// leverageQuantity 在总敞口上限内可成交的最大数量 (仅约束买入和开空部分)
+func (m *Manager) leverageQuantity(trade types.Trade) float64 {
+	pos := m.portfolio.Positions[trade.Symbol]
+	if trade.Side != "BUY" && pos.Quantity-trade.Quantity >= 0 {
+		return trade.Quantity
+	}
+
+	fits := func(quantity float64) bool {
+		t := trade
+		t.Quantity = quantity
+		t.Value = quantity * trade.Price
+		fee := m.fee(t)
+		if trade.Side == "BUY" {
+			return m.withinLeverage(trade.Symbol, quantity, trade.Price, m.portfolio.Cash-t.Value-fee)
+		}
+		return m.withinLeverage(trade.Symbol, -quantity, trade.Price, m.portfolio.Cash+t.Value-fee)
+	}
+	if fits(trade.Quantity) {
+		return trade.Quantity
+	}
+
+	// 卖出时平掉多头的部分不受约束
+	lo := 0.0
+	if trade.Side != "BUY" && pos.Quantity > 0 {
+		lo = pos.Quantity
+	}
+	hi := trade.Quantity
+	for i := 0; i < 40; i++ {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// AccrueFinancing 计提融资利息/融券费用，从现金中扣除
+func (m *Manager) AccrueFinancing(amount float64) {
+	m.portfolio.Cash -= amount
+	m.portfolio.TotalValue -= amount
+}
+
+// AccrueHoldingCost 从现金中扣除计提的管理费
+func (m *Manager) AccrueHoldingCost(amount float64) {
+	m.portfolio.Cash -= amount
+	m.portfolio.TotalValue -= amount
+}
+
+// Restore 恢复断点保存的持仓和现金 (交易记录从空开始)，在途的赎回款在下一交易日到账
+func (m *Manager) Restore(p types.Portfolio) {
+	positions := make(map[string]types.Position, len(p.Positions))
+	for symbol, pos := range p.Positions {
+		positions[symbol] = pos
+	}
+	p.Positions = positions
+	m.portfolio = &p
+	m.receivables = nil
+	if p.Receivable != 0 {
+		m.receivables = []receivable{{amount: p.Receivable, daysLeft: 1}}
+	}
+}
+
+// Deposit 追加投入现金
+func (m *Manager) Deposit(amount float64) {
+	m.portfolio.Cash += amount
+	m.portfolio.TotalValue += amount
+}
+
+// ShortValue 空头持仓市值 (正数)
+func (m *Manager) ShortValue() float64 {
+	value := 0.0
+	for _, pos := range m.portfolio.Positions {
+		if pos.Value < 0 {
+			value -= pos.Value
+		}
+	}
+	return value
+}
+
 // UpdatePrices 更新持仓价值
 func (m *Manager) UpdatePrices(prices map[string]float64, timestamp time.Time) {
 	m.portfolio.Timestamp = timestamp
+	prices = m.applyHaircuts(prices)
 	m.portfolio.UpdateValue(prices)
 
 	// 更新盈亏
@@ -48,6 +202,18 @@ func (m *Manager) UpdatePrices(prices map[string]float64, timestamp time.Time) {
 	}
 }
 
+// applyHaircuts 按估值折扣调整估值价格
+func (m *Manager) applyHaircuts(prices map[string]float64) map[string]float64 {
+	if len(m.haircuts) == 0 {
+		return prices
+	}
+	adjusted := make(map[string]float64, len(prices))
+	for symbol, price := range prices {
+		adjusted[symbol] = price * (1 - m.haircuts[symbol])
+	}
+	return adjusted
+}
+
 // UpdateFundamentals 更新基本面数据
 func (m *Manager) UpdateFundamentals(fundamentals map[string]*types.FundamentalData) {
 	for symbol, pos := range m.portfolio.Positions {
@@ -60,8 +226,51 @@ func (m *Manager) UpdateFundamentals(fundamentals map[string]*types.FundamentalD
 
 // ExecuteOrder 执行订单
 func (m *Manager) ExecuteOrder(order types.Order, timestamp time.Time) (types.Trade, error) {
-	// 计算滑点调整后的价格
-	executionPrice := m.costModel.CalculateSlippage(order.Price, order.Side)
+	return m.execute(order, timestamp, false)
+}
+
+// ExecutePartial 按可成交数量执行订单，剩余部分作为挂单留待后续交易日继续成交
+func (m *Manager) ExecutePartial(order types.Order, fillQuantity float64, timestamp time.Time) (types.Trade, error) {
+	if fillQuantity > order.Quantity {
+		fillQuantity = order.Quantity
+	}
+
+	remainder := order
+	remainder.Quantity = order.Quantity - fillQuantity
+	if remainder.Quantity > 1e-6 {
+		m.working = append(m.working, remainder)
+	}
+
+	if fillQuantity <= 0 {
+		return types.Trade{}, nil
+	}
+	order.Quantity = fillQuantity
+	return m.execute(order, timestamp, remainder.Quantity > 1e-6)
+}
+
+// WorkingOrders 返回当前未成交的挂单
+func (m *Manager) WorkingOrders() []types.Order {
+	return m.working
+}
+
+// TakeWorkingOrders 取出所有挂单 (取出后由调用方重新提交)
+func (m *Manager) TakeWorkingOrders() []types.Order {
+	orders := m.working
+	m.working = nil
+	return orders
+}
+
+// CancelWorkingOrders 撤销所有挂单，返回撤销数量
+func (m *Manager) CancelWorkingOrders() int {
+	n := len(m.working)
+	m.working = nil
+	return n
+}
+
+// execute 执行订单并记录成交，partial 标记受成交量限制的部分成交
+func (m *Manager) execute(order types.Order, timestamp time.Time, partial bool) (types.Trade, error) {
+	// 计算滑点调整后的价格 (场外基金按净值)
+	executionPrice := m.executionPrice(order.Symbol, order.Price, order.Side)
 
 	trade := types.Trade{
 		Timestamp: timestamp,
@@ -70,10 +279,39 @@ func (m *Manager) ExecuteOrder(order types.Order, timestamp time.Time) (types.Tr
 		Quantity:  order.Quantity,
 		Price:     executionPrice,
 		Value:     order.Quantity * executionPrice,
+		Partial:   partial,
+		Tag:       order.Tag,
+		Trigger:   order.Trigger,
 	}
 
 	// 计算交易费用
-	trade.Fee = m.costModel.CalculateCost(trade)
+	trade.Fee = m.fee(trade)
+
+	// 可用资金不足以覆盖金额+费用 (或超出总敞口上限) 时，按可成交的最大数量成交
+	quantity := trade.Quantity
+	if m.leveraged() || m.margin.AllowShort {
+		quantity = m.leverageQuantity(trade)
+	} else if order.Side == "BUY" && trade.Value+trade.Fee > m.portfolio.Cash {
+		quantity = m.AffordableQuantity(order)
+	}
+	if quantity < trade.Quantity {
+		quantity = types.RoundLot(quantity, m.lotSizes[order.Symbol])
+	}
+	// 有在途的赎回款时，现金不足的部分作为挂单，赎回款到账后继续买入
+	if order.Side == "BUY" && quantity < trade.Quantity && m.portfolio.Receivable > 0 {
+		remainder := order
+		remainder.Quantity = trade.Quantity - math.Max(quantity, 0)
+		m.working = append(m.working, remainder)
+		if quantity <= 0 {
+			return types.Trade{}, nil
+		}
+		trade.Partial = true
+	}
+	if quantity > 0 && quantity < trade.Quantity {
+		trade.Quantity = quantity
+		trade.Value = quantity * executionPrice
+		trade.Fee = m.fee(trade)
+	}
 
 	// 执行交易
 	if order.Side == "BUY" {
@@ -96,21 +334,36 @@ func (m *Manager) ExecuteOrder(order types.Order, timestamp time.Time) (types.Tr
 func (m *Manager) executeBuy(trade types.Trade) error {
 	totalCost := trade.Value + trade.Fee
 
-	if m.portfolio.Cash < totalCost {
+	if m.leveraged() {
+		if !m.withinLeverage(trade.Symbol, trade.Quantity, trade.Price, m.portfolio.Cash-totalCost) {
+			return fmt.Errorf("exceeds max gross exposure %.2f", m.margin.MaxGrossExposure)
+		}
+	} else if m.portfolio.Cash < totalCost {
 		return fmt.Errorf("insufficient cash: need %.2f, have %.2f", totalCost, m.portfolio.Cash)
 	}
 
 	// 扣减现金
 	m.portfolio.Cash -= totalCost
+	m.subscribe(trade)
 
 	// 更新持仓
 	pos, exists := m.portfolio.Positions[trade.Symbol]
 	if exists {
-		// 计算新的平均成本
 		totalQuantity := pos.Quantity + trade.Quantity
-		totalCostBasis := pos.AvgCost*pos.Quantity + trade.Price*trade.Quantity
-		pos.AvgCost = totalCostBasis / totalQuantity
+		switch {
+		case pos.Quantity >= 0:
+			// 加仓：计算新的平均成本
+			totalCostBasis := pos.AvgCost*pos.Quantity + trade.Price*trade.Quantity
+			pos.AvgCost = totalCostBasis / totalQuantity
+		case totalQuantity > 0:
+			// 空头回补后转为多头
+			pos.AvgCost = trade.Price
+		}
 		pos.Quantity = totalQuantity
+		if math.Abs(pos.Quantity) < 0.0001 {
+			delete(m.portfolio.Positions, trade.Symbol)
+			return nil
+		}
 	} else {
 		pos = types.Position{
 			Symbol:   trade.Symbol,
@@ -127,20 +380,40 @@ func (m *Manager) executeBuy(trade types.Trade) error {
 // executeSell 执行卖出
 func (m *Manager) executeSell(trade types.Trade) error {
 	pos, exists := m.portfolio.Positions[trade.Symbol]
-	if !exists {
-		return fmt.Errorf("no position in %s", trade.Symbol)
+	if _, fund := m.funds[trade.Symbol]; fund && pos.Quantity < trade.Quantity-1e-9 {
+		return fmt.Errorf("cannot redeem %.4f shares of mutual fund %s, have %.4f", trade.Quantity, trade.Symbol, pos.Quantity)
 	}
-
-	if pos.Quantity < trade.Quantity {
-		return fmt.Errorf("insufficient shares: need %.4f, have %.4f", trade.Quantity, pos.Quantity)
+	if !m.margin.AllowShort {
+		if !exists {
+			return fmt.Errorf("no position in %s", trade.Symbol)
+		}
+		if pos.Quantity < trade.Quantity {
+			return fmt.Errorf("insufficient shares: need %.4f, have %.4f", trade.Quantity, pos.Quantity)
+		}
+	} else if pos.Quantity-trade.Quantity < 0 {
+		// 做空部分受总敞口上限约束
+		if !m.withinLeverage(trade.Symbol, -trade.Quantity, trade.Price, m.portfolio.Cash+trade.Value-trade.Fee) {
+			return fmt.Errorf("short sale exceeds max gross exposure %.2f", m.margin.MaxGrossExposure)
+		}
+		if !exists {
+			pos = types.Position{Symbol: trade.Symbol}
+		}
+		switch {
+		case pos.Quantity > 0:
+			// 多头卖出后转为空头
+			pos.AvgCost = trade.Price
+		default:
+			// 加空：按数量加权的平均开空价
+			pos.AvgCost = (pos.AvgCost*(-pos.Quantity) + trade.Price*trade.Quantity) / (trade.Quantity - pos.Quantity)
+		}
 	}
 
-	// 增加现金 (扣除费用)
-	m.portfolio.Cash += trade.Value - trade.Fee
+	// 增加现金 (扣除费用，场外基金的赎回款按到账天数在途)
+	m.redeem(trade)
 
 	// 更新持仓
 	pos.Quantity -= trade.Quantity
-	if pos.Quantity < 0.0001 {
+	if math.Abs(pos.Quantity) < 0.0001 {
 		// 清仓
 		delete(m.portfolio.Positions, trade.Symbol)
 	} else {
@@ -161,12 +434,65 @@ func (m *Manager) TakeSnapshot() types.PortfolioSnapshot {
 	return types.PortfolioSnapshot{
 		Timestamp:  m.portfolio.Timestamp,
 		Cash:       m.portfolio.Cash,
+		Receivable: m.portfolio.Receivable,
 		Positions:  positions,
 		TotalValue: m.portfolio.TotalValue,
 		Weights:    m.portfolio.GetWeights(),
 	}
 }
 
+// EstimateBuyCost 估算买单所需现金 (含滑点和费用)
+func (m *Manager) EstimateBuyCost(order types.Order) float64 {
+	price := m.executionPrice(order.Symbol, order.Price, "BUY")
+	trade := types.Trade{
+		Symbol:   order.Symbol,
+		Side:     "BUY",
+		Quantity: order.Quantity,
+		Price:    price,
+		Value:    order.Quantity * price,
+	}
+	return trade.Value + m.fee(trade)
+}
+
+// EstimateFee 估算订单在 timestamp 成交的交易费用 (场外基金为申购/赎回费)
+func (m *Manager) EstimateFee(order types.Order, timestamp time.Time) float64 {
+	price := m.executionPrice(order.Symbol, order.Price, order.Side)
+	return m.fee(types.Trade{
+		Timestamp: timestamp,
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		Quantity:  order.Quantity,
+		Price:     price,
+		Value:     order.Quantity * price,
+	})
+}
+
+// AffordableQuantity 计算当前现金在扣除滑点和费用后可买入的最大数量 (不超过订单数量)
+func (m *Manager) AffordableQuantity(order types.Order) float64 {
+	cash := m.BuyingPower()
+	if cash <= 0 || order.Price <= 0 {
+		return 0
+	}
+
+	sized := order
+	if sized.Quantity*order.Price > cash {
+		sized.Quantity = cash / order.Price
+	}
+
+	// 费用随数量单调递增，迭代缩小数量直到总成本不超过现金
+	for i := 0; i < 20; i++ {
+		total := m.EstimateBuyCost(sized)
+		if total <= cash {
+			return sized.Quantity
+		}
+		sized.Quantity *= cash / total * 0.999999
+		if sized.Quantity*order.Price < 1e-6 {
+			break
+		}
+	}
+	return 0
+}
+
 // CanBuy 检查是否可以买入指定金额
 func (m *Manager) CanBuy(symbol string, amount float64, price float64) bool {
 	quantity := amount / price
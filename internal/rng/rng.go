@@ -0,0 +1,25 @@
+// Package rng 提供按种子派生的随机数源。一次运行只配置一个种子 (backtest.seed)，
+// 各随机组件 (行为偏差模拟、合成数据、蒙特卡洛、随机搜索等) 按组件名派生各自独立的序列，
+// 相同种子的运行结果可复现，增减某个组件也不会改变其他组件的随机序列
+package rng
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math/rand"
+)
+
+// New 返回由种子和组件名派生的随机数源
+func New(seed int64, stream string) *rand.Rand {
+	return rand.New(rand.NewSource(Derive(seed, stream)))
+}
+
+// Derive 由种子和组件名派生子种子
+func Derive(seed int64, stream string) int64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], uint64(seed))
+	h.Write(buf[:])
+	h.Write([]byte(stream))
+	return int64(h.Sum64())
+}
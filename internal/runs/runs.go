@@ -0,0 +1,126 @@
+// Package runs 回测运行的输出目录：每次运行写入 <输出目录>/<run-id>/，并在 index.json 中登记最近的运行
+package runs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 输出目录结构
+const (
+	LayoutRun  = "run"  // 每次运行一个目录 (默认)
+	LayoutFlat = "flat" // 直接写入输出目录，覆盖上次结果
+)
+
+// IndexFile 运行索引文件名
+const IndexFile = "index.json"
+
+// maxIndexEntries 索引保留的最近运行数
+const maxIndexEntries = 100
+
+// Entry 索引中的一次运行
+type Entry struct {
+	ID          string    `json:"id"`
+	Time        time.Time `json:"time"`
+	Strategy    string    `json:"strategy"`
+	Config      string    `json:"config"`
+	Dir         string    `json:"dir"`
+	FinalValue  float64   `json:"final_value"`
+	TotalReturn float64   `json:"total_return"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ID 生成运行ID：时间戳_策略_配置哈希前8位，如 20240105-163000_fixed_weight_1a2b3c4d
+func ID(now time.Time, strategy string, config []byte) string {
+	return fmt.Sprintf("%s_%s_%s", now.Format("20060102-150405"), slug(strategy), ConfigHash(config)[:8])
+}
+
+// ConfigHash 配置内容的SHA-256
+func ConfigHash(config []byte) string {
+	sum := sha256.Sum256(config)
+	return hex.EncodeToString(sum[:])
+}
+
+// slug 将策略名转换为可用作目录名的形式
+func slug(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "strategy"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// Create 创建运行目录并写入生效的配置，返回最终的运行ID和目录
+// 同一秒内相同配置的运行在ID后追加序号
+func Create(outputDir, id string, config []byte) (string, string, error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return "", "", fmt.Errorf("failed to create output dir: %w", err)
+	}
+	base := id
+	dir := filepath.Join(outputDir, id)
+	for n := 2; ; n++ {
+		err := os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return "", "", fmt.Errorf("failed to create run dir: %w", err)
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+		dir = filepath.Join(outputDir, id)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.yaml"), config, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to copy config: %w", err)
+	}
+	return id, dir, nil
+}
+
+// LoadIndex 读取输出目录中的运行索引 (最新的在前)，不存在时返回空列表
+func LoadIndex(outputDir string) ([]Entry, error) {
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, IndexFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run index: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse run index: %w", err)
+	}
+	return entries, nil
+}
+
+// Record 将运行登记到索引开头，只保留最近的运行 (较早运行的目录不删除)
+func Record(outputDir string, entry Entry) error {
+	entries, err := LoadIndex(outputDir)
+	if err != nil {
+		return err
+	}
+	entries = append([]Entry{entry}, entries...)
+	if len(entries) > maxIndexEntries {
+		entries = entries[:maxIndexEntries]
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run index: %w", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(outputDir, IndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write run index: %w", err)
+	}
+	return nil
+}
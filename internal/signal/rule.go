@@ -0,0 +1,49 @@
+// Package signal 估值信号规则
+// 规则只根据基本面数据判断估值水平 (低估/合理/高估)，如何据此调仓由各策略决定
+package signal
+
+import (
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Level 估值水平
+type Level int
+
+const (
+	LevelUnknown     Level = iota // 规则不适用或缺少数据
+	LevelExtremeLow               // 极度低估
+	LevelLow                      // 低估
+	LevelNeutral                  // 合理
+	LevelHigh                     // 高估
+	LevelExtremeHigh              // 极度高估
+)
+
+// Cheap 是否低估
+func (l Level) Cheap() bool {
+	return l == LevelLow || l == LevelExtremeLow
+}
+
+// Expensive 是否高估
+func (l Level) Expensive() bool {
+	return l == LevelHigh || l == LevelExtremeHigh
+}
+
+// Assessment 规则评估结果
+type Assessment struct {
+	Level  Level
+	Reason types.ReasonCode
+}
+
+// Rule 估值规则
+type Rule interface {
+	// Evaluate 评估标的估值水平，规则不适用时返回 LevelUnknown
+	Evaluate(symbol string, fund *types.FundamentalData) Assessment
+}
+
+// NormalizeRank 将百分位归一化到0-1 (大于1的值视为0-100刻度)
+func NormalizeRank(rank float64) float64 {
+	if rank > 1 {
+		return rank / 100
+	}
+	return rank
+}
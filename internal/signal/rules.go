@@ -0,0 +1,109 @@
+package signal
+
+import (
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// PERankRule PE百分位规则 (阈值与数据均为0-100刻度)，阈值为0表示不启用该档
+type PERankRule struct {
+	ExtremeHigh float64
+	High        float64
+	Low         float64
+}
+
+// Evaluate 按PE百分位评估
+func (r PERankRule) Evaluate(symbol string, fund *types.FundamentalData) Assessment {
+	if fund == nil {
+		return Assessment{Level: LevelUnknown, Reason: types.ReasonNoFundamental}
+	}
+	rank := fund.PERank
+	if rank <= 0 {
+		return Assessment{Level: LevelUnknown, Reason: types.ReasonNoFundamental}
+	}
+
+	switch {
+	case r.ExtremeHigh > 0 && rank >= r.ExtremeHigh:
+		return Assessment{Level: LevelExtremeHigh, Reason: types.ReasonPEExtremeHigh}
+	case r.High > 0 && rank >= r.High:
+		return Assessment{Level: LevelHigh, Reason: types.ReasonPEHigh}
+	case r.Low > 0 && rank <= r.Low:
+		return Assessment{Level: LevelLow, Reason: types.ReasonPELow}
+	}
+	return Assessment{Level: LevelNeutral, Reason: types.ReasonNormal}
+}
+
+// PBRule 市净率规则 (按PB绝对值判断，适用于恒生等PE失真的指数)
+type PBRule struct {
+	High float64 // 高于该值为高估
+	Low  float64 // 低于该值为低估
+}
+
+// Evaluate 按PB评估
+func (r PBRule) Evaluate(symbol string, fund *types.FundamentalData) Assessment {
+	if fund == nil || fund.PB <= 0 {
+		return Assessment{Level: LevelUnknown, Reason: types.ReasonNoFundamental}
+	}
+	switch {
+	case r.High > 0 && fund.PB > r.High:
+		return Assessment{Level: LevelHigh, Reason: types.ReasonPBHigh}
+	case fund.PB < r.Low:
+		return Assessment{Level: LevelLow, Reason: types.ReasonPBLow}
+	}
+	return Assessment{Level: LevelNeutral, Reason: types.ReasonNormal}
+}
+
+// YieldThreshold 收益率阈值
+type YieldThreshold struct {
+	High float64 // 高息阈值 (便宜)
+	Low  float64 // 低息阈值 (贵)
+}
+
+// BondYieldRule 债券收益率规则 (按标的设置阈值，收益率越高越便宜)
+type BondYieldRule struct {
+	Thresholds map[string]YieldThreshold
+}
+
+// Evaluate 按到期收益率 (FundamentalData.Yield) 评估，未设置阈值的标的不适用
+func (r BondYieldRule) Evaluate(symbol string, fund *types.FundamentalData) Assessment {
+	threshold, ok := r.Thresholds[symbol]
+	if !ok || fund == nil {
+		return Assessment{Level: LevelUnknown, Reason: types.ReasonNone}
+	}
+
+	yieldValue := fund.Yield
+	switch {
+	case yieldValue > threshold.High:
+		return Assessment{Level: LevelLow, Reason: types.ReasonYieldHigh}
+	case yieldValue < threshold.Low && yieldValue > 0:
+		return Assessment{Level: LevelHigh, Reason: types.ReasonYieldLow}
+	}
+	return Assessment{Level: LevelNeutral, Reason: types.ReasonNormal}
+}
+
+// DividendYieldRule 股息率百分位规则 (REITs/红利ETF，股息率越高越便宜)，阈值与数据均为0-100刻度，阈值为0表示不启用该档
+type DividendYieldRule struct {
+	High       float64 // 高股息阈值 (低估)
+	Low        float64 // 低股息阈值 (高估)
+	ExtremeLow float64 // 极低股息阈值 (极度高估)
+}
+
+// Evaluate 按股息率百分位评估
+func (r DividendYieldRule) Evaluate(symbol string, fund *types.FundamentalData) Assessment {
+	if fund == nil {
+		return Assessment{Level: LevelUnknown, Reason: types.ReasonNoFundamental}
+	}
+	rank := fund.DividendYieldRank
+	if rank <= 0 {
+		return Assessment{Level: LevelUnknown, Reason: types.ReasonNoFundamental}
+	}
+
+	switch {
+	case r.High > 0 && rank >= r.High:
+		return Assessment{Level: LevelLow, Reason: types.ReasonYieldHigh}
+	case r.ExtremeLow > 0 && rank <= r.ExtremeLow:
+		return Assessment{Level: LevelExtremeHigh, Reason: types.ReasonYieldExtremeLow}
+	case r.Low > 0 && rank <= r.Low:
+		return Assessment{Level: LevelHigh, Reason: types.ReasonYieldLow}
+	}
+	return Assessment{Level: LevelNeutral, Reason: types.ReasonNormal}
+}
@@ -0,0 +1,206 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // 纯Go实现的SQLite驱动，不需要cgo
+)
+
+// sqliteSchema 运行记录表及其配置项、指标子表，配置项和指标按运行ID索引
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          TEXT PRIMARY KEY,
+	time        TEXT NOT NULL,
+	strategy    TEXT NOT NULL,
+	config      TEXT NOT NULL,
+	config_hash TEXT NOT NULL,
+	dir         TEXT NOT NULL DEFAULT '',
+	error       TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS runs_time ON runs (time);
+CREATE INDEX IF NOT EXISTS runs_config_hash ON runs (config_hash);
+CREATE TABLE IF NOT EXISTS params (
+	run_id TEXT NOT NULL REFERENCES runs (id),
+	key    TEXT NOT NULL,
+	value  TEXT NOT NULL,
+	PRIMARY KEY (run_id, key)
+);
+CREATE TABLE IF NOT EXISTS metrics (
+	run_id TEXT NOT NULL REFERENCES runs (id),
+	name   TEXT NOT NULL,
+	value  REAL,
+	PRIMARY KEY (run_id, name)
+);
+`
+
+// SQLiteStore 以SQLite数据库保存的记录
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore 打开 (不存在时创建) SQLite数据库并建表
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create store dir: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	// 单个连接，避免多个连接同时写入时的锁等待
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize store %s: %w", path, err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// Add 在一个事务中写入记录及其配置项和指标
+func (s *SQLiteStore) Add(record Record) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO runs (id, time, strategy, config, config_hash, dir, error) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		record.ID, record.Time.Format(time.RFC3339Nano), record.Strategy, record.Config, record.ConfigHash,
+		record.Dir, record.Error); err != nil {
+		return fmt.Errorf("failed to write record %s: %w", record.ID, err)
+	}
+	for key, value := range record.Params {
+		if _, err := tx.Exec(`INSERT INTO params (run_id, key, value) VALUES (?, ?, ?)`, record.ID, key, value); err != nil {
+			return fmt.Errorf("failed to write record %s: %w", record.ID, err)
+		}
+	}
+	for name, value := range record.Metrics {
+		if _, err := tx.Exec(`INSERT INTO metrics (run_id, name, value) VALUES (?, ?, ?)`, record.ID, name, value); err != nil {
+			return fmt.Errorf("failed to write record %s: %w", record.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to write record %s: %w", record.ID, err)
+	}
+	return nil
+}
+
+// List 按时间先后返回全部记录
+func (s *SQLiteStore) List() ([]Record, error) {
+	records, err := s.runs(`ORDER BY time, id`)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.details(records, "", nil); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Get 按ID或唯一的ID前缀查找记录 (前缀匹配走主键索引)
+func (s *SQLiteStore) Get(id string) (Record, error) {
+	records, err := s.runs(`WHERE id = ? OR (id > ? AND id < ?) ORDER BY id = ? DESC LIMIT 2`, id, id, id+"\U0010FFFF", id)
+	if err != nil {
+		return Record{}, err
+	}
+	switch {
+	case len(records) == 0:
+		return Record{}, fmt.Errorf("run %s not found", id)
+	case len(records) > 1 && records[0].ID != id:
+		var matches int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM runs WHERE id > ? AND id < ?`, id, id+"\U0010FFFF").Scan(&matches); err != nil {
+			return Record{}, fmt.Errorf("failed to query store: %w", err)
+		}
+		return Record{}, fmt.Errorf("run id %s is ambiguous (%d matches)", id, matches)
+	}
+	records = records[:1]
+	if err := s.details(records, `WHERE run_id = ?`, []interface{}{records[0].ID}); err != nil {
+		return Record{}, err
+	}
+	return records[0], nil
+}
+
+// Close 关闭数据库
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// runs 查询运行记录 (不含配置项和指标)，clause 为 WHERE/ORDER BY 子句
+func (s *SQLiteStore) runs(clause string, args ...interface{}) ([]Record, error) {
+	rows, err := s.db.Query(`SELECT id, time, strategy, config, config_hash, dir, error FROM runs `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query store: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var record Record
+		var t string
+		if err := rows.Scan(&record.ID, &t, &record.Strategy, &record.Config, &record.ConfigHash, &record.Dir, &record.Error); err != nil {
+			return nil, fmt.Errorf("failed to read store: %w", err)
+		}
+		if record.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+			return nil, fmt.Errorf("failed to parse time of run %s: %w", record.ID, err)
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+	return records, nil
+}
+
+// details 读取记录的配置项和指标，where 限定运行ID
+func (s *SQLiteStore) details(records []Record, where string, args []interface{}) error {
+	index := make(map[string]*Record, len(records))
+	for i := range records {
+		records[i].Params = make(map[string]string)
+		records[i].Metrics = make(map[string]float64)
+		index[records[i].ID] = &records[i]
+	}
+
+	rows, err := s.db.Query(`SELECT run_id, key, value FROM params `+where, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query store: %w", err)
+	}
+	for rows.Next() {
+		var id, key, value string
+		if err := rows.Scan(&id, &key, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read store: %w", err)
+		}
+		if record := index[id]; record != nil {
+			record.Params[key] = value
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read store: %w", err)
+	}
+
+	rows, err = s.db.Query(`SELECT run_id, name, value FROM metrics `+where, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query store: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, name string
+		var value sql.NullFloat64
+		if err := rows.Scan(&id, &name, &value); err != nil {
+			return fmt.Errorf("failed to read store: %w", err)
+		}
+		// SQLite 将 NaN 存为 NULL，读取时跳过
+		if record := index[id]; record != nil && value.Valid {
+			record.Metrics[name] = value.Float64
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read store: %w", err)
+	}
+	return nil
+}
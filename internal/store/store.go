@@ -0,0 +1,269 @@
+// Package store 实验记录：登记每次运行的配置哈希、参数和指标，用于跨时间比较回测实验
+//
+// 记录默认保存在SQLite数据库中 (纯Go驱动，不需要cgo)，按运行ID索引；
+// 扩展名为 .jsonl 的文件以 JSON Lines 追加写入 (每行一条)，兼容早期的记录文件
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Record 一次运行的实验记录
+type Record struct {
+	ID         string             `json:"id"`
+	Time       time.Time          `json:"time"`
+	Strategy   string             `json:"strategy"`
+	Config     string             `json:"config"`      // 配置文件路径
+	ConfigHash string             `json:"config_hash"` // 配置文件内容的SHA-256
+	Params     map[string]string  `json:"params"`      // 展开的配置项，如 strategy.params.threshold
+	Metrics    map[string]float64 `json:"metrics"`
+	Dir        string             `json:"dir,omitempty"` // 运行目录
+	Error      string             `json:"error,omitempty"`
+}
+
+// Store 实验记录存储
+type Store interface {
+	// Add 登记一条记录
+	Add(record Record) error
+
+	// List 按时间先后返回全部记录
+	List() ([]Record, error)
+
+	// Get 按ID (或唯一的ID前缀) 查找记录
+	Get(id string) (Record, error)
+
+	// Close 释放存储占用的资源
+	Close() error
+}
+
+// Open 按扩展名打开记录存储：.jsonl 为 JSON Lines 文件，其他 (如 .db) 为SQLite数据库
+func Open(path string) (Store, error) {
+	if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+		return NewFileStore(path), nil
+	}
+	return NewSQLiteStore(path)
+}
+
+// FileStore 以 JSON Lines 文件保存的记录，首次读取后缓存全部记录
+type FileStore struct {
+	path    string
+	records []Record
+	loaded  bool
+}
+
+// NewFileStore 创建文件存储，文件不存在时在首次写入时创建
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Add 追加一条记录
+func (s *FileStore) Add(record Record) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create store dir: %w", err)
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write record: %w", err)
+	}
+	if s.loaded {
+		s.records = append(s.records, record)
+	}
+	return nil
+}
+
+// List 返回全部记录，只在首次调用时读取文件
+func (s *FileStore) List() ([]Record, error) {
+	if s.loaded {
+		return s.records, nil
+	}
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		s.loaded = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("failed to parse store line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+	s.records, s.loaded = records, true
+	return records, nil
+}
+
+// Close 文件存储不持有打开的文件，无需释放
+func (s *FileStore) Close() error {
+	return nil
+}
+
+// Get 按ID或唯一的ID前缀查找记录
+func (s *FileStore) Get(id string) (Record, error) {
+	records, err := s.List()
+	if err != nil {
+		return Record{}, err
+	}
+	var matches []Record
+	for _, record := range records {
+		if record.ID == id {
+			return record, nil
+		}
+		if strings.HasPrefix(record.ID, id) {
+			matches = append(matches, record)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Record{}, fmt.Errorf("run %s not found", id)
+	case 1:
+		return matches[0], nil
+	}
+	return Record{}, fmt.Errorf("run id %s is ambiguous (%d matches)", id, len(matches))
+}
+
+// FlattenConfig 将YAML配置展开为 路径 -> 值，列表元素以下标为路径段
+func FlattenConfig(config []byte) (map[string]string, error) {
+	var tree interface{}
+	if err := yaml.Unmarshal(config, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	params := make(map[string]string)
+	flatten("", tree, params)
+	return params, nil
+}
+
+// flatten 递归展开配置树
+func flatten(prefix string, node interface{}, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flatten(join(key), child, out)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(join(fmt.Sprint(i)), child, out)
+		}
+	case nil:
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// Metrics 从回测结果计算记录的指标：收益、年化收益、最大回撤、年化波动率、夏普比率 (无风险利率为0) 和交易统计
+func Metrics(result *types.BacktestResult) map[string]float64 {
+	metrics := map[string]float64{
+		"final_value":  result.FinalValue,
+		"total_return": result.TotalReturn,
+		"total_trades": float64(result.TotalTrades),
+		"total_fees":   result.TotalFees,
+	}
+	snapshots := result.Snapshots
+	if len(snapshots) < 2 {
+		return metrics
+	}
+
+	years := snapshots[len(snapshots)-1].Timestamp.Sub(snapshots[0].Timestamp).Hours() / 24 / 365.25
+	if years > 0 && result.TotalReturn > -1 {
+		metrics["annual_return"] = math.Pow(1+result.TotalReturn, 1/years) - 1
+	}
+
+	peak, maxDrawdown := 0.0, 0.0
+	returns := make([]float64, 0, len(snapshots)-1)
+	for i, s := range snapshots {
+		peak = math.Max(peak, s.TotalValue)
+		if peak > 0 {
+			maxDrawdown = math.Max(maxDrawdown, 1-s.TotalValue/peak)
+		}
+		if i > 0 && snapshots[i-1].TotalValue > 0 {
+			returns = append(returns, s.TotalValue/snapshots[i-1].TotalValue-1)
+		}
+	}
+	metrics["max_drawdown"] = maxDrawdown
+
+	if len(returns) > 1 && years > 0 {
+		periodsPerYear := float64(len(returns)) / years
+		mean := 0.0
+		for _, r := range returns {
+			mean += r
+		}
+		mean /= float64(len(returns))
+		variance := 0.0
+		for _, r := range returns {
+			variance += (r - mean) * (r - mean)
+		}
+		variance /= float64(len(returns) - 1)
+		vol := math.Sqrt(variance * periodsPerYear)
+		metrics["volatility"] = vol
+		if vol > 0 {
+			metrics["sharpe"] = mean * periodsPerYear / vol
+		}
+	}
+	return metrics
+}
+
+// ParamDiff 两条记录不同的配置项
+type ParamDiff struct {
+	Key   string
+	Left  string
+	Right string
+}
+
+// DiffParams 比较两条记录的配置项，按路径排序 (一侧缺少的项为空字符串)
+func DiffParams(left, right Record) []ParamDiff {
+	keys := make(map[string]bool)
+	for key := range left.Params {
+		keys[key] = true
+	}
+	for key := range right.Params {
+		keys[key] = true
+	}
+	var diffs []ParamDiff
+	for key := range keys {
+		if l, r := left.Params[key], right.Params[key]; l != r {
+			diffs = append(diffs, ParamDiff{Key: key, Left: l, Right: r})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Key < diffs[j].Key
+	})
+	return diffs
+}
@@ -0,0 +1,193 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecords() []Record {
+	base := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	return []Record{
+		{
+			ID: "20240102-093000_threshold_aaaa", Time: base, Strategy: "threshold", Config: "configs/default.yaml",
+			ConfigHash: "hash-a", Params: map[string]string{"strategy.params.threshold": "0.05", "assets.0.symbol": "SPY"},
+			Metrics: map[string]float64{"total_return": 0.36, "sharpe": 1.2}, Dir: "output/a",
+		},
+		{
+			ID: "20240102-093000_threshold_bbbb", Time: base.Add(time.Minute), Strategy: "threshold", Config: "configs/default.yaml",
+			ConfigHash: "hash-b", Params: map[string]string{"strategy.params.threshold": "0.10", "assets.0.symbol": "SPY"},
+			Metrics: map[string]float64{"total_return": 0.30, "sharpe": 1.0},
+		},
+		{
+			ID: "20240103-100000_valuation_cccc", Time: base.Add(24 * time.Hour), Strategy: "valuation", Config: "configs/v.yaml",
+			ConfigHash: "hash-c", Params: map[string]string{}, Metrics: map[string]float64{}, Error: "data not found",
+		},
+	}
+}
+
+// stores 各存储实现，open 重新打开同一路径以检查持久化
+var stores = []struct {
+	name string
+	file string
+}{
+	{"sqlite", "results.db"},
+	{"jsonl", "results.jsonl"},
+}
+
+func TestStore(t *testing.T) {
+	for _, tt := range stores {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "store")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "nested", tt.file)
+
+			s, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if records, err := s.List(); err != nil || len(records) != 0 {
+				t.Fatalf("empty store List = %v, %v", records, err)
+			}
+			want := testRecords()
+			for _, record := range want {
+				if err := s.Add(record); err != nil {
+					t.Fatal(err)
+				}
+			}
+			checkRecords(t, s, want)
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 重新打开后记录仍在
+			s, err = Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			checkRecords(t, s, want)
+
+			for _, c := range []struct {
+				id   string
+				want string
+				err  string
+			}{
+				{id: "20240102-093000_threshold_bbbb", want: "20240102-093000_threshold_bbbb"},
+				{id: "20240103", want: "20240103-100000_valuation_cccc"},
+				{id: "20240102", err: "ambiguous (2 matches)"},
+				{id: "2025", err: "not found"},
+			} {
+				got, err := s.Get(c.id)
+				if c.err != "" {
+					if err == nil || !strings.Contains(err.Error(), c.err) {
+						t.Errorf("Get(%q) error = %v, want %q", c.id, err, c.err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("Get(%q): %v", c.id, err)
+					continue
+				}
+				if got.ID != c.want {
+					t.Errorf("Get(%q) = %s, want %s", c.id, got.ID, c.want)
+				}
+			}
+			if got, err := s.Get("20240102-093000_threshold_aaaa"); err != nil {
+				t.Error(err)
+			} else {
+				checkRecord(t, got, want[0])
+			}
+		})
+	}
+}
+
+// checkRecords 比较全部记录及其顺序
+func checkRecords(t *testing.T, s Store, want []Record) {
+	t.Helper()
+	got, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("List returned %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		checkRecord(t, got[i], want[i])
+	}
+}
+
+// checkRecord 比较一条记录的各字段
+func checkRecord(t *testing.T, got, want Record) {
+	t.Helper()
+	if got.ID != want.ID || !got.Time.Equal(want.Time) || got.Strategy != want.Strategy || got.Config != want.Config ||
+		got.ConfigHash != want.ConfigHash || got.Dir != want.Dir || got.Error != want.Error {
+		t.Errorf("record = %+v, want %+v", got, want)
+	}
+	if len(got.Params) != len(want.Params) {
+		t.Errorf("%s params = %v, want %v", want.ID, got.Params, want.Params)
+	}
+	for key, value := range want.Params {
+		if got.Params[key] != value {
+			t.Errorf("%s param %s = %q, want %q", want.ID, key, got.Params[key], value)
+		}
+	}
+	if len(got.Metrics) != len(want.Metrics) {
+		t.Errorf("%s metrics = %v, want %v", want.ID, got.Metrics, want.Metrics)
+	}
+	for name, value := range want.Metrics {
+		if got.Metrics[name] != value {
+			t.Errorf("%s metric %s = %v, want %v", want.ID, name, got.Metrics[name], value)
+		}
+	}
+}
+
+func TestSQLiteStoreDuplicateID(t *testing.T) {
+	dir, err := ioutil.TempDir("", "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := NewSQLiteStore(filepath.Join(dir, "results.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	record := testRecords()[0]
+	if err := s.Add(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(record); err == nil {
+		t.Error("expected error for duplicate run id")
+	}
+	// 失败的写入整体回滚，不留下部分配置项或指标
+	got, err := s.Get(record.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkRecord(t, got, record)
+}
+
+func TestDiffParams(t *testing.T) {
+	records := testRecords()
+	diffs := DiffParams(records[0], records[2])
+	want := []ParamDiff{
+		{Key: "assets.0.symbol", Left: "SPY", Right: ""},
+		{Key: "strategy.params.threshold", Left: "0.05", Right: ""},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("DiffParams = %v, want %v", diffs, want)
+	}
+	for i := range want {
+		if diffs[i] != want[i] {
+			t.Errorf("diff %d = %v, want %v", i, diffs[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package strategy
+
+import (
+	"math"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 再平衡调仓模式
+const (
+	RebalanceToTarget  = "target"  // 调回目标权重 (默认)
+	RebalanceToBand    = "band"    // 仅调回偏离区间边缘
+	RebalanceToHalfway = "halfway" // 调回区间边缘与目标权重的中点
+	RebalanceWorst     = "worst"   // 只把偏离最大的 (超出区间的) 标的调回目标权重，其余不交易
+)
+
+// applyRebalanceMode 按调仓模式调整目标权重，减少换手
+// 区间内的标的保持当前权重不交易，超出区间的标的只调回区间边缘 (或中点)
+// bandWidth 返回标的允许偏离的单边宽度 (绝对权重)；topK 为 worst 模式下最多调整的标的数 (0为不限)
+func applyRebalanceMode(mode string, topK int, portfolio *types.Portfolio, target map[string]float64, bandWidth func(symbol string, target float64) float64) map[string]float64 {
+	if mode == "" || mode == RebalanceToTarget {
+		return target
+	}
+	// 首次建仓直接按目标权重
+	if len(portfolio.Positions) == 0 {
+		return target
+	}
+
+	current := portfolio.GetWeights()
+	if mode == RebalanceWorst {
+		return rebalanceWorst(topK, current, target, bandWidth)
+	}
+	adjusted := make(map[string]float64, len(target))
+	for symbol, t := range target {
+		w := current[symbol]
+		h := bandWidth(symbol, t)
+		if mode == RebalanceToHalfway {
+			h = h / 2
+		}
+
+		switch {
+		case w > t+h:
+			adjusted[symbol] = t + h
+		case w < t-h:
+			adjusted[symbol] = t - h
+		default:
+			adjusted[symbol] = w
+		}
+		if adjusted[symbol] < 0 {
+			adjusted[symbol] = 0
+		}
+	}
+	return adjusted
+}
+
+// rebalanceWorst 超出区间的标的按偏离 (绝对权重差) 从大到小取前 topK 个调回目标权重，其余保持当前权重
+// 没有标的超出区间 (如阈值为0) 时在全部标的中取偏离最大的 topK 个
+func rebalanceWorst(topK int, current, target map[string]float64, bandWidth func(symbol string, target float64) float64) map[string]float64 {
+	symbols := make([]string, 0, len(target))
+	for symbol, t := range target {
+		if symbol != types.CashSymbol && math.Abs(current[symbol]-t) > bandWidth(symbol, t) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		for symbol := range target {
+			if symbol != types.CashSymbol {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		di := math.Abs(current[symbols[i]] - target[symbols[i]])
+		dj := math.Abs(current[symbols[j]] - target[symbols[j]])
+		if di != dj {
+			return di > dj
+		}
+		return symbols[i] < symbols[j]
+	})
+	if topK > 0 && len(symbols) > topK {
+		symbols = symbols[:topK]
+	}
+
+	adjusted := make(map[string]float64, len(target))
+	for symbol := range target {
+		adjusted[symbol] = current[symbol]
+	}
+	for _, symbol := range symbols {
+		adjusted[symbol] = target[symbol]
+	}
+	return adjusted
+}
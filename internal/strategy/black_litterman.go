@@ -0,0 +1,171 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/internal/signal"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// BlackLittermanStrategy 按 Black-Litterman 方法融合估值观点的再平衡策略
+// 基础目标权重视为市场均衡配置，反推先验收益 π = δσ²w；PE百分位形成相对先验的超额收益观点，
+// 按置信度与先验融合后的收益再换算回权重。倾斜幅度由波动率和置信度决定，而不是固定的调整比例
+// 协方差简化为对角阵 (各资产波动率)，每个有PE百分位的资产对应一个绝对观点
+type BlackLittermanStrategy struct {
+	orderGenerator
+	trendOverlay
+	rebalanceClock
+
+	name                 string
+	params               types.BlackLittermanParams
+	baseWeights          map[string]float64
+	threshold            float64
+	thresholdMode        string
+	thresholds           map[string]float64
+	minTradeValue        float64
+	minRebalanceInterval int
+	trigger              types.RebalanceTrigger
+	source               data.IndicatorSource
+}
+
+// NewBlackLittermanStrategy 创建 Black-Litterman 策略
+func NewBlackLittermanStrategy(config types.StrategyConfig) *BlackLittermanStrategy {
+	params := types.DefaultBlackLittermanParams()
+	if p := config.BlackLittermanParams; p != nil {
+		if p.RiskAversion > 0 {
+			params.RiskAversion = p.RiskAversion
+		}
+		if p.ViewReturn > 0 {
+			params.ViewReturn = p.ViewReturn
+		}
+		if p.Confidence > 0 {
+			params.Confidence = p.Confidence
+		}
+		if p.VolWindow > 0 {
+			params.VolWindow = p.VolWindow
+		}
+		if p.DefaultVol > 0 {
+			params.DefaultVol = p.DefaultVol
+		}
+	}
+
+	return &BlackLittermanStrategy{
+		orderGenerator:       newOrderGenerator(config),
+		trendOverlay:         newTrendOverlay(config),
+		name:                 config.Name,
+		params:               *params,
+		baseWeights:          types.ExpandClassWeights(config.AssetClasses, config.TargetWeights),
+		threshold:            config.Threshold,
+		thresholdMode:        thresholdMode(config.ThresholdMode, ThresholdAbsolute),
+		thresholds:           config.SymbolThresholds,
+		minTradeValue:        config.MinTradeValue,
+		minRebalanceInterval: config.MinRebalanceInterval,
+	}
+}
+
+// Name 返回策略名称
+func (s *BlackLittermanStrategy) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "BlackLitterman"
+}
+
+// SetIndicatorSource 设置波动率数据源 (未设置时使用默认波动率)
+func (s *BlackLittermanStrategy) SetIndicatorSource(source data.IndicatorSource) {
+	s.source = source
+}
+
+// variance 标的年化收益方差
+func (s *BlackLittermanStrategy) variance(symbol string, date time.Time) float64 {
+	vol := s.params.DefaultVol
+	if s.source != nil {
+		if v, ok := s.source.Indicator(symbol, indicators.Volatility, s.params.VolWindow, date); ok && v > 0 {
+			vol = v
+		}
+	}
+	return vol * vol
+}
+
+// view PE百分位对应的超额收益观点 (百分位50为0，两端为 ±ViewReturn)，没有百分位时无观点
+func (s *BlackLittermanStrategy) view(pos types.Position) (float64, bool) {
+	if pos.Fundamental == nil {
+		return 0, false
+	}
+	rank := signal.NormalizeRank(pos.Fundamental.PERank)
+	if rank <= 0 {
+		return 0, false
+	}
+	return s.params.ViewReturn * (1 - 2*rank), true
+}
+
+// TargetWeights 融合观点后的目标权重
+// 观点与先验按置信度 c 融合：μ = π + c/(1+c)·q，换算回权重 w = μ/(δσ²) = w₀ + c/(1+c)·q/(δσ²)
+func (s *BlackLittermanStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	blend := s.params.Confidence / (1 + s.params.Confidence)
+
+	weights := make(map[string]float64, len(s.baseWeights))
+	total, baseTotal := 0.0, 0.0
+	for symbol, base := range s.baseWeights {
+		w := base
+		if q, ok := s.view(ctx.Portfolio.Positions[symbol]); ok {
+			w += blend * q / (s.params.RiskAversion * s.variance(symbol, date))
+		}
+		w = math.Max(w, 0)
+		weights[symbol] = w
+		total += w
+		baseTotal += base
+	}
+
+	// 保持总权重与基础目标一致
+	if total <= 0 {
+		return s.baseWeights
+	}
+	for symbol := range weights {
+		weights[symbol] *= baseTotal / total
+	}
+	return weights
+}
+
+// ShouldRebalance 当前权重偏离融合后的目标权重超过阈值时再平衡 (阈值为0时每个间隔都再平衡)
+func (s *BlackLittermanStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	if !s.started() {
+		s.trigger = types.TriggerInitial
+		return true
+	}
+
+	if !s.elapsed(date, s.minRebalanceInterval) {
+		return false
+	}
+
+	if s.threshold <= 0 {
+		s.trigger = types.TriggerTime
+		return true
+	}
+	current := ctx.Portfolio.GetWeights()
+	for symbol, target := range s.TargetWeights(date, ctx) {
+		if math.Abs(deviation(s.thresholdMode, current[symbol], target)) > symbolThreshold(s.thresholds, symbol, s.threshold) {
+			s.trigger = types.TriggerValuation
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateOrders 生成交易订单
+func (s *BlackLittermanStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
+}
+
+// OnRebalance 再平衡后回调
+func (s *BlackLittermanStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
+}
+
+// Trigger 返回最近一次再平衡的触发类型
+func (s *BlackLittermanStrategy) Trigger() types.RebalanceTrigger {
+	return s.trigger
+}
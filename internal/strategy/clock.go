@@ -0,0 +1,31 @@
+package strategy
+
+import (
+	"time"
+)
+
+// rebalanceClock 按实际日期计算距上次再平衡的间隔
+// 间隔只取决于传入的日期，同一天多次判断或非日频数据都不会影响计算
+type rebalanceClock struct {
+	last time.Time // 上次再平衡日期，零值表示尚未建仓
+}
+
+// started 是否已完成首次再平衡
+func (c *rebalanceClock) started() bool {
+	return !c.last.IsZero()
+}
+
+// daysSince 距上次再平衡的自然日数
+func (c *rebalanceClock) daysSince(date time.Time) int {
+	return int(date.Sub(c.last).Hours() / 24)
+}
+
+// elapsed 距上次再平衡是否已满 interval 个自然日 (interval 不大于0或尚未建仓时为 true)
+func (c *rebalanceClock) elapsed(date time.Time, interval int) bool {
+	return interval <= 0 || !c.started() || c.daysSince(date) >= interval
+}
+
+// mark 记录再平衡日期
+func (c *rebalanceClock) mark(date time.Time) {
+	c.last = date
+}
@@ -0,0 +1,180 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 组合策略的再平衡投票方式
+const (
+	VoteAny      = "any"      // 任一子策略需要再平衡即再平衡 (默认)
+	VoteMajority = "majority" // 超过半数 (按混合权重) 的子策略需要再平衡
+	VoteAll      = "all"      // 全部子策略都需要再平衡
+)
+
+// CompositeStrategy 组合策略
+// 按配置的混合权重加权各子策略的目标权重 (如 70% 固定权重 + 30% 估值驱动)，
+// 是否再平衡由各子策略投票决定
+type CompositeStrategy struct {
+	orderGenerator
+	trendOverlay
+
+	name          string
+	children      []RebalanceStrategy
+	weights       []float64 // 混合权重 (合计为1)
+	voting        string
+	minTradeValue float64
+	trigger       types.RebalanceTrigger
+}
+
+// NewCompositeStrategy 创建组合策略
+func NewCompositeStrategy(config types.StrategyConfig) (*CompositeStrategy, error) {
+	if len(config.Components) == 0 {
+		return nil, fmt.Errorf("composite strategy requires at least one component")
+	}
+	voting := config.Voting
+	if voting == "" {
+		voting = VoteAny
+	}
+	if voting != VoteAny && voting != VoteMajority && voting != VoteAll {
+		return nil, fmt.Errorf("unknown composite voting mode: %s", config.Voting)
+	}
+
+	s := &CompositeStrategy{
+		orderGenerator: newOrderGenerator(config),
+		trendOverlay:   newTrendOverlay(config),
+		name:           config.Name,
+		voting:         voting,
+		minTradeValue:  config.MinTradeValue,
+	}
+	total := 0.0
+	for i, component := range config.Components {
+		if component.Weight <= 0 {
+			return nil, fmt.Errorf("composite component %d must have a positive weight", i+1)
+		}
+		child, err := New(component.Config)
+		if err != nil {
+			return nil, fmt.Errorf("composite component %d: %w", i+1, err)
+		}
+		s.children = append(s.children, child)
+		s.weights = append(s.weights, component.Weight)
+		total += component.Weight
+	}
+	for i := range s.weights {
+		s.weights[i] /= total
+	}
+	return s, nil
+}
+
+// Name 返回策略名称
+func (s *CompositeStrategy) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "Composite"
+}
+
+// SetIndicatorSource 向需要技术指标的子策略注入数据源
+func (s *CompositeStrategy) SetIndicatorSource(source data.IndicatorSource) {
+	for _, child := range s.children {
+		if consumer, ok := child.(IndicatorConsumer); ok {
+			consumer.SetIndicatorSource(source)
+		}
+	}
+}
+
+// ReferenceSymbols 各子策略需要加载的参考标的
+func (s *CompositeStrategy) ReferenceSymbols() []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, child := range s.children {
+		consumer, ok := child.(BenchmarkConsumer)
+		if !ok {
+			continue
+		}
+		for _, symbol := range consumer.ReferenceSymbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// TargetWeights 各子策略目标权重按混合权重加权
+func (s *CompositeStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	blended := make(map[string]float64)
+	for i, child := range s.children {
+		for symbol, w := range child.TargetWeights(date, ctx) {
+			blended[symbol] += s.weights[i] * w
+		}
+	}
+	return blended
+}
+
+// ShouldRebalance 按投票方式汇总各子策略的判断，触发类型取第一个投票再平衡的子策略
+func (s *CompositeStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	votes, voters := 0.0, 0
+	s.trigger = ""
+	for i, child := range s.children {
+		if !child.ShouldRebalance(date, ctx) {
+			continue
+		}
+		votes += s.weights[i]
+		voters++
+		if s.trigger == "" {
+			s.trigger = types.TriggerOther
+			if reporter, ok := child.(TriggerReporter); ok {
+				s.trigger = reporter.Trigger()
+			}
+		}
+	}
+
+	switch s.voting {
+	case VoteAll:
+		return voters == len(s.children)
+	case VoteMajority:
+		return votes > 0.5
+	}
+	return voters > 0
+}
+
+// GenerateOrders 生成交易订单
+func (s *CompositeStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
+}
+
+// OnRebalance 再平衡后通知所有子策略
+func (s *CompositeStrategy) OnRebalance(date time.Time) {
+	for _, child := range s.children {
+		child.OnRebalance(date)
+	}
+}
+
+// GetSignals 汇总子策略的估值信号 (同一标的取第一个给出信号的子策略)
+func (s *CompositeStrategy) GetSignals(portfolio *types.Portfolio) map[string]types.Signal {
+	signals := make(map[string]types.Signal)
+	for _, child := range s.children {
+		reporter, ok := child.(SignalReporter)
+		if !ok {
+			continue
+		}
+		for symbol, signal := range reporter.GetSignals(portfolio) {
+			if _, exists := signals[symbol]; !exists {
+				signals[symbol] = signal
+			}
+		}
+	}
+	return signals
+}
+
+// Trigger 返回最近一次再平衡的触发类型
+func (s *CompositeStrategy) Trigger() types.RebalanceTrigger {
+	return s.trigger
+}
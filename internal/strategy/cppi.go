@@ -0,0 +1,188 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// CPPIStrategy 固定比例投资组合保险策略
+// 风险资产敞口 = 乘数 × 缓冲垫 (组合价值 - 保底价值)，风险资产内部按目标权重分配，
+// 其余配置到安全资产 (未配置时持有现金)。组合跌向保底价值时敞口自动降低，适合有回撤约束的保守账户
+type CPPIStrategy struct {
+	orderGenerator
+	trendOverlay
+	rebalanceClock
+
+	name                 string
+	params               types.CPPIParams
+	riskyWeights         map[string]float64 // 风险资产内部的相对权重 (合计为1)
+	safeWeights          map[string]float64 // 安全资产内部的相对权重 (合计为1)
+	threshold            float64            // 风险资产权重偏离阈值
+	thresholdMode        string             // 偏离口径 (默认绝对偏离)
+	minTradeValue        float64
+	minRebalanceInterval int
+	trigger              types.RebalanceTrigger
+
+	start        time.Time
+	initialValue float64
+	peakValue    float64
+}
+
+// NewCPPIStrategy 创建CPPI策略
+func NewCPPIStrategy(config types.StrategyConfig) *CPPIStrategy {
+	params := types.DefaultCPPIParams()
+	if p := config.CPPIParams; p != nil {
+		params.FloorGrowth = p.FloorGrowth
+		params.Ratchet = p.Ratchet
+		params.SafeWeights = p.SafeWeights
+		if p.Floor > 0 {
+			params.Floor = p.Floor
+		}
+		if p.Multiplier > 0 {
+			params.Multiplier = p.Multiplier
+		}
+		if p.MaxExposure > 0 {
+			params.MaxExposure = p.MaxExposure
+		}
+	}
+
+	return &CPPIStrategy{
+		orderGenerator:       newOrderGenerator(config),
+		trendOverlay:         newTrendOverlay(config),
+		name:                 config.Name,
+		params:               *params,
+		riskyWeights:         relativeWeights(types.ExpandClassWeights(config.AssetClasses, config.TargetWeights)),
+		safeWeights:          relativeWeights(params.SafeWeights),
+		threshold:            config.Threshold,
+		thresholdMode:        thresholdMode(config.ThresholdMode, ThresholdAbsolute),
+		minTradeValue:        config.MinTradeValue,
+		minRebalanceInterval: config.MinRebalanceInterval,
+	}
+}
+
+// relativeWeights 归一化为合计为1的相对权重
+func relativeWeights(weights map[string]float64) map[string]float64 {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	result := make(map[string]float64, len(weights))
+	if total <= 0 {
+		return result
+	}
+	for symbol, w := range weights {
+		result[symbol] = w / total
+	}
+	return result
+}
+
+// Name 返回策略名称
+func (s *CPPIStrategy) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "CPPI"
+}
+
+// observe 记录初始价值和最高价值 (保底价值的基准)
+func (s *CPPIStrategy) observe(date time.Time, portfolio *types.Portfolio) {
+	if s.initialValue == 0 {
+		s.initialValue = portfolio.TotalValue
+		s.start = date
+	}
+	if portfolio.TotalValue > s.peakValue {
+		s.peakValue = portfolio.TotalValue
+	}
+}
+
+// floor 当前保底价值：初始资金 (Ratchet 时为最高价值) × Floor，并按 FloorGrowth 随时间增长
+func (s *CPPIStrategy) floor(date time.Time) float64 {
+	base := s.initialValue
+	if s.params.Ratchet && s.peakValue > base {
+		base = s.peakValue
+	}
+	floor := base * s.params.Floor
+	if s.params.FloorGrowth != 0 && date.After(s.start) {
+		years := date.Sub(s.start).Hours() / 24 / 365
+		floor *= math.Pow(1+s.params.FloorGrowth, years)
+	}
+	return floor
+}
+
+// riskyExposure 按CPPI规则计算的风险资产目标权重
+func (s *CPPIStrategy) riskyExposure(date time.Time, portfolio *types.Portfolio) float64 {
+	value := portfolio.TotalValue
+	if value <= 0 {
+		return 0
+	}
+	cushion := value - s.floor(date)
+	if cushion <= 0 {
+		return 0
+	}
+	return math.Min(s.params.Multiplier*cushion/value, s.params.MaxExposure)
+}
+
+// currentExposure 当前风险资产权重
+func (s *CPPIStrategy) currentExposure(portfolio *types.Portfolio) float64 {
+	current := portfolio.GetWeights()
+	exposure := 0.0
+	for symbol := range s.riskyWeights {
+		exposure += current[symbol]
+	}
+	return exposure
+}
+
+// TargetWeights 风险资产按敞口分配，其余分配给安全资产
+func (s *CPPIStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	s.observe(date, ctx.Portfolio)
+	exposure := s.riskyExposure(date, ctx.Portfolio)
+
+	weights := make(map[string]float64, len(s.riskyWeights)+len(s.safeWeights))
+	for symbol, w := range s.riskyWeights {
+		weights[symbol] += exposure * w
+	}
+	for symbol, w := range s.safeWeights {
+		weights[symbol] += (1 - exposure) * w
+	}
+	return weights
+}
+
+// ShouldRebalance 风险资产权重偏离CPPI目标敞口超过阈值时再平衡 (阈值为0时每个间隔都再平衡)
+func (s *CPPIStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	s.observe(date, ctx.Portfolio)
+	if !s.started() {
+		s.trigger = types.TriggerInitial
+		return true
+	}
+
+	if !s.elapsed(date, s.minRebalanceInterval) {
+		return false
+	}
+
+	if s.threshold <= 0 {
+		s.trigger = types.TriggerTime
+		return true
+	}
+	if math.Abs(deviation(s.thresholdMode, s.currentExposure(ctx.Portfolio), s.riskyExposure(date, ctx.Portfolio))) > s.threshold {
+		s.trigger = types.TriggerThreshold
+		return true
+	}
+	return false
+}
+
+// GenerateOrders 生成交易订单
+func (s *CPPIStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
+}
+
+// OnRebalance 再平衡后回调
+func (s *CPPIStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
+}
+
+// Trigger 返回最近一次再平衡的触发类型
+func (s *CPPIStrategy) Trigger() types.RebalanceTrigger {
+	return s.trigger
+}
@@ -0,0 +1,42 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// New 根据策略类型创建策略
+func New(config types.StrategyConfig) (RebalanceStrategy, error) {
+	switch strings.ToLower(config.Type) {
+	case "fixed_weight", "fixedweight", "threshold", "thresholdbased", "threshold_based":
+		return NewFixedWeightStrategy(config), nil
+	case "time_based", "timebased":
+		return NewTimeBasedStrategy(config), nil
+	case "valuation":
+		return NewValuationStrategy(config), nil
+	case "weighted_valuation", "weightedvaluation":
+		return NewWeightedValuationStrategy(config), nil
+	case "multi_level", "multilevel":
+		return NewMultiLevelStrategy(config), nil
+	case "cppi":
+		return NewCPPIStrategy(config), nil
+	case "black_litterman", "blacklitterman":
+		return NewBlackLittermanStrategy(config), nil
+	case "composite":
+		composite, err := NewCompositeStrategy(config)
+		if err != nil {
+			return nil, err
+		}
+		return composite, nil
+	case "regime_switch", "regimeswitch", "regime":
+		regime, err := NewRegimeSwitchStrategy(config)
+		if err != nil {
+			return nil, err
+		}
+		return regime, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy type: %s", config.Type)
+	}
+}
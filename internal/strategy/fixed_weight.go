@@ -9,24 +9,37 @@ import (
 
 // FixedWeightStrategy 固定权重再平衡策略
 type FixedWeightStrategy struct {
+	orderGenerator
+	trendOverlay
+	benchmarkDrift
+	rebalanceClock
+
 	name                 string
 	targetWeights        map[string]float64
-	threshold            float64 // 偏离阈值，触发再平衡
-	minTradeValue        float64 // 最小交易金额
-	minRebalanceInterval int     // 最小再平衡间隔天数
-	lastRebalanceTime    time.Time
-	daysSinceRebalance   int
+	threshold            float64            // 偏离阈值，触发再平衡
+	thresholdMode        string             // 偏离口径 (默认绝对偏离)
+	thresholds           map[string]float64 // 按标的的偏离阈值
+	minTradeValue        float64            // 最小交易金额
+	minRebalanceInterval int                // 最小再平衡间隔天数
+	rebalanceMode        string             // 调仓模式
+	rebalanceTopK        int                // worst 模式下每次最多调整的标的数
 }
 
 // NewFixedWeightStrategy 创建固定权重策略
 func NewFixedWeightStrategy(config types.StrategyConfig) *FixedWeightStrategy {
 	return &FixedWeightStrategy{
+		orderGenerator:       newOrderGenerator(config),
+		trendOverlay:         newTrendOverlay(config),
+		benchmarkDrift:       newBenchmarkDrift(config),
 		name:                 config.Name,
-		targetWeights:        config.TargetWeights,
+		targetWeights:        types.ExpandClassWeights(config.AssetClasses, config.TargetWeights),
 		threshold:            config.Threshold,
+		thresholdMode:        thresholdMode(config.ThresholdMode, ThresholdAbsolute),
+		thresholds:           config.SymbolThresholds,
 		minTradeValue:        config.MinTradeValue,
 		minRebalanceInterval: config.MinRebalanceInterval,
-		daysSinceRebalance:   0,
+		rebalanceMode:        config.RebalanceMode,
+		rebalanceTopK:        config.RebalanceTopK,
 	}
 }
 
@@ -38,16 +51,21 @@ func (s *FixedWeightStrategy) Name() string {
 	return "FixedWeight"
 }
 
-// TargetWeights 返回目标权重
-func (s *FixedWeightStrategy) TargetWeights(portfolio *types.Portfolio, prices map[string]float64) map[string]float64 {
-	return s.targetWeights
+// TargetWeights 返回目标权重 (相对漂移模式下为按基准收益漂移后的权重)
+func (s *FixedWeightStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	target := s.driftedWeights(s.targetWeights, date)
+	return applyRebalanceMode(s.rebalanceMode, s.rebalanceTopK, ctx.Portfolio, target, s.BandWidth)
+}
+
+// BandWidth 返回标的允许偏离的单边宽度 (按偏离口径换算为绝对权重)
+func (s *FixedWeightStrategy) BandWidth(symbol string, target float64) float64 {
+	return thresholdWidth(s.thresholdMode, symbolThreshold(s.thresholds, symbol, s.threshold), target)
 }
 
 // ShouldRebalance 判断是否需要再平衡
-func (s *FixedWeightStrategy) ShouldRebalance(portfolio *types.Portfolio, prices map[string]float64) bool {
+func (s *FixedWeightStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
 	// 检查最小再平衡间隔
-	s.daysSinceRebalance++
-	if s.minRebalanceInterval > 0 && s.daysSinceRebalance < s.minRebalanceInterval {
+	if !s.elapsed(date, s.minRebalanceInterval) {
 		return false
 	}
 
@@ -57,16 +75,15 @@ func (s *FixedWeightStrategy) ShouldRebalance(portfolio *types.Portfolio, prices
 	}
 
 	// 计算当前权重与目标权重的偏离
-	currentWeights := portfolio.GetWeights()
+	currentWeights := ctx.Portfolio.GetWeights()
 
-	for symbol, targetWeight := range s.targetWeights {
+	for symbol, targetWeight := range s.driftedWeights(s.targetWeights, date) {
 		currentWeight, ok := currentWeights[symbol]
 		if !ok {
 			currentWeight = 0
 		}
 
-		deviation := math.Abs(currentWeight - targetWeight)
-		if deviation > s.threshold {
+		if math.Abs(deviation(s.thresholdMode, currentWeight, targetWeight)) > symbolThreshold(s.thresholds, symbol, s.threshold) {
 			return true
 		}
 	}
@@ -75,74 +92,13 @@ func (s *FixedWeightStrategy) ShouldRebalance(portfolio *types.Portfolio, prices
 }
 
 // GenerateOrders 生成交易订单
-func (s *FixedWeightStrategy) GenerateOrders(portfolio *types.Portfolio, targetWeights map[string]float64, prices map[string]float64) []types.Order {
-	orders := make([]types.Order, 0)
-	totalValue := portfolio.TotalValue
-
-	if totalValue <= 0 {
-		return orders
-	}
-
-	// 计算目标持仓金额
-	targetValues := make(map[string]float64)
-	for symbol, weight := range targetWeights {
-		targetValues[symbol] = totalValue * weight
-	}
-
-	// 先处理卖出订单，释放现金
-	sellOrders := make([]types.Order, 0)
-	buyOrders := make([]types.Order, 0)
-
-	for symbol, targetValue := range targetValues {
-		price, ok := prices[symbol]
-		if !ok || price <= 0 {
-			continue
-		}
-
-		currentValue := 0.0
-		if pos, exists := portfolio.Positions[symbol]; exists {
-			currentValue = pos.Value
-		}
-
-		diff := targetValue - currentValue
-
-		// 忽略小额交易
-		if math.Abs(diff) < s.minTradeValue {
-			continue
-		}
-
-		quantity := math.Abs(diff) / price
-
-		if diff < 0 {
-			// 需要卖出
-			sellOrders = append(sellOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "SELL",
-				Quantity: quantity,
-				Price:    price,
-			})
-		} else {
-			// 需要买入
-			buyOrders = append(buyOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "BUY",
-				Quantity: quantity,
-				Price:    price,
-			})
-		}
-	}
-
-	// 先添加卖出订单，再添加买入订单
-	orders = append(orders, sellOrders...)
-	orders = append(orders, buyOrders...)
-
-	return orders
+func (s *FixedWeightStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
 }
 
 // OnRebalance 再平衡后回调
-func (s *FixedWeightStrategy) OnRebalance() {
-	s.lastRebalanceTime = time.Now()
-	s.daysSinceRebalance = 0
+func (s *FixedWeightStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
 }
 
 // SetThreshold 设置阈值
@@ -154,3 +110,11 @@ func (s *FixedWeightStrategy) SetThreshold(threshold float64) {
 func (s *FixedWeightStrategy) SetMinTradeValue(minValue float64) {
 	s.minTradeValue = minValue
 }
+
+// Trigger 返回再平衡触发类型 (阈值为0时每个间隔都再平衡，视为定期)
+func (s *FixedWeightStrategy) Trigger() types.RebalanceTrigger {
+	if s.threshold <= 0 {
+		return types.TriggerTime
+	}
+	return types.TriggerThreshold
+}
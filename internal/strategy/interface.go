@@ -1,23 +1,76 @@
 package strategy
 
 import (
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
 )
 
+// Context 策略决策所需的当日市场和账户状态，由引擎在每个交易日构造
+type Context struct {
+	Portfolio *types.Portfolio   // 当前投资组合 (已按当日价格估值)
+	Prices    map[string]float64 // 当日价格
+}
+
 // RebalanceStrategy 再平衡策略接口
+// 各方法接收当前交易日，策略按实际日期计算间隔等时间状态，不依赖调用次数
 type RebalanceStrategy interface {
 	// Name 策略名称
 	Name() string
 
 	// TargetWeights 计算目标权重
-	TargetWeights(portfolio *types.Portfolio, prices map[string]float64) map[string]float64
+	TargetWeights(date time.Time, ctx *Context) map[string]float64
 
-	// ShouldRebalance 判断是否需要再平衡
-	ShouldRebalance(portfolio *types.Portfolio, prices map[string]float64) bool
+	// ShouldRebalance 判断是否需要再平衡 (同一天可重复调用，结果不变)
+	ShouldRebalance(date time.Time, ctx *Context) bool
 
 	// GenerateOrders 生成交易订单
-	GenerateOrders(portfolio *types.Portfolio, targetWeights map[string]float64, prices map[string]float64) []types.Order
+	GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order
 
 	// OnRebalance 再平衡后回调 (用于更新内部状态)
-	OnRebalance()
+	OnRebalance(date time.Time)
+}
+
+// SignalReporter 可输出估值信号的策略 (用于报告和日志)
+type SignalReporter interface {
+	// GetSignals 获取所有持仓的信号
+	GetSignals(portfolio *types.Portfolio) map[string]types.Signal
+}
+
+// CashReserveReporter 可报告现金约束突破的策略
+type CashReserveReporter interface {
+	// CashViolations 返回买单超过可用现金的记录
+	CashViolations() []types.CashViolation
+}
+
+// BandReporter 有偏离区间的策略 (用于统计实际权重超出区间的天数)
+type BandReporter interface {
+	// BandWidth 标的在目标权重为 target 时允许偏离的单边宽度 (绝对权重)
+	BandWidth(symbol string, target float64) float64
+}
+
+// TriggerReporter 可说明再平衡触发类型的策略 (用于按触发类型统计)
+type TriggerReporter interface {
+	// Trigger 返回最近一次 ShouldRebalance 为 true 时的触发类型
+	Trigger() types.RebalanceTrigger
+}
+
+// IndicatorConsumer 需要技术指标的策略，引擎加载数据后注入指标数据源
+// 策略在 ShouldRebalance/TargetWeights 中以传入的交易日为当前日期查询指标
+type IndicatorConsumer interface {
+	// SetIndicatorSource 设置技术指标数据源
+	SetIndicatorSource(source data.IndicatorSource)
+}
+
+// BenchmarkConsumer 需要参考标的 (如各资产的基准指数) 历史的策略，引擎在注入指标数据源前加载这些标的
+type BenchmarkConsumer interface {
+	// ReferenceSymbols 需要加载的参考标的
+	ReferenceSymbols() []string
+}
+
+// RegimeReporter 可报告市场状态切换的策略
+type RegimeReporter interface {
+	// RegimeChanges 返回状态切换记录
+	RegimeChanges() []types.RegimeChange
 }
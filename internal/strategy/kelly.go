@@ -0,0 +1,94 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 估值倾斜的仓位模式
+const (
+	SizingFixed = "fixed" // 按固定比例 (TrimRatio/BuyRatio等) 调整权重 (默认)
+	SizingKelly = "kelly" // 按分数凯利公式确定调整幅度
+)
+
+// kellySizing 分数凯利仓位：估值信号换算为年化超额收益预期 μ，
+// 权重倾斜比例 = Fraction × μ/σ² (σ 为历史波动率)，加仓和减仓分别受 MaxTilt/MaxCut 限制。
+// 同样的信号下，波动率越高的资产倾斜越小
+type kellySizing struct {
+	mode   string
+	params types.KellyParams
+	source data.IndicatorSource
+}
+
+// newKellySizing 根据策略配置创建凯利仓位
+func newKellySizing(config types.StrategyConfig) kellySizing {
+	params := types.DefaultKellyParams()
+	if p := config.KellyParams; p != nil {
+		if p.Fraction > 0 {
+			params.Fraction = p.Fraction
+		}
+		if p.ExpectedReturn > 0 {
+			params.ExpectedReturn = p.ExpectedReturn
+		}
+		if p.VolWindow > 0 {
+			params.VolWindow = p.VolWindow
+		}
+		if p.DefaultVol > 0 {
+			params.DefaultVol = p.DefaultVol
+		}
+		if p.MaxTilt > 0 {
+			params.MaxTilt = p.MaxTilt
+		}
+		if p.MaxCut > 0 {
+			params.MaxCut = math.Min(p.MaxCut, 1)
+		}
+	}
+	return kellySizing{mode: config.Sizing, params: *params}
+}
+
+// SetIndicatorSource 设置波动率数据源 (未设置时使用默认波动率)
+func (k *kellySizing) SetIndicatorSource(source data.IndicatorSource) {
+	k.source = source
+}
+
+// enabled 是否使用凯利仓位
+func (k *kellySizing) enabled() bool {
+	return k.mode == SizingKelly
+}
+
+// signalScore 估值信号的强度 (-1 到 1)，乘以 ExpectedReturn 即为超额收益预期
+func signalScore(signalType types.SignalType) float64 {
+	switch signalType {
+	case types.SignalStrongSell, types.SignalSell:
+		return -1
+	case types.SignalReduce, types.SignalTrim:
+		return -0.5
+	case types.SignalStrongBuy, types.SignalBuy:
+		return 1
+	case types.SignalStrongHold:
+		return 0.5
+	}
+	return 0
+}
+
+// tilt 按分数凯利计算的权重倾斜比例 (相对基础权重)
+func (k *kellySizing) tilt(symbol string, signalType types.SignalType, date time.Time) float64 {
+	mu := signalScore(signalType) * k.params.ExpectedReturn
+	if mu == 0 {
+		return 0
+	}
+
+	vol := k.params.DefaultVol
+	if k.source != nil {
+		if v, ok := k.source.Indicator(symbol, indicators.Volatility, k.params.VolWindow, date); ok && v > 0 {
+			vol = v
+		}
+	}
+
+	f := k.params.Fraction * mu / (vol * vol)
+	return math.Max(math.Min(f, k.params.MaxTilt), -k.params.MaxCut)
+}
@@ -0,0 +1,137 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// MultiLevelStrategy 两级再平衡策略
+// 先将资产类别 (股票/债券/黄金等) 恢复到类别目标权重，再在类别内按估值信号分配
+type MultiLevelStrategy struct {
+	orderGenerator
+	trendOverlay
+	rebalanceClock
+
+	name                 string
+	classes              []types.AssetClass
+	intraWeights         map[string]float64 // 类别内相对权重 (未归类标的为绝对权重)
+	threshold            float64            // 类别权重偏离阈值
+	thresholdMode        string             // 偏离口径 (默认绝对偏离)
+	minTradeValue        float64
+	minRebalanceInterval int
+	trigger              types.RebalanceTrigger
+
+	valuation *ValuationStrategy // 类别内估值信号
+}
+
+// NewMultiLevelStrategy 创建两级再平衡策略
+func NewMultiLevelStrategy(config types.StrategyConfig) *MultiLevelStrategy {
+	return &MultiLevelStrategy{
+		orderGenerator:       newOrderGenerator(config),
+		trendOverlay:         newTrendOverlay(config),
+		name:                 config.Name,
+		classes:              config.AssetClasses,
+		intraWeights:         config.TargetWeights,
+		threshold:            config.Threshold,
+		thresholdMode:        thresholdMode(config.ThresholdMode, ThresholdAbsolute),
+		minTradeValue:        config.MinTradeValue,
+		minRebalanceInterval: config.MinRebalanceInterval,
+		valuation:            NewValuationStrategy(config),
+	}
+}
+
+// Name 返回策略名称
+func (s *MultiLevelStrategy) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "MultiLevel"
+}
+
+// SetIndicatorSource 设置类别内估值倾斜使用的波动率数据源 (凯利仓位模式)
+func (s *MultiLevelStrategy) SetIndicatorSource(source data.IndicatorSource) {
+	s.valuation.SetIndicatorSource(source)
+}
+
+// TargetWeights 类别权重固定为类别目标，类别内按估值信号倾斜后重新分配
+func (s *MultiLevelStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	tilted := make(map[string]float64, len(s.intraWeights))
+	for symbol, w := range s.intraWeights {
+		tilted[symbol] = w
+	}
+	for symbol, pos := range ctx.Portfolio.Positions {
+		if pos.Fundamental == nil {
+			continue
+		}
+		signal := s.valuation.evaluateAsset(pos)
+		tilted[symbol] = s.valuation.tiltWeight(symbol, signal.Type, s.intraWeights[symbol], date)
+	}
+
+	// 倾斜只改变类别内的相对比例，类别权重仍按类别目标分配
+	return s.valuation.normalizeWeights(types.ExpandClassWeights(s.classes, tilted))
+}
+
+// ShouldRebalance 类别权重偏离超过阈值时做类别层面再平衡，否则按类别内估值信号判断
+func (s *MultiLevelStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	if !s.started() {
+		s.trigger = types.TriggerInitial
+		return true
+	}
+
+	if !s.elapsed(date, s.minRebalanceInterval) {
+		return false
+	}
+
+	if s.classDrifted(ctx.Portfolio) {
+		s.trigger = types.TriggerThreshold
+		return true
+	}
+
+	for _, pos := range ctx.Portfolio.Positions {
+		signal := s.valuation.evaluateAsset(pos)
+		switch signal.Type {
+		case types.SignalStrongSell, types.SignalSell, types.SignalReduce, types.SignalTrim, types.SignalBuy:
+			s.trigger = types.TriggerValuation
+			return true
+		}
+	}
+
+	return false
+}
+
+// classDrifted 是否有设置了目标的类别偏离超过阈值
+func (s *MultiLevelStrategy) classDrifted(portfolio *types.Portfolio) bool {
+	current := types.AggregateByClass(s.classes, portfolio.GetWeights())
+	for _, class := range s.classes {
+		if class.Weight <= 0 {
+			continue
+		}
+		if math.Abs(deviation(s.thresholdMode, current[class.Name], class.Weight)) > s.threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateOrders 生成交易订单
+func (s *MultiLevelStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
+}
+
+// OnRebalance 再平衡后回调
+func (s *MultiLevelStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
+}
+
+// GetSignals 获取所有持仓的信号 (用于报告)
+func (s *MultiLevelStrategy) GetSignals(portfolio *types.Portfolio) map[string]types.Signal {
+	return s.valuation.GetSignals(portfolio)
+}
+
+// Trigger 返回最近一次再平衡的触发类型 (类别偏离为阈值触发，类别内调整为估值触发)
+func (s *MultiLevelStrategy) Trigger() types.RebalanceTrigger {
+	return s.trigger
+}
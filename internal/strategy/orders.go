@@ -0,0 +1,179 @@
+package strategy
+
+import (
+	"math"
+	"sort"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// orderGenerator 各策略共用的订单生成逻辑 (现金保留约束、买单按可用现金缩放)
+type orderGenerator struct {
+	minCashWeight  float64            // 最低现金权重
+	allowShort     bool               // 允许负目标权重
+	maxGross       float64            // 总敞口上限 (大于1允许融资)
+	minTradeValues map[string]float64 // 按标的的最小交易金额
+	lotSizes       map[string]float64 // 按标的的交易单位
+	cashViolations []types.CashViolation
+}
+
+// newOrderGenerator 根据策略配置创建订单生成器
+func newOrderGenerator(config types.StrategyConfig) orderGenerator {
+	return orderGenerator{
+		minCashWeight:  config.MinCashWeight,
+		allowShort:     config.AllowShort,
+		maxGross:       config.MaxGrossExposure,
+		minTradeValues: config.SymbolMinTradeValues,
+		lotSizes:       config.LotSizes,
+	}
+}
+
+// generateOrders 根据目标权重生成订单，先卖后买；目标权重中的现金 (CashSymbol) 不生成订单，留作现金
+// 买单按 (现金 + 卖出所得 - 保留现金) 计算可用资金，不足时按比例缩放并记录约束突破
+func (g *orderGenerator) generateOrders(portfolio *types.Portfolio, targetWeights map[string]float64, prices map[string]float64, minTradeValue float64) []types.Order {
+	orders := make([]types.Order, 0)
+	totalValue := portfolio.TotalValue
+
+	if totalValue <= 0 {
+		return orders
+	}
+
+	// 目标权重超过可投资比例时整体缩放，保证最低现金权重 (允许融资时按总敞口上限)
+	scale := 1.0
+	investable := 1 - g.minCashWeight
+	if g.maxGross > 1 {
+		investable = g.maxGross
+	}
+	exposure := 0.0
+	for symbol, w := range targetWeights {
+		if symbol == types.CashSymbol || (w < 0 && !g.allowShort) {
+			continue
+		}
+		exposure += math.Abs(w)
+	}
+	if exposure > investable && exposure > 0 {
+		scale = investable / exposure
+	}
+
+	symbols := make([]string, 0, len(targetWeights))
+	for symbol := range targetWeights {
+		if symbol == types.CashSymbol {
+			continue
+		}
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	// 先处理卖出订单，释放现金
+	sellOrders := make([]types.Order, 0)
+	buyOrders := make([]types.Order, 0)
+	sellValue := 0.0
+	buyValue := 0.0
+
+	for _, symbol := range symbols {
+		price, ok := prices[symbol]
+		if !ok || price <= 0 {
+			continue
+		}
+
+		weight := targetWeights[symbol]
+		if weight < 0 && !g.allowShort {
+			weight = 0
+		}
+		targetValue := totalValue * weight * scale
+		currentValue := 0.0
+		if pos, exists := portfolio.Positions[symbol]; exists {
+			currentValue = pos.Value
+		}
+
+		diff := targetValue - currentValue
+
+		// 忽略小额交易
+		if math.Abs(diff) < g.minTrade(symbol, minTradeValue) {
+			continue
+		}
+
+		quantity := math.Abs(diff) / price
+		if diff < 0 {
+			quantity = g.roundSell(symbol, quantity, portfolio)
+		} else {
+			quantity = types.RoundLot(quantity, g.lotSizes[symbol])
+		}
+		if quantity <= 0 {
+			continue
+		}
+
+		if diff < 0 {
+			sellOrders = append(sellOrders, types.Order{
+				Symbol:   symbol,
+				Side:     "SELL",
+				Quantity: quantity,
+				Price:    price,
+			})
+			sellValue += quantity * price
+		} else {
+			buyOrders = append(buyOrders, types.Order{
+				Symbol:   symbol,
+				Side:     "BUY",
+				Quantity: quantity,
+				Price:    price,
+			})
+			buyValue += quantity * price
+		}
+	}
+
+	// 按可用现金缩放买单
+	available := portfolio.Cash + sellValue - totalValue*g.minCashWeight
+	if g.maxGross > 1 {
+		available += (g.maxGross - 1) * totalValue
+	}
+	if buyValue > available && buyValue > 0 {
+		g.cashViolations = append(g.cashViolations, types.CashViolation{
+			Timestamp: portfolio.Timestamp,
+			Required:  buyValue,
+			Available: available,
+		})
+
+		ratio := math.Max(available, 0) / buyValue
+		scaled := make([]types.Order, 0, len(buyOrders))
+		for _, order := range buyOrders {
+			order.Quantity = types.RoundLot(order.Quantity*ratio, g.lotSizes[order.Symbol])
+			if order.Quantity*order.Price < g.minTrade(order.Symbol, minTradeValue) || order.Quantity <= 0 {
+				continue
+			}
+			scaled = append(scaled, order)
+		}
+		buyOrders = scaled
+	}
+
+	// 先添加卖出订单，再添加买入订单
+	orders = append(orders, sellOrders...)
+	orders = append(orders, buyOrders...)
+
+	return orders
+}
+
+// roundSell 卖出数量按交易单位向下取整，卖出全部持仓时按持有数量卖出 (零股只能一次性卖出)
+func (g *orderGenerator) roundSell(symbol string, quantity float64, portfolio *types.Portfolio) float64 {
+	lot := g.lotSizes[symbol]
+	if lot <= 0 {
+		return quantity
+	}
+	if pos, ok := portfolio.Positions[symbol]; ok && pos.Quantity > 0 && quantity >= pos.Quantity*(1-1e-9) {
+		return pos.Quantity
+	}
+	return types.RoundLot(quantity, lot)
+}
+
+// minTrade 标的的最小交易金额，未单独设置时为 minTradeValue
+func (g *orderGenerator) minTrade(symbol string, minTradeValue float64) float64 {
+	if v, ok := g.minTradeValues[symbol]; ok {
+		return v
+	}
+	return minTradeValue
+}
+
+// CashViolations 返回买单因现金不足 (含保留现金) 被缩放的记录
+func (g *orderGenerator) CashViolations() []types.CashViolation {
+	return g.cashViolations
+}
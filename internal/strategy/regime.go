@@ -0,0 +1,243 @@
+package strategy
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// RegimeSwitchStrategy 状态切换策略
+// 按状态指标 (基准价格相对均线，或已实现波动率) 判断 risk_on/risk_off，分别使用两个子策略。
+// 状态切换当日按新策略的目标权重再平衡 (触发类型 regime)，其余时间由当前子策略决定
+type RegimeSwitchStrategy struct {
+	orderGenerator
+	trendOverlay
+
+	name          string
+	params        types.RegimeParams
+	riskOn        RebalanceStrategy
+	riskOff       RebalanceStrategy
+	minTradeValue float64
+	source        data.IndicatorSource
+	regime        string
+	started       bool // 已完成首次建仓
+	changes       []types.RegimeChange
+	trigger       types.RebalanceTrigger
+}
+
+// NewRegimeSwitchStrategy 创建状态切换策略
+func NewRegimeSwitchStrategy(config types.StrategyConfig) (*RegimeSwitchStrategy, error) {
+	if config.Regime == nil || config.Regime.RiskOn == nil || config.Regime.RiskOff == nil {
+		return nil, fmt.Errorf("regime switch strategy requires risk_on and risk_off strategies")
+	}
+	params := *config.Regime
+	switch params.Indicator {
+	case "", types.RegimeIndicatorMA:
+		params.Indicator = types.RegimeIndicatorMA
+		if params.Window <= 0 {
+			params.Window = 200
+		}
+	case types.RegimeIndicatorVolatility:
+		if params.Window <= 0 {
+			params.Window = 20
+		}
+		if params.Threshold <= 0 {
+			return nil, fmt.Errorf("regime volatility indicator requires a positive threshold")
+		}
+	default:
+		return nil, fmt.Errorf("unknown regime indicator: %s", params.Indicator)
+	}
+	if params.Band < 0 || params.Band >= 1 {
+		return nil, fmt.Errorf("regime band must be between 0 and 1")
+	}
+	if params.Symbol == "" {
+		return nil, fmt.Errorf("regime switch strategy requires a symbol")
+	}
+
+	riskOn, err := New(*params.RiskOn)
+	if err != nil {
+		return nil, fmt.Errorf("risk_on strategy: %w", err)
+	}
+	riskOff, err := New(*params.RiskOff)
+	if err != nil {
+		return nil, fmt.Errorf("risk_off strategy: %w", err)
+	}
+
+	return &RegimeSwitchStrategy{
+		orderGenerator: newOrderGenerator(config),
+		trendOverlay:   newTrendOverlay(config),
+		name:           config.Name,
+		params:         params,
+		riskOn:         riskOn,
+		riskOff:        riskOff,
+		minTradeValue:  config.MinTradeValue,
+		regime:         types.RegimeRiskOn,
+	}, nil
+}
+
+// Name 返回策略名称
+func (s *RegimeSwitchStrategy) Name() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "RegimeSwitch"
+}
+
+// SetIndicatorSource 设置状态指标数据源，并注入需要技术指标的子策略
+func (s *RegimeSwitchStrategy) SetIndicatorSource(source data.IndicatorSource) {
+	s.source = source
+	for _, child := range []RebalanceStrategy{s.riskOn, s.riskOff} {
+		if consumer, ok := child.(IndicatorConsumer); ok {
+			consumer.SetIndicatorSource(source)
+		}
+	}
+}
+
+// ReferenceSymbols 状态标的及子策略需要加载的参考标的
+func (s *RegimeSwitchStrategy) ReferenceSymbols() []string {
+	seen := map[string]bool{s.params.Symbol: true}
+	symbols := []string{s.params.Symbol}
+	for _, child := range []RebalanceStrategy{s.riskOn, s.riskOff} {
+		consumer, ok := child.(BenchmarkConsumer)
+		if !ok {
+			continue
+		}
+		for _, symbol := range consumer.ReferenceSymbols() {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// active 当前状态使用的子策略
+func (s *RegimeSwitchStrategy) active() RebalanceStrategy {
+	if s.regime == types.RegimeRiskOff {
+		return s.riskOff
+	}
+	return s.riskOn
+}
+
+// observe 按状态指标判断当前状态，指标数据不足时保持原状态
+// 指标处于切换点 ×(1±Band) 的区间内时保持原状态，避免在切换点附近反复切换
+func (s *RegimeSwitchStrategy) observe(date time.Time) (string, float64, bool) {
+	if s.source == nil {
+		return s.regime, 0, false
+	}
+
+	// value 高于 upper 为 risk_off (波动率) 或 risk_on (价格/均线)
+	var value, pivot float64
+	riskOnAbove := false
+	if s.params.Indicator == types.RegimeIndicatorVolatility {
+		vol, ok := s.source.Indicator(s.params.Symbol, indicators.Volatility, s.params.Window, date)
+		if !ok {
+			return s.regime, 0, false
+		}
+		value, pivot = vol, s.params.Threshold
+	} else {
+		price, ok := s.source.Indicator(s.params.Symbol, indicators.Price, 0, date)
+		ma, maOK := s.source.Indicator(s.params.Symbol, indicators.SMA, s.params.Window, date)
+		if !ok || !maOK || ma <= 0 {
+			return s.regime, 0, false
+		}
+		value, pivot, riskOnAbove = price/ma, 1, true
+	}
+
+	upper, lower := pivot*(1+s.params.Band), pivot*(1-s.params.Band)
+	above, below := types.RegimeRiskOff, types.RegimeRiskOn
+	if riskOnAbove {
+		above, below = below, above
+	}
+	switch {
+	case value > upper:
+		return above, value, true
+	case value < lower:
+		return below, value, true
+	}
+	return s.regime, value, true
+}
+
+// update 更新当前状态，建仓后的状态切换记入切换记录，当日发生过切换时返回 true
+func (s *RegimeSwitchStrategy) update(date time.Time) bool {
+	if n := len(s.changes); n > 0 && s.changes[n-1].Timestamp.Equal(date) {
+		return true
+	}
+	regime, value, ok := s.observe(date)
+	if !ok || regime == s.regime {
+		return false
+	}
+	if !s.started {
+		s.regime = regime
+		return false
+	}
+	s.changes = append(s.changes, types.RegimeChange{
+		Timestamp: date,
+		From:      s.regime,
+		To:        regime,
+		Value:     value,
+	})
+	s.regime = regime
+	return true
+}
+
+// TargetWeights 当前状态子策略的目标权重 (首次建仓时按建仓日的状态)
+func (s *RegimeSwitchStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	if !s.started {
+		s.update(date)
+	}
+	return s.active().TargetWeights(date, ctx)
+}
+
+// ShouldRebalance 状态切换时再平衡，否则由当前子策略判断
+func (s *RegimeSwitchStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	if s.update(date) {
+		s.trigger = types.TriggerRegime
+		return true
+	}
+
+	active := s.active()
+	if !active.ShouldRebalance(date, ctx) {
+		return false
+	}
+	s.trigger = types.TriggerOther
+	if reporter, ok := active.(TriggerReporter); ok {
+		s.trigger = reporter.Trigger()
+	}
+	return true
+}
+
+// GenerateOrders 生成交易订单
+func (s *RegimeSwitchStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
+}
+
+// OnRebalance 再平衡后通知当前子策略
+func (s *RegimeSwitchStrategy) OnRebalance(date time.Time) {
+	s.started = true
+	s.active().OnRebalance(date)
+}
+
+// GetSignals 当前子策略的估值信号
+func (s *RegimeSwitchStrategy) GetSignals(portfolio *types.Portfolio) map[string]types.Signal {
+	if reporter, ok := s.active().(SignalReporter); ok {
+		return reporter.GetSignals(portfolio)
+	}
+	return nil
+}
+
+// Trigger 返回最近一次再平衡的触发类型
+func (s *RegimeSwitchStrategy) Trigger() types.RebalanceTrigger {
+	return s.trigger
+}
+
+// RegimeChanges 状态切换记录
+func (s *RegimeSwitchStrategy) RegimeChanges() []types.RegimeChange {
+	return s.changes
+}
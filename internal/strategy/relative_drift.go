@@ -0,0 +1,95 @@
+package strategy
+
+import (
+	"sort"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/data"
+	"github.com/opsxjacky/Rebalance-backtest/internal/indicators"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 目标权重漂移模式
+const (
+	DriftStatic   = "static"   // 目标权重固定 (默认)
+	DriftRelative = "relative" // 目标权重随各资产基准的收益漂移
+)
+
+// benchmarkDrift 相对漂移模式：目标权重为按各资产基准收益漂移后的权重 (即持有基准组合不再平衡时的权重)，
+// 偏离以此衡量，适合跟踪指数的子账户，不会被强制拉回固定权重。未设置基准的资产以自身价格为基准
+type benchmarkDrift struct {
+	mode       string
+	benchmarks map[string]string // 资产 -> 基准标的
+	source     data.IndicatorSource
+	baseLevels map[string]float64 // 各基准首次观察到的价格
+}
+
+// newBenchmarkDrift 根据策略配置创建相对漂移
+func newBenchmarkDrift(config types.StrategyConfig) benchmarkDrift {
+	return benchmarkDrift{
+		mode:       config.DriftMode,
+		benchmarks: config.Benchmarks,
+		baseLevels: make(map[string]float64),
+	}
+}
+
+// SetIndicatorSource 设置基准价格数据源
+func (d *benchmarkDrift) SetIndicatorSource(source data.IndicatorSource) {
+	d.source = source
+}
+
+// ReferenceSymbols 相对漂移模式下需要加载的基准标的
+func (d *benchmarkDrift) ReferenceSymbols() []string {
+	if d.mode != DriftRelative {
+		return nil
+	}
+	seen := make(map[string]bool)
+	symbols := make([]string, 0, len(d.benchmarks))
+	for _, bench := range d.benchmarks {
+		if !seen[bench] {
+			seen[bench] = true
+			symbols = append(symbols, bench)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// driftedWeights 按基准自首次观察以来的收益漂移目标权重，总权重保持不变
+// 非相对模式或没有数据源时返回原目标权重
+func (d *benchmarkDrift) driftedWeights(target map[string]float64, date time.Time) map[string]float64 {
+	if d.mode != DriftRelative || d.source == nil {
+		return target
+	}
+
+	drifted := make(map[string]float64, len(target))
+	total, driftedTotal := 0.0, 0.0
+	for symbol, w := range target {
+		bench := symbol
+		if b, ok := d.benchmarks[symbol]; ok && b != "" {
+			bench = b
+		}
+
+		growth := 1.0
+		if level, ok := d.source.Indicator(bench, indicators.Price, 0, date); ok && level > 0 {
+			base, seen := d.baseLevels[bench]
+			if !seen {
+				base = level
+				d.baseLevels[bench] = level
+			}
+			growth = level / base
+		}
+
+		drifted[symbol] = w * growth
+		total += w
+		driftedTotal += drifted[symbol]
+	}
+
+	if driftedTotal <= 0 {
+		return target
+	}
+	for symbol := range drifted {
+		drifted[symbol] *= total / driftedTotal
+	}
+	return drifted
+}
@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// StateSaver 可保存和恢复内部状态的策略
+// 用于断点续跑，以及按前一交易日保存的状态继续生成当日的再平衡信号
+type StateSaver interface {
+	// SaveState 导出内部状态
+	SaveState() (json.RawMessage, error)
+
+	// LoadState 恢复 SaveState 导出的状态
+	LoadState(state json.RawMessage) error
+}
+
+// strategyState 各策略共用的状态格式，未使用的字段省略
+type strategyState struct {
+	LastRebalance time.Time            `json:"last_rebalance"`        // 上次再平衡日期
+	BaseLevels    map[string]float64   `json:"base_levels,omitempty"` // 相对漂移模式下各基准的初始价格
+	Values        map[string]float64   `json:"values,omitempty"`      // 策略特有的数值状态
+	Regime        string               `json:"regime,omitempty"`
+	Started       bool                 `json:"started,omitempty"`
+	RegimeChanges []types.RegimeChange `json:"regime_changes,omitempty"`
+	Children      []json.RawMessage    `json:"children,omitempty"` // 子策略状态 (组合/状态切换策略)
+}
+
+// saveState 编码策略状态
+func saveState(state strategyState) (json.RawMessage, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal strategy state: %w", err)
+	}
+	return data, nil
+}
+
+// loadState 解码策略状态
+func loadState(data json.RawMessage) (strategyState, error) {
+	var state strategyState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("failed to unmarshal strategy state: %w", err)
+	}
+	return state, nil
+}
+
+// saveChildren 导出子策略状态，不支持状态保存的子策略为 null
+func saveChildren(children []RebalanceStrategy) ([]json.RawMessage, error) {
+	states := make([]json.RawMessage, len(children))
+	for i, child := range children {
+		saver, ok := child.(StateSaver)
+		if !ok {
+			states[i] = json.RawMessage("null")
+			continue
+		}
+		state, err := saver.SaveState()
+		if err != nil {
+			return nil, err
+		}
+		states[i] = state
+	}
+	return states, nil
+}
+
+// loadChildren 恢复子策略状态
+func loadChildren(children []RebalanceStrategy, states []json.RawMessage) error {
+	if len(states) != len(children) {
+		return fmt.Errorf("strategy state has %d children, strategy has %d", len(states), len(children))
+	}
+	for i, child := range children {
+		saver, ok := child.(StateSaver)
+		if !ok || string(states[i]) == "null" {
+			continue
+		}
+		if err := saver.LoadState(states[i]); err != nil {
+			return fmt.Errorf("child strategy %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// SaveState 导出上次再平衡日期
+func (c *rebalanceClock) SaveState() (json.RawMessage, error) {
+	return saveState(strategyState{LastRebalance: c.last})
+}
+
+// LoadState 恢复上次再平衡日期
+func (c *rebalanceClock) LoadState(data json.RawMessage) error {
+	state, err := loadState(data)
+	if err != nil {
+		return err
+	}
+	c.last = state.LastRebalance
+	return nil
+}
+
+// saveDriftState 导出上次再平衡日期和相对漂移的基准初始价格
+func saveDriftState(c *rebalanceClock, d *benchmarkDrift) (json.RawMessage, error) {
+	return saveState(strategyState{LastRebalance: c.last, BaseLevels: d.baseLevels})
+}
+
+// loadDriftState 恢复上次再平衡日期和相对漂移的基准初始价格
+func loadDriftState(c *rebalanceClock, d *benchmarkDrift, data json.RawMessage) error {
+	state, err := loadState(data)
+	if err != nil {
+		return err
+	}
+	c.last = state.LastRebalance
+	d.baseLevels = make(map[string]float64, len(state.BaseLevels))
+	for symbol, level := range state.BaseLevels {
+		d.baseLevels[symbol] = level
+	}
+	return nil
+}
+
+// SaveState 导出策略状态
+func (s *FixedWeightStrategy) SaveState() (json.RawMessage, error) {
+	return saveDriftState(&s.rebalanceClock, &s.benchmarkDrift)
+}
+
+// LoadState 恢复策略状态
+func (s *FixedWeightStrategy) LoadState(data json.RawMessage) error {
+	return loadDriftState(&s.rebalanceClock, &s.benchmarkDrift, data)
+}
+
+// SaveState 导出策略状态
+func (s *TimeBasedStrategy) SaveState() (json.RawMessage, error) {
+	return saveDriftState(&s.rebalanceClock, &s.benchmarkDrift)
+}
+
+// LoadState 恢复策略状态
+func (s *TimeBasedStrategy) LoadState(data json.RawMessage) error {
+	return loadDriftState(&s.rebalanceClock, &s.benchmarkDrift, data)
+}
+
+// SaveState 导出策略状态 (含保底价值的基准)
+func (s *CPPIStrategy) SaveState() (json.RawMessage, error) {
+	state := strategyState{LastRebalance: s.last}
+	if s.initialValue > 0 {
+		state.Values = map[string]float64{
+			"initial_value": s.initialValue,
+			"peak_value":    s.peakValue,
+			"start":         float64(s.start.Unix()),
+		}
+	}
+	return saveState(state)
+}
+
+// LoadState 恢复策略状态
+func (s *CPPIStrategy) LoadState(data json.RawMessage) error {
+	state, err := loadState(data)
+	if err != nil {
+		return err
+	}
+	s.last = state.LastRebalance
+	if v := state.Values; v["initial_value"] > 0 {
+		s.initialValue = v["initial_value"]
+		s.peakValue = v["peak_value"]
+		s.start = time.Unix(int64(v["start"]), 0).UTC()
+	}
+	return nil
+}
+
+// SaveState 导出各子策略的状态
+func (s *CompositeStrategy) SaveState() (json.RawMessage, error) {
+	children, err := saveChildren(s.children)
+	if err != nil {
+		return nil, err
+	}
+	return saveState(strategyState{Children: children})
+}
+
+// LoadState 恢复各子策略的状态
+func (s *CompositeStrategy) LoadState(data json.RawMessage) error {
+	state, err := loadState(data)
+	if err != nil {
+		return err
+	}
+	return loadChildren(s.children, state.Children)
+}
+
+// SaveState 导出当前状态、切换记录和两个子策略的状态
+func (s *RegimeSwitchStrategy) SaveState() (json.RawMessage, error) {
+	children, err := saveChildren([]RebalanceStrategy{s.riskOn, s.riskOff})
+	if err != nil {
+		return nil, err
+	}
+	return saveState(strategyState{
+		Regime:        s.regime,
+		Started:       s.started,
+		RegimeChanges: s.changes,
+		Children:      children,
+	})
+}
+
+// LoadState 恢复当前状态、切换记录和两个子策略的状态
+func (s *RegimeSwitchStrategy) LoadState(data json.RawMessage) error {
+	state, err := loadState(data)
+	if err != nil {
+		return err
+	}
+	if err := loadChildren([]RebalanceStrategy{s.riskOn, s.riskOff}, state.Children); err != nil {
+		return err
+	}
+	if state.Regime != "" {
+		s.regime = state.Regime
+	}
+	s.started = state.Started
+	s.changes = state.RegimeChanges
+	return nil
+}
@@ -0,0 +1,43 @@
+package strategy
+
+import (
+	"math"
+)
+
+// 偏离阈值口径
+const (
+	ThresholdAbsolute = "absolute" // 当前权重与目标权重之差 (如0.05表示偏离5个百分点)
+	ThresholdRelative = "relative" // 偏离占目标权重的比例 (如0.10表示偏离目标权重的10%)
+)
+
+// thresholdMode 配置的偏离口径，未设置时使用策略的默认口径
+func thresholdMode(mode, defaultMode string) string {
+	if mode == "" {
+		return defaultMode
+	}
+	return mode
+}
+
+// symbolThreshold 标的的偏离阈值，未单独设置时为策略阈值
+func symbolThreshold(thresholds map[string]float64, symbol string, threshold float64) float64 {
+	if t, ok := thresholds[symbol]; ok {
+		return t
+	}
+	return threshold
+}
+
+// deviation 按口径计算的偏离 (正值为超配)；相对口径下目标权重为0时按绝对偏离计算
+func deviation(mode string, current, target float64) float64 {
+	if mode == ThresholdRelative && target != 0 {
+		return (current - target) / math.Abs(target)
+	}
+	return current - target
+}
+
+// thresholdWidth 偏离阈值对应的单边宽度 (绝对权重)
+func thresholdWidth(mode string, threshold, target float64) float64 {
+	if mode == ThresholdRelative {
+		return math.Abs(target) * threshold
+	}
+	return threshold
+}
@@ -1,7 +1,6 @@
 package strategy
 
 import (
-	"math"
 	"time"
 
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
@@ -9,13 +8,15 @@ import (
 
 // TimeBasedStrategy 定期再平衡策略
 type TimeBasedStrategy struct {
+	orderGenerator
+	trendOverlay
+	benchmarkDrift
+	rebalanceClock
+
 	name              string
 	targetWeights     map[string]float64
 	rebalanceInterval int // 再平衡间隔天数
 	minTradeValue     float64
-	daysSinceRebalance int
-	lastRebalanceTime  time.Time
-	isFirstDay        bool
 }
 
 // NewTimeBasedStrategy 创建定期再平衡策略
@@ -26,12 +27,13 @@ func NewTimeBasedStrategy(config types.StrategyConfig) *TimeBasedStrategy {
 	}
 
 	return &TimeBasedStrategy{
+		orderGenerator:    newOrderGenerator(config),
+		trendOverlay:      newTrendOverlay(config),
+		benchmarkDrift:    newBenchmarkDrift(config),
 		name:              config.Name,
-		targetWeights:     config.TargetWeights,
+		targetWeights:     types.ExpandClassWeights(config.AssetClasses, config.TargetWeights),
 		rebalanceInterval: interval,
 		minTradeValue:     config.MinTradeValue,
-		daysSinceRebalance: 0,
-		isFirstDay:        true,
 	}
 }
 
@@ -43,87 +45,28 @@ func (s *TimeBasedStrategy) Name() string {
 	return "TimeBased"
 }
 
-// TargetWeights 返回目标权重
-func (s *TimeBasedStrategy) TargetWeights(portfolio *types.Portfolio, prices map[string]float64) map[string]float64 {
-	return s.targetWeights
+// TargetWeights 返回目标权重 (相对漂移模式下为按基准收益漂移后的权重)
+func (s *TimeBasedStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	return s.driftedWeights(s.targetWeights, date)
 }
 
 // ShouldRebalance 判断是否需要再平衡
-func (s *TimeBasedStrategy) ShouldRebalance(portfolio *types.Portfolio, prices map[string]float64) bool {
-	// 第一天需要建仓
-	if s.isFirstDay {
-		return true
-	}
-
-	s.daysSinceRebalance++
-	return s.daysSinceRebalance >= s.rebalanceInterval
+// 尚未建仓时需要建仓，此后距上次再平衡满间隔天数时再平衡
+func (s *TimeBasedStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	return s.elapsed(date, s.rebalanceInterval)
 }
 
 // GenerateOrders 生成交易订单
-func (s *TimeBasedStrategy) GenerateOrders(portfolio *types.Portfolio, targetWeights map[string]float64, prices map[string]float64) []types.Order {
-	orders := make([]types.Order, 0)
-	totalValue := portfolio.TotalValue
-
-	if totalValue <= 0 {
-		return orders
-	}
-
-	// 计算目标持仓金额
-	targetValues := make(map[string]float64)
-	for symbol, weight := range targetWeights {
-		targetValues[symbol] = totalValue * weight
-	}
-
-	// 先处理卖出订单，释放现金
-	sellOrders := make([]types.Order, 0)
-	buyOrders := make([]types.Order, 0)
-
-	for symbol, targetValue := range targetValues {
-		price, ok := prices[symbol]
-		if !ok || price <= 0 {
-			continue
-		}
-
-		currentValue := 0.0
-		if pos, exists := portfolio.Positions[symbol]; exists {
-			currentValue = pos.Value
-		}
-
-		diff := targetValue - currentValue
-
-		// 忽略小额交易
-		if math.Abs(diff) < s.minTradeValue {
-			continue
-		}
-
-		quantity := math.Abs(diff) / price
-
-		if diff < 0 {
-			sellOrders = append(sellOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "SELL",
-				Quantity: quantity,
-				Price:    price,
-			})
-		} else {
-			buyOrders = append(buyOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "BUY",
-				Quantity: quantity,
-				Price:    price,
-			})
-		}
-	}
-
-	orders = append(orders, sellOrders...)
-	orders = append(orders, buyOrders...)
-
-	return orders
+func (s *TimeBasedStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
 }
 
 // OnRebalance 再平衡后回调
-func (s *TimeBasedStrategy) OnRebalance() {
-	s.lastRebalanceTime = time.Now()
-	s.daysSinceRebalance = 0
-	s.isFirstDay = false
+func (s *TimeBasedStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
+}
+
+// Trigger 返回再平衡触发类型
+func (s *TimeBasedStrategy) Trigger() types.RebalanceTrigger {
+	return types.TriggerTime
 }
@@ -0,0 +1,58 @@
+package strategy
+
+import (
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// TrendFilterer 支持均线趋势过滤的策略
+type TrendFilterer interface {
+	// TrendWindow 均线窗口，0表示未启用
+	TrendWindow() int
+
+	// ApplyTrend 按价格与均线的关系调整目标权重
+	ApplyTrend(portfolio *types.Portfolio, targetWeights map[string]float64, prices, ma map[string]float64) map[string]float64
+}
+
+// trendOverlay 各策略共用的均线趋势过滤
+type trendOverlay struct {
+	trend types.TrendFilter
+}
+
+// newTrendOverlay 根据策略配置创建趋势过滤
+func newTrendOverlay(config types.StrategyConfig) trendOverlay {
+	return trendOverlay{trend: config.Trend}
+}
+
+// TrendWindow 均线窗口
+func (t *trendOverlay) TrendWindow() int {
+	return t.trend.Window
+}
+
+// ApplyTrend 价格低于均线的标的不加仓 (目标权重不高于当前权重)，减仓时多减 TrimFactor
+// 没有均线数据 (历史不足) 的标的不做调整；多减的权重留作现金，不分配给其他标的
+func (t *trendOverlay) ApplyTrend(portfolio *types.Portfolio, targetWeights map[string]float64, prices, ma map[string]float64) map[string]float64 {
+	current := portfolio.GetWeights()
+	adjusted := make(map[string]float64, len(targetWeights))
+	for symbol, target := range targetWeights {
+		adjusted[symbol] = target
+
+		avg, ok := ma[symbol]
+		price := prices[symbol]
+		if !ok || price <= 0 || price >= avg {
+			continue
+		}
+
+		w := current[symbol]
+		switch {
+		case target > w:
+			adjusted[symbol] = w
+		case target < w:
+			trimmed := target - (w-target)*t.trend.TrimFactor
+			if trimmed < 0 {
+				trimmed = 0
+			}
+			adjusted[symbol] = trimmed
+		}
+	}
+	return adjusted
+}
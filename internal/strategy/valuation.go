@@ -1,43 +1,68 @@
 package strategy
 
 import (
-	"math"
 	"time"
 
+	"github.com/opsxjacky/Rebalance-backtest/internal/signal"
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
 )
 
 // ValuationStrategy 估值驱动再平衡策略
 // 基于PE百分位、PEG、ROE等基本面指标动态调整持仓
 type ValuationStrategy struct {
-	name               string
-	baseWeights        map[string]float64 // 基础目标权重
-	params             *types.ValuationParams
-	minTradeValue      float64
-	daysSinceRebalance int
+	orderGenerator
+	trendOverlay
+	kellySizing
+	rebalanceClock
+
+	name                 string
+	baseWeights          map[string]float64 // 基础目标权重
+	params               *types.ValuationParams
+	minTradeValue        float64
 	minRebalanceInterval int
-	lastRebalanceTime  time.Time
-	isFirstDay         bool
+
+	// 估值规则 (由参数构建)
+	peRule       signal.PERankRule
+	coreLowRule  signal.PERankRule // 核心/科技ETF使用更宽松的低估阈值
+	dividendRule signal.DividendYieldRule
 }
 
 // NewValuationStrategy 创建估值驱动策略
 func NewValuationStrategy(config types.StrategyConfig) *ValuationStrategy {
-	params := config.ValuationParams
-	if params == nil {
-		params = types.DefaultValuationParams()
-	}
+	params := valuationParams(config.ValuationParams)
 
 	return &ValuationStrategy{
-		name:               config.Name,
-		baseWeights:        config.TargetWeights,
-		params:             params,
-		minTradeValue:      config.MinTradeValue,
+		orderGenerator:       newOrderGenerator(config),
+		trendOverlay:         newTrendOverlay(config),
+		kellySizing:          newKellySizing(config),
+		name:                 config.Name,
+		baseWeights:          types.ExpandClassWeights(config.AssetClasses, config.TargetWeights),
+		params:               params,
+		minTradeValue:        config.MinTradeValue,
 		minRebalanceInterval: config.MinRebalanceInterval,
-		daysSinceRebalance: 0,
-		isFirstDay:         true,
+		peRule: signal.PERankRule{
+			ExtremeHigh: params.ExtremeHighPERank,
+			High:        params.HighPERank,
+			Low:         params.LowPERank,
+		},
+		coreLowRule: signal.PERankRule{Low: params.CoreLowPERank},
+		dividendRule: signal.DividendYieldRule{
+			High:       params.HighYieldRank,
+			Low:        params.LowYieldRank,
+			ExtremeLow: params.ExtremeLowYieldRank,
+		},
 	}
 }
 
+// valuationParams 未设置估值参数时使用默认值；设置时为完整参数 (配置加载时已按键合并默认值)，
+// 其中为0的阈值表示关闭对应档位
+func valuationParams(p *types.ValuationParams) *types.ValuationParams {
+	if p == nil {
+		return types.DefaultValuationParams()
+	}
+	return p
+}
+
 // Name 返回策略名称
 func (s *ValuationStrategy) Name() string {
 	if s.name != "" {
@@ -47,7 +72,7 @@ func (s *ValuationStrategy) Name() string {
 }
 
 // TargetWeights 根据估值计算动态目标权重
-func (s *ValuationStrategy) TargetWeights(portfolio *types.Portfolio, prices map[string]float64) map[string]float64 {
+func (s *ValuationStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
 	// 首先复制基础权重
 	dynamicWeights := make(map[string]float64)
 	for symbol, weight := range s.baseWeights {
@@ -55,39 +80,47 @@ func (s *ValuationStrategy) TargetWeights(portfolio *types.Portfolio, prices map
 	}
 
 	// 根据每个持仓的估值信号调整权重
-	for symbol, pos := range portfolio.Positions {
+	for symbol, pos := range ctx.Portfolio.Positions {
 		if pos.Fundamental == nil {
 			continue
 		}
 
-		signal := s.evaluateAsset(pos)
-		baseWeight := s.baseWeights[symbol]
-
-		switch signal {
-		case types.SignalStrongSell, types.SignalSell:
-			// 极高风险/卖出：大幅减少权重
-			dynamicWeights[symbol] = baseWeight * (1 - s.params.SellRatio)
-		case types.SignalTrim:
-			// 动态再平衡：适度减少权重
-			dynamicWeights[symbol] = baseWeight * (1 - s.params.TrimRatio)
-		case types.SignalReduce:
-			// 减仓：减少权重
-			dynamicWeights[symbol] = baseWeight * (1 - s.params.ReduceRatio)
-		case types.SignalBuy:
-			// 买入：增加权重
-			dynamicWeights[symbol] = baseWeight * (1 + s.params.BuyRatio)
-		case types.SignalStrongHold:
-			// 优质持有：略微增加权重
-			dynamicWeights[symbol] = baseWeight * 1.1
-		default:
-			// 其他情况保持基础权重
-		}
+		sig := s.evaluateAsset(pos)
+		dynamicWeights[symbol] = s.tiltWeight(symbol, sig.Type, s.baseWeights[symbol], date)
 	}
 
 	// 归一化权重
 	return s.normalizeWeights(dynamicWeights)
 }
 
+// tiltWeight 按估值信号调整基础权重 (凯利模式下调整幅度由分数凯利公式确定)
+func (s *ValuationStrategy) tiltWeight(symbol string, signalType types.SignalType, baseWeight float64, date time.Time) float64 {
+	if s.kellySizing.enabled() {
+		return baseWeight * (1 + s.kellySizing.tilt(symbol, signalType, date))
+	}
+
+	switch signalType {
+	case types.SignalStrongSell, types.SignalSell:
+		// 极高风险/卖出：大幅减少权重
+		return baseWeight * (1 - s.params.SellRatio)
+	case types.SignalTrim:
+		// 动态再平衡：适度减少权重
+		return baseWeight * (1 - s.params.TrimRatio)
+	case types.SignalReduce:
+		// 减仓：减少权重
+		return baseWeight * (1 - s.params.ReduceRatio)
+	case types.SignalBuy:
+		// 买入：增加权重
+		return baseWeight * (1 + s.params.BuyRatio)
+	case types.SignalStrongHold:
+		// 优质持有：略微增加权重
+		return baseWeight * 1.1
+	default:
+		// 其他情况保持基础权重
+		return baseWeight
+	}
+}
+
 // normalizeWeights 归一化权重使总和为1
 func (s *ValuationStrategy) normalizeWeights(weights map[string]float64) map[string]float64 {
 	total := 0.0
@@ -107,10 +140,20 @@ func (s *ValuationStrategy) normalizeWeights(weights map[string]float64) map[str
 }
 
 // evaluateAsset 评估单个资产并返回交易信号
-func (s *ValuationStrategy) evaluateAsset(pos types.Position) types.SignalType {
+func (s *ValuationStrategy) evaluateAsset(pos types.Position) types.Signal {
 	fund := pos.Fundamental
 	if fund == nil {
-		return types.SignalUnknown
+		return types.NewSignal(types.SignalUnknown, types.ReasonNoFundamental)
+	}
+
+	// 大宗商品：没有估值锚，不做估值倾斜，仅按权重配置
+	if fund.AssetType == types.AssetTypeCommodity {
+		return types.NewSignal(types.SignalAllocate, types.ReasonCommodity)
+	}
+
+	// REITs/红利ETF：按股息率百分位判断，不适用PE/ROE的垃圾股检测
+	if isDividendAsset(pos.Symbol, fund, s.params.DividendSymbols) {
+		return s.evaluateDividendYield(pos.Symbol, fund)
 	}
 
 	// 计算盈亏
@@ -119,7 +162,7 @@ func (s *ValuationStrategy) evaluateAsset(pos types.Position) types.SignalType {
 	// 垃圾股检测：亏损且基本面差
 	isTrash := plVal < 0 && (fund.PE == 0 || fund.ROE < s.params.PoorROE)
 	if isTrash {
-		return types.SignalStrongSell
+		return types.NewSignal(types.SignalStrongSell, types.ReasonTrash)
 	}
 
 	// 安全资产
@@ -127,81 +170,110 @@ func (s *ValuationStrategy) evaluateAsset(pos types.Position) types.SignalType {
 		fund.AssetType == types.AssetTypeGold ||
 		fund.AssetType == types.AssetTypeCash
 	if isSafe {
-		return types.SignalAllocate
+		return types.NewSignal(types.SignalAllocate, types.ReasonSafeAsset)
 	}
 
 	// 估值区间判断
-	peRank := fund.PERank
-	isExtremeHigh := peRank > 0 && peRank >= s.params.ExtremeHighPERank
-	isHigh := peRank > 0 && peRank >= s.params.HighPERank
-	isLow := peRank > 0 && peRank <= s.params.LowPERank
-	isCoreLow := (fund.IsCoreETF || fund.IsTechETF) && peRank > 0 && peRank <= s.params.CoreLowPERank
+	pe := s.peRule.Evaluate(pos.Symbol, fund).Level
+	isExtremeHigh := pe == signal.LevelExtremeHigh
+	isHigh := pe.Expensive()
+	isLow := pe.Cheap()
+	isCoreLow := (fund.IsCoreETF || fund.IsTechETF) && s.coreLowRule.Evaluate(pos.Symbol, fund).Level.Cheap()
 
 	// ETF 评估
 	if fund.AssetType == types.AssetTypeETF {
 		if isExtremeHigh && fund.IsCoreETF {
-			return types.SignalTrim // 核心ETF极高估：动态再平衡
+			return types.NewSignal(types.SignalTrim, types.ReasonPEExtremeHigh) // 核心ETF极高估：动态再平衡
 		}
 		if isExtremeHigh && fund.IsTechETF {
-			return types.SignalHold // 科技ETF极高估：趋势持有
+			return types.NewSignal(types.SignalHold, types.ReasonTechTrend) // 科技ETF极高估：趋势持有
 		}
 		if isExtremeHigh {
-			return types.SignalSell // 其他ETF极高估：卖出
+			return types.NewSignal(types.SignalSell, types.ReasonPEExtremeHigh) // 其他ETF极高估：卖出
 		}
 		if isLow || isCoreLow {
-			return types.SignalBuy // 低估：买入
+			return types.NewSignal(types.SignalBuy, types.ReasonPELow) // 低估：买入
 		}
 		if isHigh {
-			return types.SignalWatch // 偏高：观察
+			return types.NewSignal(types.SignalWatch, types.ReasonPEHigh) // 偏高：观察
 		}
-		return types.SignalHold
+		return types.NewSignal(types.SignalHold, types.ReasonNormal)
 	}
 
 	// 个股评估
 	if fund.AssetType == types.AssetTypeStock {
+		peRank := fund.PERank
 		peg := fund.PEG
 		roe := fund.ROE
 
 		// 泡沫破裂：PE>=80且PEG>2.5
 		if peRank >= 80 && peg > s.params.BubblePEG {
-			return types.SignalStrongSell
+			return types.NewSignal(types.SignalStrongSell, types.ReasonPEGBubble)
 		}
 		// 估值透支：PEG>2.0
 		if peg > s.params.HighPEG {
-			return types.SignalReduce
+			return types.NewSignal(types.SignalReduce, types.ReasonPEGHigh)
 		}
 		// 优质持有：PEG<1.5或ROE>=20
 		if (peg > 0 && peg < s.params.LowPEG) || roe >= s.params.GoodROE {
-			return types.SignalStrongHold
+			return types.NewSignal(types.SignalStrongHold, types.ReasonQuality)
 		}
 		// 估值过高：PE>=80
 		if peRank >= 80 {
-			return types.SignalReduce
+			return types.NewSignal(types.SignalReduce, types.ReasonPEHigh)
 		}
-		return types.SignalHold
+		return types.NewSignal(types.SignalHold, types.ReasonNormal)
 	}
 
-	return types.SignalUnknown
+	return types.NewSignal(types.SignalUnknown, types.ReasonNone)
 }
 
-// ShouldRebalance 判断是否需要再平衡
-func (s *ValuationStrategy) ShouldRebalance(portfolio *types.Portfolio, prices map[string]float64) bool {
-	// 第一天需要建仓
-	if s.isFirstDay {
+// evaluateDividendYield 按股息率百分位评估 (股息率越高越便宜)
+func (s *ValuationStrategy) evaluateDividendYield(symbol string, fund *types.FundamentalData) types.Signal {
+	a := s.dividendRule.Evaluate(symbol, fund)
+	switch a.Level {
+	case signal.LevelUnknown:
+		// 无股息率数据，按权重配置
+		return types.NewSignal(types.SignalAllocate, types.ReasonNoFundamental)
+	case signal.LevelLow:
+		return types.NewSignal(types.SignalBuy, a.Reason) // 高股息：低估，买入
+	case signal.LevelExtremeHigh:
+		return types.NewSignal(types.SignalSell, a.Reason) // 极低股息：极度高估，卖出
+	case signal.LevelHigh:
+		return types.NewSignal(types.SignalTrim, a.Reason) // 低股息：高估，动态再平衡
+	}
+	return types.NewSignal(types.SignalHold, types.ReasonNormal)
+}
+
+// isDividendAsset 判断是否按股息率估值 (REITs、红利ETF或配置指定的标的)
+func isDividendAsset(symbol string, fund *types.FundamentalData, dividendSymbols []string) bool {
+	if fund.AssetType == types.AssetTypeREIT || fund.IsDividendETF {
 		return true
 	}
+	for _, s := range dividendSymbols {
+		if s == symbol {
+			return true
+		}
+	}
+	return false
+}
 
-	s.daysSinceRebalance++
+// ShouldRebalance 判断是否需要再平衡
+func (s *ValuationStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	// 尚未建仓时需要建仓
+	if !s.started() {
+		return true
+	}
 
 	// 检查最小再平衡间隔
-	if s.minRebalanceInterval > 0 && s.daysSinceRebalance < s.minRebalanceInterval {
+	if !s.elapsed(date, s.minRebalanceInterval) {
 		return false
 	}
 
 	// 检查是否有任何资产需要操作
-	for _, pos := range portfolio.Positions {
-		signal := s.evaluateAsset(pos)
-		switch signal {
+	for _, pos := range ctx.Portfolio.Positions {
+		sig := s.evaluateAsset(pos)
+		switch sig.Type {
 		case types.SignalStrongSell, types.SignalSell, types.SignalReduce, types.SignalTrim, types.SignalBuy:
 			return true
 		}
@@ -211,79 +283,25 @@ func (s *ValuationStrategy) ShouldRebalance(portfolio *types.Portfolio, prices m
 }
 
 // GenerateOrders 生成交易订单
-func (s *ValuationStrategy) GenerateOrders(portfolio *types.Portfolio, targetWeights map[string]float64, prices map[string]float64) []types.Order {
-	orders := make([]types.Order, 0)
-	totalValue := portfolio.TotalValue
-
-	if totalValue <= 0 {
-		return orders
-	}
-
-	// 计算目标持仓金额
-	targetValues := make(map[string]float64)
-	for symbol, weight := range targetWeights {
-		targetValues[symbol] = totalValue * weight
-	}
-
-	// 先处理卖出订单，释放现金
-	sellOrders := make([]types.Order, 0)
-	buyOrders := make([]types.Order, 0)
-
-	for symbol, targetValue := range targetValues {
-		price, ok := prices[symbol]
-		if !ok || price <= 0 {
-			continue
-		}
-
-		currentValue := 0.0
-		if pos, exists := portfolio.Positions[symbol]; exists {
-			currentValue = pos.Value
-		}
-
-		diff := targetValue - currentValue
-
-		// 忽略小额交易
-		if math.Abs(diff) < s.minTradeValue {
-			continue
-		}
-
-		quantity := math.Abs(diff) / price
-
-		if diff < 0 {
-			sellOrders = append(sellOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "SELL",
-				Quantity: quantity,
-				Price:    price,
-			})
-		} else {
-			buyOrders = append(buyOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "BUY",
-				Quantity: quantity,
-				Price:    price,
-			})
-		}
-	}
-
-	orders = append(orders, sellOrders...)
-	orders = append(orders, buyOrders...)
-
-	return orders
+func (s *ValuationStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
 }
 
 // OnRebalance 再平衡后回调
-func (s *ValuationStrategy) OnRebalance() {
-	s.lastRebalanceTime = time.Now()
-	s.daysSinceRebalance = 0
-	s.isFirstDay = false
+func (s *ValuationStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
 }
 
 // GetSignals 获取所有持仓的信号 (用于报告)
-func (s *ValuationStrategy) GetSignals(portfolio *types.Portfolio) map[string]types.SignalType {
-	signals := make(map[string]types.SignalType)
+func (s *ValuationStrategy) GetSignals(portfolio *types.Portfolio) map[string]types.Signal {
+	signals := make(map[string]types.Signal)
 	for symbol, pos := range portfolio.Positions {
 		signals[symbol] = s.evaluateAsset(pos)
 	}
 	return signals
 }
+
+// Trigger 返回再平衡触发类型
+func (s *ValuationStrategy) Trigger() types.RebalanceTrigger {
+	return types.TriggerValuation
+}
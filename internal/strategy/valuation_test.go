@@ -0,0 +1,57 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/signal"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// 相同的股息率百分位阈值 (0-1 或 0-100 刻度写法) 在两种估值策略中判断一致
+func TestDividendRankScale(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	levels := map[float64]signal.Level{
+		95:  signal.LevelLow, // ≥80 低估
+		50:  signal.LevelNeutral,
+		15:  signal.LevelHigh, // ≤20 高估
+		3:   signal.LevelExtremeHigh,
+		0.5: signal.LevelExtremeHigh, // 低于1%的百分位不应按0-1刻度理解
+	}
+
+	for _, yaml := range []config.ValuationParamsYAML{
+		{HighYieldRank: float(0.8), LowYieldRank: float(0.2), ExtremeLowYieldRank: float(0.05)},
+		{HighYieldRank: float(80), LowYieldRank: float(20), ExtremeLowYieldRank: float(5)},
+	} {
+		valuation := NewValuationStrategy(types.StrategyConfig{ValuationParams: yaml.Params("valuation")})
+		weighted := NewWeightedValuationStrategy(types.StrategyConfig{ValuationParams: yaml.Params("weighted_valuation")})
+		for rank, want := range levels {
+			fund := &types.FundamentalData{DividendYieldRank: rank}
+			got := valuation.dividendRule.Evaluate("508000", fund).Level
+			if got != want {
+				t.Errorf("valuation (high=%v) rank %v: level %v, want %v", *yaml.HighYieldRank, rank, got, want)
+			}
+			if w := weighted.dividendRule.Evaluate("508000", fund).Level; w != got {
+				t.Errorf("weighted_valuation (high=%v) rank %v: level %v, valuation %v", *yaml.HighYieldRank, rank, w, got)
+			}
+		}
+	}
+}
+
+// 债券按 Yield 字段判断，不读取 ROE
+func TestBondYieldRuleReadsYield(t *testing.T) {
+	rule := signal.BondYieldRule{Thresholds: map[string]signal.YieldThreshold{"511260": {High: 2.5, Low: 2.1}}}
+	for _, tt := range []struct {
+		fund types.FundamentalData
+		want signal.Level
+	}{
+		{types.FundamentalData{Yield: 2.8, ROE: 1.5}, signal.LevelLow},
+		{types.FundamentalData{Yield: 1.9, ROE: 3.0}, signal.LevelHigh},
+		{types.FundamentalData{Yield: 2.3, ROE: 3.0}, signal.LevelNeutral},
+	} {
+		fund := tt.fund
+		if got := rule.Evaluate("511260", &fund).Level; got != tt.want {
+			t.Errorf("yield %v roe %v: level %v, want %v", fund.Yield, fund.ROE, got, tt.want)
+		}
+	}
+}
@@ -4,20 +4,32 @@ import (
 	"math"
 	"time"
 
+	"github.com/opsxjacky/Rebalance-backtest/internal/signal"
 	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
 )
 
 // WeightedValuationStrategy 权重偏离+估值驱动再平衡策略
 // 用于平安证券账户，结合权重偏离和估值信号
 type WeightedValuationStrategy struct {
+	orderGenerator
+	trendOverlay
+	rebalanceClock
+
 	name                 string
 	targetWeights        map[string]float64 // 目标权重
 	params               *WeightedValuationParams
 	minTradeValue        float64
-	daysSinceRebalance   int
 	minRebalanceInterval int
-	lastRebalanceTime    time.Time
-	isFirstDay           bool
+	rebalanceMode        string
+	rebalanceTopK        int
+	thresholdMode        string             // 偏离口径 (默认相对偏离)
+	thresholds           map[string]float64 // 按标的的偏离阈值
+
+	// 估值规则 (由参数构建)
+	peRule        signal.PERankRule
+	pbRule        signal.PBRule
+	bondYieldRule signal.BondYieldRule
+	dividendRule  signal.DividendYieldRule
 }
 
 // WeightedValuationParams 权重估值策略参数
@@ -25,9 +37,9 @@ type WeightedValuationParams struct {
 	// 偏离阈值
 	DeviationThreshold float64 // 默认0.10 (10%)
 
-	// PE百分位阈值
-	PEHighRank float64 // 高估阈值 (默认0.70)
-	PELowRank  float64 // 低估阈值 (默认0.30)
+	// PE百分位阈值 (0-100)
+	PEHighRank float64 // 高估阈值 (默认70)
+	PELowRank  float64 // 低估阈值 (默认30)
 
 	// 恒生ETF PB阈值
 	PBLow  float64 // PB低估阈值 (默认1.0)
@@ -36,6 +48,12 @@ type WeightedValuationParams struct {
 	// 债券Yield阈值 (按标的)
 	BondYieldThresholds map[string]YieldThreshold
 
+	// 股息率百分位阈值 (REITs/红利ETF，0-100，与 ValuationStrategy 相同刻度)
+	YieldHighRank       float64  // 高股息阈值，便宜 (默认70)
+	YieldLowRank        float64  // 低股息阈值，贵 (默认30)
+	YieldExtremeLowRank float64  // 极低股息阈值 (默认10)
+	DividendSymbols     []string // 强制按股息率估值的标的
+
 	// 操作比例
 	TrimRatio   float64 // 减仓比例 (默认0.3)
 	AddRatio    float64 // 补仓比例 (默认0.2)
@@ -43,17 +61,14 @@ type WeightedValuationParams struct {
 }
 
 // YieldThreshold 收益率阈值
-type YieldThreshold struct {
-	High float64 // 高息阈值 (便宜)
-	Low  float64 // 低息阈值 (贵)
-}
+type YieldThreshold = signal.YieldThreshold
 
 // DefaultWeightedValuationParams 默认参数
 func DefaultWeightedValuationParams() *WeightedValuationParams {
 	return &WeightedValuationParams{
 		DeviationThreshold: 0.10,
-		PEHighRank:         0.70,
-		PELowRank:          0.30,
+		PEHighRank:         70,
+		PELowRank:          30,
 		PBLow:              1.0,
 		PBHigh:             1.3,
 		BondYieldThresholds: map[string]YieldThreshold{
@@ -62,9 +77,12 @@ func DefaultWeightedValuationParams() *WeightedValuationParams {
 			"511520": {High: 2.3, Low: 1.9}, // 7-10年政策性金融债
 			"511090": {High: 2.4, Low: 2.0}, // 30年期国债
 		},
-		TrimRatio:   0.3,
-		AddRatio:    0.2,
-		StrongRatio: 0.5,
+		YieldHighRank:       70,
+		YieldLowRank:        30,
+		YieldExtremeLowRank: 10,
+		TrimRatio:           0.3,
+		AddRatio:            0.2,
+		StrongRatio:         0.5,
 	}
 }
 
@@ -76,15 +94,37 @@ func NewWeightedValuationStrategy(config types.StrategyConfig) *WeightedValuatio
 	if config.Threshold > 0 {
 		params.DeviationThreshold = config.Threshold
 	}
+	// 估值参数为完整参数 (配置加载时已按键合并默认值并换算为0-100刻度)，为0的股息率阈值表示关闭对应档位
+	if v := config.ValuationParams; v != nil {
+		params.YieldHighRank = v.HighYieldRank
+		params.YieldLowRank = v.LowYieldRank
+		params.YieldExtremeLowRank = v.ExtremeLowYieldRank
+		params.DividendSymbols = v.DividendSymbols
+	}
 
 	return &WeightedValuationStrategy{
+		orderGenerator:       newOrderGenerator(config),
+		trendOverlay:         newTrendOverlay(config),
 		name:                 config.Name,
-		targetWeights:        config.TargetWeights,
+		targetWeights:        types.ExpandClassWeights(config.AssetClasses, config.TargetWeights),
 		params:               params,
 		minTradeValue:        config.MinTradeValue,
 		minRebalanceInterval: config.MinRebalanceInterval,
-		daysSinceRebalance:   0,
-		isFirstDay:           true,
+		rebalanceMode:        config.RebalanceMode,
+		rebalanceTopK:        config.RebalanceTopK,
+		thresholdMode:        thresholdMode(config.ThresholdMode, ThresholdRelative),
+		thresholds:           config.SymbolThresholds,
+		peRule: signal.PERankRule{
+			High: params.PEHighRank,
+			Low:  params.PELowRank,
+		},
+		pbRule:        signal.PBRule{High: params.PBHigh, Low: params.PBLow},
+		bondYieldRule: signal.BondYieldRule{Thresholds: params.BondYieldThresholds},
+		dividendRule: signal.DividendYieldRule{
+			High:       params.YieldHighRank,
+			Low:        params.YieldLowRank,
+			ExtremeLow: params.YieldExtremeLowRank,
+		},
 	}
 }
 
@@ -96,22 +136,9 @@ func (s *WeightedValuationStrategy) Name() string {
 	return "WeightedValuation"
 }
 
-// SignalType 信号类型
-type PingAnSignal string
-
-const (
-	SignalStrongSell   PingAnSignal = "🔴 坚决止盈"
-	SignalSell         PingAnSignal = "🟠 减仓"
-	SignalHoldNoSell   PingAnSignal = "🟡 暂不卖"
-	SignalStrongBuy    PingAnSignal = "🟢 积极补仓"
-	SignalBuy          PingAnSignal = "🔵 补仓"
-	SignalHoldNoBuy    PingAnSignal = "🟡 暂不买"
-	SignalNormal       PingAnSignal = "⚪️ 正常"
-	SignalSkip         PingAnSignal = ""
-)
-
 // TargetWeights 计算动态目标权重
-func (s *WeightedValuationStrategy) TargetWeights(portfolio *types.Portfolio, prices map[string]float64) map[string]float64 {
+func (s *WeightedValuationStrategy) TargetWeights(date time.Time, ctx *Context) map[string]float64 {
+	portfolio := ctx.Portfolio
 	dynamicWeights := make(map[string]float64)
 	for symbol, weight := range s.targetWeights {
 		dynamicWeights[symbol] = weight
@@ -126,21 +153,21 @@ func (s *WeightedValuationStrategy) TargetWeights(portfolio *types.Portfolio, pr
 		}
 
 		currentWeight := currentWeights[symbol]
-		signal := s.evaluatePosition(symbol, pos, currentWeight, targetWeight)
+		sig := s.evaluatePosition(symbol, pos, currentWeight, targetWeight)
 
-		switch signal {
-		case SignalStrongSell:
+		switch sig.Type {
+		case types.SignalStrongSell:
 			dynamicWeights[symbol] = targetWeight * (1 - s.params.StrongRatio)
-		case SignalSell:
+		case types.SignalReduce:
 			dynamicWeights[symbol] = targetWeight * (1 - s.params.TrimRatio)
-		case SignalHoldNoSell:
+		case types.SignalHoldNoSell:
 			// 保持当前权重，不减仓
 			dynamicWeights[symbol] = currentWeight
-		case SignalStrongBuy:
+		case types.SignalStrongBuy:
 			dynamicWeights[symbol] = targetWeight * (1 + s.params.StrongRatio)
-		case SignalBuy:
+		case types.SignalBuy:
 			dynamicWeights[symbol] = targetWeight * (1 + s.params.AddRatio)
-		case SignalHoldNoBuy:
+		case types.SignalHoldNoBuy:
 			// 保持当前权重，不加仓
 			dynamicWeights[symbol] = currentWeight
 		default:
@@ -149,7 +176,13 @@ func (s *WeightedValuationStrategy) TargetWeights(portfolio *types.Portfolio, pr
 		}
 	}
 
-	return s.normalizeWeights(dynamicWeights)
+	// 按调仓模式调整 (区间宽度按偏离口径换算)
+	return applyRebalanceMode(s.rebalanceMode, s.rebalanceTopK, portfolio, s.normalizeWeights(dynamicWeights), s.BandWidth)
+}
+
+// BandWidth 返回标的允许偏离的单边宽度 (按偏离口径换算为绝对权重)
+func (s *WeightedValuationStrategy) BandWidth(symbol string, target float64) float64 {
+	return thresholdWidth(s.thresholdMode, symbolThreshold(s.thresholds, symbol, s.params.DeviationThreshold), target)
 }
 
 // normalizeWeights 归一化权重
@@ -171,206 +204,138 @@ func (s *WeightedValuationStrategy) normalizeWeights(weights map[string]float64)
 }
 
 // evaluatePosition 评估持仓信号
-func (s *WeightedValuationStrategy) evaluatePosition(symbol string, pos types.Position, currentWeight, targetWeight float64) PingAnSignal {
+func (s *WeightedValuationStrategy) evaluatePosition(symbol string, pos types.Position, currentWeight, targetWeight float64) types.Signal {
 	if targetWeight == 0 {
-		return SignalSkip
+		return types.NewSignal(types.SignalNone, types.ReasonNoTarget)
 	}
 
 	// 计算偏离度
-	deviation := (currentWeight - targetWeight) / targetWeight
-	over := deviation > s.params.DeviationThreshold
-	under := deviation < -s.params.DeviationThreshold
+	d := deviation(s.thresholdMode, currentWeight, targetWeight)
+	threshold := symbolThreshold(s.thresholds, symbol, s.params.DeviationThreshold)
+	over := d > threshold
+	under := d < -threshold
 
 	fund := pos.Fundamental
 	if fund == nil {
 		// 无基本面数据，仅按偏离度操作
-		if over {
-			return SignalSell
-		}
-		if under {
-			return SignalBuy
-		}
-		return SignalNormal
-	}
-
-	// PE百分位 (归一化到0-1)
-	peRank := fund.PERank
-	if peRank > 1 {
-		peRank = peRank / 100
+		return deviationSignal(over, under, signal.Assessment{})
 	}
-	peLow := peRank > 0 && peRank <= s.params.PELowRank
-	peHigh := peRank > 0 && peRank >= s.params.PEHighRank
 
-	// 恒生ETF (159920) 特殊处理
+	// 恒生ETF (159920) 特殊处理：PE+PB双因子
 	if symbol == "159920" {
-		return s.evaluateHangSeng(pos, over, under, peRank)
+		return s.evaluateHangSeng(symbol, fund, over, under)
 	}
 
 	// 债券ETF特殊处理
-	if yieldThreshold, ok := s.params.BondYieldThresholds[symbol]; ok {
-		return s.evaluateBondETF(pos, over, under, yieldThreshold)
+	if a := s.bondYieldRule.Evaluate(symbol, fund); a.Level != signal.LevelUnknown {
+		return deviationSignal(over, under, a)
 	}
 
-	// 黄金/其他债券 - 简单再平衡
-	if fund.AssetType == types.AssetTypeBond || fund.AssetType == types.AssetTypeGold {
-		if over {
-			return SignalSell
-		}
-		if under {
-			return SignalBuy
+	// REITs/红利ETF - 股息率百分位判断
+	if isDividendAsset(symbol, fund, s.params.DividendSymbols) {
+		a := s.dividendRule.Evaluate(symbol, fund)
+		// 未偏离但股息率极低：提前减仓
+		if !over && !under && a.Level == signal.LevelExtremeHigh {
+			return types.NewSignal(types.SignalReduce, types.ReasonYieldExtremeLow)
 		}
-		return SignalNormal
+		return deviationSignal(over, under, a)
 	}
 
-	// 通用股票/ETF
-	return s.evaluateGenericETF(over, under, peLow, peHigh)
-}
-
-// evaluateHangSeng 评估恒生ETF
-func (s *WeightedValuationStrategy) evaluateHangSeng(pos types.Position, over, under bool, peRank float64) PingAnSignal {
-	fund := pos.Fundamental
-	if fund == nil {
-		if over {
-			return SignalSell
-		}
-		if under {
-			return SignalBuy
-		}
-		return SignalNormal
+	// 黄金/商品/其他债券 - 简单再平衡 (商品无估值锚，不做估值倾斜)
+	if fund.AssetType == types.AssetTypeBond || fund.AssetType == types.AssetTypeGold ||
+		fund.AssetType == types.AssetTypeCommodity {
+		return deviationSignal(over, under, signal.Assessment{})
 	}
 
-	// PE和PB状态
-	peLow := peRank > 0 && peRank <= s.params.PELowRank
-	peHigh := peRank > 0 && peRank >= s.params.PEHighRank
+	// 通用股票/ETF
+	return deviationSignal(over, under, s.peRule.Evaluate(symbol, fund))
+}
 
-	// 需要从FundamentalData获取PB (这里用PE代替模拟，实际需要扩展)
-	// 假设PB通过其他方式传入，这里简化处理
-	pbValue := 1.0 // 默认值，实际应从数据中获取
-	pbLow := pbValue < s.params.PBLow
-	pbHigh := pbValue > s.params.PBHigh
+// evaluateHangSeng 评估恒生ETF (PE与PB同时高估/低估时加大操作力度)
+func (s *WeightedValuationStrategy) evaluateHangSeng(symbol string, fund *types.FundamentalData, over, under bool) types.Signal {
+	pe := s.peRule.Evaluate(symbol, fund)
+	pb := s.pbRule.Evaluate(symbol, fund)
 
-	doubleLow := peLow && pbLow
-	doubleHigh := peHigh && pbHigh
-	anyLow := peLow || pbLow
-	anyHigh := peHigh || pbHigh
+	doubleLow := pe.Level.Cheap() && pb.Level.Cheap()
+	doubleHigh := pe.Level.Expensive() && pb.Level.Expensive()
 
 	if over {
 		if doubleHigh {
-			return SignalStrongSell
+			return types.NewSignal(types.SignalStrongSell, types.ReasonDoubleHigh)
 		}
 		if doubleLow {
-			return SignalHoldNoSell
+			return types.NewSignal(types.SignalHoldNoSell, types.ReasonDoubleLow)
 		}
-		if anyHigh {
-			return SignalSell
+		for _, a := range []signal.Assessment{pe, pb} {
+			if a.Level.Expensive() {
+				return types.NewSignal(types.SignalReduce, a.Reason)
+			}
 		}
-		return SignalSell
+		return types.NewSignal(types.SignalReduce, types.ReasonDeviationOver)
 	}
 
 	if under {
 		if doubleLow {
-			return SignalStrongBuy
+			return types.NewSignal(types.SignalStrongBuy, types.ReasonDoubleLow)
 		}
 		if doubleHigh {
-			return SignalHoldNoBuy
-		}
-		if anyLow {
-			return SignalBuy
-		}
-		return SignalBuy
-	}
-
-	return SignalNormal
-}
-
-// evaluateBondETF 评估债券ETF
-func (s *WeightedValuationStrategy) evaluateBondETF(pos types.Position, over, under bool, threshold YieldThreshold) PingAnSignal {
-	fund := pos.Fundamental
-	if fund == nil {
-		if over {
-			return SignalSell
-		}
-		if under {
-			return SignalBuy
-		}
-		return SignalNormal
-	}
-
-	// 从ROE字段借用存储Yield数据 (临时方案)
-	yieldValue := fund.ROE // 需要扩展FundamentalData添加Yield字段
-
-	yieldCheap := yieldValue > threshold.High
-	yieldExpensive := yieldValue < threshold.Low && yieldValue > 0
-
-	if over {
-		if yieldExpensive {
-			return SignalStrongSell
+			return types.NewSignal(types.SignalHoldNoBuy, types.ReasonDoubleHigh)
 		}
-		if yieldCheap {
-			return SignalHoldNoSell
+		for _, a := range []signal.Assessment{pe, pb} {
+			if a.Level.Cheap() {
+				return types.NewSignal(types.SignalBuy, a.Reason)
+			}
 		}
-		return SignalSell
+		return types.NewSignal(types.SignalBuy, types.ReasonDeviationUnder)
 	}
 
-	if under {
-		if yieldCheap {
-			return SignalStrongBuy
-		}
-		if yieldExpensive {
-			return SignalHoldNoBuy
-		}
-		return SignalBuy
-	}
-
-	return SignalNormal
+	return types.NewSignal(types.SignalHold, types.ReasonNormal)
 }
 
-// evaluateGenericETF 评估通用ETF
-func (s *WeightedValuationStrategy) evaluateGenericETF(over, under, peLow, peHigh bool) PingAnSignal {
+// deviationSignal 按偏离方向和估值水平生成信号
+// 超配时高估坚决卖出、低估暂不卖；低配时低估积极补仓、高估暂不买；其余按偏离调回目标
+func deviationSignal(over, under bool, a signal.Assessment) types.Signal {
 	if over {
-		if peHigh {
-			return SignalStrongSell
+		if a.Level.Expensive() {
+			return types.NewSignal(types.SignalStrongSell, a.Reason)
 		}
-		if peLow {
-			return SignalHoldNoSell
+		if a.Level.Cheap() {
+			return types.NewSignal(types.SignalHoldNoSell, a.Reason)
 		}
-		return SignalSell
+		return types.NewSignal(types.SignalReduce, types.ReasonDeviationOver)
 	}
 
 	if under {
-		if peLow {
-			return SignalStrongBuy
+		if a.Level.Cheap() {
+			return types.NewSignal(types.SignalStrongBuy, a.Reason)
 		}
-		if peHigh {
-			return SignalHoldNoBuy
+		if a.Level.Expensive() {
+			return types.NewSignal(types.SignalHoldNoBuy, a.Reason)
 		}
-		return SignalBuy
+		return types.NewSignal(types.SignalBuy, types.ReasonDeviationUnder)
 	}
 
-	return SignalNormal
+	return types.NewSignal(types.SignalHold, types.ReasonNormal)
 }
 
 // ShouldRebalance 判断是否需要再平衡
-func (s *WeightedValuationStrategy) ShouldRebalance(portfolio *types.Portfolio, prices map[string]float64) bool {
-	if s.isFirstDay {
+func (s *WeightedValuationStrategy) ShouldRebalance(date time.Time, ctx *Context) bool {
+	if !s.started() {
 		return true
 	}
 
-	s.daysSinceRebalance++
-
-	if s.minRebalanceInterval > 0 && s.daysSinceRebalance < s.minRebalanceInterval {
+	if !s.elapsed(date, s.minRebalanceInterval) {
 		return false
 	}
 
 	// 检查是否有偏离超过阈值的持仓
-	currentWeights := portfolio.GetWeights()
+	currentWeights := ctx.Portfolio.GetWeights()
 	for symbol, targetWeight := range s.targetWeights {
 		if targetWeight == 0 {
 			continue
 		}
 		currentWeight := currentWeights[symbol]
-		deviation := math.Abs((currentWeight - targetWeight) / targetWeight)
-		if deviation > s.params.DeviationThreshold {
+		if math.Abs(deviation(s.thresholdMode, currentWeight, targetWeight)) > symbolThreshold(s.thresholds, symbol, s.params.DeviationThreshold) {
 			return true
 		}
 	}
@@ -379,67 +344,26 @@ func (s *WeightedValuationStrategy) ShouldRebalance(portfolio *types.Portfolio,
 }
 
 // GenerateOrders 生成交易订单
-func (s *WeightedValuationStrategy) GenerateOrders(portfolio *types.Portfolio, targetWeights map[string]float64, prices map[string]float64) []types.Order {
-	orders := make([]types.Order, 0)
-	totalValue := portfolio.TotalValue
-
-	if totalValue <= 0 {
-		return orders
-	}
-
-	targetValues := make(map[string]float64)
-	for symbol, weight := range targetWeights {
-		targetValues[symbol] = totalValue * weight
-	}
-
-	sellOrders := make([]types.Order, 0)
-	buyOrders := make([]types.Order, 0)
-
-	for symbol, targetValue := range targetValues {
-		price, ok := prices[symbol]
-		if !ok || price <= 0 {
-			continue
-		}
-
-		currentValue := 0.0
-		if pos, exists := portfolio.Positions[symbol]; exists {
-			currentValue = pos.Value
-		}
-
-		diff := targetValue - currentValue
+func (s *WeightedValuationStrategy) GenerateOrders(date time.Time, ctx *Context, targetWeights map[string]float64) []types.Order {
+	return s.generateOrders(ctx.Portfolio, targetWeights, ctx.Prices, s.minTradeValue)
+}
 
-		if math.Abs(diff) < s.minTradeValue {
-			continue
-		}
+// OnRebalance 再平衡后回调
+func (s *WeightedValuationStrategy) OnRebalance(date time.Time) {
+	s.mark(date)
+}
 
-		quantity := math.Abs(diff) / price
-
-		if diff < 0 {
-			sellOrders = append(sellOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "SELL",
-				Quantity: quantity,
-				Price:    price,
-			})
-		} else {
-			buyOrders = append(buyOrders, types.Order{
-				Symbol:   symbol,
-				Side:     "BUY",
-				Quantity: quantity,
-				Price:    price,
-			})
-		}
+// GetSignals 获取所有持仓的信号 (用于报告)
+func (s *WeightedValuationStrategy) GetSignals(portfolio *types.Portfolio) map[string]types.Signal {
+	signals := make(map[string]types.Signal)
+	currentWeights := portfolio.GetWeights()
+	for symbol, pos := range portfolio.Positions {
+		signals[symbol] = s.evaluatePosition(symbol, pos, currentWeights[symbol], s.targetWeights[symbol])
 	}
-
-	orders = append(orders, sellOrders...)
-	orders = append(orders, buyOrders...)
-
-	return orders
+	return signals
 }
 
-// OnRebalance 再平衡后回调
-func (s *WeightedValuationStrategy) OnRebalance() {
-	s.lastRebalanceTime = time.Now()
-	s.daysSinceRebalance = 0
-	s.isFirstDay = false
+// Trigger 返回再平衡触发类型 (按偏离阈值触发，调仓幅度由估值信号决定)
+func (s *WeightedValuationStrategy) Trigger() types.RebalanceTrigger {
+	return types.TriggerThreshold
 }
@@ -0,0 +1,39 @@
+// Package version 引擎版本信息，写入导出结果的元数据以便追溯旧结果由哪个版本生成
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version 引擎版本，发布构建时以 -ldflags "-X github.com/opsxjacky/Rebalance-backtest/internal/version.Version=v1.2.0" 设置
+var Version = "dev"
+
+// Info 构建信息
+type Info struct {
+	Version   string
+	GoVersion string
+	Commit    string // git提交 (go build 在git仓库中构建时由构建信息提供)
+	Modified  bool   // 构建时工作区是否有未提交的修改
+}
+
+// Get 返回当前程序的构建信息
+func Get() Info {
+	info := Info{Version: Version, GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Commit = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
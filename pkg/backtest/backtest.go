@@ -0,0 +1,99 @@
+// Package backtest 是回测库的公开入口：按YAML配置运行单账户回测并返回结果，
+// 或按实盘当前持仓生成当日的信号和建议订单。
+//
+// 命令行工具 (cmd/backtest) 提供缓存、多子账户和各类比较命令；在其他Go程序中
+// 只需要运行回测并读取结果时使用本包，结果类型见 pkg/types。
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/opsxjacky/Rebalance-backtest/internal/config"
+	"github.com/opsxjacky/Rebalance-backtest/internal/engine"
+	"github.com/opsxjacky/Rebalance-backtest/pkg/types"
+)
+
+// Option 运行选项
+type Option func(*options)
+
+type options struct {
+	dataDir    string
+	log        io.Writer
+	checkpoint *types.Checkpoint
+}
+
+// WithDataDir 覆盖配置中的数据目录 (backtest.data_dir)
+func WithDataDir(dir string) Option {
+	return func(o *options) {
+		o.dataDir = dir
+	}
+}
+
+// WithLog 输出运行日志 (进度、警告)，默认不输出
+func WithLog(w io.Writer) Option {
+	return func(o *options) {
+		o.log = w
+	}
+}
+
+// WithCheckpoint 从断点恢复：RunFile 从断点之后的交易日继续回测，SignalFile 恢复策略内部状态
+func WithCheckpoint(cp *types.Checkpoint) Option {
+	return func(o *options) {
+		o.checkpoint = cp
+	}
+}
+
+// RunFile 加载配置文件并运行回测
+// 配置了多个子账户 (sleeves) 时返回错误，请使用命令行工具
+func RunFile(path string, opts ...Option) (*types.BacktestResult, error) {
+	e, err := newEngine(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.Run()
+}
+
+// SignalFile 加载配置文件，按当前持仓生成当日的信号和建议订单 (不运行历史回测)
+func SignalFile(path string, holdings *types.Holdings, opts ...Option) (*types.LiveSignal, error) {
+	e, err := newEngine(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.LiveSignal(holdings)
+}
+
+// LoadHoldings 加载持仓文件 (格式见 examples/holdings.yaml)
+func LoadHoldings(path string) (*types.Holdings, error) {
+	return config.LoadHoldings(path)
+}
+
+// newEngine 按配置文件和选项创建回测引擎
+func newEngine(path string, opts []Option) (*engine.BacktestEngine, error) {
+	o := &options{log: ioutil.Discard}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Sleeves) > 0 {
+		return nil, fmt.Errorf("config %s defines sleeves; run it with the backtest command", path)
+	}
+	if o.dataDir != "" {
+		cfg.Backtest.DataDir = o.dataDir
+	}
+
+	sleeve, err := engine.NewSleeve(cfg.Strategy.Name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	sleeve.Engine.SetLogOutput(o.log)
+	if o.checkpoint != nil {
+		sleeve.Engine.Resume(o.checkpoint)
+	}
+	return sleeve.Engine, nil
+}
@@ -0,0 +1,113 @@
+// Package split 按时间划分回测区间的训练集和测试集，用于参数优化的交叉验证：
+// 扩展窗口 (训练起点固定、逐段向后滚动)、滑动窗口 (训练长度固定) 和带清洗间隔的K折划分。
+//
+// 各区间均为包含起止日期的闭区间，可直接写入 backtest.start_date / backtest.end_date 分别回测。
+package split
+
+import (
+	"fmt"
+	"time"
+)
+
+// Range 闭区间 [Start, End]
+type Range struct {
+	Start time.Time
+	End   time.Time
+}
+
+// String 以 2006-01-02~2006-01-02 形式显示
+func (r Range) String() string {
+	return r.Start.Format("2006-01-02") + "~" + r.End.Format("2006-01-02")
+}
+
+// Fold 一次划分：测试区间和训练区间 (K折划分时测试区间两侧各有一段训练区间)
+type Fold struct {
+	Train []Range
+	Test  Range
+}
+
+// day 一个自然日
+const day = 24 * time.Hour
+
+// ExpandingWindow 扩展窗口划分：第一个测试区间从 start 之后 trainMonths 个月开始，每个测试区间长 testMonths 个月并依次向后滚动，
+// 训练区间从 start 至测试区间前一日；最后一个不完整的测试区间舍弃
+func ExpandingWindow(start, end time.Time, trainMonths, testMonths int) ([]Fold, error) {
+	return walkForward(start, end, trainMonths, testMonths, false)
+}
+
+// SlidingWindow 滑动窗口划分：与 ExpandingWindow 相同，但训练区间固定为测试区间之前的 trainMonths 个月
+func SlidingWindow(start, end time.Time, trainMonths, testMonths int) ([]Fold, error) {
+	return walkForward(start, end, trainMonths, testMonths, true)
+}
+
+// walkForward 按测试区间向后滚动生成划分，sliding 时训练区间长度固定
+// 各区间边界均由 start 按月数推算 (不逐段累加)，月末起点不会向后漂移
+func walkForward(start, end time.Time, trainMonths, testMonths int, sliding bool) ([]Fold, error) {
+	if trainMonths <= 0 || testMonths <= 0 {
+		return nil, fmt.Errorf("train and test lengths must be positive, got %d and %d months", trainMonths, testMonths)
+	}
+	var folds []Fold
+	for k := 0; ; k++ {
+		testStart := addMonths(start, trainMonths+k*testMonths)
+		testEnd := addMonths(start, trainMonths+(k+1)*testMonths).Add(-day)
+		if testEnd.After(end) {
+			break
+		}
+		trainStart := start
+		if sliding {
+			trainStart = addMonths(start, k*testMonths)
+		}
+		folds = append(folds, Fold{
+			Train: []Range{{Start: trainStart, End: testStart.Add(-day)}},
+			Test:  Range{Start: testStart, End: testEnd},
+		})
+	}
+	if len(folds) == 0 {
+		return nil, fmt.Errorf("period %s~%s is shorter than %d train + %d test months",
+			start.Format("2006-01-02"), end.Format("2006-01-02"), trainMonths, testMonths)
+	}
+	return folds, nil
+}
+
+// addMonths t 之后 months 个月的同一日，目标月份没有该日时取月末 (如1月31日加1个月为2月末)
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, months, 0)
+	last := first.AddDate(0, 1, -1).Day()
+	d := t.Day()
+	if d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// Purged 带清洗间隔的K折划分：将 [start, end] 按天数等分为 k 段，每段依次作为测试区间，其余部分为训练区间，
+// 测试区间之前 purgeDays 天 (避免训练期信号的远期收益与测试期重叠) 和之后 embargoDays 天 (避免序列相关) 不用于训练
+func Purged(start, end time.Time, k, purgeDays, embargoDays int) ([]Fold, error) {
+	if k < 2 {
+		return nil, fmt.Errorf("purged split requires at least 2 folds, got %d", k)
+	}
+	if purgeDays < 0 || embargoDays < 0 {
+		return nil, fmt.Errorf("purge and embargo must be non-negative, got %d and %d days", purgeDays, embargoDays)
+	}
+	days := int(end.Sub(start)/day) + 1
+	if days < k {
+		return nil, fmt.Errorf("period %s~%s has fewer than %d days", start.Format("2006-01-02"), end.Format("2006-01-02"), k)
+	}
+
+	folds := make([]Fold, 0, k)
+	for i := 0; i < k; i++ {
+		test := Range{
+			Start: start.AddDate(0, 0, days*i/k),
+			End:   start.AddDate(0, 0, days*(i+1)/k-1),
+		}
+		fold := Fold{Test: test}
+		if before := test.Start.AddDate(0, 0, -purgeDays-1); !before.Before(start) {
+			fold.Train = append(fold.Train, Range{Start: start, End: before})
+		}
+		if after := test.End.AddDate(0, 0, embargoDays+1); !after.After(end) {
+			fold.Train = append(fold.Train, Range{Start: after, End: end})
+		}
+		folds = append(folds, fold)
+	}
+	return folds, nil
+}
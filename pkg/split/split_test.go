@@ -0,0 +1,151 @@
+package split
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func rng(start, end string) Range {
+	return Range{Start: date(start), End: date(end)}
+}
+
+// checkFolds 逐个比较划分的训练和测试区间
+func checkFolds(t *testing.T, got []Fold, want []Fold) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d folds, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Test != want[i].Test {
+			t.Errorf("fold %d test = %s, want %s", i, got[i].Test, want[i].Test)
+		}
+		if len(got[i].Train) != len(want[i].Train) {
+			t.Errorf("fold %d train = %v, want %v", i, got[i].Train, want[i].Train)
+			continue
+		}
+		for j := range want[i].Train {
+			if got[i].Train[j] != want[i].Train[j] {
+				t.Errorf("fold %d train[%d] = %s, want %s", i, j, got[i].Train[j], want[i].Train[j])
+			}
+		}
+	}
+}
+
+func TestWalkForward(t *testing.T) {
+	tests := []struct {
+		name        string
+		start, end  string
+		train, test int
+		sliding     bool
+		want        []Fold
+	}{
+		{
+			name: "expanding", start: "2020-01-01", end: "2022-12-31", train: 12, test: 6,
+			want: []Fold{
+				{Train: []Range{rng("2020-01-01", "2020-12-31")}, Test: rng("2021-01-01", "2021-06-30")},
+				{Train: []Range{rng("2020-01-01", "2021-06-30")}, Test: rng("2021-07-01", "2021-12-31")},
+				{Train: []Range{rng("2020-01-01", "2021-12-31")}, Test: rng("2022-01-01", "2022-06-30")},
+				{Train: []Range{rng("2020-01-01", "2022-06-30")}, Test: rng("2022-07-01", "2022-12-31")},
+			},
+		},
+		{
+			// 最后一个测试区间 2022-07-01~2022-12-31 超出 end，舍弃
+			name: "incomplete final fold dropped", start: "2020-01-01", end: "2022-12-30", train: 12, test: 6,
+			want: []Fold{
+				{Train: []Range{rng("2020-01-01", "2020-12-31")}, Test: rng("2021-01-01", "2021-06-30")},
+				{Train: []Range{rng("2020-01-01", "2021-06-30")}, Test: rng("2021-07-01", "2021-12-31")},
+				{Train: []Range{rng("2020-01-01", "2021-12-31")}, Test: rng("2022-01-01", "2022-06-30")},
+			},
+		},
+		{
+			name: "sliding", start: "2020-01-01", end: "2021-12-31", train: 12, test: 6, sliding: true,
+			want: []Fold{
+				{Train: []Range{rng("2020-01-01", "2020-12-31")}, Test: rng("2021-01-01", "2021-06-30")},
+				{Train: []Range{rng("2020-07-01", "2021-06-30")}, Test: rng("2021-07-01", "2021-12-31")},
+			},
+		},
+		{
+			// 月末起点：边界取各月月末，不随滚动漂移
+			name: "month-end start", start: "2020-01-31", end: "2020-06-30", train: 1, test: 1,
+			want: []Fold{
+				{Train: []Range{rng("2020-01-31", "2020-02-28")}, Test: rng("2020-02-29", "2020-03-30")},
+				{Train: []Range{rng("2020-01-31", "2020-03-30")}, Test: rng("2020-03-31", "2020-04-29")},
+				{Train: []Range{rng("2020-01-31", "2020-04-29")}, Test: rng("2020-04-30", "2020-05-30")},
+				{Train: []Range{rng("2020-01-31", "2020-05-30")}, Test: rng("2020-05-31", "2020-06-29")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := ExpandingWindow
+			if tt.sliding {
+				split = SlidingWindow
+			}
+			got, err := split(date(tt.start), date(tt.end), tt.train, tt.test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkFolds(t, got, tt.want)
+		})
+	}
+}
+
+func TestWalkForwardErrors(t *testing.T) {
+	if _, err := ExpandingWindow(date("2020-01-01"), date("2022-12-31"), 0, 6); err == nil {
+		t.Error("expected error for zero train months")
+	}
+	if _, err := SlidingWindow(date("2020-01-01"), date("2022-12-31"), 12, -1); err == nil {
+		t.Error("expected error for negative test months")
+	}
+	if _, err := ExpandingWindow(date("2020-01-01"), date("2020-12-31"), 12, 6); err == nil {
+		t.Error("expected error when period is shorter than train + test")
+	}
+}
+
+func TestPurged(t *testing.T) {
+	// 30天分为3段，测试区间前清洗2天、后禁用1天
+	got, err := Purged(date("2020-01-01"), date("2020-01-30"), 3, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFolds(t, got, []Fold{
+		{Train: []Range{rng("2020-01-12", "2020-01-30")}, Test: rng("2020-01-01", "2020-01-10")},
+		{Train: []Range{rng("2020-01-01", "2020-01-08"), rng("2020-01-22", "2020-01-30")}, Test: rng("2020-01-11", "2020-01-20")},
+		{Train: []Range{rng("2020-01-01", "2020-01-18")}, Test: rng("2020-01-21", "2020-01-30")},
+	})
+
+	// 测试区间覆盖全部日期，训练区间不与测试区间及清洗/禁用间隔重叠
+	for i, fold := range got {
+		purgeStart := fold.Test.Start.AddDate(0, 0, -2)
+		embargoEnd := fold.Test.End.AddDate(0, 0, 1)
+		for _, r := range fold.Train {
+			if !r.End.Before(purgeStart) && !r.Start.After(embargoEnd) {
+				t.Errorf("fold %d train %s overlaps test %s with purge/embargo", i, r, fold.Test)
+			}
+		}
+		if i > 0 && !fold.Test.Start.Equal(got[i-1].Test.End.AddDate(0, 0, 1)) {
+			t.Errorf("fold %d test %s does not follow %s", i, fold.Test, got[i-1].Test)
+		}
+	}
+}
+
+func TestPurgedErrors(t *testing.T) {
+	start, end := date("2020-01-01"), date("2020-01-30")
+	if _, err := Purged(start, end, 1, 0, 0); err == nil {
+		t.Error("expected error for fewer than 2 folds")
+	}
+	if _, err := Purged(start, end, 3, -1, 0); err == nil {
+		t.Error("expected error for negative purge")
+	}
+	if _, err := Purged(start, date("2020-01-02"), 3, 0, 0); err == nil {
+		t.Error("expected error when period has fewer days than folds")
+	}
+}
@@ -0,0 +1,221 @@
+package types
+
+import (
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 testdata/api.txt 快照")
+
+const apiSnapshot = "testdata/api.txt"
+
+// exportedAPI 解析包源码，收集公开标识符及其是否带有 Deprecated 注释
+func exportedAPI(t *testing.T) map[string]bool {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parse package: %v", err)
+	}
+
+	api := make(map[string]bool)
+	add := func(name string, doc *ast.CommentGroup) {
+		api[name] = isDeprecated(doc)
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				switch d := decl.(type) {
+				case *ast.FuncDecl:
+					if !d.Name.IsExported() {
+						continue
+					}
+					name := d.Name.Name
+					if d.Recv != nil {
+						recv := receiverName(d.Recv.List[0].Type)
+						if !ast.IsExported(recv) {
+							continue
+						}
+						name = recv + "." + name
+					}
+					add(name, d.Doc)
+				case *ast.GenDecl:
+					for _, spec := range d.Specs {
+						switch s := spec.(type) {
+						case *ast.TypeSpec:
+							if !s.Name.IsExported() {
+								continue
+							}
+							doc := s.Doc
+							if doc == nil {
+								doc = d.Doc
+							}
+							add(s.Name.Name, doc)
+							if st, ok := s.Type.(*ast.StructType); ok {
+								for _, field := range st.Fields.List {
+									for _, n := range field.Names {
+										if n.IsExported() {
+											add(s.Name.Name+"."+n.Name, field.Doc)
+										}
+									}
+								}
+							}
+						case *ast.ValueSpec:
+							for _, n := range s.Names {
+								if n.IsExported() {
+									add(n.Name, s.Doc)
+								}
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return api
+}
+
+// receiverName 方法接收者的类型名
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// isDeprecated 注释中是否包含 Deprecated 段落
+func isDeprecated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, line := range strings.Split(doc.Text(), "\n") {
+		if strings.HasPrefix(line, "Deprecated:") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersion 解析 主版本.次版本
+func parseVersion(t *testing.T, v string) [2]int {
+	t.Helper()
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 2 {
+		t.Fatalf("invalid version %q", v)
+	}
+	var out [2]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			t.Fatalf("invalid version %q", v)
+		}
+		out[i] = n
+	}
+	return out
+}
+
+// versionLess a < b
+func versionLess(a, b [2]int) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
+
+func TestDeprecationsAreDocumented(t *testing.T) {
+	api := exportedAPI(t)
+	current := parseVersion(t, APIVersion)
+
+	registered := make(map[string]bool)
+	for _, d := range Deprecations() {
+		registered[d.Name] = true
+
+		since := parseVersion(t, d.Since)
+		removeIn := parseVersion(t, d.RemoveIn)
+		if versionLess(current, since) {
+			t.Errorf("%s: deprecated since %s, which is newer than APIVersion %s", d.Name, d.Since, APIVersion)
+		}
+		if removeIn[0] <= since[0] {
+			t.Errorf("%s: RemoveIn %s must be a later major version than Since %s", d.Name, d.RemoveIn, d.Since)
+		}
+		if d.Replacement == "" {
+			t.Errorf("%s: missing replacement", d.Name)
+		}
+
+		deprecated, exists := api[d.Name]
+		if !exists {
+			continue
+		}
+		if !deprecated {
+			t.Errorf("%s: registered as deprecated but has no \"Deprecated:\" doc comment", d.Name)
+		}
+		if !versionLess(current, removeIn) {
+			t.Errorf("%s: scheduled for removal in %s, APIVersion is %s; remove it", d.Name, d.RemoveIn, APIVersion)
+		}
+	}
+
+	for name, deprecated := range api {
+		if deprecated && !registered[name] {
+			t.Errorf("%s: has \"Deprecated:\" doc comment but is not registered in deprecations", name)
+		}
+	}
+}
+
+func TestAPISnapshot(t *testing.T) {
+	api := exportedAPI(t)
+
+	names := make([]string, 0, len(api))
+	for name := range api {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if *update {
+		data := strings.Join(names, "\n") + "\n"
+		if err := ioutil.WriteFile(apiSnapshot, []byte(data), 0644); err != nil {
+			t.Fatalf("write snapshot: %v", err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadFile(filepath.FromSlash(apiSnapshot))
+	if err != nil {
+		t.Fatalf("read snapshot: %v (run with -update to create it)", err)
+	}
+
+	current := parseVersion(t, APIVersion)
+	removable := make(map[string]bool)
+	for _, d := range Deprecations() {
+		if !versionLess(current, parseVersion(t, d.RemoveIn)) {
+			removable[d.Name] = true
+		}
+	}
+
+	snapshot := make(map[string]bool)
+	for _, name := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		snapshot[name] = true
+		if _, ok := api[name]; !ok && !removable[name] {
+			t.Errorf("%s was removed without completing the deprecation cycle", name)
+		}
+	}
+
+	for _, name := range names {
+		if !snapshot[name] {
+			t.Errorf("%s is new; run go test ./pkg/types -run TestAPISnapshot -update", name)
+		}
+	}
+}
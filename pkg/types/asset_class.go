@@ -0,0 +1,68 @@
+package types
+
+import (
+	"sort"
+)
+
+// AssetClass 资产类别 (如股票/债券/黄金)，用于类别层面的目标权重和约束
+type AssetClass struct {
+	Name    string
+	Symbols []string
+	Weight  float64 // 类别目标权重，0表示不设类别目标 (沿用标的权重)
+}
+
+// ClassOf 返回标的所属类别名，不属于任何类别时返回空字符串
+func ClassOf(classes []AssetClass, symbol string) string {
+	for _, class := range classes {
+		for _, s := range class.Symbols {
+			if s == symbol {
+				return class.Name
+			}
+		}
+	}
+	return ""
+}
+
+// AggregateByClass 将标的权重汇总为类别权重，未归类的标的不计入
+func AggregateByClass(classes []AssetClass, weights map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(classes))
+	for _, class := range classes {
+		sum := 0.0
+		for _, symbol := range class.Symbols {
+			sum += weights[symbol]
+		}
+		result[class.Name] = sum
+	}
+	return result
+}
+
+// ExpandClassWeights 将类别目标权重分配到类别内各标的
+// 类别内按 intra 中的相对权重分配，intra 未给出时等权；未设类别权重的类别和未归类标的沿用 intra 中的权重
+func ExpandClassWeights(classes []AssetClass, intra map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(intra))
+	for symbol, w := range intra {
+		result[symbol] = w
+	}
+
+	for _, class := range classes {
+		if class.Weight <= 0 || len(class.Symbols) == 0 {
+			continue
+		}
+
+		symbols := append([]string(nil), class.Symbols...)
+		sort.Strings(symbols)
+
+		sum := 0.0
+		for _, symbol := range symbols {
+			sum += intra[symbol]
+		}
+		for _, symbol := range symbols {
+			if sum > 0 {
+				result[symbol] = class.Weight * intra[symbol] / sum
+			} else {
+				result[symbol] = class.Weight / float64(len(symbols))
+			}
+		}
+	}
+	return result
+}
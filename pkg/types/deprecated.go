@@ -0,0 +1,89 @@
+package types
+
+// APIVersion 公共类型的 API 版本 (见包文档中的稳定性约定)
+const APIVersion = "1.1"
+
+// Deprecation 弃用登记
+type Deprecation struct {
+	Name        string // 标识符，方法写作 Type.Method
+	Since       string // 开始弃用的版本
+	RemoveIn    string // 计划移除的版本
+	Replacement string // 替代用法
+}
+
+// deprecations 当前处于弃用期的标识符
+var deprecations = []Deprecation{
+	{
+		Name:        "SignalType.LegacyString",
+		Since:       "1.1",
+		RemoveIn:    "2.0",
+		Replacement: "SignalType.Label(LocaleZH) 或 SignalType 英文代码",
+	},
+	{
+		Name:        "LegacySignalType",
+		Since:       "1.1",
+		RemoveIn:    "2.0",
+		Replacement: "ParseSignalType",
+	},
+}
+
+// Deprecations 返回当前处于弃用期的标识符列表
+func Deprecations() []Deprecation {
+	return append([]Deprecation(nil), deprecations...)
+}
+
+// legacySignalValues 1.0 版本中 SignalType 的取值 (中文展示文字)
+var legacySignalValues = map[SignalType]string{
+	SignalStrongSell: "🔴 极高风险",
+	SignalSell:       "🔴 卖出",
+	SignalTrim:       "🟠 动态再平衡",
+	SignalReduce:     "🟠 减仓",
+	SignalWatch:      "🟡 观察",
+	SignalHold:       "⚪️ 正常持有",
+	SignalAllocate:   "⚪️ 按权重配置",
+	SignalBuy:        "🟢 买入",
+	SignalStrongHold: "🟢 优质持有",
+	SignalUnknown:    "❓ 未知",
+}
+
+// LegacyString 返回信号在 1.0 版本中的取值，供仍按旧取值比较或存储的调用方过渡使用
+//
+// Deprecated: 信号取值已改为英文代码，展示文字请使用 Label(LocaleZH)。将在 2.0 移除。
+func (s SignalType) LegacyString() string {
+	if v, ok := legacySignalValues[s]; ok {
+		return v
+	}
+	return s.Label(LocaleZH)
+}
+
+// LegacySignalType 将 1.0 版本的信号取值转换为当前的信号类型，无法识别时返回 SignalUnknown
+//
+// Deprecated: 请使用 ParseSignalType，它同时接受英文代码和旧取值。将在 2.0 移除。
+func LegacySignalType(value string) SignalType {
+	for t, v := range legacySignalValues {
+		if v == value {
+			return t
+		}
+	}
+	return SignalUnknown
+}
+
+// ParseSignalType 解析信号取值，接受英文代码、各语言展示文字以及 1.0 版本的旧取值
+func ParseSignalType(value string) (SignalType, bool) {
+	if _, ok := signalRegistry[SignalType(value)]; ok {
+		return SignalType(value), true
+	}
+	for t, meta := range signalRegistry {
+		for _, label := range meta.labels {
+			if label != "" && label == value {
+				return t, true
+			}
+		}
+	}
+	for t, v := range legacySignalValues {
+		if v == value {
+			return t, true
+		}
+	}
+	return SignalUnknown, false
+}
@@ -0,0 +1,14 @@
+// Package types 定义回测引擎与外部调用方共用的公共类型。
+//
+// API 稳定性约定:
+//
+//   - APIVersion 采用 主版本.次版本 格式。次版本只新增类型、字段和方法，不删除、不改名。
+//   - 需要移除或替换的标识符先标注 "Deprecated:" 注释并登记到 Deprecations，
+//     注明引入弃用的版本 (Since) 和计划移除的版本 (RemoveIn，必须是下一个主版本或更晚)。
+//   - 弃用期间保留兼容层 (如 SignalType.LegacyString)，保证旧代码可继续编译和运行。
+//   - 只有当 APIVersion 达到 RemoveIn 后才能删除对应标识符。
+//
+// 以上约定由 api_test.go 检查: 公开标识符与 testdata/api.txt 快照比对，
+// 未按弃用流程删除标识符、弃用标识符缺少注释或超期未删除都会导致测试失败。
+// 新增公开标识符后运行 go test ./pkg/types -run TestAPISnapshot -update 更新快照。
+package types
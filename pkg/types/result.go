@@ -0,0 +1,69 @@
+package types
+
+import (
+	"sort"
+	"time"
+)
+
+// snapshotIndex 返回不晚于 date 的最后一个快照下标，不存在时返回-1
+// 快照按时间升序排列
+func (r *BacktestResult) snapshotIndex(date time.Time) int {
+	i := sort.Search(len(r.Snapshots), func(i int) bool {
+		return r.Snapshots[i].Timestamp.After(date)
+	})
+	return i - 1
+}
+
+// SnapshotOn 返回指定日期 (含) 之前最近一次快照
+func (r *BacktestResult) SnapshotOn(date time.Time) (PortfolioSnapshot, bool) {
+	i := r.snapshotIndex(date)
+	if i < 0 {
+		return PortfolioSnapshot{}, false
+	}
+	return r.Snapshots[i], true
+}
+
+// ValueOn 返回指定日期的组合价值 (非交易日取之前最近一个交易日)
+func (r *BacktestResult) ValueOn(date time.Time) (float64, bool) {
+	snapshot, ok := r.SnapshotOn(date)
+	return snapshot.TotalValue, ok
+}
+
+// WeightsOn 返回指定日期的持仓权重
+func (r *BacktestResult) WeightsOn(date time.Time) (map[string]float64, bool) {
+	snapshot, ok := r.SnapshotOn(date)
+	return snapshot.Weights, ok
+}
+
+// DrawdownOn 返回指定日期相对此前最高净值的回撤 (正数，如0.1表示回撤10%)
+func (r *BacktestResult) DrawdownOn(date time.Time) (float64, bool) {
+	i := r.snapshotIndex(date)
+	if i < 0 {
+		return 0, false
+	}
+
+	peak := 0.0
+	for _, snapshot := range r.Snapshots[:i+1] {
+		if snapshot.TotalValue > peak {
+			peak = snapshot.TotalValue
+		}
+	}
+	if peak <= 0 {
+		return 0, true
+	}
+	return (peak - r.Snapshots[i].TotalValue) / peak, true
+}
+
+// TradesBetween 返回成交时间在 [from, to] 区间内的交易 (交易按时间升序排列)
+func (r *BacktestResult) TradesBetween(from, to time.Time) []Trade {
+	start := sort.Search(len(r.Trades), func(i int) bool {
+		return !r.Trades[i].Timestamp.Before(from)
+	})
+	end := sort.Search(len(r.Trades), func(i int) bool {
+		return r.Trades[i].Timestamp.After(to)
+	})
+	if start >= end {
+		return nil
+	}
+	return r.Trades[start:end]
+}
@@ -0,0 +1,168 @@
+package types
+
+import (
+	"time"
+)
+
+// SignalType 交易信号类型 (所有策略共用的统一信号枚举)
+// 信号值为稳定的英文代码，展示文字通过 Label 按语言获取
+type SignalType string
+
+const (
+	SignalUnknown    SignalType = "unknown"
+	SignalNone       SignalType = "none" // 不参与评估 (如目标权重为0)
+	SignalStrongSell SignalType = "strong_sell"
+	SignalSell       SignalType = "sell"
+	SignalReduce     SignalType = "reduce"
+	SignalTrim       SignalType = "trim"
+	SignalWatch      SignalType = "watch"
+	SignalHoldNoSell SignalType = "hold_no_sell"
+	SignalHold       SignalType = "hold"
+	SignalAllocate   SignalType = "allocate"
+	SignalHoldNoBuy  SignalType = "hold_no_buy"
+	SignalStrongHold SignalType = "strong_hold"
+	SignalBuy        SignalType = "buy"
+	SignalStrongBuy  SignalType = "strong_buy"
+)
+
+// SignalDirection 信号方向
+type SignalDirection string
+
+const (
+	DirectionBuy  SignalDirection = "buy"
+	DirectionSell SignalDirection = "sell"
+	DirectionHold SignalDirection = "hold"
+)
+
+// Locale 信号展示语言
+type Locale string
+
+const (
+	LocaleZH Locale = "zh"
+	LocaleEN Locale = "en"
+)
+
+// signalMeta 信号元数据
+type signalMeta struct {
+	direction SignalDirection
+	severity  int // 0-3，越大操作力度越强
+	labels    map[Locale]string
+}
+
+var signalRegistry = map[SignalType]signalMeta{
+	SignalUnknown:    {DirectionHold, 0, map[Locale]string{LocaleZH: "❓ 未知", LocaleEN: "Unknown"}},
+	SignalNone:       {DirectionHold, 0, map[Locale]string{LocaleZH: "", LocaleEN: ""}},
+	SignalStrongSell: {DirectionSell, 3, map[Locale]string{LocaleZH: "🔴 坚决卖出", LocaleEN: "Strong Sell"}},
+	SignalSell:       {DirectionSell, 2, map[Locale]string{LocaleZH: "🔴 卖出", LocaleEN: "Sell"}},
+	SignalReduce:     {DirectionSell, 2, map[Locale]string{LocaleZH: "🟠 减仓", LocaleEN: "Reduce"}},
+	SignalTrim:       {DirectionSell, 1, map[Locale]string{LocaleZH: "🟠 动态再平衡", LocaleEN: "Trim"}},
+	SignalWatch:      {DirectionHold, 1, map[Locale]string{LocaleZH: "🟡 观察", LocaleEN: "Watch"}},
+	SignalHoldNoSell: {DirectionHold, 1, map[Locale]string{LocaleZH: "🟡 暂不卖", LocaleEN: "Hold (No Sell)"}},
+	SignalHold:       {DirectionHold, 0, map[Locale]string{LocaleZH: "⚪️ 正常持有", LocaleEN: "Hold"}},
+	SignalAllocate:   {DirectionHold, 0, map[Locale]string{LocaleZH: "⚪️ 按权重配置", LocaleEN: "Allocate"}},
+	SignalHoldNoBuy:  {DirectionHold, 1, map[Locale]string{LocaleZH: "🟡 暂不买", LocaleEN: "Hold (No Buy)"}},
+	SignalStrongHold: {DirectionBuy, 1, map[Locale]string{LocaleZH: "🟢 优质持有", LocaleEN: "Strong Hold"}},
+	SignalBuy:        {DirectionBuy, 2, map[Locale]string{LocaleZH: "🟢 买入", LocaleEN: "Buy"}},
+	SignalStrongBuy:  {DirectionBuy, 3, map[Locale]string{LocaleZH: "🟢 积极补仓", LocaleEN: "Strong Buy"}},
+}
+
+// Direction 返回信号方向
+func (s SignalType) Direction() SignalDirection {
+	if meta, ok := signalRegistry[s]; ok {
+		return meta.direction
+	}
+	return DirectionHold
+}
+
+// Severity 返回信号强度 (0-3)
+func (s SignalType) Severity() int {
+	return signalRegistry[s].severity
+}
+
+// Label 返回指定语言的展示文字，未知语言回退到中文
+func (s SignalType) Label(locale Locale) string {
+	meta, ok := signalRegistry[s]
+	if !ok {
+		return string(s)
+	}
+	if label, ok := meta.labels[locale]; ok {
+		return label
+	}
+	return meta.labels[LocaleZH]
+}
+
+// String 返回中文展示文字 (用于日志)
+func (s SignalType) String() string {
+	return s.Label(LocaleZH)
+}
+
+// ReasonCode 信号触发原因代码
+type ReasonCode string
+
+const (
+	ReasonNone            ReasonCode = ""
+	ReasonNoTarget        ReasonCode = "no_target"         // 无目标权重
+	ReasonNoFundamental   ReasonCode = "no_fundamental"    // 无基本面数据
+	ReasonNormal          ReasonCode = "normal"            // 无触发条件
+	ReasonDeviationOver   ReasonCode = "deviation_over"    // 权重超配
+	ReasonDeviationUnder  ReasonCode = "deviation_under"   // 权重低配
+	ReasonSafeAsset       ReasonCode = "safe_asset"        // 债券/黄金/现金等安全资产
+	ReasonCommodity       ReasonCode = "commodity"         // 商品无估值锚
+	ReasonTrash           ReasonCode = "trash"             // 亏损且基本面差
+	ReasonPEExtremeHigh   ReasonCode = "pe_extreme_high"   // PE百分位极高
+	ReasonPEHigh          ReasonCode = "pe_high"           // PE百分位偏高
+	ReasonPELow           ReasonCode = "pe_low"            // PE百分位低
+	ReasonTechTrend       ReasonCode = "tech_trend"        // 科技ETF趋势持有
+	ReasonPEGBubble       ReasonCode = "peg_bubble"        // PEG泡沫
+	ReasonPEGHigh         ReasonCode = "peg_high"          // PEG估值透支
+	ReasonQuality         ReasonCode = "quality"           // 低PEG或高ROE
+	ReasonYieldHigh       ReasonCode = "yield_high"        // 股息率/收益率高 (便宜)
+	ReasonYieldLow        ReasonCode = "yield_low"         // 股息率/收益率低 (贵)
+	ReasonYieldExtremeLow ReasonCode = "yield_extreme_low" // 股息率极低
+	ReasonDoubleHigh      ReasonCode = "pe_pb_double_high" // PE与PB双高
+	ReasonDoubleLow       ReasonCode = "pe_pb_double_low"  // PE与PB双低
+	ReasonPBHigh          ReasonCode = "pb_high"           // PB偏高
+	ReasonPBLow           ReasonCode = "pb_low"            // PB偏低
+)
+
+// Signal 结构化交易信号，导出时供下游程序直接使用，无需解析展示文字
+type Signal struct {
+	Type      SignalType
+	Direction SignalDirection
+	Strength  int // 1-3，0表示无操作
+	Reason    ReasonCode
+}
+
+// NewSignal 根据信号类型和原因创建结构化信号
+func NewSignal(signalType SignalType, reason ReasonCode) Signal {
+	return Signal{
+		Type:      signalType,
+		Direction: signalType.Direction(),
+		Strength:  signalType.Severity(),
+		Reason:    reason,
+	}
+}
+
+// SignalAccuracy 同一类信号 (信号类型和原因) 发出后标的的远期收益统计
+type SignalAccuracy struct {
+	Type      SignalType
+	Reason    ReasonCode
+	Direction SignalDirection
+	Count     int // 信号次数 (标的的信号变为该信号时计一次)
+	Horizons  []SignalHorizon
+}
+
+// SignalHorizon 信号在一个远期期限上的表现
+type SignalHorizon struct {
+	Months    int
+	Samples   int     // 期限结束前仍有价格数据的信号次数
+	HitRate   float64 // 买入信号远期收益为正、卖出信号远期收益为负的比例
+	AvgReturn float64 // 标的的平均远期收益
+}
+
+// SignalRecord 信号记录 (再平衡日的持仓信号)
+type SignalRecord struct {
+	Timestamp time.Time
+	Symbol    string
+	Signal    Signal
+}
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"math"
 	"time"
 )
 
@@ -20,26 +22,39 @@ type PriceData struct {
 type AssetType string
 
 const (
-	AssetTypeETF    AssetType = "ETF"
-	AssetTypeStock  AssetType = "个股"
-	AssetTypeBond   AssetType = "债券"
-	AssetTypeGold   AssetType = "黄金"
-	AssetTypeCash   AssetType = "现金"
-	AssetTypeOther  AssetType = "其他"
+	AssetTypeETF   AssetType = "ETF"
+	AssetTypeStock AssetType = "个股"
+	AssetTypeBond  AssetType = "债券"
+	AssetTypeGold  AssetType = "黄金"
+	AssetTypeCash  AssetType = "现金"
+	AssetTypeOther AssetType = "其他"
+
+	// AssetTypeREIT 不动产投资信托，按股息率百分位估值 (股息率越高越便宜)
+	AssetTypeREIT AssetType = "REITs"
+	// AssetTypeCommodity 大宗商品 (原油/有色/农产品等)
+	// 商品没有可比的估值锚，不做估值倾斜，仅按目标权重再平衡；
+	// 期货型商品ETF存在展期成本 (contango)，长期持有收益会低于现货，回测时需留意
+	AssetTypeCommodity AssetType = "商品"
 )
 
 // FundamentalData 基本面数据
 type FundamentalData struct {
-	Symbol     string
-	Timestamp  time.Time
-	PE         float64 // 市盈率
-	PERank     float64 // PE百分位 (0-100)
-	PEG        float64 // PEG值
-	ROE        float64 // 净资产收益率 (%)
-	AssetType  AssetType
-	Name       string
-	IsCoreETF  bool // 是否核心指数ETF (SPY/QQQ/DXJ等)
-	IsTechETF  bool // 是否科技类ETF
+	Symbol            string
+	Timestamp         time.Time
+	PE                float64 // 市盈率
+	PERank            float64 // PE百分位 (0-100)
+	PEG               float64 // PEG值
+	PB                float64 // 市净率
+	PBRank            float64 // PB百分位 (0-100)
+	ROE               float64 // 净资产收益率 (%)
+	Yield             float64 // 债券到期收益率 (%)
+	DividendYield     float64 // 股息率 (%)
+	DividendYieldRank float64 // 股息率百分位 (0-100)
+	AssetType         AssetType
+	Name              string
+	IsCoreETF         bool // 是否核心指数ETF (SPY/QQQ/DXJ等)
+	IsTechETF         bool // 是否科技类ETF
+	IsDividendETF     bool // 是否红利/高股息ETF (按股息率估值)
 }
 
 // AssetData 综合资产数据 (价格+基本面)
@@ -48,29 +63,13 @@ type AssetData struct {
 	Fundamental FundamentalData
 }
 
-// SignalType 交易信号类型
-type SignalType string
-
-const (
-	SignalStrongSell  SignalType = "🔴 极高风险"
-	SignalSell        SignalType = "🔴 卖出"
-	SignalTrim        SignalType = "🟠 动态再平衡"
-	SignalReduce      SignalType = "🟠 减仓"
-	SignalWatch       SignalType = "🟡 观察"
-	SignalHold        SignalType = "⚪️ 正常持有"
-	SignalAllocate    SignalType = "⚪️ 按权重配置"
-	SignalBuy         SignalType = "🟢 买入"
-	SignalStrongHold  SignalType = "🟢 优质持有"
-	SignalUnknown     SignalType = "❓ 未知"
-)
-
 // Position 投资组合持仓
 type Position struct {
 	Symbol      string
 	Quantity    float64
 	AvgCost     float64
 	Value       float64
-	ProfitLoss  float64   // 浮动盈亏
+	ProfitLoss  float64 // 浮动盈亏
 	Fundamental *FundamentalData
 }
 
@@ -78,6 +77,7 @@ type Position struct {
 type Portfolio struct {
 	Timestamp  time.Time
 	Cash       float64
+	Receivable float64 // 在途的场外基金赎回款 (计入总价值和现金权重，到账前不能用于买入)
 	Positions  map[string]Position
 	TotalValue float64
 }
@@ -102,10 +102,13 @@ func (p *Portfolio) UpdateValue(prices map[string]float64) {
 			totalPositionValue += pos.Value
 		}
 	}
-	p.TotalValue = p.Cash + totalPositionValue
+	p.TotalValue = p.Cash + p.Receivable + totalPositionValue
 }
 
-// GetWeights 获取当前权重
+// CashSymbol 现金在权重中的代码：GetWeights 以此报告现金权重，目标权重中也可以用它指定刻意持有的现金比例
+const CashSymbol = "CASH"
+
+// GetWeights 获取当前权重 (含现金 CashSymbol)
 func (p *Portfolio) GetWeights() map[string]float64 {
 	weights := make(map[string]float64)
 	if p.TotalValue == 0 {
@@ -114,7 +117,7 @@ func (p *Portfolio) GetWeights() map[string]float64 {
 	for symbol, pos := range p.Positions {
 		weights[symbol] = pos.Value / p.TotalValue
 	}
-	weights["CASH"] = p.Cash / p.TotalValue
+	weights[CashSymbol] = (p.Cash + p.Receivable) / p.TotalValue
 	return weights
 }
 
@@ -126,7 +129,10 @@ type Trade struct {
 	Quantity  float64
 	Price     float64
 	Fee       float64
-	Value     float64 // 交易金额 (不含手续费)
+	Value     float64          // 交易金额 (不含手续费)
+	Partial   bool             // 受成交量限制 (或等待赎回款到账) 部分成交，剩余部分转为挂单
+	Tag       string           // 交易标记 (如 "dust" 表示碎仓清理)
+	Trigger   RebalanceTrigger // 产生该交易的再平衡触发类型
 }
 
 // Order 交易订单
@@ -135,45 +141,728 @@ type Order struct {
 	Side     string // "BUY" or "SELL"
 	Quantity float64
 	Price    float64
+	Tag      string           // 订单标记，成交后写入 Trade.Tag
+	Trigger  RebalanceTrigger // 再平衡触发类型，成交后写入 Trade.Trigger
+
+	OrderType  OrderType // 订单类型，默认市价单
+	LimitPrice float64   // 限价 (仅限价单)
+	TTL        int       // 限价单有效交易日数，到期未成交则撤销
+}
+
+// RoundLot 将数量按交易单位 (如整股为1、A股一手为100) 向下取整，lotSize 不大于0时允许零碎份额，不取整
+func RoundLot(quantity, lotSize float64) float64 {
+	if lotSize <= 0 {
+		return quantity
+	}
+	return math.Floor(quantity/lotSize+1e-9) * lotSize
+}
+
+// OrderType 订单类型
+type OrderType string
+
+const (
+	OrderMarket OrderType = "market" // 市价单，按成交价格策略当日成交
+	OrderLimit  OrderType = "limit"  // 限价单，按后续交易日K线判断成交
+)
+
+// TagDust 碎仓清理交易的标记
+const TagDust = "dust"
+
+// TagDelisted 退市清仓交易的标记
+const TagDelisted = "delisted"
+
+// RebalanceTrigger 再平衡触发类型
+type RebalanceTrigger string
+
+const (
+	TriggerInitial    RebalanceTrigger = "initial"     // 首次建仓
+	TriggerTime       RebalanceTrigger = "time"        // 定期
+	TriggerThreshold  RebalanceTrigger = "threshold"   // 偏离阈值
+	TriggerValuation  RebalanceTrigger = "valuation"   // 估值信号
+	TriggerOther      RebalanceTrigger = "other"       // 未标明触发类型 (如期末清仓)
+	TriggerKillSwitch RebalanceTrigger = "kill_switch" // 触发止损开关，转为避险配置
+	TriggerCashFlow   RebalanceTrigger = "cash_flow"   // 追加投入后投资新增资金
+	TriggerRegime     RebalanceTrigger = "regime"      // 市场状态切换，改用另一策略的目标权重
+	TriggerInception  RebalanceTrigger = "inception"   // 回测期间上市的标的分批建仓
+)
+
+// TradeAnalytics 成交记录的汇总统计
+type TradeAnalytics struct {
+	AvgTradeValue      float64  // 平均每笔成交金额
+	TradesPerYear      float64  // 年均成交笔数
+	BuyValue           float64  // 买入金额合计 (不含首次建仓，下同)
+	SellValue          float64  // 卖出金额合计
+	Imbalance          float64  // 买卖不平衡度 (买入 - 卖出) / (买入 + 卖出)，正值为净买入
+	LargestTrade       Trade    // 成交金额最大的一笔
+	LargestTradeWeight float64  // 最大一笔成交金额占当日组合价值的比例
+	RebalanceGaps      GapStats // 相邻两次再平衡间隔的自然日数分布
+}
+
+// GapStats 间隔天数的分布
+type GapStats struct {
+	Count  int // 间隔个数 (再平衡次数 - 1)
+	Min    float64
+	P25    float64
+	Median float64
+	P75    float64
+	Max    float64
+	Mean   float64
+}
+
+// TriggerStat 按触发类型汇总的交易统计
+// 贡献按 "成交后持有至期末" 计算: 方向 × 数量 × (期末价 - 成交价)，与不做该笔交易相比的收益差
+type TriggerStat struct {
+	Trigger            RebalanceTrigger
+	Rebalances         int     // 再平衡次数
+	Trades             int     // 交易笔数
+	Turnover           float64 // 成交金额合计
+	Fees               float64 // 费用合计
+	GrossContribution  float64 // 费用前贡献
+	NetContribution    float64 // 费用后贡献
+	AnnualContribution float64 // 费用后贡献占初始资金的年化比例
+}
+
+// ClosedLot 按先进先出配对的已平仓批次
+type ClosedLot struct {
+	Symbol      string
+	OpenDate    time.Time
+	CloseDate   time.Time
+	Quantity    float64
+	Short       bool // 空头批次 (先卖后买)
+	OpenPrice   float64
+	ClosePrice  float64
+	RealizedPnL float64 // 扣除分摊的开平仓费用后的已实现盈亏
+	HoldingDays int     // 持有自然日数
+}
+
+// SymbolPnL 单个标的的已实现盈亏统计
+type SymbolPnL struct {
+	Symbol         string
+	RealizedPnL    float64 // 已平仓批次的已实现盈亏合计
+	ClosedLots     int     // 已平仓批次数
+	WinningLots    int     // 盈利的批次数
+	WinRate        float64 // 盈利批次占比
+	AvgHoldingDays float64 // 按数量加权的平均持有天数
+}
+
+// ExposureStat 标的 (含现金 CashSymbol) 在回测期间的实际权重统计，平均值按自然日时间加权
+// 目标权重取当日或之前最近一次再平衡记录的目标权重，首次记录之前的交易日不计入目标相关的统计
+type ExposureStat struct {
+	Symbol          string
+	AvgWeight       float64
+	MinWeight       float64
+	MaxWeight       float64
+	AvgTarget       float64 // 平均目标权重
+	MaxOverweight   float64 // 相对目标权重的最大超配
+	MaxUnderweight  float64 // 相对目标权重的最大低配 (正值)
+	Band            float64 // 策略按平均目标权重的偏离区间单边宽度，0表示策略没有偏离区间
+	DaysOutsideBand int     // 权重超出偏离区间的交易日数
+}
+
+// ClassExposure 资产类别 (assets[].class) 的实际权重统计，平均值按自然日时间加权
+type ClassExposure struct {
+	Class     string
+	AvgWeight float64
+	MinWeight float64
+	MaxWeight float64
+	AvgTarget float64
 }
 
 // PortfolioSnapshot 投资组合快照 (用于记录历史)
 type PortfolioSnapshot struct {
 	Timestamp  time.Time
 	Cash       float64
+	Receivable float64 // 在途的场外基金赎回款
 	Positions  map[string]Position
 	TotalValue float64
 	Weights    map[string]float64
+	Signals    map[string]Signal // 当日各持仓的估值信号 (策略支持输出信号时)
+}
+
+// PnLTotalSymbol 快照流式输出时每日盈亏只保留的组合合计行的代码
+const PnLTotalSymbol = "TOTAL"
+
+// DailyPnL 单个标的的每日盯市盈亏
+// 价格使用复权价时分红已体现在价格效应中，Income 仅记录额外的现金收益
+type DailyPnL struct {
+	Timestamp   time.Time
+	Symbol      string
+	PriceEffect float64 // 昨日持仓因价格变动产生的盈亏
+	TradeEffect float64 // 当日交易产生的盈亏 (成交价与收盘价之差、费用)
+	Income      float64 // 现金收益：当日计提的管理费、融资利息和融券费用 (为负)，融资利息记在 CashSymbol 下
+	Total       float64 // 合计 (价格效应 + 交易效应 + 现金收益)
+}
+
+// CashViolation 现金约束突破记录 (买单超过可用现金，已按比例缩放)
+type CashViolation struct {
+	Timestamp time.Time
+	Required  float64 // 买单所需金额
+	Available float64 // 扣除保留现金后的可用金额
 }
 
 // BacktestConfig 回测配置
 type BacktestConfig struct {
-	StartDate      time.Time
-	EndDate        time.Time
-	InitialCapital float64
-	Symbols        []string
-	Benchmark      string
+	StartDate          time.Time
+	EndDate            time.Time
+	InitialCapital     float64
+	Symbols            []string
+	Benchmark          string
+	BenchmarkBlend     map[string]float64 // 加权混合基准 (如 {000300: 0.6, H11006: 0.4})，设置后代替 Benchmark 计算基准收益
+	BenchmarkRebalance BenchmarkRebalance // 混合基准的再平衡频率，默认与组合同日再平衡
+	StopConditions     StopConditions
+	BaseCurrency       string                // 基础币种 (如CNY)，为空时不做汇率换算
+	Currencies         map[string]string     // 标的计价币种，未配置的标的视为基础币种
+	LiquidateAtEnd     bool                  // 期末是否清仓 (计入交易成本)
+	ExecutionPolicy    ExecutionPolicy       // 订单成交价格策略，默认当日收盘价
+	ExecutionLag       int                   // 执行延迟交易日数 (T日决策，T+N日成交)，0表示当日成交
+	ScaleBuys          bool                  // 买单总成本 (含滑点和费用) 超过现金时按比例缩放所有买单
+	MaxVolumePct       float64               // 单笔订单当日成交量上限 (占当日Volume比例)，超出部分次日继续成交，0表示不限制
+	Dust               DustPolicy            // 碎仓清理
+	Margin             MarginConfig          // 融资融券
+	AssetClasses       map[string]string     // 标的所属资产类别
+	Constraints        WeightConstraints     // 目标权重约束
+	InitialBuild       InitialBuildPolicy    // 首次建仓方式，默认首日按目标权重建仓
+	OrderType          OrderType             // 策略订单的下单方式，默认市价单
+	LimitOffset        float64               // 限价单相对决策价的偏移 (买单低于、卖单高于决策价)
+	LimitTTL           int                   // 限价单有效交易日数，默认1
+	MinOrderValue      float64               // 同一标的订单合并后，净额低于该值的订单丢弃 (碎仓清理订单除外)
+	Haircuts           map[string]float64    // 估值折扣 (如0.005表示按收盘价的99.5%估值)，用于流动性差的标的
+	LotSizes           map[string]float64    // 按标的的交易单位 (1为整股，100为一手)，未设置的标的允许零碎份额
+	MutualFunds        map[string]MutualFund // 场外基金：按当日净值申赎，按费率表收费，赎回款延迟到账
+	CoveragePolicy     CoveragePolicy        // 部分标的数据不完整时的处理方式，默认报错
+	Inception          InceptionPhaseIn      // stage 策略下回测期间上市的标的的分批建仓
+	Delistings         map[string]time.Time  // 标的退市日期：当日 (或之后首个交易日) 按最后价格清仓，此后不再交易
+	Halts              map[string][]Halt     // 标的停牌区间：期间不交易，仍按价格数据 (没有数据时为最后价格) 估值
+	Limits             RunLimits             // 运行资源限制 (服务/优化器场景防止病态回测占用资源)
+	KillSwitch         KillSwitch            // 止损开关
+	CostGate           CostGate              // 再平衡成本收益门槛
+	Behavior           BehaviorOverlay       // 投资者行为偏差模拟
+	Contributions      ContributionSchedule  // 定期追加投入
+	RankWindowYears    int                   // 由原始PE/PB计算滚动百分位的窗口年数 (数据缺少百分位列时)，0表示不计算
+	Shocks             []Shock               // 压力测试：加载数据后施加的合成价格冲击
+	Calendar           string                // 交易日历 (NYSE/SSE/SZSE)，设置后各标的数据对齐到日历，为空时交易日为各标的日期的并集
+	CalendarHolidays   string                // 追加的休市日期文件
+	Seed               int64                 // 随机数种子，各随机组件由其派生独立序列，相同种子结果可复现
+}
+
+// CoveragePolicy 数据覆盖不完整时的处理策略
+type CoveragePolicy string
+
+const (
+	CoverageError  CoveragePolicy = "error"  // 报错，要求显式选择处理方式
+	CoverageShrink CoveragePolicy = "shrink" // 收缩回测区间到所有标的都有数据的区间
+	CoverageStage  CoveragePolicy = "stage"  // 分批建仓，标的有数据后再参与交易
+)
+
+// InitialBuildPolicy 首次建仓方式
+type InitialBuildPolicy string
+
+const (
+	InitialBuildTarget   InitialBuildPolicy = "target"   // 引擎在首个交易日按目标权重建仓 (默认，各策略起点一致)
+	InitialBuildStrategy InitialBuildPolicy = "strategy" // 由策略的再平衡判断决定何时建仓
+)
+
+// SymbolCoverage 标的数据覆盖情况：FirstDate 之前、LastDate 之后和 Gaps 中的交易日没有数据
+type SymbolCoverage struct {
+	Symbol    string
+	FirstDate time.Time
+	LastDate  time.Time
+	Rows      int
+	Coverage  float64       // 有数据的交易日占全部交易日的比例
+	Gaps      []CoverageGap // 首末日期之间缺少数据的交易日区间
+	Partial   bool          // 是否只覆盖部分回测区间或中间有缺失
+}
+
+// CoverageGap 标的连续缺少数据的交易日区间 [Start, End]
+type CoverageGap struct {
+	Start time.Time
+	End   time.Time
+	Days  int // 区间内的交易日数
+}
+
+// ExecutionPolicy 订单成交价格策略
+type ExecutionPolicy string
+
+const (
+	ExecutionClose    ExecutionPolicy = "close"     // 成交日收盘价
+	ExecutionOpen     ExecutionPolicy = "open"      // 成交日开盘价 (需要执行延迟>=1)
+	ExecutionNextOpen ExecutionPolicy = "next_open" // 次日开盘价成交 (等价于 open + 延迟1日)
+	ExecutionVWAP     ExecutionPolicy = "vwap"      // 成交日VWAP近似价 (最高+最低+收盘)/3
+)
+
+// StopConditions 提前终止条件 (0表示不启用)
+type StopConditions struct {
+	MaxDrawdown     float64 // 回撤超过该比例时终止 (如0.3表示30%)
+	ValueFloor      float64 // 组合价值低于该值时终止
+	MaxLosingMonths int     // 连续亏损月数达到该值时终止
+}
+
+// TrendFilter 均线趋势过滤 (Window为0表示不启用)
+// 价格低于均线时不加仓，减仓时按 TrimFactor 多减 (多减部分留作现金)
+type TrendFilter struct {
+	Window     int     // 均线窗口 (交易日)，如200
+	TrimFactor float64 // 额外减仓比例，如0.5表示在正常减仓量基础上再多减50%
+}
+
+// 市场状态
+const (
+	RegimeRiskOn  = "risk_on"  // 基准在均线上方或波动率不高于阈值
+	RegimeRiskOff = "risk_off" // 基准跌破均线或波动率高于阈值
+)
+
+// RegimeChange 市场状态切换记录
+type RegimeChange struct {
+	Timestamp time.Time
+	From      string
+	To        string
+	Value     float64 // 切换时的状态指标值 (价格/均线 或 年化波动率)
+}
+
+// Checkpoint 回测断点：某个交易日收盘后的组合状态和策略内部状态
+// 可用于从该交易日之后继续回测，或按前一交易日的状态生成实盘再平衡信号
+type Checkpoint struct {
+	Date          time.Time       // 最后处理的交易日
+	Strategy      string          // 策略名称 (恢复时校验)
+	Portfolio     Portfolio       // 持仓和现金
+	StrategyState json.RawMessage // 策略内部状态 (策略不支持时为空)
+	Contributed   float64         // 截至断点的累计追加投入
+	Stops         StopState       // 提前终止条件的跟踪状态
+	KillSwitch    KillSwitchState // 止损开关的状态
+}
+
+// StopState 终止条件的跟踪状态 (峰值、当月起点和连续亏损月数)
+type StopState struct {
+	PeakValue       float64
+	MonthKey        int // 年*12+月，为0表示尚未开始跟踪
+	MonthStartValue float64
+	LastValue       float64
+	LosingMonths    int
+}
+
+// KillSwitchState 止损开关的状态
+type KillSwitchState struct {
+	Event    *KillSwitchEvent // 触发记录，未触发时为空
+	Executed bool             // 避险配置是否已下单
+	Tracker  StopState        // 触发条件的跟踪状态
+}
+
+// Holdings 实盘账户的当前持仓 (信号模式的输入)
+type Holdings struct {
+	Date         time.Time // 交易日，为零时取数据中最后一个交易日
+	Cash         float64
+	Positions    map[string]float64         // 各标的持有数量
+	AvgCost      map[string]float64         // 各标的持仓成本 (可选)
+	Prices       map[string]float64         // 当日价格，未提供的标的取数据中截至当日的收盘价
+	Fundamentals map[string]FundamentalData // 当日估值数据，未提供的标的取数据中截至当日的值
+}
+
+// LiveSignal 信号模式的输出：当日信号、目标权重和建议订单
+type LiveSignal struct {
+	Date          time.Time
+	Strategy      string
+	TotalValue    float64
+	Weights       map[string]float64 // 当前持仓权重
+	Rebalance     bool               // 当日是否应再平衡
+	Trigger       RebalanceTrigger   // 再平衡触发类型
+	TargetWeights map[string]float64
+	Signals       map[string]Signal // 各持仓的估值信号 (策略支持输出信号时)
+	Orders        []Order           // 建议订单，不需要再平衡时为空
+}
+
+// TrendAdjustment 趋势过滤调整记录
+type TrendAdjustment struct {
+	Timestamp time.Time
+	Symbol    string
+	Price     float64
+	MA        float64
+	Target    float64 // 策略目标权重
+	Adjusted  float64 // 过滤后的目标权重
+}
+
+// KillSwitch 止损开关：指标突破限制后，剩余回测期内转为避险配置并不再承担风险
+// 模拟投资者在大幅回撤后"认输"的行为，用于评估这种行为的代价
+type KillSwitch struct {
+	Conditions  StopConditions     // 触发条件 (与提前终止条件相同的指标)
+	SafeWeights map[string]float64 // 避险配置 (如 {TLT: 1})，为空表示全部转为现金
+}
+
+// CostGate 再平衡成本收益门槛 (0表示不启用)，只作用于策略发起的再平衡
+// 估算的交易成本超过订单带来的目标权重偏离减少 (按市值) 的 MaxCostRatio 时跳过本次再平衡
+type CostGate struct {
+	MaxCostRatio float64 // 如0.1表示成本不超过偏离减少市值的10%
+}
+
+// CostGateSkip 因成本收益检查跳过的再平衡
+type CostGateSkip struct {
+	Timestamp     time.Time
+	EstimatedCost float64 // 估算交易成本 (佣金、税费、价差和滑点)
+	Benefit       float64 // 目标权重偏离减少的市值
+}
+
+// Shock 合成价格冲击：从 Start 起的 Days 个交易日内，Symbols 的价格累计变动 Return (如-0.3)
+// 债券收益率冲击在配置中按久期换算为价格变动
+type Shock struct {
+	Name    string
+	Start   time.Time
+	Days    int
+	Symbols []string
+	Return  float64
+}
+
+// ShockEvent 实际施加的冲击 (首末交易日)
+type ShockEvent struct {
+	Name    string
+	Symbols []string
+	Start   time.Time
+	End     time.Time
+	Return  float64
+}
+
+// CalendarMisalignment 标的数据与交易日历不一致的行 (按交易日历对齐时记录)
+type CalendarMisalignment struct {
+	Symbol  string
+	Dropped []time.Time // 非交易日的数据行 (已丢弃)
+	Filled  []time.Time // 缺少数据的交易日 (已用前一交易日的数据填充，成交量记为0)
+}
+
+// ResultMetadata 结果元数据：生成结果的引擎版本、配置和数据的指纹，用于追溯和审计旧结果
+type ResultMetadata struct {
+	EngineVersion string
+	GoVersion     string
+	Commit        string // 构建时的git提交
+	Modified      bool   // 构建时工作区是否有未提交的修改
+	ConfigSHA256  string // 生效配置内容 (应用覆盖项后) 的SHA256
+	GeneratedAt   time.Time
+	Data          []DataFingerprint
+}
+
+// DataFingerprint 标的数据指纹
+type DataFingerprint struct {
+	Symbol    string
+	FirstDate time.Time // 回测使用的首个数据日期
+	LastDate  time.Time
+	Rows      int
+	SHA256    string // 数据文件内容的SHA256 (合成数据为空)
+}
+
+// KillSwitchEvent 止损开关触发记录
+type KillSwitchEvent struct {
+	Timestamp time.Time
+	Reason    string
+	Value     float64 // 触发时的组合价值
+}
+
+// BehaviorOverlay 投资者行为偏差模拟 (0表示不启用该项)，只作用于策略发起的再平衡
+// 与不启用时的理想回测对比，可评估纪律执行不到位的代价
+type BehaviorOverlay struct {
+	SkipProbability float64 // 每次再平衡被随机跳过的概率 (如0.2)
+	DelayDays       int     // 订单在执行延迟之外再推迟的交易日数
+	NoBuyAfterLoss  float64 // 上月亏损超过该比例 (如0.05表示-5%) 时当月拒绝买入
+	Seed            int64   // 随机数种子 (非0时覆盖运行种子 BacktestConfig.Seed)
+}
+
+// Enabled 是否启用了任一行为偏差
+func (b BehaviorOverlay) Enabled() bool {
+	return b.SkipProbability > 0 || b.DelayDays > 0 || b.NoBuyAfterLoss > 0
+}
+
+// BehaviorStats 行为偏差的影响统计
+type BehaviorStats struct {
+	SkippedRebalances int     // 被跳过的再平衡次数
+	DelayedRebalances int     // 被推迟执行的再平衡次数
+	RefusedBuys       int     // 亏损月后被拒绝的买单数
+	RefusedBuyValue   float64 // 被拒绝的买单金额
+}
+
+// ContributionSchedule 定期追加投入计划 (每月首个交易日投入，投入当日按策略目标权重投资)
+// 条件规则可按市场状态调整投入金额，0表示不启用该规则
+type ContributionSchedule struct {
+	Monthly        float64 // 每月投入金额，0表示不追加投入
+	BoostDrawdown  float64 // 基准较历史最高价回撤超过该比例 (如0.2) 时加大投入
+	BoostFactor    float64 // 加大投入的倍数 (默认2)
+	PauseCashAbove float64 // 账户现金超过该金额时暂停投入
+}
+
+// 追加投入规则
+const (
+	ContributionBoost = "boost" // 基准深度回撤，加大投入
+	ContributionPause = "pause" // 现金超过上限，暂停投入
+)
+
+// BenchmarkRebalance 混合基准的再平衡频率
+type BenchmarkRebalance string
+
+const (
+	BenchmarkRebalancePortfolio BenchmarkRebalance = "portfolio" // 与组合的再平衡同日 (默认，公平比较)
+	BenchmarkRebalanceMonthly   BenchmarkRebalance = "monthly"   // 每月首个交易日
+	BenchmarkRebalanceQuarterly BenchmarkRebalance = "quarterly" // 每季度首个交易日
+	BenchmarkRebalanceYearly    BenchmarkRebalance = "yearly"    // 每年首个交易日
+	BenchmarkRebalanceNone      BenchmarkRebalance = "none"      // 不再平衡 (买入持有)
+)
+
+// BenchmarkResult 基准 (单一标的或加权混合) 的净值和收益，净值按与组合相同的初始资金和追加投入计算
+type BenchmarkResult struct {
+	Name         string             // 如 "60% 000300 + 40% H11006"
+	Weights      map[string]float64 // 各成分权重 (合计为1)
+	Rebalance    BenchmarkRebalance
+	Rebalances   int              // 建仓后的再平衡次数
+	Values       []BenchmarkPoint // 各交易日的基准净值
+	TotalReturn  float64
+	ExcessReturn float64 // 组合收益率 - 基准收益率
+}
+
+// BenchmarkPoint 基准净值
+type BenchmarkPoint struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// Baseline 自动运行的对照回测
+type Baseline string
+
+const (
+	BaselineBuyAndHold Baseline = "buy_and_hold" // 按初始权重建仓后不再平衡
+	BaselineAnnual     Baseline = "annual"       // 按初始权重每年再平衡一次
+)
+
+// BaselineResult 对照回测的结果，再平衡收益为主策略相对对照回测多获得的收益
+type BaselineResult struct {
+	Name         Baseline
+	Weights      map[string]float64 // 初始权重 (主策略首次建仓的目标权重)
+	FinalValue   float64
+	TotalReturn  float64
+	AnnualReturn float64
+	MaxDrawdown  float64
+	TotalTrades  int
+	TotalFees    float64
+	Bonus        float64 // 再平衡收益：主策略收益率 - 对照收益率
+	AnnualBonus  float64 // 年化收益率之差
+}
+
+// InceptionPhaseIn 回测期间上市 (数据晚于首个交易日开始) 的标的的建仓方式：上市前其目标权重按比例分配给其他资产，
+// 上市后分 Steps 批、每隔 Interval 个交易日提高到完整目标权重
+type InceptionPhaseIn struct {
+	Steps    int // 分批次数，默认1 (上市当日一次建仓)
+	Interval int // 相邻两批间隔的交易日数，默认5
+}
+
+// MutualFund 场外基金的申赎规则：申购和赎回按成交日净值 (价格数据的收盘价) 成交，不计滑点、价差和成本模型的佣金税费，
+// 按申购费率表和赎回费率表收费；赎回款在 SettlementDays 个交易日后到账 (T+N)，到账前计入总价值但不能用于买入
+type MutualFund struct {
+	SettlementDays   int               // 赎回款到账的交易日数，0表示当日到账
+	SubscriptionFees []SubscriptionFee // 申购费率表，按申购金额从低到高分档
+	RedemptionFees   []RedemptionFee   // 赎回费率表，按持有天数从短到长分档
+}
+
+// SubscriptionFee 申购费率档：申购金额 (不含费用) 低于 Below 时适用，Below 为0表示不设上限；
+// 按金额的 Rate 收费，Fixed 大于0时改为每笔收取固定费用
+type SubscriptionFee struct {
+	Below float64
+	Rate  float64
+	Fixed float64
+}
+
+// RedemptionFee 赎回费率档：份额持有天数 (自然日，按先进先出) 少于 Days 时适用，Days 为0表示不设上限
+type RedemptionFee struct {
+	Days int
+	Rate float64
+}
+
+// SubscriptionCost 按费率表计算申购金额 amount 的申购费，没有适用的费率档时为0
+func (f MutualFund) SubscriptionCost(amount float64) float64 {
+	for _, tier := range f.SubscriptionFees {
+		if tier.Below > 0 && amount >= tier.Below {
+			continue
+		}
+		if tier.Fixed > 0 {
+			return tier.Fixed
+		}
+		return amount * tier.Rate
+	}
+	return 0
+}
+
+// RedemptionRate 持有 days 天的份额适用的赎回费率，没有适用的费率档时为0
+func (f MutualFund) RedemptionRate(days int) float64 {
+	for _, tier := range f.RedemptionFees {
+		if tier.Days <= 0 || days < tier.Days {
+			return tier.Rate
+		}
+	}
+	return 0
+}
+
+// Halt 停牌区间 [Start, End)，End 为复牌日，零值表示停牌至回测结束
+type Halt struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SymbolStatus 标的交易状态变更类型
+type SymbolStatus string
+
+const (
+	StatusDelisted SymbolStatus = "delisted" // 退市，按最后价格清仓
+	StatusHalted   SymbolStatus = "halted"   // 停牌，暂停交易
+	StatusResumed  SymbolStatus = "resumed"  // 复牌
+)
+
+// StatusEvent 标的交易状态变更记录
+type StatusEvent struct {
+	Timestamp time.Time
+	Symbol    string
+	Status    SymbolStatus
+	Price     float64 // 退市清仓价格 (最后价格)
+	Quantity  float64 // 退市清仓数量
+	Blocked   int     // 停牌期间 (复牌时记录) 或退市后丢弃的订单数
+}
+
+// InceptionEvent 回测期间上市的标的的建仓记录
+type InceptionEvent struct {
+	Symbol   string
+	Date     time.Time // 首个有数据的交易日
+	PhasedIn time.Time // 达到完整目标权重 (最后一批) 的交易日，回测结束时未完成为零值
+	Steps    int       // 已执行的批次
+}
+
+// ContributionRecord 追加投入记录
+type ContributionRecord struct {
+	Timestamp time.Time
+	Scheduled float64 // 固定计划金额
+	Amount    float64 // 实际投入金额
+	Rule      string  // 生效的条件规则，为空表示按计划投入
+}
+
+// RunLimits 单次回测的运行限制 (0表示不限制)，超出时中止回测并返回已完成部分的结果
+type RunLimits struct {
+	MaxWallTime   time.Duration // 最长运行时间
+	MaxTrades     int           // 最多成交笔数
+	MaxRebalances int           // 最多再平衡次数
+}
+
+// WeightBounds 权重上下限 (Max为0表示不设上限)
+type WeightBounds struct {
+	Min float64
+	Max float64
+}
+
+// WeightConstraints 目标权重约束，在策略计算目标权重后生效
+type WeightConstraints struct {
+	MaxWeight float64                 // 任一标的权重上限，0表示不限制
+	Symbols   map[string]WeightBounds // 单个标的上下限
+	Classes   map[string]WeightBounds // 资产类别上下限
+}
+
+// TargetWeightRecord 再平衡日的策略目标权重 (已施加权重约束)
+type TargetWeightRecord struct {
+	Timestamp time.Time
+	Trigger   RebalanceTrigger
+	Weights   map[string]float64
+}
+
+// ConstraintBinding 约束生效记录
+type ConstraintBinding struct {
+	Timestamp time.Time
+	Target    string  // 标的代码或 "class:类别名"
+	Bound     string  // "max" / "min"
+	Limit     float64 // 约束值
+	Weight    float64 // 约束前的权重
+}
+
+// MarginConfig 融资融券设置
+type MarginConfig struct {
+	AllowShort       bool    // 允许负权重 (做空)
+	MaxGrossExposure float64 // 总敞口 (多空市值绝对值之和) 占净值的上限，大于1表示允许融资，0表示1倍
+}
+
+// DustPolicy 碎仓清理策略 (0表示不启用)
+// 权重或市值低于阈值的持仓在下次再平衡时清仓并入现金，策略仍在买入的标的除外
+type DustPolicy struct {
+	MaxWeight float64 // 权重低于该值视为碎仓 (如0.005表示0.5%)
+	MaxValue  float64 // 市值低于该值视为碎仓
 }
 
 // BacktestResult 回测结果
 type BacktestResult struct {
-	Config        BacktestConfig
-	Trades        []Trade
-	Snapshots     []PortfolioSnapshot
-	FinalValue    float64
-	TotalReturn   float64
-	TotalTrades   int
-	TotalFees     float64
-	StartDate     time.Time
-	EndDate       time.Time
+	Config           BacktestConfig
+	Trades           []Trade
+	Snapshots        []PortfolioSnapshot
+	FinalValue       float64
+	TotalReturn      float64
+	TotalTrades      int
+	TotalFees        float64
+	StartDate        time.Time
+	EndDate          time.Time
+	Stopped          bool                   // 是否提前终止
+	StopReason       string                 // 提前终止原因
+	KillSwitch       *KillSwitchEvent       // 止损开关触发记录，未触发为nil
+	Behavior         *BehaviorStats         // 行为偏差影响统计，未启用为nil
+	CostGateSkips    []CostGateSkip         // 因交易成本超过预期收益而跳过的再平衡
+	Shocks           []ShockEvent           // 施加的合成价格冲击
+	Calendar         string                 // 使用的交易日历
+	Misalignments    []CalendarMisalignment // 与交易日历不一致的数据行
+	Inceptions       []InceptionEvent       // 回测期间上市的标的的建仓记录
+	StatusEvents     []StatusEvent          // 退市、停牌和复牌记录
+	Benchmark        *BenchmarkResult       // 基准净值和超额收益，未设置基准或缺少基准数据为nil
+	Baselines        []BaselineResult       // 买入持有和每年再平衡的对照回测 (backtest.baselines)
+	Seed             int64                  // 运行使用的随机数种子
+	Metadata         ResultMetadata         // 引擎版本、配置哈希和数据指纹
+	SnapshotStream   string                 // 完整快照的流式输出文件，非空时 Snapshots 只含日期、现金和总价值
+	Contributions    []ContributionRecord   // 追加投入记录
+	TotalContributed float64                // 累计追加投入 (收益率按初始资金+追加投入计算)
+	RegimeChanges    []RegimeChange         // 状态切换策略的切换记录
+	Aborted          bool                   // 是否因超出运行限制而中止
+	AbortReason      string                 // 中止原因 (含中止日期和已用资源)
+	BaseCurrency     string
+	CurrencyReturns  []CurrencyReturn // 各外币汇率收益
+	Signals          []SignalRecord   // 再平衡日的持仓信号
+
+	ExecutionMode      string               // 实际使用的成交模式 (如 "T+1 open")
+	Coverage           []SymbolCoverage     // 各标的数据覆盖情况
+	CashViolations     []CashViolation      // 现金约束突破记录
+	UnfilledOrders     []Order              // 回测结束时仍未成交的挂单
+	ExpiredOrders      []Order              // 到期未成交而撤销的限价单
+	DailyPnL           []DailyPnL           // 各标的每日盈亏 (快照流式输出时只有组合合计 PnLTotalSymbol)
+	FinancingCost      float64              // 累计融资利息和融券费用
+	HoldingCost        float64              // 累计按管理费率计提的持有成本
+	ConstraintBindings []ConstraintBinding  // 目标权重约束生效记录
+	TriggerStats       []TriggerStat        // 按再平衡触发类型汇总的统计
+	TradeAnalytics     *TradeAnalytics      // 成交记录统计，没有成交时为nil
+	SignalAccuracy     []SignalAccuracy     // 买入/卖出信号的远期收益命中率 (输出信号的策略)
+	ClosedLots         []ClosedLot          // 已平仓批次 (先进先出)
+	SymbolPnL          []SymbolPnL          // 各标的已实现盈亏、胜率和平均持有期
+	TargetWeights      []TargetWeightRecord // 各再平衡日的目标权重
+	Exposures          []ExposureStat       // 各标的实际权重统计
+	ClassExposures     []ClassExposure      // 各资产类别实际权重统计 (设置了 assets[].class 时)
+	TrendAdjustments   []TrendAdjustment    // 均线趋势过滤调整记录
+
+	// 期末清仓结果 (LiquidateAtEnd时有效)，FinalValue/TotalReturn为盯市结果
+	Liquidated        bool
+	LiquidationValue  float64 // 清仓后可变现现金
+	LiquidationReturn float64
+}
+
+// CurrencyReturn 外币汇率收益
+type CurrencyReturn struct {
+	Currency     string
+	StartRate    float64
+	EndRate      float64
+	FXReturn     float64 // 汇率变动收益率
+	Contribution float64 // 对组合收益的贡献 (按持仓权重累计)
 }
 
 // CostConfig 成本配置
 type CostConfig struct {
-	CommissionRate float64 // 佣金率
-	MinCommission  float64 // 最低佣金
-	SlippageRate   float64 // 滑点率
-	TaxRate        float64 // 税率
+	CommissionRate  float64            // 佣金率
+	MinCommission   float64            // 最低佣金
+	SlippageRate    float64            // 滑点率
+	TaxRate         float64            // 税率
+	MarginRate      float64            // 融资年利率 (按借入现金计息)
+	ShortBorrowRate float64            // 融券年费率 (按空头市值计费)
+	Model           string             // 成本模型名称 (空或 default、per_share、zero)
+	PerShare        float64            // per_share 模型的每股佣金
+	Spreads         map[string]float64 // 按标的的单边买卖价差成本 (占成交额)，计入交易费用
+	ExpenseRatios   map[string]float64 // 按标的的年管理费率，按持仓市值逐日计提 (仅在价格未扣除费率时设置)
 }
 
 // StrategyConfig 策略配置
@@ -181,13 +870,126 @@ type StrategyConfig struct {
 	Name                 string
 	Type                 string
 	TargetWeights        map[string]float64
-	Threshold            float64 // 阈值触发再平衡的偏离阈值
-	RebalanceInterval    int     // 定期再平衡的间隔天数
-	MinTradeValue        float64 // 最小交易金额
-	MinRebalanceInterval int     // 最小再平衡间隔天数
+	Threshold            float64             // 阈值触发再平衡的偏离阈值
+	ThresholdMode        string              // 偏离阈值口径: absolute (绝对权重差) / relative (相对目标权重的比例)，为空时按策略默认
+	RebalanceInterval    int                 // 定期再平衡的间隔天数 (自然日)
+	MinTradeValue        float64             // 最小交易金额
+	SymbolThresholds     map[string]float64  // 按标的的偏离阈值 (覆盖 Threshold)
+	SymbolMinTradeValues map[string]float64  // 按标的的最小交易金额 (覆盖 MinTradeValue)
+	LotSizes             map[string]float64  // 按标的的交易单位，订单数量按其向下取整 (清仓时保留零股)
+	MinRebalanceInterval int                 // 最小再平衡间隔天数 (自然日)
+	RebalanceMode        string              // 调仓模式: target (调回目标) / band (调回区间边缘) / halfway (调回中点) / worst (只调偏离最大的标的)
+	RebalanceTopK        int                 // worst 模式下每次最多调整的标的数 (0为超出区间的全部标的)
+	MinCashWeight        float64             // 最低现金权重 (如0.02表示始终保留2%现金)
+	AssetClasses         []AssetClass        // 资产类别，设置类别权重时目标权重按类别分配
+	AllowShort           bool                // 允许负目标权重 (做空)
+	MaxGrossExposure     float64             // 总敞口上限，大于1表示允许融资
+	Trend                TrendFilter         // 均线趋势过滤
+	DriftMode            string              // 目标权重漂移模式: static (固定) / relative (随各资产基准收益漂移)
+	Benchmarks           map[string]string   // 各资产的基准标的 (相对漂移模式)
+	Sizing               string              // 估值倾斜的仓位模式: fixed (固定比例) / kelly (分数凯利)
+	Components           []StrategyComponent // 组合策略的子策略
+	Voting               string              // 组合策略的再平衡投票方式: any / majority / all
+	Regime               *RegimeParams       // 状态切换策略参数
 
 	// 估值策略参数
 	ValuationParams *ValuationParams
+
+	// 分数凯利仓位参数 (Sizing 为 kelly 时使用)
+	KellyParams *KellyParams
+
+	// CPPI策略参数
+	CPPIParams *CPPIParams
+
+	// Black-Litterman 观点融合参数
+	BlackLittermanParams *BlackLittermanParams
+}
+
+// StrategyComponent 组合策略中的子策略及其混合权重
+type StrategyComponent struct {
+	Weight float64 // 混合权重 (各子策略按合计归一化)
+	Config StrategyConfig
+}
+
+// 状态指标
+const (
+	RegimeIndicatorMA         = "ma"         // 价格相对均线
+	RegimeIndicatorVolatility = "volatility" // 已实现波动率
+)
+
+// RegimeParams 状态切换策略参数：按状态指标在两个子策略之间切换
+type RegimeParams struct {
+	Indicator string          // 状态指标: ma / volatility
+	Symbol    string          // 判断状态的标的 (默认为回测基准)
+	Window    int             // 均线或波动率窗口 (交易日，默认 ma 200 / volatility 20)
+	Threshold float64         // 波动率阈值 (年化)，高于阈值为 risk_off
+	Band      float64         // 切换缓冲 (相对切换点的比例，如0.02)，指标在缓冲区间内时保持原状态
+	RiskOn    *StrategyConfig // risk_on 状态使用的策略
+	RiskOff   *StrategyConfig // risk_off 状态使用的策略
+}
+
+// KellyParams 分数凯利仓位参数
+// 估值信号换算为年化超额收益预期 μ (最强信号为 ±ExpectedReturn)，权重倾斜比例 = Fraction × μ/σ²
+type KellyParams struct {
+	Fraction       float64 // 凯利比例 (默认0.25，即四分之一凯利)
+	ExpectedReturn float64 // 最强买入/卖出信号对应的年化超额收益 (默认0.04)
+	VolWindow      int     // 估计波动率的窗口 (交易日，默认252)
+	DefaultVol     float64 // 历史不足时使用的年化波动率 (默认0.2)
+	MaxTilt        float64 // 加仓倾斜上限，相对基础权重 (默认0.5)
+	MaxCut         float64 // 减仓倾斜上限，相对基础权重 (默认0.5，最大1)
+}
+
+// DefaultKellyParams 默认分数凯利参数
+func DefaultKellyParams() *KellyParams {
+	return &KellyParams{
+		Fraction:       0.25,
+		ExpectedReturn: 0.04,
+		VolWindow:      252,
+		DefaultVol:     0.2,
+		MaxTilt:        0.5,
+		MaxCut:         0.5,
+	}
+}
+
+// BlackLittermanParams Black-Litterman 观点融合参数
+// 先验收益由基础目标权重反推 (π = δσ²w)，PE百分位形成各资产相对先验的超额收益观点，按置信度融合后再换算为权重
+type BlackLittermanParams struct {
+	RiskAversion float64 // 风险厌恶系数 δ (默认2.5)
+	ViewReturn   float64 // 百分位处于两端时观点的年化超额收益 (默认0.02，百分位0为+2%、100为-2%)
+	Confidence   float64 // 观点相对先验的置信度 (默认1，即观点与先验等权)
+	VolWindow    int     // 估计波动率的窗口 (交易日，默认252)
+	DefaultVol   float64 // 历史不足时使用的年化波动率 (默认0.15)
+}
+
+// DefaultBlackLittermanParams 默认 Black-Litterman 参数
+func DefaultBlackLittermanParams() *BlackLittermanParams {
+	return &BlackLittermanParams{
+		RiskAversion: 2.5,
+		ViewReturn:   0.02,
+		Confidence:   1,
+		VolWindow:    252,
+		DefaultVol:   0.15,
+	}
+}
+
+// CPPIParams 固定比例投资组合保险 (CPPI) 参数
+// 风险资产敞口 = Multiplier × (组合价值 - 保底价值)，其余配置到安全资产或现金
+type CPPIParams struct {
+	Floor       float64            // 保底价值占初始资金的比例 (默认0.8)
+	Multiplier  float64            // 乘数 (默认3)
+	MaxExposure float64            // 风险资产权重上限 (默认1)
+	FloorGrowth float64            // 保底价值年增长率 (如无风险利率0.02)
+	Ratchet     bool               // 保底价值随组合新高上移 (保持占最高价值的 Floor 比例)
+	SafeWeights map[string]float64 // 安全资产的相对权重，为空表示安全部分持有现金
+}
+
+// DefaultCPPIParams 默认CPPI参数
+func DefaultCPPIParams() *CPPIParams {
+	return &CPPIParams{
+		Floor:       0.8,
+		Multiplier:  3,
+		MaxExposure: 1,
+	}
 }
 
 // ValuationParams 估值策略参数
@@ -199,36 +1001,45 @@ type ValuationParams struct {
 	CoreLowPERank     float64 // 核心资产低估阈值 (默认50)
 
 	// PEG阈值
-	HighPEG           float64 // PEG高估阈值 (默认2.0)
-	BubblePEG         float64 // PEG泡沫阈值 (默认2.5)
-	LowPEG            float64 // PEG低估阈值 (默认1.5)
+	HighPEG   float64 // PEG高估阈值 (默认2.0)
+	BubblePEG float64 // PEG泡沫阈值 (默认2.5)
+	LowPEG    float64 // PEG低估阈值 (默认1.5)
 
 	// ROE阈值
-	GoodROE           float64 // 优质ROE阈值 (默认20)
-	PoorROE           float64 // 差ROE阈值 (默认5)
+	GoodROE float64 // 优质ROE阈值 (默认20)
+	PoorROE float64 // 差ROE阈值 (默认5)
+
+	// 股息率百分位阈值 (REITs、红利ETF、港股高股息ETF)
+	HighYieldRank       float64  // 高股息阈值，低估 (默认80)
+	LowYieldRank        float64  // 低股息阈值，高估 (默认20)
+	ExtremeLowYieldRank float64  // 极低股息阈值，极度高估 (默认5)
+	DividendSymbols     []string // 强制按股息率估值的标的
 
 	// 操作比例
-	TrimRatio         float64 // 动态再平衡减仓比例 (默认0.2)
-	ReduceRatio       float64 // 减仓比例 (默认0.3)
-	SellRatio         float64 // 卖出比例 (默认0.5)
-	BuyRatio          float64 // 买入增仓比例 (默认0.2)
+	TrimRatio   float64 // 动态再平衡减仓比例 (默认0.2)
+	ReduceRatio float64 // 减仓比例 (默认0.3)
+	SellRatio   float64 // 卖出比例 (默认0.5)
+	BuyRatio    float64 // 买入增仓比例 (默认0.2)
 }
 
 // DefaultValuationParams 默认估值参数
 func DefaultValuationParams() *ValuationParams {
 	return &ValuationParams{
-		ExtremeHighPERank: 90,
-		HighPERank:        75,
-		LowPERank:         20,
-		CoreLowPERank:     50,
-		HighPEG:           2.0,
-		BubblePEG:         2.5,
-		LowPEG:            1.5,
-		GoodROE:           20,
-		PoorROE:           5,
-		TrimRatio:         0.2,
-		ReduceRatio:       0.3,
-		SellRatio:         0.5,
-		BuyRatio:          0.2,
+		ExtremeHighPERank:   90,
+		HighPERank:          75,
+		LowPERank:           20,
+		CoreLowPERank:       50,
+		HighPEG:             2.0,
+		BubblePEG:           2.5,
+		LowPEG:              1.5,
+		GoodROE:             20,
+		PoorROE:             5,
+		HighYieldRank:       80,
+		LowYieldRank:        20,
+		ExtremeLowYieldRank: 5,
+		TrimRatio:           0.2,
+		ReduceRatio:         0.3,
+		SellRatio:           0.5,
+		BuyRatio:            0.2,
 	}
 }
@@ -46,6 +46,16 @@ class BacktestAnalyzer:
 
         return pd.Series(values, index=pd.DatetimeIndex(dates))
 
+    def get_benchmark_values(self) -> pd.Series:
+        """获取基准净值时间序列 (未设置基准时为空)"""
+        benchmark = self.data.get('benchmark') or {}
+        points = benchmark.get('Values') or []
+        if not points:
+            return pd.Series(dtype=float)
+
+        dates = pd.to_datetime([p.get('Timestamp', '') for p in points], utc=True).tz_localize(None)
+        return pd.Series([p.get('Value', 0) for p in points], index=pd.DatetimeIndex(dates))
+
     def get_trades(self) -> pd.DataFrame:
         """获取交易记录DataFrame"""
         trades = self.data.get('trades', [])
@@ -69,6 +79,92 @@ class BacktestAnalyzer:
             df['timestamp'] = pd.to_datetime(df['timestamp'])
         return df
 
+    def get_signals(self) -> pd.DataFrame:
+        """获取再平衡日的结构化信号DataFrame"""
+        signals = self.data.get('signals', [])
+        if not signals:
+            return pd.DataFrame()
+
+        records = []
+        for record in signals:
+            signal = record.get('Signal', {})
+            records.append({
+                'timestamp': record.get('Timestamp', ''),
+                'symbol': record.get('Symbol', ''),
+                'type': signal.get('Type', ''),
+                'direction': signal.get('Direction', ''),
+                'strength': signal.get('Strength', 0),
+                'reason': signal.get('Reason', ''),
+            })
+
+        df = pd.DataFrame(records)
+        if not df.empty and 'timestamp' in df.columns:
+            df['timestamp'] = pd.to_datetime(df['timestamp'])
+        return df
+
+    def get_daily_signals(self) -> pd.DataFrame:
+        """获取每日快照中的持仓信号 (长表: timestamp, symbol, type, ...)"""
+        records = []
+        for snap in self.data.get('snapshots', []):
+            for symbol, signal in (snap.get('Signals') or {}).items():
+                records.append({
+                    'timestamp': snap.get('Timestamp', ''),
+                    'symbol': symbol,
+                    'type': signal.get('Type', ''),
+                    'direction': signal.get('Direction', ''),
+                    'strength': signal.get('Strength', 0),
+                    'reason': signal.get('Reason', ''),
+                })
+
+        df = pd.DataFrame(records)
+        if not df.empty:
+            df['timestamp'] = pd.to_datetime(df['timestamp'])
+        return df
+
+    def get_daily_pnl(self) -> pd.DataFrame:
+        """获取各标的每日盈亏DataFrame (价格效应/交易效应/现金收益)"""
+        pnl = self.data.get('daily_pnl', [])
+        if not pnl:
+            return pd.DataFrame()
+
+        records = []
+        for record in pnl:
+            records.append({
+                'timestamp': record.get('Timestamp', ''),
+                'symbol': record.get('Symbol', ''),
+                'price_effect': record.get('PriceEffect', 0),
+                'trade_effect': record.get('TradeEffect', 0),
+                'income': record.get('Income', 0),
+                'total': record.get('Total', 0),
+            })
+
+        df = pd.DataFrame(records)
+        if not df.empty and 'timestamp' in df.columns:
+            df['timestamp'] = pd.to_datetime(df['timestamp'])
+        return df
+
+    def get_monthly_pnl_by_symbol(self) -> pd.DataFrame:
+        """按月汇总各标的盈亏 (行: 月份, 列: 标的)"""
+        df = self.get_daily_pnl()
+        if df.empty:
+            return df
+
+        df['month'] = df['timestamp'].dt.to_period('M')
+        return df.pivot_table(index='month', columns='symbol', values='total', aggfunc='sum', fill_value=0)
+
+    def get_symbol_pnl(self) -> pd.DataFrame:
+        """获取各标的已实现盈亏DataFrame (先进先出配对的已平仓批次汇总)"""
+        records = []
+        for stat in self.data.get('symbol_pnl') or []:
+            records.append({
+                'symbol': stat.get('Symbol', ''),
+                'realized_pnl': stat.get('RealizedPnL', 0),
+                'closed_lots': stat.get('ClosedLots', 0),
+                'win_rate': stat.get('WinRate', 0),
+                'avg_holding_days': stat.get('AvgHoldingDays', 0),
+            })
+        return pd.DataFrame(records)
+
     def get_summary(self) -> Dict:
         """获取回测摘要"""
         return self.data.get('summary', {})
@@ -81,6 +177,17 @@ class BacktestAnalyzer:
 
         metrics = self.metrics.calculate_all(portfolio_values)
 
+        # 添加相对基准的指标
+        benchmark_values = self.get_benchmark_values()
+        if not benchmark_values.empty:
+            values = portfolio_values.copy()
+            if values.index.tz is not None:
+                values.index = values.index.tz_localize(None)
+            relative = self.metrics.calculate_relative(values, benchmark_values)
+            if relative:
+                relative['benchmark_name'] = (self.data.get('benchmark') or {}).get('Name', '')
+                metrics.update(relative)
+
         # 添加交易统计
         trades_df = self.get_trades()
         if not trades_df.empty:
@@ -90,6 +197,11 @@ class BacktestAnalyzer:
             metrics['sell_trades'] = len(trades_df[trades_df['side'] == 'SELL'])
             metrics['avg_trade_value'] = trades_df['value'].mean()
 
+        # 添加各标的已实现盈亏
+        pnl_df = self.get_symbol_pnl()
+        if not pnl_df.empty:
+            metrics['symbol_pnl'] = pnl_df.to_dict('records')
+
         # 添加基本信息
         summary = self.get_summary()
         metrics['strategy_name'] = summary.get('strategy_name', 'Unknown')
@@ -119,6 +231,34 @@ class BacktestAnalyzer:
             df = df.set_index('timestamp')
         return df
 
+    def get_target_weights_history(self, daily: bool = True) -> pd.DataFrame:
+        """
+        获取策略目标权重历史
+        Args:
+            daily: 为True时按快照日期前向填充 (便于与实际权重对比)，否则只返回再平衡日
+        """
+        records = []
+        for record in self.data.get('target_weights', []):
+            ts = record.get('Timestamp', '')
+            weights = record.get('Weights', {})
+            if ts and weights:
+                row = {'timestamp': ts}
+                row.update(weights)
+                records.append(row)
+
+        df = pd.DataFrame(records)
+        if df.empty:
+            return df
+
+        df['timestamp'] = pd.to_datetime(df['timestamp'])
+        df = df.set_index('timestamp').fillna(0)
+
+        if daily:
+            actual = self.get_weights_history()
+            if not actual.empty:
+                df = df.reindex(df.index.union(actual.index)).ffill().reindex(actual.index)
+        return df
+
     def print_report(self):
         """打印分析报告"""
         metrics = self.calculate_all_metrics()
@@ -142,11 +282,39 @@ class BacktestAnalyzer:
         print(f"  最大回撤持续: {metrics.get('max_drawdown_duration', 0)} 天")
         print(f"  VaR (95%): {metrics.get('var_95', 0) * 100:.2f}%")
         print(f"  CVaR (95%): {metrics.get('cvar_95', 0) * 100:.2f}%")
+        print(f"  Ulcer Index: {metrics.get('ulcer_index', 0) * 100:.2f}%")
+        print(f"  Pain Index: {metrics.get('pain_index', 0) * 100:.2f}%")
+
+        if 'worst_day' in metrics:
+            print("\n--- 尾部风险 (VaR/CVaR 95% | 99%) ---")
+            for period, label in (('daily', '日收益'), ('monthly', '月收益')):
+                for method, name in (('hist', '历史法'), ('param', '参数法')):
+                    values = [metrics.get(f'{period}_{kind}_{level}_{method}', 0) * 100
+                              for level in (95, 99) for kind in ('var', 'cvar')]
+                    print(f"  {label} {name}: {values[0]:6.2f}% / {values[1]:6.2f}% | "
+                          f"{values[2]:6.2f}% / {values[3]:6.2f}%")
+            print(f"  最差单日: {metrics['worst_day'] * 100:.2f}% ({metrics['worst_day_date']}), "
+                  f"亏损日占比 {metrics['losing_days'] * 100:.1f}%")
+            if 'worst_month' in metrics:
+                print(f"  最差单月: {metrics['worst_month'] * 100:.2f}% ({metrics['worst_month_date']}), "
+                      f"亏损月占比 {metrics['losing_months'] * 100:.1f}%")
 
         print("\n--- 风险调整收益 ---")
         print(f"  Sharpe Ratio: {metrics.get('sharpe_ratio', 0):.3f}")
         print(f"  Sortino Ratio: {metrics.get('sortino_ratio', 0):.3f}")
         print(f"  Calmar Ratio: {metrics.get('calmar_ratio', 0):.3f}")
+        print(f"  Martin Ratio: {metrics.get('martin_ratio', 0):.3f}")
+        print(f"  Pain Ratio: {metrics.get('pain_ratio', 0):.3f}")
+
+        if 'information_ratio' in metrics:
+            print(f"\n--- 相对基准 ({metrics.get('benchmark_name', '')}) ---")
+            print(f"  信息比率: {metrics['information_ratio']:.3f}")
+            print(f"  跟踪误差: {metrics['tracking_error'] * 100:.2f}%")
+            print(f"  上行捕获率: {metrics['up_capture'] * 100:.1f}%")
+            print(f"  下行捕获率: {metrics['down_capture'] * 100:.1f}%")
+            print(f"  Beta: {metrics['beta']:.3f}  (滚动{metrics['rolling_beta_window']}日: "
+                  f"最新 {metrics['rolling_beta_last']:.3f}, 区间 {metrics['rolling_beta_min']:.3f} ~ "
+                  f"{metrics['rolling_beta_max']:.3f})")
 
         print("\n--- 交易统计 ---")
         print(f"  总交易次数: {metrics.get('total_trades', 0)}")
@@ -155,6 +323,13 @@ class BacktestAnalyzer:
         print(f"  总交易费用: ${metrics.get('total_fees', 0):,.2f}")
         print(f"  平均交易金额: ${metrics.get('avg_trade_value', 0):,.2f}")
 
+        if metrics.get('symbol_pnl'):
+            print("\n--- 各标的已实现盈亏 ---")
+            for stat in metrics['symbol_pnl']:
+                print(f"  {stat['symbol']:<10} ${stat['realized_pnl']:>12,.2f}  "
+                      f"平仓批次 {stat['closed_lots']:>4}  胜率 {stat['win_rate'] * 100:5.1f}%  "
+                      f"平均持有 {stat['avg_holding_days']:.0f} 天")
+
         print("\n" + "=" * 60)
 
 
@@ -18,10 +18,27 @@ class PerformanceMetrics:
 
     def calculate_all(self, portfolio_values: pd.Series) -> Dict:
         """计算所有指标"""
+        indexes = self.calculate_drawdown_indexes(portfolio_values)
         return {
             **self.calculate_returns(portfolio_values),
-            **self.calculate_risk(portfolio_values),
-            **self.calculate_ratios(portfolio_values),
+            **self.calculate_risk(portfolio_values, indexes),
+            **self.calculate_ratios(portfolio_values, indexes),
+            **self.calculate_tail_risk(portfolio_values),
+        }
+
+    def calculate_drawdown_indexes(self, portfolio_values: pd.Series) -> Dict:
+        """
+        计算回撤指数：Ulcer Index (回撤的均方根) 和 Pain Index (回撤绝对值的均值)，同时反映回撤的深度和持续时间
+        calculate_risk 直接输出，calculate_ratios 用于 Martin/Pain Ratio
+        """
+        if len(portfolio_values) < 2:
+            return {'ulcer_index': 0, 'pain_index': 0}
+
+        cummax = portfolio_values.cummax()
+        drawdown = (portfolio_values - cummax) / cummax
+        return {
+            'ulcer_index': np.sqrt((drawdown ** 2).mean()),
+            'pain_index': drawdown.abs().mean(),
         }
 
     def calculate_returns(self, portfolio_values: pd.Series) -> Dict:
@@ -50,8 +67,8 @@ class PerformanceMetrics:
             'cagr': cagr,
         }
 
-    def calculate_risk(self, portfolio_values: pd.Series) -> Dict:
-        """计算风险指标"""
+    def calculate_risk(self, portfolio_values: pd.Series, drawdown_indexes: Optional[Dict] = None) -> Dict:
+        """计算风险指标，drawdown_indexes 为已计算的回撤指数 (calculate_drawdown_indexes)，省略时重新计算"""
         if len(portfolio_values) < 2:
             return {
                 'volatility': 0,
@@ -59,6 +76,8 @@ class PerformanceMetrics:
                 'max_drawdown_duration': 0,
                 'var_95': 0,
                 'cvar_95': 0,
+                'ulcer_index': 0,
+                'pain_index': 0,
             }
 
         returns = portfolio_values.pct_change().dropna()
@@ -74,6 +93,9 @@ class PerformanceMetrics:
         # 最大回撤持续时间
         max_drawdown_duration = self._calculate_max_drawdown_duration(portfolio_values)
 
+        if drawdown_indexes is None:
+            drawdown_indexes = self.calculate_drawdown_indexes(portfolio_values)
+
         # VaR (95%)
         var_95 = np.percentile(returns, 5)
 
@@ -86,15 +108,19 @@ class PerformanceMetrics:
             'max_drawdown_duration': max_drawdown_duration,
             'var_95': var_95,
             'cvar_95': cvar_95,
+            'ulcer_index': drawdown_indexes['ulcer_index'],
+            'pain_index': drawdown_indexes['pain_index'],
         }
 
-    def calculate_ratios(self, portfolio_values: pd.Series) -> Dict:
-        """计算风险调整收益指标"""
+    def calculate_ratios(self, portfolio_values: pd.Series, drawdown_indexes: Optional[Dict] = None) -> Dict:
+        """计算风险调整收益指标，drawdown_indexes 为已计算的回撤指数 (calculate_drawdown_indexes)，省略时重新计算"""
         if len(portfolio_values) < 2:
             return {
                 'sharpe_ratio': 0,
                 'sortino_ratio': 0,
                 'calmar_ratio': 0,
+                'martin_ratio': 0,
+                'pain_ratio': 0,
             }
 
         returns = portfolio_values.pct_change().dropna()
@@ -129,10 +155,117 @@ class PerformanceMetrics:
         else:
             calmar_ratio = 0
 
+        # Martin Ratio (超额收益 / Ulcer Index) 和 Pain Ratio (超额收益 / Pain Index)
+        if drawdown_indexes is None:
+            drawdown_indexes = self.calculate_drawdown_indexes(portfolio_values)
+        ulcer_index = drawdown_indexes['ulcer_index']
+        pain_index = drawdown_indexes['pain_index']
+        martin_ratio = (annualized_return - self.risk_free_rate) / ulcer_index if ulcer_index > 0 else 0
+        pain_ratio = (annualized_return - self.risk_free_rate) / pain_index if pain_index > 0 else 0
+
         return {
             'sharpe_ratio': sharpe_ratio,
             'sortino_ratio': sortino_ratio,
             'calmar_ratio': calmar_ratio,
+            'martin_ratio': martin_ratio,
+            'pain_ratio': pain_ratio,
+        }
+
+    def calculate_tail_risk(self, portfolio_values: pd.Series) -> Dict:
+        """
+        计算尾部风险：日收益和月收益的历史法、参数法 (正态分布) 95%/99% VaR和CVaR，以及最差单日/单月
+        VaR和CVaR以收益率表示 (亏损为负)，键名如 daily_var_95_hist、monthly_cvar_99_param
+        """
+        if len(portfolio_values) < 2:
+            return {}
+
+        daily = portfolio_values.pct_change().dropna()
+        month_end = portfolio_values.resample('M').last().dropna()
+        previous = month_end.shift(1)
+        previous.iloc[0] = portfolio_values.iloc[0]
+        monthly = (month_end / previous - 1).dropna()
+
+        metrics = {}
+        for period, returns in (('daily', daily), ('monthly', monthly)):
+            for level in (95, 99):
+                metrics.update(self._tail_metrics(returns, level, period))
+
+        worst_day = daily.idxmin()
+        metrics['worst_day'] = daily[worst_day]
+        metrics['worst_day_date'] = worst_day.strftime('%Y-%m-%d')
+        metrics['losing_days'] = (daily < 0).mean()
+        if len(monthly) > 0:
+            worst_month = monthly.idxmin()
+            metrics['worst_month'] = monthly[worst_month]
+            metrics['worst_month_date'] = worst_month.strftime('%Y-%m')
+            metrics['losing_months'] = (monthly < 0).mean()
+        return metrics
+
+    def _tail_metrics(self, returns: pd.Series, level: int, period: str) -> Dict:
+        """单个置信水平的历史法和参数法 VaR/CVaR (样本不足2个时为0)"""
+        keys = [f'{period}_{kind}_{level}_{method}' for method in ('hist', 'param') for kind in ('var', 'cvar')]
+        if len(returns) < 2:
+            return dict.fromkeys(keys, 0)
+
+        alpha = 1 - level / 100
+        # 历史法: 收益分布的分位数及其以下收益的均值
+        var_hist = np.percentile(returns, alpha * 100)
+        tail = returns[returns <= var_hist]
+        cvar_hist = tail.mean() if len(tail) > 0 else var_hist
+
+        # 参数法: 按收益均值和标准差的正态分布
+        z = {95: 1.6448536, 99: 2.3263479}[level]
+        mean, std = returns.mean(), returns.std()
+        var_param = mean - z * std
+        cvar_param = mean - std * np.exp(-z * z / 2) / np.sqrt(2 * np.pi) / alpha
+
+        return dict(zip(keys, [var_hist, cvar_hist, var_param, cvar_param]))
+
+    def calculate_relative(self, portfolio_values: pd.Series, benchmark_values: pd.Series,
+                           beta_window: int = 252) -> Dict:
+        """
+        计算相对基准的指标
+        Args:
+            portfolio_values: 组合净值
+            benchmark_values: 基准净值 (与组合相同的初始资金和追加投入)
+            beta_window: 滚动Beta的窗口 (交易日)
+        """
+        values = pd.concat([portfolio_values, benchmark_values], axis=1, join='inner').dropna()
+        if len(values) < 3:
+            return {}
+        values.columns = ['portfolio', 'benchmark']
+        returns = values.pct_change().dropna()
+
+        # 信息比率: 年化超额收益 / 跟踪误差
+        active = returns['portfolio'] - returns['benchmark']
+        tracking_error = active.std() * np.sqrt(252)
+        information_ratio = active.mean() * 252 / tracking_error if tracking_error > 0 else 0
+
+        # 上行/下行捕获率: 基准上涨 (下跌) 月份中组合与基准的平均月收益之比
+        monthly = values.resample('M').last().pct_change().dropna()
+        up = monthly[monthly['benchmark'] > 0]
+        down = monthly[monthly['benchmark'] < 0]
+        up_capture = up['portfolio'].mean() / up['benchmark'].mean() if len(up) > 0 else 0
+        down_capture = down['portfolio'].mean() / down['benchmark'].mean() if len(down) > 0 else 0
+
+        # Beta: 全区间和滚动窗口
+        variance = returns['benchmark'].var()
+        beta = returns['portfolio'].cov(returns['benchmark']) / variance if variance > 0 else 0
+        window = min(beta_window, len(returns))
+        rolling_beta = (returns['portfolio'].rolling(window).cov(returns['benchmark']) /
+                        returns['benchmark'].rolling(window).var()).dropna()
+
+        return {
+            'information_ratio': information_ratio,
+            'tracking_error': tracking_error,
+            'up_capture': up_capture,
+            'down_capture': down_capture,
+            'beta': beta,
+            'rolling_beta_window': window,
+            'rolling_beta': rolling_beta,
+            'rolling_beta_last': rolling_beta.iloc[-1] if len(rolling_beta) > 0 else beta,
+            'rolling_beta_min': rolling_beta.min() if len(rolling_beta) > 0 else beta,
+            'rolling_beta_max': rolling_beta.max() if len(rolling_beta) > 0 else beta,
         }
 
     def _calculate_max_drawdown_duration(self, portfolio_values: pd.Series) -> int:
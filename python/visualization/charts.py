@@ -157,6 +157,54 @@ class ChartGenerator:
             plt.savefig(save_path, dpi=150, bbox_inches='tight')
         plt.show()
 
+    def plot_target_vs_actual(self, weights_df: pd.DataFrame,
+                              target_df: pd.DataFrame,
+                              title: str = "目标权重 vs 实际权重",
+                              save_path: Optional[str] = None):
+        """
+        绘制各资产目标权重 (虚线) 与实际权重 (实线) 对比图
+        Args:
+            weights_df: 实际权重DataFrame (索引为日期，列为资产)
+            target_df: 目标权重DataFrame (索引为日期，列为资产)
+            title: 图表标题
+            save_path: 保存路径 (可选)
+        """
+        if not HAS_MATPLOTLIB:
+            print("需要安装 matplotlib 才能绘制图表")
+            return
+
+        if weights_df.empty or target_df.empty:
+            print("没有权重数据")
+            return
+
+        fig, ax = plt.subplots(figsize=(12, 6))
+
+        symbols = [c for c in target_df.columns if c != 'CASH']
+        colors = plt.rcParams['axes.prop_cycle'].by_key()['color']
+        for i, symbol in enumerate(symbols):
+            color = colors[i % len(colors)]
+            if symbol in weights_df.columns:
+                ax.plot(weights_df.index, weights_df[symbol] * 100,
+                        color=color, linewidth=1.2, label=f'{symbol} 实际')
+            ax.plot(target_df.index, target_df[symbol] * 100,
+                    color=color, linewidth=1, linestyle='--', label=f'{symbol} 目标')
+
+        ax.set_title(title, fontsize=14, fontweight='bold')
+        ax.set_xlabel('日期')
+        ax.set_ylabel('权重 (%)')
+        ax.legend(loc='upper left', bbox_to_anchor=(1, 1))
+        ax.grid(True, alpha=0.3)
+
+        ax.xaxis.set_major_formatter(mdates.DateFormatter('%Y-%m'))
+        ax.xaxis.set_major_locator(mdates.MonthLocator(interval=3))
+        plt.xticks(rotation=45)
+
+        plt.tight_layout()
+
+        if save_path:
+            plt.savefig(save_path, dpi=150, bbox_inches='tight')
+        plt.show()
+
     def plot_monthly_returns(self, portfolio_values: pd.Series,
                              title: str = "月度收益热力图",
                              save_path: Optional[str] = None):
@@ -34,14 +34,21 @@ class ReportGenerator:
             max_drawdown_duration=metrics.get('max_drawdown_duration', 0),
             var_95=f"{metrics.get('var_95', 0) * 100:.2f}%",
             cvar_95=f"{metrics.get('cvar_95', 0) * 100:.2f}%",
+            ulcer_index=f"{metrics.get('ulcer_index', 0) * 100:.2f}%",
+            pain_index=f"{metrics.get('pain_index', 0) * 100:.2f}%",
             sharpe_ratio=f"{metrics.get('sharpe_ratio', 0):.3f}",
             sortino_ratio=f"{metrics.get('sortino_ratio', 0):.3f}",
             calmar_ratio=f"{metrics.get('calmar_ratio', 0):.3f}",
+            martin_ratio=f"{metrics.get('martin_ratio', 0):.3f}",
+            pain_ratio=f"{metrics.get('pain_ratio', 0):.3f}",
             total_trades=metrics.get('total_trades', 0),
             buy_trades=metrics.get('buy_trades', 0),
             sell_trades=metrics.get('sell_trades', 0),
             total_fees=f"${metrics.get('total_fees', 0):,.2f}",
             avg_trade_value=f"${metrics.get('avg_trade_value', 0):,.2f}",
+            symbol_pnl_rows=self._symbol_pnl_rows(metrics.get('symbol_pnl', [])),
+            benchmark_section=self._benchmark_section(metrics),
+            tail_risk_section=self._tail_risk_section(metrics),
         )
 
         with open(output_path, 'w', encoding='utf-8') as f:
@@ -49,6 +56,77 @@ class ReportGenerator:
 
         print(f"报告已生成: {output_path}")
 
+    def _symbol_pnl_rows(self, stats) -> str:
+        """各标的已实现盈亏表格行"""
+        if not stats:
+            return '<tr><td colspan="5">无已平仓批次</td></tr>'
+        rows = []
+        for stat in stats:
+            css = 'positive' if stat['realized_pnl'] >= 0 else 'negative'
+            rows.append(
+                f"<tr><td>{stat['symbol']}</td>"
+                f"<td class=\"{css}\">${stat['realized_pnl']:,.2f}</td>"
+                f"<td>{stat['closed_lots']}</td>"
+                f"<td>{stat['win_rate'] * 100:.1f}%</td>"
+                f"<td>{stat['avg_holding_days']:.0f} 天</td></tr>")
+        return '\n'.join(rows)
+
+    def _tail_risk_section(self, metrics: Dict) -> str:
+        """尾部风险：日收益和月收益的历史法、参数法 VaR/CVaR 表格和最差单日/单月"""
+        if 'worst_day' not in metrics:
+            return ''
+        rows = []
+        for period, label in (('daily', '日收益'), ('monthly', '月收益')):
+            for method, name in (('hist', '历史法'), ('param', '参数法')):
+                cells = ''.join(
+                    f"<td>{metrics.get(f'{period}_{kind}_{level}_{method}', 0) * 100:.2f}%</td>"
+                    for level in (95, 99) for kind in ('var', 'cvar'))
+                rows.append(f"<tr><td>{label} ({name})</td>{cells}</tr>")
+        worst = (f"最差单日 {metrics['worst_day'] * 100:.2f}% ({metrics['worst_day_date']})，"
+                 f"亏损日占比 {metrics['losing_days'] * 100:.1f}%")
+        if 'worst_month' in metrics:
+            worst += (f"；最差单月 {metrics['worst_month'] * 100:.2f}% ({metrics['worst_month_date']})，"
+                      f"亏损月占比 {metrics['losing_months'] * 100:.1f}%")
+        body = '\n                '.join(rows)
+        return f'''
+        <div class="section">
+            <h2>尾部风险</h2>
+            <table class="pnl-table">
+                <tr><th>收益</th><th>VaR 95%</th><th>CVaR 95%</th><th>VaR 99%</th><th>CVaR 99%</th></tr>
+                {body}
+            </table>
+            <p>{worst}</p>
+        </div>
+'''
+
+    def _benchmark_section(self, metrics: Dict) -> str:
+        """相对基准的指标 (结果中没有基准净值时不显示)"""
+        if 'information_ratio' not in metrics:
+            return ''
+        cards = [
+            (f"{metrics['information_ratio']:.3f}", '信息比率'),
+            (f"{metrics['tracking_error'] * 100:.2f}%", '跟踪误差'),
+            (f"{metrics['up_capture'] * 100:.1f}%", '上行捕获率'),
+            (f"{metrics['down_capture'] * 100:.1f}%", '下行捕获率'),
+            (f"{metrics['beta']:.3f}", 'Beta'),
+            (f"{metrics['rolling_beta_last']:.3f}",
+             f"滚动Beta ({metrics['rolling_beta_window']}日, 区间 "
+             f"{metrics['rolling_beta_min']:.2f} ~ {metrics['rolling_beta_max']:.2f})"),
+        ]
+        html = ''.join(
+            f'\n                <div class="metric-card">\n'
+            f'                    <div class="value">{value}</div>\n'
+            f'                    <div class="label">{label}</div>\n'
+            f'                </div>'
+            for value, label in cards)
+        return (f'''
+        <div class="section">
+            <h2>相对基准 ({metrics.get('benchmark_name', '')})</h2>
+            <div class="metrics-grid">{html}
+            </div>
+        </div>
+''')
+
     def _get_template(self) -> str:
         return '''<!DOCTYPE html>
 <html lang="zh-CN">
@@ -128,6 +206,24 @@ class ReportGenerator:
         .metric-card.negative .value {{
             color: #dc3545;
         }}
+        .pnl-table {{
+            width: 100%;
+            border-collapse: collapse;
+        }}
+        .pnl-table th, .pnl-table td {{
+            padding: 8px 12px;
+            text-align: right;
+            border-bottom: 1px solid #eee;
+        }}
+        .pnl-table th:first-child, .pnl-table td:first-child {{
+            text-align: left;
+        }}
+        .pnl-table td.positive {{
+            color: #28a745;
+        }}
+        .pnl-table td.negative {{
+            color: #dc3545;
+        }}
         .footer {{
             text-align: center;
             padding: 20px;
@@ -192,6 +288,14 @@ class ReportGenerator:
                     <div class="value">{cvar_95}</div>
                     <div class="label">CVaR (95%)</div>
                 </div>
+                <div class="metric-card">
+                    <div class="value">{ulcer_index}</div>
+                    <div class="label">Ulcer Index</div>
+                </div>
+                <div class="metric-card">
+                    <div class="value">{pain_index}</div>
+                    <div class="label">Pain Index</div>
+                </div>
             </div>
         </div>
 
@@ -210,9 +314,19 @@ class ReportGenerator:
                     <div class="value">{calmar_ratio}</div>
                     <div class="label">Calmar Ratio</div>
                 </div>
+                <div class="metric-card">
+                    <div class="value">{martin_ratio}</div>
+                    <div class="label">Martin Ratio</div>
+                </div>
+                <div class="metric-card">
+                    <div class="value">{pain_ratio}</div>
+                    <div class="label">Pain Ratio</div>
+                </div>
             </div>
         </div>
 
+{tail_risk_section}
+{benchmark_section}
         <div class="section">
             <h2>交易统计</h2>
             <div class="metrics-grid">
@@ -239,6 +353,14 @@ class ReportGenerator:
             </div>
         </div>
 
+        <div class="section">
+            <h2>各标的已实现盈亏</h2>
+            <table class="pnl-table">
+                <tr><th>标的</th><th>已实现盈亏</th><th>平仓批次</th><th>胜率</th><th>平均持有期</th></tr>
+                {symbol_pnl_rows}
+            </table>
+        </div>
+
         <div class="footer">
             <p>Rebalance-Backtest 回测系统 | Powered by Go + Python</p>
         </div>
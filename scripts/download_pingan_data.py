@@ -223,7 +223,9 @@ def add_fundamental_data(df: pd.DataFrame, symbol: str, info: dict) -> pd.DataFr
     df["PE"] = 20  # 简化
     df["PE_Rank"] = pe_rank_series.round(2)
     df["PEG"] = 1.5
-    df["ROE"] = info.get("yield", 15)  # 用ROE存储Yield for 债券
+    df["ROE"] = info.get("yield", 15)
+    if "yield" in info:
+        df["Yield"] = info["yield"]  # 债券到期收益率 (债券收益率规则读取 Yield 列)
     df["Asset_Type"] = info.get("asset_type", "ETF")
     df["Name"] = info.get("name", symbol)
     df["Is_Core"] = str(info.get("is_core", False)).lower()
@@ -241,6 +243,8 @@ def save_to_csv(df: pd.DataFrame, symbol: str, output_dir: str):
     
     columns = ["Date", "Open", "High", "Low", "Close", "Volume", "Adj Close",
                "PE", "PE_Rank", "PEG", "ROE", "Asset_Type", "Name", "Is_Core", "Is_Tech"]
+    if "Yield" in df.columns:
+        columns.insert(columns.index("ROE") + 1, "Yield")
     df = df[columns]
     
     output_path = os.path.join(output_dir, f"{symbol}.csv")